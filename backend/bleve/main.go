@@ -36,8 +36,9 @@ const shardCount = 3
 
 // MarkerDB represents the structure of the marker data from DB.
 type MarkerDB struct {
-	MarkerID int    `json:"markerId"`
-	Address  string `json:"address"`
+	MarkerID   int            `json:"markerId"`
+	Address    string         `json:"address"`
+	Facilities map[string]int `json:"facilities,omitempty"` // FacilityID -> Quantity
 }
 
 type Marker struct {
@@ -47,6 +48,8 @@ type Marker struct {
 	Address           string `json:"address"` // such as Korean: 경기도 부천시 소사구 경인로29번길 32, 우성아파트
 	FullAddress       string `json:"fullAddress"`
 	InitialConsonants string `json:"initialConsonants"` // 초성
+	// FacilityID -> Quantity, indexed as numeric fields such as "facilities.1" for facility filters
+	Facilities map[string]int `json:"facilities,omitempty"`
 }
 
 // Load environment variables from .env file
@@ -99,7 +102,11 @@ func saveJson() error {
 	defer db.Close()
 
 	// Query to select all rows from the Markers table
-	selectSQL := `SELECT MarkerID, Address FROM Markers`
+	selectSQL := `SELECT m.MarkerID, m.Address,
+	(SELECT GROUP_CONCAT(CONCAT(f.FacilityID, ':', f.Quantity) SEPARATOR ';')
+		FROM MarkerFacilities f
+		WHERE f.MarkerID = m.MarkerID AND f.Quantity > 0 AND f.DeletedAt IS NULL) AS Facilities
+FROM Markers m`
 
 	// Execute the query
 	rows, err := db.Query(selectSQL)
@@ -112,10 +119,12 @@ func saveJson() error {
 	var markers []MarkerDB
 	for rows.Next() {
 		var marker MarkerDB
-		err := rows.Scan(&marker.MarkerID, &marker.Address)
+		var facilities sql.NullString
+		err := rows.Scan(&marker.MarkerID, &marker.Address, &facilities)
 		if err != nil {
 			return fmt.Errorf("error scanning row: %v", err)
 		}
+		marker.Facilities = parseFacilities(facilities.String)
 		markers = append(markers, marker)
 	}
 
@@ -727,6 +736,21 @@ func splitAddress(address string) (string, string, string) {
 	return province, city, rest
 }
 
+// parseFacilities reads the "1:2;2:1" FacilityID:Quantity pairs the query aggregates facilities into.
+func parseFacilities(s string) map[string]int {
+	facilities := make(map[string]int)
+	for _, pair := range strings.Split(s, ";") {
+		facilityID, quantity, ok := strings.Cut(pair, ":")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(quantity); err == nil {
+			facilities[facilityID] = n
+		}
+	}
+	return facilities
+}
+
 func getMarkersFromJson(filepath string) ([]Marker, error) {
 	var markerData []Marker
 
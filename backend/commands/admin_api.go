@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// newAdminRequest builds a request to the admin API of a running server, for commands that have to go
+// through the same services (and caches) as the endpoints.
+//
+//	IMPORT_API_URL  server base URL, default http://localhost:$SERVER_PORT
+//	ADMIN_TOKEN     login token of an admin account, sent as the TOKEN_COOKIE cookie
+func newAdminRequest(method, path string, body io.Reader) (*http.Request, error) {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("ADMIN_TOKEN is not set")
+	}
+	cookieName := os.Getenv("TOKEN_COOKIE")
+	if cookieName == "" {
+		return nil, fmt.Errorf("TOKEN_COOKIE is not set")
+	}
+
+	baseURL := os.Getenv("IMPORT_API_URL")
+	if baseURL == "" {
+		port := os.Getenv("SERVER_PORT")
+		if port == "" {
+			port = "8080"
+		}
+		baseURL = "http://localhost:" + port
+	}
+
+	req, err := http.NewRequest(method, baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.AddCookie(&http.Cookie{Name: cookieName, Value: token})
+	return req, nil
+}
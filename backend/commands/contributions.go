@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/goccy/go-json"
+)
+
+// runContributions handles "contributions recompute", which calls POST /api/v1/admin/contributions/recompute
+// of a running server so the cached profiles of users whose total changed are dropped as well.
+func runContributions(args []string) error {
+	if len(args) < 1 || args[0] != "recompute" {
+		return fmt.Errorf("contributions needs: recompute [-dry-run]")
+	}
+
+	fs := flag.NewFlagSet("contributions recompute", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only print what would change")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	req, err := newAdminRequest(http.MethodPost, "/api/v1/admin/contributions/recompute?dryRun="+strconv.FormatBool(*dryRun), nil)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling recompute API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("recompute API returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var result dto.ContributionRecompute
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding recompute result: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EVENT\tPOINTS\tAWARDED\tREVOKED")
+	for _, r := range result.Rules {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", r.EventType, r.Points, r.Awarded, r.Revoked)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	verb := "changed"
+	if result.DryRun {
+		verb = "would change"
+	}
+	fmt.Printf("\ntotals of %d users %s\n", result.UsersChanged, verb)
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/goccy/go-json"
+)
+
+// runImport uploads a marker file to POST /api/v1/admin/markers/import of a running server, so the rows
+// go through exactly the same validation, address lookup and indexing as the endpoint.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only validate, print what would be inserted")
+	format := fs.String("format", "", "geojson, csv or kml (default: from the file extension)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("import needs exactly one file")
+	}
+	path := fs.Arg(0)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("dryRun", strconv.FormatBool(*dryRun))
+	if *format != "" {
+		query.Set("format", *format)
+	}
+
+	req, err := newAdminRequest(http.MethodPost, "/api/v1/admin/markers/import?"+query.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling import API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("import API returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	var report dto.MarkerImportReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return fmt.Errorf("decoding import report: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROW\tSTATUS\tMARKER\tLAT\tLNG\tREASON")
+	for _, r := range report.Results {
+		marker := "-"
+		if r.MarkerID > 0 {
+			marker = strconv.Itoa(r.MarkerID)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%.6f\t%.6f\t%s\n", r.Row, r.Status, marker, r.Latitude, r.Longitude, r.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	verb := "imported"
+	if report.DryRun {
+		verb = "would import"
+	}
+	fmt.Printf("\n%s: %d rows, %s %d, rejected %d\n", report.Format, report.Total, verb, report.Accepted, report.Rejected)
+	return nil
+}
@@ -0,0 +1,144 @@
+// Command commands holds the backend maintenance tasks that run outside the API server.
+//
+//	go run ./commands migrate up
+//	go run ./commands migrate down [steps]
+//	go run ./commands migrate status
+//	go run ./commands import [-dry-run] [-format csv] <file>
+//	go run ./commands contributions recompute [-dry-run]
+//
+// Database settings are read from the same DB_* variables (and .env file) as the server.
+// import and contributions go through the admin API of a running server, see newAdminRequest.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Alfex4936/chulbong-kr/service"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: commands <command> [arguments]
+
+commands:
+  migrate up             apply all pending migrations
+  migrate down [steps]   roll back the last applied migration(s), default 1
+  migrate status         list migrations and whether they are applied
+  import [-dry-run] [-format geojson|csv|kml] <file>
+                         bulk import markers (GeoJSON, CSV or KML) through the admin API
+  contributions recompute [-dry-run]
+                         reconcile the contribution points ledger with the rules and rebuild totals
+`
+
+func main() {
+	if os.Getenv("DEPLOYMENT") != "production" {
+		godotenv.Overload()
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	logger, err := zap.NewDevelopment()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	var runErr error
+	switch os.Args[1] {
+	case "migrate":
+		runErr = runMigrate(logger, os.Args[2:])
+	case "import":
+		runErr = runImport(os.Args[2:])
+	case "contributions":
+		runErr = runContributions(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if runErr != nil {
+		logger.Error("Command failed", zap.String("command", os.Args[1]), zap.Error(runErr))
+		os.Exit(1)
+	}
+}
+
+// newDatabase mirrors NewDatabase in main.go.
+func newDatabase() (*sqlx.DB, error) {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
+		os.Getenv("DB_USERNAME"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
+
+	db, err := sqlx.Connect("mysql", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the database: %v", err)
+	}
+	return db, nil
+}
+
+func runMigrate(logger *zap.Logger, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("migrate needs one of: up, down, status")
+	}
+
+	db, err := newDatabase()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrationService := service.NewMigrationService(db, logger)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrationService.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied  %06d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid steps %q: %w", args[1], err)
+			}
+		}
+		rolledBack, err := migrationService.Down(ctx, steps)
+		for _, m := range rolledBack {
+			fmt.Printf("reverted %06d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrationService.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range statuses {
+			appliedAt := "pending"
+			if st.AppliedAt != nil {
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\n", st.Version, st.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
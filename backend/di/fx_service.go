@@ -49,6 +49,24 @@ var (
 			service.NewReportService,
 			service.NewMarkerCacheService,
 			service.NewMarkerStoryService,
+			service.NewMarkerRevisionService,
+			service.NewMarkerStatusService,
+			service.NewMarkerMergeService,
+			service.NewMarkerImportService,
+			service.NewMarkerExportService,
+			service.NewMarkerTileService,
+			service.NewMarkerClusterService,
+			service.NewMarkerChangeService,
+			service.NewFacilityCatalogService,
+			service.NewFacilityAssignmentService,
+			service.NewMarkerAccessService,
+			service.NewMarkerReviewService,
+			service.NewMarkerCheckInService,
+			service.NewLeaderboardService,
+			service.NewBadgeService,
+			service.NewContributionService,
+			service.NewPhotoModerationService,
+			service.NewPhotoVariantService,
 		),
 	)
 
@@ -75,6 +93,7 @@ var (
 		fx.Provide(
 			service.NewTokenService,
 			service.NewSchedulerService,
+			service.NewMigrationService,
 			util.NewTokenUtil,
 			util.NewChatUtil,
 			util.NewBadWordUtil,
@@ -0,0 +1,14 @@
+package dto
+
+import "time"
+
+// Badge is an achievement badge with the user's progress towards it.
+type Badge struct {
+	EarnedAt    *time.Time `json:"earnedAt,omitempty"`
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Goal        int        `json:"goal"`
+	Progress    int        `json:"progress"` // at most Goal
+	Earned      bool       `json:"earned"`
+}
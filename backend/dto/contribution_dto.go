@@ -0,0 +1,27 @@
+package dto
+
+import "github.com/Alfex4936/chulbong-kr/model"
+
+// ContributionHistory is a page of a user's points ledger, newest first, with the running total.
+type ContributionHistory struct {
+	Entries           []model.ContributionEntry `json:"entries"`
+	ContributionLevel string                    `json:"contributionLevel"`
+	ContributionCount int                       `json:"contributionCount"`
+	CurrentPage       int                       `json:"currentPage"`
+	TotalPages        int                       `json:"totalPages"`
+	TotalEntries      int                       `json:"totalEntries"`
+}
+
+// ContributionRuleRecompute tells how many entries recomputing one rule wrote.
+type ContributionRuleRecompute struct {
+	EventType string `json:"eventType"`
+	Points    int    `json:"points"`
+	Awarded   int    `json:"awarded"`
+	Revoked   int    `json:"revoked"`
+}
+
+type ContributionRecompute struct {
+	Rules        []ContributionRuleRecompute `json:"rules"`
+	UsersChanged int                         `json:"usersChanged"` // users whose total is different afterwards
+	DryRun       bool                        `json:"dryRun"`
+}
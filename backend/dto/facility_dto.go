@@ -0,0 +1,24 @@
+package dto
+
+// FacilityTypeRequest creates or replaces a facility catalog entry.
+type FacilityTypeRequest struct {
+	Slug      string `json:"slug"`
+	Category  string `json:"category"`
+	NameKo    string `json:"nameKo"`
+	NameEn    string `json:"nameEn"`
+	Icon      string `json:"icon"`
+	SortOrder int    `json:"sortOrder"`
+}
+
+// MarkerFacilityDetail is a facility at a marker resolved against the catalog.
+// The catalog fields are empty for IDs that are not in the catalog (anymore).
+type MarkerFacilityDetail struct {
+	FacilityID int    `json:"facilityId" db:"FacilityID"`
+	MarkerID   int    `json:"markerId" db:"MarkerID"`
+	Quantity   int    `json:"quantity" db:"Quantity"`
+	Slug       string `json:"slug" db:"Slug"`
+	Category   string `json:"category" db:"Category"`
+	NameKo     string `json:"nameKo" db:"NameKo"`
+	NameEn     string `json:"nameEn" db:"NameEn"`
+	Icon       string `json:"icon" db:"Icon"`
+}
@@ -0,0 +1,18 @@
+package dto
+
+// LeaderboardEntry is one trainee on a leaderboard, Score counts their ranked check-ins in the window.
+type LeaderboardEntry struct {
+	Username string `json:"username" db:"Username"`
+	Rank     int    `json:"rank"` // trainees with the same score share a rank
+	UserID   int    `json:"userId" db:"UserID"`
+	Score    int    `json:"score" db:"Score"`
+}
+
+type Leaderboard struct {
+	Scope     string             `json:"scope"`            // marker, city, province or national
+	Region    string             `json:"region,omitempty"` // "<province> <city>" or the province
+	Period    string             `json:"period"`           // weekly, monthly or all
+	PeriodKey string             `json:"periodKey"`        // 2024-W07, 2024-02 or all
+	Entries   []LeaderboardEntry `json:"entries"`
+	MarkerID  int                `json:"markerId,omitempty"`
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MarkerAccessFilter narrows marker listings down by their access metadata, the zero value matches every marker.
+// Markers whose metadata is unknown never pass a set field.
+type MarkerAccessFilter struct {
+	AccessTypes []string // sorted, any of them
+	Lit         *bool
+	Indoor      *bool
+}
+
+func (f MarkerAccessFilter) IsEmpty() bool {
+	return len(f.AccessTypes) == 0 && f.Lit == nil && f.Indoor == nil
+}
+
+// CacheKey identifies the filter in cache keys, it is empty for the zero value so unfiltered keys stay as they were.
+func (f MarkerAccessFilter) CacheKey() string {
+	if f.IsEmpty() {
+		return ""
+	}
+
+	parts := make([]string, 0, 3)
+	if len(f.AccessTypes) > 0 {
+		parts = append(parts, "access="+strings.Join(f.AccessTypes, ","))
+	}
+	if f.Lit != nil {
+		parts = append(parts, "lit="+strconv.FormatBool(*f.Lit))
+	}
+	if f.Indoor != nil {
+		parts = append(parts, "indoor="+strconv.FormatBool(*f.Indoor))
+	}
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,13 @@
+package dto
+
+// MarkerChangesResponse is one page of the marker change log.
+// Upserts carry the current state of markers changed since the cursor, Deleted the IDs of markers that are gone.
+// When Reset is set the cursor was too old (or missing): the client drops its copy, loads the full
+// marker list and continues from Cursor.
+type MarkerChangesResponse struct {
+	Upserts []MarkerSimple `json:"upserts"`
+	Deleted []int          `json:"deleted"`
+	Cursor  int64          `json:"cursor"`
+	HasMore bool           `json:"hasMore"`
+	Reset   bool           `json:"reset"`
+}
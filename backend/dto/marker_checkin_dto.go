@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/model"
+)
+
+// CheckInRequest checks the caller in at a marker. The position is optional, with it the check-in can be verified.
+type CheckInRequest struct {
+	Latitude  *float64                `json:"latitude,omitempty"`
+	Longitude *float64                `json:"longitude,omitempty"`
+	Note      string                  `json:"note,omitempty"`
+	Exercises []model.CheckInExercise `json:"exercises,omitempty"`
+}
+
+type CheckInWithMarker struct {
+	model.MarkerCheckIn
+	Address *string `json:"address,omitempty" db:"Address"`
+}
+
+type CheckInList struct {
+	CheckIns      []CheckInWithMarker `json:"checkIns"`
+	CurrentPage   int                 `json:"currentPage"`
+	TotalPages    int                 `json:"totalPages"`
+	TotalCheckIns int                 `json:"totalCheckIns"`
+}
+
+// ExerciseTotal sums up one exercise over all check-ins of a user.
+type ExerciseTotal struct {
+	Exercise string `json:"exercise" db:"Exercise"`
+	Sets     int    `json:"sets" db:"Sets"`
+	Reps     int    `json:"reps" db:"Reps"` // over all sets
+	Workouts int    `json:"workouts" db:"Workouts"`
+}
+
+// CheckInStats are the personal training stats of a user, streaks count days in KST.
+type CheckInStats struct {
+	LastCheckInAt    *time.Time      `json:"lastCheckInAt,omitempty"`
+	Exercises        []ExerciseTotal `json:"exercises"`
+	CurrentStreak    int             `json:"currentStreak"`
+	LongestStreak    int             `json:"longestStreak"`
+	ActiveDays       int             `json:"activeDays"`
+	TotalCheckIns    int             `json:"totalCheckIns"`
+	VerifiedCheckIns int             `json:"verifiedCheckIns"`
+	MarkersVisited   int             `json:"markersVisited"`
+}
+
+// MarkerVisitedWithAddr is a marker in the visits ranking.
+type MarkerVisitedWithAddr struct {
+	MarkerSimpleWithAddr
+	Visitors int `json:"visitors" db:"Visitors"`
+}
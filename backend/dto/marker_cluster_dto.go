@@ -0,0 +1,23 @@
+package dto
+
+type MarkerClusterBounds struct {
+	MinLat float64 `json:"minLat"`
+	MinLng float64 `json:"minLng"`
+	MaxLat float64 `json:"maxLat"`
+	MaxLng float64 `json:"maxLng"`
+}
+
+// MarkerCluster is a group of nearby markers, a lone marker is a cluster of one with its markerId set.
+type MarkerCluster struct {
+	Bounds    MarkerClusterBounds `json:"bounds"`
+	Latitude  float64             `json:"latitude"`
+	Longitude float64             `json:"longitude"`
+	Count     int                 `json:"count"`
+	MarkerID  int                 `json:"markerId,omitempty"`
+}
+
+type MarkerClusterResponse struct {
+	Clusters []MarkerCluster `json:"clusters"`
+	Zoom     int             `json:"zoom"`  // zoom the clusters were computed for, clamped to the supported range
+	Total    int             `json:"total"` // markers in all returned clusters
+}
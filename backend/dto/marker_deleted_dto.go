@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// DeletedMarker is a soft-deleted marker waiting to be restored or purged.
+type DeletedMarker struct {
+	DeletedAt   time.Time `json:"deletedAt" db:"DeletedAt"`
+	Latitude    float64   `json:"latitude" db:"Latitude"`
+	Longitude   float64   `json:"longitude" db:"Longitude"`
+	MarkerID    int       `json:"markerId" db:"MarkerID"`
+	UserID      *int      `json:"userId,omitempty" db:"UserID"`
+	Description string    `json:"description" db:"Description"`
+	Address     *string   `json:"address,omitempty" db:"Address"`
+}
+
+type DeletedMarkerList struct {
+	Markers      []DeletedMarker `json:"markers"`
+	CurrentPage  int             `json:"currentPage"`
+	TotalPages   int             `json:"totalPages"`
+	TotalMarkers int             `json:"totalMarkers"`
+}
@@ -22,6 +22,15 @@ type MarkerResponse struct {
 	// Username    string   `json:"username"`
 	// UserID      int      `json:"userId"`
 	// PhotoURLs   []string `json:"photoUrls"`
+
+	PhotoWarnings []PhotoWarning `json:"photoWarnings,omitempty" msg:"-"`
+}
+
+// PhotoWarning is a quality problem of an uploaded photo that was accepted anyway.
+type PhotoWarning struct {
+	Filename string `json:"filename"`
+	Check    string `json:"check"` // EXPOSURE or BLUR
+	Message  string `json:"message"`
 }
 
 type QueryParams struct {
@@ -40,13 +49,14 @@ type MarkerWithDistance struct {
 }
 
 type MarkerWithDistanceAndPhoto struct {
-	Latitude    float64 `db:"Latitude" json:"latitude"`
-	Longitude   float64 `db:"Longitude" json:"longitude"`
-	Distance    float64 `db:"Distance" json:"distance"`
-	MarkerID    int     `db:"MarkerID" json:"markerId"`
-	Description string  `db:"Description" json:"description"`
-	Address     string  `db:"Address" json:"address"`
-	Thumbnail   *string `db:"Thumbnail" json:"thumbnail,omitempty"`
+	Latitude    float64  `db:"Latitude" json:"latitude"`
+	Longitude   float64  `db:"Longitude" json:"longitude"`
+	Distance    float64  `db:"Distance" json:"distance"`
+	MarkerID    int      `db:"MarkerID" json:"markerId"`
+	Description string   `db:"Description" json:"description"`
+	Address     string   `db:"Address" json:"address"`
+	Thumbnail   *string  `db:"Thumbnail" json:"thumbnail,omitempty"`
+	Rating      *float64 `db:"-" json:"rating,omitempty"` // set by the rating ranking only
 }
 
 type MarkerWithDislike struct {
@@ -63,6 +73,7 @@ type MarkerSimple struct {
 	Longitude float64 `json:"longitude" db:"Longitude"`
 	MarkerID  int     `json:"markerId" db:"MarkerID"`
 	HasPhoto  bool    `json:"hasPhoto,omitempty" db:"HasPhoto"`
+	Status    string  `json:"status,omitempty" db:"Status"`
 }
 
 type MarkerNewResponse struct {
@@ -144,9 +155,12 @@ type MarkerNewPictureExtra struct {
 
 type MarkersWithUsernames struct {
 	model.Marker
+	model.MarkerAccess
+	model.MarkerRating
 	Username      string `db:"Username"`
 	DislikeCount  int    `db:"DislikeCount"`
 	FavoriteCount int    `db:"FavoriteCount"`
+	VisitorCount  int    `db:"VisitorCount"`
 }
 
 type MarkersClose struct {
@@ -1426,6 +1426,12 @@ func (z *MarkerSimple) DecodeMsg(dc *msgp.Reader) (err error) {
 				err = msgp.WrapError(err, "HasPhoto")
 				return
 			}
+		case "Status":
+			z.Status, err = dc.ReadString()
+			if err != nil {
+				err = msgp.WrapError(err, "Status")
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -1439,9 +1445,9 @@ func (z *MarkerSimple) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *MarkerSimple) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 4
+	// map header, size 5
 	// write "Latitude"
-	err = en.Append(0x84, 0xa8, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65)
+	err = en.Append(0x85, 0xa8, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65)
 	if err != nil {
 		return
 	}
@@ -1480,15 +1486,25 @@ func (z *MarkerSimple) EncodeMsg(en *msgp.Writer) (err error) {
 		err = msgp.WrapError(err, "HasPhoto")
 		return
 	}
+	// write "Status"
+	err = en.Append(0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteString(z.Status)
+	if err != nil {
+		err = msgp.WrapError(err, "Status")
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *MarkerSimple) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 4
+	// map header, size 5
 	// string "Latitude"
-	o = append(o, 0x84, 0xa8, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65)
+	o = append(o, 0x85, 0xa8, 0x4c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65)
 	o = msgp.AppendFloat64(o, z.Latitude)
 	// string "Longitude"
 	o = append(o, 0xa9, 0x4c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65)
@@ -1499,6 +1515,9 @@ func (z *MarkerSimple) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "HasPhoto"
 	o = append(o, 0xa8, 0x48, 0x61, 0x73, 0x50, 0x68, 0x6f, 0x74, 0x6f)
 	o = msgp.AppendBool(o, z.HasPhoto)
+	// string "Status"
+	o = append(o, 0xa6, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73)
+	o = msgp.AppendString(o, z.Status)
 	return
 }
 
@@ -1544,6 +1563,12 @@ func (z *MarkerSimple) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				err = msgp.WrapError(err, "HasPhoto")
 				return
 			}
+		case "Status":
+			z.Status, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "Status")
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -1558,7 +1583,7 @@ func (z *MarkerSimple) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *MarkerSimple) Msgsize() (s int) {
-	s = 1 + 9 + msgp.Float64Size + 10 + msgp.Float64Size + 9 + msgp.IntSize + 9 + msgp.BoolSize
+	s = 1 + 9 + msgp.Float64Size + 10 + msgp.Float64Size + 9 + msgp.IntSize + 9 + msgp.BoolSize + 7 + msgp.StringPrefixSize + len(z.Status)
 	return
 }
 
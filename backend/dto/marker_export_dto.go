@@ -0,0 +1,13 @@
+package dto
+
+import "github.com/Alfex4936/chulbong-kr/util"
+
+// MarkerExportFilter narrows down which markers an export contains, zero values mean no filter.
+type MarkerExportFilter struct {
+	BBox       *util.BBox
+	Facilities util.FacilityFilter
+	Access     MarkerAccessFilter
+	Statuses   []string
+	Province   string // first word of the address, e.g. 서울특별시 (서울 works too)
+	City       string // any later word of the address, e.g. 강남구
+}
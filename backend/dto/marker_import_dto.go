@@ -0,0 +1,28 @@
+package dto
+
+const (
+	ImportStatusAccepted = "ACCEPTED" // valid, would be inserted (dry run)
+	ImportStatusImported = "IMPORTED"
+	ImportStatusRejected = "REJECTED"
+)
+
+// MarkerImportResult is the outcome of one row of an import file.
+type MarkerImportResult struct {
+	Facilities  []FacilityQuantity `json:"facilities,omitempty"`
+	Latitude    float64            `json:"latitude"`
+	Longitude   float64            `json:"longitude"`
+	Row         int                `json:"row"`
+	MarkerID    int                `json:"markerId,omitempty"`
+	Status      string             `json:"status"`
+	Reason      string             `json:"reason,omitempty"`
+	Description string             `json:"description"`
+}
+
+type MarkerImportReport struct {
+	Results  []MarkerImportResult `json:"results"`
+	Format   string               `json:"format"`
+	DryRun   bool                 `json:"dryRun"`
+	Total    int                  `json:"total"`
+	Accepted int                  `json:"accepted"` // imported, or would be imported in a dry run
+	Rejected int                  `json:"rejected"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "github.com/Alfex4936/chulbong-kr/model"
+
+type MarkerMergeRequest struct {
+	MergeIDs []int `json:"mergeIds"`
+}
+
+// MarkerMergeResult is returned after duplicates were merged into the survivor.
+type MarkerMergeResult struct {
+	Merges           []model.MarkerMerge `json:"merges"`
+	SurvivorMarkerID int                 `json:"survivorMarkerId"`
+}
+
+type MarkerMergeList struct {
+	Merges      []model.MarkerMerge `json:"merges"`
+	CurrentPage int                 `json:"currentPage"`
+	TotalPages  int                 `json:"totalPages"`
+	TotalMerges int                 `json:"totalMerges"`
+}
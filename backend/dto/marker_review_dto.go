@@ -0,0 +1,33 @@
+package dto
+
+import "github.com/Alfex4936/chulbong-kr/model"
+
+// MarkerReviewRequest creates or replaces the caller's review of a marker, every score is 1 to 5.
+type MarkerReviewRequest struct {
+	Rating       int    `json:"rating"`
+	Grip         *int   `json:"grip,omitempty"`
+	Height       *int   `json:"height,omitempty"`
+	Stability    *int   `json:"stability,omitempty"`
+	Surroundings *int   `json:"surroundings,omitempty"`
+	Comment      string `json:"comment,omitempty"`
+}
+
+type MarkerReviewWithUsername struct {
+	model.MarkerReview
+	Username string `json:"username" db:"Username"`
+}
+
+type MarkerReviewList struct {
+	Reviews      []MarkerReviewWithUsername `json:"reviews"`
+	Rating       *model.MarkerRating        `json:"rating,omitempty"`
+	CurrentPage  int                        `json:"currentPage"`
+	TotalPages   int                        `json:"totalPages"`
+	TotalReviews int                        `json:"totalReviews"`
+}
+
+// MarkerRatedWithAddr is a marker in the rating ranking.
+type MarkerRatedWithAddr struct {
+	MarkerSimpleWithAddr
+	AvgRating   float64 `json:"rating" db:"AvgRating"`
+	ReviewCount int     `json:"reviewCount" db:"ReviewCount"`
+}
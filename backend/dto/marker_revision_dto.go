@@ -0,0 +1,29 @@
+package dto
+
+import "github.com/Alfex4936/chulbong-kr/model"
+
+type MarkerRevisionWithUsername struct {
+	model.MarkerRevision
+	Username *string `json:"username,omitempty" db:"Username"`
+}
+
+type MarkerRevisionList struct {
+	Revisions      []MarkerRevisionWithUsername `json:"revisions"`
+	CurrentPage    int                          `json:"currentPage"`
+	TotalPages     int                          `json:"totalPages"`
+	TotalRevisions int                          `json:"totalRevisions"`
+}
+
+// MarkerFieldChange is one field that differs between two revisions.
+type MarkerFieldChange struct {
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+	Field string `json:"field"`
+}
+
+type MarkerRevisionDiff struct {
+	Changes        []MarkerFieldChange `json:"changes"`
+	MarkerID       int                 `json:"markerId"`
+	FromRevisionID int                 `json:"fromRevisionId"`
+	ToRevisionID   int                 `json:"toRevisionId"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+// MarkerRouteRequest is what a workout route is planned from.
+type MarkerRouteRequest struct {
+	Latitude  float64 `query:"latitude"`
+	Longitude float64 `query:"longitude"`
+	Distance  int     `query:"distance"`  // meters the whole route may be long
+	Stops     int     `query:"stops"`     // markers the route may visit
+	MinRating float64 `query:"minRating"` // 0 for any
+}
+
+// RouteStop is a marker on a planned route.
+type RouteStop struct {
+	Thumbnail     *string  `json:"thumbnail,omitempty"`
+	Rating        *float64 `json:"rating,omitempty"` // set when the route was planned with minRating
+	Latitude      float64  `json:"latitude"`
+	Longitude     float64  `json:"longitude"`
+	LegDistance   float64  `json:"legDistance"`   // meters from the previous stop or the start
+	RouteDistance float64  `json:"routeDistance"` // meters from the start along the route
+	MarkerID      int      `json:"markerId"`
+	Description   string   `json:"description"`
+	Address       string   `json:"address"`
+}
+
+// MarkerRoute is a workout route that visits markers in order from the start.
+type MarkerRoute struct {
+	Stops          []RouteStop `json:"stops"`
+	StartLatitude  float64     `json:"startLatitude"`
+	StartLongitude float64     `json:"startLongitude"`
+	TotalDistance  float64     `json:"totalDistance"` // meters from the start to the last stop
+}
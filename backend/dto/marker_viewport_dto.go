@@ -0,0 +1,16 @@
+package dto
+
+// MarkerViewport is a marker inside the requested bounding box, Thumbnail is only filled when asked for.
+type MarkerViewport struct {
+	Thumbnail *string `json:"thumbnail,omitempty" db:"Thumbnail"`
+	Latitude  float64 `json:"latitude" db:"Latitude"`
+	Longitude float64 `json:"longitude" db:"Longitude"`
+	MarkerID  int     `json:"markerId" db:"MarkerID"`
+	HasPhoto  bool    `json:"hasPhoto" db:"HasPhoto"`
+	Status    string  `json:"status" db:"Status"`
+}
+
+type MarkerViewportResponse struct {
+	Markers    []MarkerViewport `json:"markers"`
+	NextCursor int              `json:"nextCursor,omitempty"` // pass as cursor for the next page, absent on the last page
+}
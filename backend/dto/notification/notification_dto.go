@@ -23,3 +23,8 @@ type NotificationLikeMetadata struct {
 	UserId   int `json:"userId"`
 	LikerId  int `json:"likerId"`
 }
+
+type NotificationBadgeMetadata struct {
+	BadgeCode string `json:"badgeCode"`
+	UserId    int    `json:"userId"`
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+// Where a flagged photo was uploaded
+const (
+	PhotoSourceMarker = "MARKER"
+	PhotoSourceReport = "REPORT"
+)
+
+// FlaggedPhoto is an uploaded photo whose EXIF did not back up the place it was uploaded for,
+// or that looks like a photo of another marker.
+type FlaggedPhoto struct {
+	UploadedAt        time.Time  `json:"uploadedAt" db:"UploadedAt"`
+	TakenAt           *time.Time `json:"takenAt,omitempty" db:"TakenAt"`
+	ExifDistance      *float64   `json:"exifDistance,omitempty" db:"ExifDistance"` // meters from the marker
+	ThumbnailURL      *string    `json:"thumbnailUrl,omitempty" db:"ThumbnailURL"`
+	ReportID          *int       `json:"reportId,omitempty" db:"ReportID"`
+	PhotoID           int        `json:"photoId" db:"PhotoID"`
+	MarkerID          int        `json:"markerId" db:"MarkerID"`
+	VerificationScore int        `json:"verificationScore" db:"VerificationScore"`
+	Source            string     `json:"source" db:"Source"` // MARKER or REPORT
+	PhotoURL          string     `json:"photoUrl" db:"PhotoURL"`
+	VerificationFlags string     `json:"verificationFlags" db:"VerificationFlags"` // comma separated: FAR, OLD, DUPLICATE
+}
+
+type FlaggedPhotoList struct {
+	Photos      []FlaggedPhoto `json:"photos"`
+	CurrentPage int            `json:"currentPage"`
+	TotalPages  int            `json:"totalPages"`
+	TotalPhotos int            `json:"totalPhotos"`
+}
+
+// DuplicatePhoto is a marker photo that looks like the other photos of its group.
+type DuplicatePhoto struct {
+	UploadedAt     time.Time `json:"uploadedAt" db:"UploadedAt"`
+	ThumbnailURL   *string   `json:"thumbnailUrl,omitempty" db:"ThumbnailURL"`
+	PhotoID        int       `json:"photoId" db:"PhotoID"`
+	MarkerID       int       `json:"markerId" db:"MarkerID"`
+	Distance       int       `json:"distance" db:"-"` // hash bits that differ from the first photo of the group
+	PhotoURL       string    `json:"photoUrl" db:"PhotoURL"`
+	PerceptualHash uint64    `json:"-" db:"PerceptualHash"`
+}
+
+// DuplicatePhotoGroup is a set of photos of the same picture, oldest first.
+type DuplicatePhotoGroup struct {
+	Photos  []DuplicatePhoto `json:"photos"`
+	Markers int              `json:"markers"` // number of markers the photos belong to
+}
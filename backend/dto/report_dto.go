@@ -2,6 +2,8 @@ package dto
 
 import (
 	"time"
+
+	"github.com/Alfex4936/chulbong-kr/model"
 )
 
 type MarkerReportRequest struct {
@@ -13,22 +15,27 @@ type MarkerReportRequest struct {
 	UserID       int     `json:"userId"`
 	Description  string  `json:"description"`
 	DoesExist    bool    `json:"doesExist,omitempty"`
+	// ReportedStatus is the marker status the reporter observed (DAMAGED, UNDER_CONSTRUCTION, REMOVED), empty if none
+	ReportedStatus string `json:"reportedStatus,omitempty"`
+	// ReportedAccess is what the reporter says about access, nil fields are left as they are on approval
+	ReportedAccess model.MarkerAccess `json:"reportedAccess"`
 }
 
 type MarkerReportResponse struct {
-	Latitude     float64   `json:"latitude" db:"Latitude"`
-	Longitude    float64   `json:"longitude" db:"Longitude"`
-	NewLatitude  float64   `json:"newLatitude,omitempty" db:"NewLatitude"`
-	NewLongitude float64   `json:"newLongitude,omitempty" db:"NewLongitude"`
-	CreatedAt    time.Time `json:"createdAt" db:"CreatedAt"`
-	ReportID     int       `json:"reportId" db:"ReportID"`
-	MarkerID     int       `json:"markerId" db:"MarkerID"`
-	UserID       *int      `json:"userId,omitempty" db:"UserID"` // Pointer to handle nullable UserID
-	Description  string    `json:"description" db:"Description"`
-	PhotoURLs    []string  `json:"photoUrls,omitempty"` // Array to store multiple photo URLs
-	Status       string    `json:"status" db:"Status"`
-	Address      string    `json:"address,omitempty" db:"Address"`
-	DoesExist    bool      `json:"doesExist,omitempty" db:"DoesExist"`
+	Latitude       float64   `json:"latitude" db:"Latitude"`
+	Longitude      float64   `json:"longitude" db:"Longitude"`
+	NewLatitude    float64   `json:"newLatitude,omitempty" db:"NewLatitude"`
+	NewLongitude   float64   `json:"newLongitude,omitempty" db:"NewLongitude"`
+	CreatedAt      time.Time `json:"createdAt" db:"CreatedAt"`
+	ReportID       int       `json:"reportId" db:"ReportID"`
+	MarkerID       int       `json:"markerId" db:"MarkerID"`
+	UserID         *int      `json:"userId,omitempty" db:"UserID"` // Pointer to handle nullable UserID
+	Description    string    `json:"description" db:"Description"`
+	PhotoURLs      []string  `json:"photoUrls,omitempty"` // Array to store multiple photo URLs
+	Status         string    `json:"status" db:"Status"`
+	Address        string    `json:"address,omitempty" db:"Address"`
+	DoesExist      bool      `json:"doesExist,omitempty" db:"DoesExist"`
+	ReportedStatus string    `json:"reportedStatus,omitempty" db:"ReportedStatus"`
 }
 
 // MarkerReports groups all reports for a specific marker.
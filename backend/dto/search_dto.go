@@ -72,6 +72,8 @@ type MarkerIndexData struct {
 	Address           string `json:"address"` // such as Korean: 경기도 부천시 소사구 경인로29번길 32, 우성아파트
 	FullAddress       string `json:"fullAddress"`
 	InitialConsonants string `json:"initialConsonants"` // 초성
+	// FacilityID -> Quantity, keyed by string so each facility is its own numeric field such as "facilities.1"
+	Facilities map[string]int `json:"facilities,omitempty"`
 }
 
 type KoreaStation struct {
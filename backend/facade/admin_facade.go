@@ -8,16 +8,18 @@ import (
 	"encoding/hex"
 	"fmt"
 	"image"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"path"
-	"strings"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/Alfex4936/chulbong-kr/dto"
 	"github.com/Alfex4936/chulbong-kr/model"
 	"github.com/Alfex4936/chulbong-kr/service"
+	"github.com/Alfex4936/chulbong-kr/util"
 	"github.com/chai2010/webp"
 	"github.com/disintegration/imaging"
 	"github.com/gofiber/fiber/v2"
@@ -33,6 +35,9 @@ var (
 			return &bytes.Buffer{}
 		},
 	}
+
+	// Images resized at once by OptimizeImageWithContext
+	imageOptimizeSlots = make(chan struct{}, 4)
 )
 
 type AdminFacadeService struct {
@@ -40,7 +45,13 @@ type AdminFacadeService struct {
 	S3Service      *service.S3Service
 	ChatService    *service.ChatService
 	MarkerFacility *service.MarkerFacilityService
+	AssignService  *service.FacilityAssignmentService
 	RedisService   *service.RedisService
+	MergeService   *service.MarkerMergeService
+	ImportService  *service.MarkerImportService
+
+	ContributionService    *service.ContributionService
+	PhotoModerationService *service.PhotoModerationService
 
 	HTTPClient *http.Client
 
@@ -54,7 +65,13 @@ type AdminFacadeParams struct {
 	S3Service      *service.S3Service
 	ChatService    *service.ChatService
 	MarkerFacility *service.MarkerFacilityService
+	AssignService  *service.FacilityAssignmentService
 	RedisService   *service.RedisService
+	MergeService   *service.MarkerMergeService
+	ImportService  *service.MarkerImportService
+
+	ContributionService    *service.ContributionService
+	PhotoModerationService *service.PhotoModerationService
 
 	HTTPClient *http.Client
 	Logger     *zap.Logger
@@ -68,9 +85,15 @@ func NewAdminFacadeService(
 		S3Service:      p.S3Service,
 		ChatService:    p.ChatService,
 		MarkerFacility: p.MarkerFacility,
+		AssignService:  p.AssignService,
 		RedisService:   p.RedisService,
+		MergeService:   p.MergeService,
+		ImportService:  p.ImportService,
 		HTTPClient:     p.HTTPClient,
 		Logger:         p.Logger,
+
+		ContributionService:    p.ContributionService,
+		PhotoModerationService: p.PhotoModerationService,
 	}
 }
 
@@ -110,8 +133,64 @@ func (afs *AdminFacadeService) CreateMarkerWithPhotos(ctx context.Context, marke
 	return afs.MarkerManage.CreateMarkerWithPhotos(ctx, markerDto, userID, form)
 }
 
-func (afs *AdminFacadeService) SetMarkerFacilities(markerID int, facilities []dto.FacilityQuantity) error {
-	return afs.MarkerFacility.SetMarkerFacilities(markerID, facilities)
+func (afs *AdminFacadeService) SetMarkerFacilities(markerID int, facilities []dto.FacilityQuantity, adminID int) error {
+	return afs.AssignService.SetMarkerFacilities(markerID, facilities, service.RevisionByUser(adminID, "admin"))
+}
+
+func (afs *AdminFacadeService) CreateFacilityType(req *dto.FacilityTypeRequest) (*model.FacilityType, error) {
+	return afs.AssignService.CatalogService.CreateFacilityType(req)
+}
+
+func (afs *AdminFacadeService) UpdateFacilityType(facilityID int, req *dto.FacilityTypeRequest) (*model.FacilityType, error) {
+	return afs.AssignService.CatalogService.UpdateFacilityType(facilityID, req)
+}
+
+func (afs *AdminFacadeService) DeleteFacilityType(facilityID int) error {
+	return afs.AssignService.CatalogService.DeleteFacilityType(facilityID)
+}
+
+func (afs *AdminFacadeService) GetDeletedMarkers(page, pageSize int) (*dto.DeletedMarkerList, error) {
+	return afs.MarkerManage.GetDeletedMarkers(page, pageSize)
+}
+
+func (afs *AdminFacadeService) RestoreMarker(markerID int) (*dto.MarkerSimpleWithAddr, error) {
+	return afs.MarkerManage.RestoreMarker(markerID)
+}
+
+func (afs *AdminFacadeService) GetDuplicateMarkerGroups() ([]dto.MarkerGroup, error) {
+	return afs.MarkerManage.CheckNearbyMarkersInDB()
+}
+
+func (afs *AdminFacadeService) MergeMarkers(survivorID int, mergedIDs []int, adminID int) (*dto.MarkerMergeResult, error) {
+	return afs.MergeService.MergeMarkers(survivorID, mergedIDs, adminID)
+}
+
+func (afs *AdminFacadeService) GetMarkerMerges(page, pageSize int) (*dto.MarkerMergeList, error) {
+	return afs.MergeService.GetMarkerMerges(page, pageSize)
+}
+
+func (afs *AdminFacadeService) ImportMarkers(format string, r io.Reader, adminID int, dryRun bool) (*dto.MarkerImportReport, error) {
+	return afs.ImportService.ImportMarkers(format, r, adminID, dryRun)
+}
+
+func (afs *AdminFacadeService) RecomputeContributions(dryRun bool) (*dto.ContributionRecompute, error) {
+	return afs.ContributionService.Recompute(dryRun)
+}
+
+func (afs *AdminFacadeService) GetFlaggedPhotos(page, pageSize int) (*dto.FlaggedPhotoList, error) {
+	return afs.PhotoModerationService.GetFlaggedPhotos(page, pageSize)
+}
+
+func (afs *AdminFacadeService) DismissPhotoFlags(source string, photoID int) error {
+	return afs.PhotoModerationService.DismissFlags(source, photoID)
+}
+
+func (afs *AdminFacadeService) GetDuplicatePhotoGroups() ([]dto.DuplicatePhotoGroup, error) {
+	return afs.PhotoModerationService.GetDuplicatePhotoGroups()
+}
+
+func (afs *AdminFacadeService) StartPhotoHashBackfill() bool {
+	return afs.PhotoModerationService.StartHashBackfill()
 }
 
 func (afs *AdminFacadeService) ResetMarkerCache() {
@@ -205,7 +284,7 @@ func (afs *AdminFacadeService) DeleteMarkerPhoto(ctx context.Context, markerID,
 	query := `
         SELECT PhotoID, PhotoURL, ThumbnailURL
         FROM Photos
-        WHERE MarkerID = ?
+        WHERE MarkerID = ? AND DeletedAt IS NULL
         ORDER BY UploadedAt ASC
         LIMIT 1 OFFSET ?
     `
@@ -245,25 +324,38 @@ func (afs *AdminFacadeService) DeleteMarkerPhoto(ctx context.Context, markerID,
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+
+	afs.MarkerManage.CacheService.InvalidateMarkerTiles()
 	return nil
 }
 
 func (afs *AdminFacadeService) OptimizeImage(srcURL string, width, quality int, acceptHeader string) ([]byte, string, error) {
+	return afs.OptimizeImageWithContext(context.Background(), srcURL, width, quality, acceptHeader)
+}
+
+// OptimizeImageWithContext resizes the image at srcURL and encodes it in the format the Accept header asks for.
+// Widths are rounded up to a few fixed ones and results are cached, so responses vary only by URL and Accept.
+// Photos in our bucket are served from the variants made after upload when there is one for the width and format.
+func (afs *AdminFacadeService) OptimizeImageWithContext(ctx context.Context, srcURL string, width, quality int, acceptHeader string) ([]byte, string, error) {
 	// Validate the input.
 	if srcURL == "" {
 		return nil, "", fmt.Errorf("missing url parameter")
 	}
 
-	// Determine output format.
-	var ext string
-	if strings.Contains(acceptHeader, "image/webp") {
-		ext = ".webp"
-	} else {
-		ext = strings.ToLower(path.Ext(srcURL))
-		// If the extension isn't one of the supported types, default to JPEG.
-		if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" && ext != ".webp" {
-			ext = ".jpeg"
-		}
+	// Determine output format and size.
+	ext := util.NegotiateImageFormat(acceptHeader, path.Ext(srcURL))
+	width = util.SnapImageWidth(width)
+
+	var contentType string
+	switch ext {
+	case ".png":
+		contentType = "image/png"
+	case ".gif":
+		contentType = "image/gif"
+	case ".webp":
+		contentType = "image/webp"
+	default:
+		contentType = "image/jpeg"
 	}
 
 	// Build a unique cache key based on the source URL and parameters.
@@ -274,36 +366,86 @@ func (afs *AdminFacadeService) OptimizeImage(srcURL string, width, quality int,
 	// Check Redis cache for an existing optimized image.
 	var cached []byte
 	if err := afs.RedisService.GetCacheEntry(cacheKey, &cached); err == nil && len(cached) > 0 {
-		var contentType string
-		switch ext {
-		case ".png":
-			contentType = "image/png"
-		case ".gif":
-			contentType = "image/gif"
-		case ".webp":
-			contentType = "image/webp"
-		default:
-			contentType = "image/jpeg"
-		}
 		return cached, contentType, nil
 	}
 
+	// Wait for a free slot, resizing is the most expensive thing this server does.
+	select {
+	case imageOptimizeSlots <- struct{}{}:
+		defer func() { <-imageOptimizeSlots }()
+	case <-ctx.Done():
+		return nil, "", fmt.Errorf("image optimizer busy: %w", ctx.Err())
+	}
+
+	resultBytes := afs.photoVariant(ctx, srcURL, width, ext)
+	if resultBytes == nil {
+		var err error
+		resultBytes, err = afs.resizeImage(ctx, srcURL, width, quality, ext)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Cache the optimized image in Redis with an expiration (e.g., 24 hours).
+	cacheExpiration := 24 * time.Hour
+	if err := afs.RedisService.SetCacheEntry(cacheKey, resultBytes, cacheExpiration); err != nil {
+		// Log the error but do not fail the request.
+		afs.Logger.Error("failed to set cache entry", zap.Error(err))
+	}
+
+	return resultBytes, contentType, nil
+}
+
+// photoVariant reads the variant made after upload of a photo in our bucket, nil when it has none in this width and format.
+func (afs *AdminFacadeService) photoVariant(ctx context.Context, srcURL string, width int, ext string) []byte {
+	variantExt := util.PhotoVariantJPEG
+	switch ext {
+	case ".webp":
+		variantExt = util.PhotoVariantWebP
+	case ".jpg", ".jpeg":
+	default:
+		return nil
+	}
+	if !slices.Contains(util.PhotoVariantWidths, width) || !afs.S3Service.IsBucketURL(srcURL) {
+		return nil
+	}
+
+	// Photos from before variants, or smaller than the width, do not have one
+	data, err := afs.S3Service.DownloadFromS3(ctx, util.PhotoVariantKey(srcURL, width, variantExt))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// resizeImage fetches the image at srcURL, resizes it to width (0 keeps it) and encodes it as ext.
+func (afs *AdminFacadeService) resizeImage(ctx context.Context, srcURL string, width, quality int, ext string) ([]byte, error) {
 	// Fetch the source image.
-	resp, err := afs.HTTPClient.Get(srcURL)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("failed to fetch image")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srcURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url parameter: %w", err)
+	}
+	resp, err := afs.HTTPClient.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to fetch image")
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch image")
+	}
 
 	// Decode the image.
 	srcImg, err := imaging.Decode(resp.Body)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+		return nil, fmt.Errorf("failed to decode image: %w", err)
 	}
 
-	// Resize the image if a width is provided (height is auto-calculated).
+	// Resize the image if a width is provided (height is auto-calculated), never enlarging it.
 	var dstImg *image.NRGBA
-	if width > 0 {
+	if width > 0 && width < srcImg.Bounds().Dx() {
 		dstImg = imaging.Resize(srcImg, width, 0, imaging.Lanczos)
 	} else {
 		// If the image is already in the desired format, avoid cloning.
@@ -322,33 +464,20 @@ func (afs *AdminFacadeService) OptimizeImage(srcURL string, width, quality int,
 	}()
 
 	// Encode the optimized image into the chosen format.
-	var contentType string
 	switch ext {
 	case ".png":
-		contentType = "image/png"
 		err = imaging.Encode(buf, dstImg, imaging.PNG)
 	case ".gif":
-		contentType = "image/gif"
 		err = imaging.Encode(buf, dstImg, imaging.GIF)
 	case ".webp":
-		contentType = "image/webp"
 		err = webp.Encode(buf, dstImg, &webp.Options{Quality: float32(quality)})
 	default: // .jpeg or .jpg
-		contentType = "image/jpeg"
 		err = imaging.Encode(buf, dstImg, imaging.JPEG, imaging.JPEGQuality(quality))
 	}
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+		return nil, fmt.Errorf("failed to encode image: %w", err)
 	}
 
-	resultBytes := buf.Bytes()
-
-	// Cache the optimized image in Redis with an expiration (e.g., 24 hours).
-	cacheExpiration := 24 * time.Hour
-	if err := afs.RedisService.SetCacheEntry(cacheKey, resultBytes, cacheExpiration); err != nil {
-		// Log the error but do not fail the request.
-		afs.Logger.Error("failed to set cache entry", zap.Error(err))
-	}
-
-	return resultBytes, contentType, nil
+	// The buffer goes back to the pool, the result must not share it
+	return bytes.Clone(buf.Bytes()), nil
 }
@@ -1,16 +1,33 @@
 package facade
 
 import (
+	"io"
+
 	"github.com/Alfex4936/chulbong-kr/dto"
 	"github.com/Alfex4936/chulbong-kr/dto/kakao"
+	"github.com/Alfex4936/chulbong-kr/util"
 )
 
 // Get
 func (mfs *MarkerFacadeService) FindClosestNMarkersWithinDistance(lat, lng float64, distance, pageSize, offset int) ([]dto.MarkerWithDistanceAndPhoto, int, error) {
 	return mfs.LocationService.FindClosestNMarkersWithinDistance(lat, lng, distance, pageSize, offset)
 }
-func (mfs *MarkerFacadeService) FindRankedMarkersInCurrentArea(lat, lng float64, distance, limit int) ([]dto.MarkerWithDistanceAndPhoto, error) {
-	return mfs.LocationService.FindRankedMarkersInCurrentArea(lat, lng, distance, limit)
+func (mfs *MarkerFacadeService) FindClosestNMarkersFiltered(lat, lng float64, distance, pageSize, offset int, facilities util.FacilityFilter, access dto.MarkerAccessFilter) ([]dto.MarkerWithDistanceAndPhoto, int, error) {
+	return mfs.LocationService.FindClosestNMarkersFiltered(lat, lng, distance, pageSize, offset, facilities, access)
+}
+func (mfs *MarkerFacadeService) FindMarkersInBBox(box util.BBox, facilities util.FacilityFilter, access dto.MarkerAccessFilter, withThumbnails bool, cursor, limit int) (*dto.MarkerViewportResponse, error) {
+	return mfs.LocationService.FindMarkersInBBox(box, facilities, access, withThumbnails, cursor, limit)
+}
+func (mfs *MarkerFacadeService) FindRankedMarkersInCurrentArea(lat, lng float64, distance, limit int, sortBy string) ([]dto.MarkerWithDistanceAndPhoto, error) {
+	return mfs.LocationService.FindRankedMarkersInCurrentArea(lat, lng, distance, limit, sortBy)
+}
+
+func (mfs *MarkerFacadeService) PlanRoute(req *dto.MarkerRouteRequest, facilities util.FacilityFilter, access dto.MarkerAccessFilter) (*dto.MarkerRoute, error) {
+	return mfs.LocationService.PlanRoute(req, facilities, access)
+}
+
+func (mfs *MarkerFacadeService) WriteRouteGPX(w io.Writer, route *dto.MarkerRoute) error {
+	return mfs.LocationService.WriteRouteGPX(w, route)
 }
 
 func (mfs *MarkerFacadeService) FetchWeatherFromAddress(lat, lng float64) (*kakao.WeatherRequest, error) {
@@ -3,6 +3,7 @@ package facade
 import (
 	"context"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"time"
 
@@ -27,9 +28,21 @@ type MarkerFacadeService struct {
 	ManageService   *service.MarkerManageService
 	RankService     *service.MarkerRankService
 	FacilityService *service.MarkerFacilityService
+	AssignService   *service.FacilityAssignmentService
 	StoryService    *service.StoryService
 	RedisService    *service.RedisService
 	ReportService   *service.ReportService
+	RevisionService *service.MarkerRevisionService
+	MergeService    *service.MarkerMergeService
+	ExportService   *service.MarkerExportService
+	TileService     *service.MarkerTileService
+	ClusterService  *service.MarkerClusterService
+	ChangeService   *service.MarkerChangeService
+	AccessService   *service.MarkerAccessService
+	ReviewService   *service.MarkerReviewService
+	CheckInService  *service.MarkerCheckInService
+
+	LeaderboardService *service.LeaderboardService
 
 	UserService *service.UserService
 
@@ -49,9 +62,21 @@ type MarkerFacadeParams struct {
 	ManageService   *service.MarkerManageService
 	RankService     *service.MarkerRankService
 	FacilityService *service.MarkerFacilityService
+	AssignService   *service.FacilityAssignmentService
 	RedisService    *service.RedisService
 	ReportService   *service.ReportService
 	StoryService    *service.StoryService
+	RevisionService *service.MarkerRevisionService
+	MergeService    *service.MarkerMergeService
+	ExportService   *service.MarkerExportService
+	TileService     *service.MarkerTileService
+	ClusterService  *service.MarkerClusterService
+	ChangeService   *service.MarkerChangeService
+	AccessService   *service.MarkerAccessService
+	ReviewService   *service.MarkerReviewService
+	CheckInService  *service.MarkerCheckInService
+
+	LeaderboardService *service.LeaderboardService
 
 	UserService *service.UserService
 
@@ -73,14 +98,26 @@ func NewMarkerFacadeService(
 		ManageService:   p.ManageService,
 		RankService:     p.RankService,
 		FacilityService: p.FacilityService,
+		AssignService:   p.AssignService,
 		RedisService:    p.RedisService,
 		ReportService:   p.ReportService,
 		UserService:     p.UserService,
 		StoryService:    p.StoryService,
+		RevisionService: p.RevisionService,
+		MergeService:    p.MergeService,
+		ExportService:   p.ExportService,
+		TileService:     p.TileService,
+		ClusterService:  p.ClusterService,
+		ChangeService:   p.ChangeService,
+		AccessService:   p.AccessService,
+		ReviewService:   p.ReviewService,
+		CheckInService:  p.CheckInService,
 		ChatUtil:        p.ChatUtil,
 		BadWordUtil:     p.BadWordUtil,
 		MapUtil:         p.MapUtil,
 		wcongCache:      gocache.New[[]byte](p.GoCache),
+
+		LeaderboardService: p.LeaderboardService,
 	}
 }
 
@@ -89,6 +126,31 @@ func (mfs *MarkerFacadeService) GetMarker(markerID int) (*model.MarkerWithPhotos
 	return mfs.ManageService.GetMarker(markerID)
 }
 
+// ExportMarkers streams the markers matching filter to w as GeoJSON, KML or GPX.
+func (mfs *MarkerFacadeService) ExportMarkers(format string, filter dto.MarkerExportFilter, w io.Writer) (int, error) {
+	return mfs.ExportService.ExportMarkers(format, filter, w)
+}
+
+// GetMarkerTile returns the vector tile z/x/y, empty when no marker is on it.
+func (mfs *MarkerFacadeService) GetMarkerTile(z, x, y int) ([]byte, error) {
+	return mfs.TileService.GetMarkerTile(z, x, y)
+}
+
+// GetMarkerClusters returns the marker clusters in box at zoom.
+func (mfs *MarkerFacadeService) GetMarkerClusters(box util.BBox, zoom int) (*dto.MarkerClusterResponse, error) {
+	return mfs.ClusterService.GetClusters(box, zoom)
+}
+
+// GetMarkerChanges returns the marker changes after the since cursor.
+func (mfs *MarkerFacadeService) GetMarkerChanges(since int64, limit int) (*dto.MarkerChangesResponse, error) {
+	return mfs.ChangeService.GetChanges(since, limit)
+}
+
+// ResolveMergedMarker returns the marker a merged duplicate now lives on.
+func (mfs *MarkerFacadeService) ResolveMergedMarker(markerID int) (int, bool) {
+	return mfs.MergeService.ResolveMergedMarker(markerID)
+}
+
 func (mfs *MarkerFacadeService) GetAllMarkers() ([]dto.MarkerSimple, error) {
 	return mfs.ManageService.GetAllMarkers()
 }
@@ -117,8 +179,8 @@ func (mfs *MarkerFacadeService) GetAllMarkersByUserWithPagination(userID, page,
 	return mfs.ManageService.GetAllMarkersByUserWithPagination(userID, page, pageSize)
 }
 
-func (mfs *MarkerFacadeService) GetFacilitiesByMarkerID(markerID int) ([]model.Facility, error) {
-	return mfs.FacilityService.GetFacilitiesByMarkerID(markerID)
+func (mfs *MarkerFacadeService) GetFacilitiesByMarkerID(markerID int) ([]dto.MarkerFacilityDetail, error) {
+	return mfs.AssignService.GetFacilitiesByMarkerID(markerID)
 }
 
 func (mfs *MarkerFacadeService) CheckNearbyMarkersInDB() ([]dto.MarkerGroup, error) {
@@ -138,8 +200,8 @@ func (mfs *MarkerFacadeService) CreateMarkerWithPhotos(ctx context.Context, mark
 	return mfs.ManageService.CreateMarkerWithPhotos(ctx, markerDto, userID, form)
 }
 
-func (mfs *MarkerFacadeService) UpdateMarkerDescriptionOnly(markerID int, description string) error {
-	return mfs.ManageService.UpdateMarkerDescriptionOnly(markerID, description)
+func (mfs *MarkerFacadeService) UpdateMarkerDescriptionOnly(markerID int, description string, userID int, userRole string) error {
+	return mfs.ManageService.UpdateMarkerDescriptionOnly(markerID, description, service.RevisionByUser(userID, userRole))
 }
 
 func (mfs *MarkerFacadeService) DeleteMarker(userID, markerID int, userRole string) error {
@@ -150,11 +212,74 @@ func (mfs *MarkerFacadeService) UploadMarkerPhotoToS3(markerID int, files []*mul
 	return mfs.ManageService.UploadMarkerPhotoToS3(markerID, files)
 }
 
-func (mfs *MarkerFacadeService) SetMarkerFacilities(markerID int, facilities []dto.FacilityQuantity) error {
-	return mfs.FacilityService.SetMarkerFacilities(markerID, facilities)
+// ListFacilityTypes returns the facility catalog.
+func (mfs *MarkerFacadeService) ListFacilityTypes() ([]model.FacilityType, error) {
+	return mfs.AssignService.CatalogService.ListFacilityTypes()
+}
+
+// ParseFacilityRequirements reads a facility filter, names are resolved through the facility catalog.
+func (mfs *MarkerFacadeService) ParseFacilityRequirements(s, match string) (util.FacilityFilter, error) {
+	return util.ParseFacilityRequirements(s, match, mfs.AssignService.CatalogService.FacilityNames())
+}
+
+func (mfs *MarkerFacadeService) SetMarkerFacilities(markerID int, facilities []dto.FacilityQuantity, userID int, userRole string) error {
+	return mfs.AssignService.SetMarkerFacilities(markerID, facilities, service.RevisionByUser(userID, userRole))
 }
+
+// UpdateMarkerAccess replaces the access metadata of a marker, for its owner or an admin.
+func (mfs *MarkerFacadeService) UpdateMarkerAccess(markerID int, access *model.MarkerAccess, userID int, userRole string) error {
+	return mfs.AccessService.UpdateMarkerAccess(markerID, access, userID, userRole)
+}
+
 func (mfs *MarkerFacadeService) UpdateMarkersAddresses() ([]dto.MarkerSimpleWithAddr, error) {
-	return mfs.FacilityService.UpdateMarkersAddresses()
+	return mfs.ManageService.UpdateMarkersAddresses()
+}
+
+// REVIEWS
+func (mfs *MarkerFacadeService) GetMarkerReviews(markerID, page, pageSize int) (*dto.MarkerReviewList, error) {
+	return mfs.ReviewService.GetReviews(markerID, page, pageSize)
+}
+
+func (mfs *MarkerFacadeService) GetMarkerReview(markerID, userID int) (*model.MarkerReview, error) {
+	return mfs.ReviewService.GetReview(markerID, userID)
+}
+
+func (mfs *MarkerFacadeService) SaveMarkerReview(markerID, userID int, req *dto.MarkerReviewRequest) (*model.MarkerReview, error) {
+	return mfs.ReviewService.SaveReview(markerID, userID, req)
+}
+
+func (mfs *MarkerFacadeService) DeleteMarkerReview(markerID, userID int) error {
+	return mfs.ReviewService.DeleteReview(markerID, userID)
+}
+
+// CHECK-INS
+func (mfs *MarkerFacadeService) CheckIn(markerID, userID int, req *dto.CheckInRequest) (*model.MarkerCheckIn, error) {
+	return mfs.CheckInService.CheckIn(markerID, userID, req)
+}
+
+func (mfs *MarkerFacadeService) DeleteCheckIn(checkInID, userID int) error {
+	return mfs.CheckInService.DeleteCheckIn(checkInID, userID)
+}
+
+func (mfs *MarkerFacadeService) GetCheckIns(userID, page, pageSize int) (*dto.CheckInList, error) {
+	return mfs.CheckInService.GetCheckIns(userID, page, pageSize)
+}
+
+func (mfs *MarkerFacadeService) GetCheckInStats(userID int) (*dto.CheckInStats, error) {
+	return mfs.CheckInService.GetCheckInStats(userID)
+}
+
+// REVISIONS
+func (mfs *MarkerFacadeService) GetMarkerRevisions(markerID, page, pageSize int) (*dto.MarkerRevisionList, error) {
+	return mfs.RevisionService.GetRevisions(markerID, page, pageSize)
+}
+
+func (mfs *MarkerFacadeService) DiffMarkerRevisions(markerID, fromRevisionID, toRevisionID int) (*dto.MarkerRevisionDiff, error) {
+	return mfs.RevisionService.DiffRevisions(markerID, fromRevisionID, toRevisionID)
+}
+
+func (mfs *MarkerFacadeService) RollbackMarkerRevision(markerID, revisionID, userID int, userRole string) (*model.MarkerRevision, error) {
+	return mfs.RevisionService.RollbackToRevision(markerID, revisionID, userID, userRole)
 }
 
 // RANK
@@ -2,6 +2,7 @@ package facade
 
 import (
 	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/service"
 )
 
 // Get
@@ -9,6 +10,26 @@ func (mfs *MarkerFacadeService) GetTopMarkers(limit int) []dto.MarkerSimpleWithA
 	return mfs.RankService.GetTopMarkers(limit)
 }
 
+func (mfs *MarkerFacadeService) GetTopRatedMarkers(limit int) ([]dto.MarkerRatedWithAddr, error) {
+	return mfs.RankService.GetTopRatedMarkers(limit)
+}
+
+func (mfs *MarkerFacadeService) GetTopVisitedMarkers(limit int) ([]dto.MarkerVisitedWithAddr, error) {
+	return mfs.RankService.GetTopVisitedMarkers(limit)
+}
+
+func (mfs *MarkerFacadeService) GetMarkerLeaderboard(markerID int, period string, limit int) (*dto.Leaderboard, error) {
+	return mfs.LeaderboardService.GetLeaderboard(service.NewMarkerLeaderboard(markerID, period), limit)
+}
+
+func (mfs *MarkerFacadeService) GetRegionLeaderboard(province, city, period string, limit int) (*dto.Leaderboard, error) {
+	board, err := service.NewRegionLeaderboard(province, city, period)
+	if err != nil {
+		return nil, err
+	}
+	return mfs.LeaderboardService.GetLeaderboard(board, limit)
+}
+
 func (mfs *MarkerFacadeService) GetUniqueVisitorCount(markerID string) int {
 	return mfs.RankService.GetUniqueVisitorCount(markerID)
 }
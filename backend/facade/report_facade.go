@@ -15,7 +15,7 @@ func (mfs *MarkerFacadeService) GetAllReportsBy(markerID int) ([]dto.MarkerRepor
 	return mfs.ReportService.GetAllReportsBy(markerID)
 }
 
-func (mfs *MarkerFacadeService) CreateReport(report *dto.MarkerReportRequest, form *multipart.Form) error {
+func (mfs *MarkerFacadeService) CreateReport(report *dto.MarkerReportRequest, form *multipart.Form) ([]dto.PhotoWarning, error) {
 	return mfs.ReportService.CreateReport(report, form)
 }
 
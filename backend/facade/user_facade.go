@@ -16,6 +16,9 @@ type UserFacadeService struct {
 	RedisService  *service.RedisService
 	ReportService *service.ReportService
 	S3Service     *service.S3Service
+	BadgeService  *service.BadgeService
+
+	ContributionService *service.ContributionService
 }
 
 func NewUserFacadeService(
@@ -23,11 +26,16 @@ func NewUserFacadeService(
 	redis *service.RedisService,
 	reporter *service.ReportService,
 	s3 *service.S3Service,
+	badge *service.BadgeService,
+	contribution *service.ContributionService,
 ) *UserFacadeService {
 	return &UserFacadeService{
 		UserService:  user,
 		RedisService: redis,
 		S3Service:    s3,
+		BadgeService: badge,
+
+		ContributionService: contribution,
 	}
 }
 
@@ -65,6 +73,18 @@ func (mfs *UserFacadeService) GetUserStatistics(userID int) (int, int, error) {
 	return mfs.UserService.GetUserStatistics(userID)
 }
 
+func (mfs *UserFacadeService) GetUserBadges(userID int) ([]dto.Badge, error) {
+	return mfs.BadgeService.GetUserBadges(userID)
+}
+
+func (mfs *UserFacadeService) GetEarnedBadges(userID int) ([]dto.Badge, error) {
+	return mfs.BadgeService.GetEarnedBadges(userID)
+}
+
+func (mfs *UserFacadeService) GetContributionHistory(userID, page, pageSize int) (*dto.ContributionHistory, error) {
+	return mfs.ContributionService.GetHistory(userID, page, pageSize)
+}
+
 func (mfs *UserFacadeService) SetRedisCache(key string, value interface{}, expiration time.Duration) error {
 	return mfs.RedisService.SetCacheEntry(key, value, expiration)
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/gif"
@@ -98,6 +99,30 @@ func RegisterAdminRoutes(api fiber.Router, handler *AdminHandler, authMiddleware
 		adminGroup.Delete("/notices/:noticeID", handler.HandleDeleteNotice)
 
 		adminGroup.Delete("/photo", handler.HandleDeletePhoto)
+		adminGroup.Get("/photos/flagged", handler.HandleListFlaggedPhotos)
+		adminGroup.Delete("/photos/:photoID/flags", handler.HandleDismissPhotoFlags)
+		adminGroup.Get("/photos/duplicates", handler.HandleListDuplicatePhotos)
+		adminGroup.Post("/photos/hashes/backfill", handler.HandleBackfillPhotoHashes)
+
+		// Soft-deleted markers
+		adminGroup.Get("/markers/deleted", handler.HandleListDeletedMarkers)
+		adminGroup.Post("/markers/:markerID/restore", handler.HandleRestoreMarker)
+
+		// Duplicate markers
+		adminGroup.Get("/markers/duplicates", handler.HandleListDuplicateMarkers)
+		adminGroup.Get("/markers/merges", handler.HandleListMarkerMerges)
+		adminGroup.Post("/markers/:markerID/merge", handler.HandleMergeMarkers)
+
+		// Bulk import
+		adminGroup.Post("/markers/import", handler.HandleImportMarkers)
+
+		// Contribution points
+		adminGroup.Post("/contributions/recompute", handler.HandleRecomputeContributions)
+
+		// Facility catalog
+		adminGroup.Post("/facilities", handler.HandleCreateFacilityType)
+		adminGroup.Put("/facilities/:facilityID", handler.HandleUpdateFacilityType)
+		adminGroup.Delete("/facilities/:facilityID", handler.HandleDeleteFacilityType)
 
 		// User warning management
 		adminGroup.Get("/users/warnings", handler.HandleGetUsersWithWarnings)
@@ -344,7 +369,7 @@ func (h *AdminHandler) HandleListUpdatedMarkers(c *fiber.Ctx) error {
 			if err := h.AdminFacade.SetMarkerFacilities(newMarkerID, []dto.FacilityQuantity{
 				{FacilityID: 1, Quantity: m.ChulbongCount},
 				{FacilityID: 2, Quantity: m.PyeongCount},
-			}); err != nil {
+			}, userID); err != nil {
 				continue
 				// return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to set facilities for marker"})
 			}
@@ -564,6 +589,471 @@ func (h *AdminHandler) HandleDeleteNotice(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Notice deleted successfully"})
 }
 
+// HandleListDeletedMarkers lists soft-deleted markers that have not been purged yet.
+//
+// @Summary List deleted markers
+// @Description Returns soft-deleted markers, most recently deleted first. Admin only.
+// @ID admin-list-deleted-markers
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of markers per page" default(10)
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DeletedMarkerList "Deleted markers"
+// @Failure 400 {object} map[string]string "Invalid pagination parameters"
+// @Failure 500 {object} map[string]string "Failed to retrieve deleted markers"
+// @Router /api/v1/admin/markers/deleted [get]
+func (h *AdminHandler) HandleListDeletedMarkers(c *fiber.Ctx) error {
+	pagination, err := util.ParsePaginationParams(c, &util.PaginationConfig{
+		DefaultPage:       1,
+		DefaultPageSize:   10,
+		PageParamName:     "page",
+		PageSizeParamName: "pageSize",
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pagination parameters"})
+	}
+
+	markers, err := h.AdminFacade.GetDeletedMarkers(pagination.Page, pagination.PageSize)
+	if err != nil {
+		h.Logger.Error("failed to list deleted markers", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve deleted markers"})
+	}
+
+	return c.JSON(markers)
+}
+
+// HandleRestoreMarker restores a soft-deleted marker with its photos, comments, favorites and facilities.
+//
+// @Summary Restore a deleted marker
+// @Description Undoes a marker deletion as long as the marker has not been purged yet. Admin only.
+// @ID admin-restore-marker
+// @Tags admin
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MarkerSimpleWithAddr "Restored marker"
+// @Failure 400 {object} map[string]string "Invalid marker ID"
+// @Failure 404 {object} map[string]string "No deleted marker with this ID"
+// @Failure 500 {object} map[string]string "Failed to restore marker"
+// @Router /api/v1/admin/markers/{markerID}/restore [post]
+func (h *AdminHandler) HandleRestoreMarker(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+
+	marker, err := h.AdminFacade.RestoreMarker(markerID)
+	if err != nil {
+		if errors.Is(err, service.ErrMarkerNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No deleted marker with this ID"})
+		}
+		h.Logger.Error("failed to restore marker", zap.Int("markerID", markerID), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to restore marker"})
+	}
+
+	return c.JSON(marker)
+}
+
+// HandleListDuplicateMarkers lists groups of markers that sit within 10 meters of each other.
+//
+// @Summary List duplicate marker candidates
+// @Description Returns every marker that has other markers within 10 meters, as candidates for a merge. Admin only.
+// @ID admin-list-duplicate-markers
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} dto.MarkerGroup "Groups of nearby markers"
+// @Failure 500 {object} map[string]string "Failed to retrieve duplicate markers"
+// @Router /api/v1/admin/markers/duplicates [get]
+func (h *AdminHandler) HandleListDuplicateMarkers(c *fiber.Ctx) error {
+	groups, err := h.AdminFacade.GetDuplicateMarkerGroups()
+	if err != nil {
+		h.Logger.Error("failed to list duplicate markers", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve duplicate markers"})
+	}
+
+	if groups == nil {
+		return c.JSON([]dto.MarkerGroup{})
+	}
+
+	return c.JSON(groups)
+}
+
+// HandleMergeMarkers merges duplicate markers into the marker in the path.
+//
+// @Summary Merge duplicate markers
+// @Description Moves photos, comments, favorites, dislikes, stories, reports, facilities, click ranks and chat history
+// @Description of the given markers onto the surviving marker and deletes them. Old marker IDs redirect to the survivor. Admin only.
+// @ID admin-merge-markers
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Surviving marker ID"
+// @Param request body dto.MarkerMergeRequest true "Markers to merge into the survivor"
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MarkerMergeResult "Merge records"
+// @Failure 400 {object} map[string]string "Invalid marker ID or nothing to merge"
+// @Failure 404 {object} map[string]string "Survivor or merged marker not found"
+// @Failure 500 {object} map[string]string "Failed to merge markers"
+// @Router /api/v1/admin/markers/{markerID}/merge [post]
+func (h *AdminHandler) HandleMergeMarkers(c *fiber.Ctx) error {
+	adminID := c.Locals("userID").(int)
+
+	survivorID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+
+	var req dto.MarkerMergeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	result, err := h.AdminFacade.MergeMarkers(survivorID, req.MergeIDs, adminID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidMerge):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "No markers to merge"})
+		case errors.Is(err, service.ErrMarkerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		default:
+			h.Logger.Error("failed to merge markers", zap.Int("survivorID", survivorID), zap.Ints("mergeIds", req.MergeIDs), zap.Error(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to merge markers"})
+		}
+	}
+
+	return c.JSON(result)
+}
+
+// HandleListMarkerMerges lists past marker merges.
+//
+// @Summary List marker merges
+// @Description Returns the audit records of merged markers, newest first. Admin only.
+// @ID admin-list-marker-merges
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of merges per page" default(10)
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MarkerMergeList "Merge records"
+// @Failure 400 {object} map[string]string "Invalid pagination parameters"
+// @Failure 500 {object} map[string]string "Failed to retrieve merges"
+// @Router /api/v1/admin/markers/merges [get]
+func (h *AdminHandler) HandleListMarkerMerges(c *fiber.Ctx) error {
+	pagination, err := util.ParsePaginationParams(c, &util.PaginationConfig{
+		DefaultPage:       1,
+		DefaultPageSize:   10,
+		PageParamName:     "page",
+		PageSizeParamName: "pageSize",
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pagination parameters"})
+	}
+
+	merges, err := h.AdminFacade.GetMarkerMerges(pagination.Page, pagination.PageSize)
+	if err != nil {
+		h.Logger.Error("failed to list marker merges", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve merges"})
+	}
+
+	return c.JSON(merges)
+}
+
+// HandleImportMarkers creates markers in bulk from a GeoJSON, CSV or KML file.
+//
+// @Summary Bulk import markers
+// @Description Validates every row like a normal marker creation (South Korea, nearby markers, restricted areas, bad words)
+// @Description and inserts the valid ones. CSV needs a header with lat and lng, plus optional description and facilities ("1:2;2:1") columns.
+// @Description With dryRun=true nothing is written and the report shows what would be inserted. Admin only.
+// @ID admin-import-markers
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "GeoJSON, CSV or KML file"
+// @Param format query string false "geojson, csv or kml, detected from the file extension when omitted"
+// @Param dryRun query bool false "Only validate, do not insert" default(false)
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MarkerImportReport "Per-row import report"
+// @Failure 400 {object} map[string]string "Missing file, unknown format or unreadable file"
+// @Router /api/v1/admin/markers/import [post]
+func (h *AdminHandler) HandleImportMarkers(c *fiber.Ctx) error {
+	adminID := c.Locals("userID").(int)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		if format, err = util.DetectImportFormat(fileHeader.Filename); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "could not read file"})
+	}
+	defer file.Close()
+
+	report, err := h.AdminFacade.ImportMarkers(format, file, adminID, c.QueryBool("dryRun", false))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(report)
+}
+
+// HandleRecomputeContributions reconciles the contribution points ledger with the current rules.
+//
+// @Summary Recompute contribution points
+// @Description Awards points that are missing and revokes points that are no longer earned (deleted markers, reversed reports),
+// @Description then rebuilds every user's total from the ledger. Corrections are appended with reason RECOMPUTE.
+// @Description With dryRun=true nothing is written and the result shows what would change. Admin only.
+// @ID admin-recompute-contributions
+// @Tags admin
+// @Produce json
+// @Param dryRun query bool false "Only report what would change" default(false)
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.ContributionRecompute "Entries written per rule and users whose total changed"
+// @Failure 500 {object} map[string]string "Failed to recompute contributions"
+// @Router /api/v1/admin/contributions/recompute [post]
+func (h *AdminHandler) HandleRecomputeContributions(c *fiber.Ctx) error {
+	result, err := h.AdminFacade.RecomputeContributions(c.QueryBool("dryRun", false))
+	if err != nil {
+		h.Logger.Error("failed to recompute contributions", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to recompute contributions"})
+	}
+
+	return c.JSON(result)
+}
+
+// HandleListFlaggedPhotos lists uploaded photos whose EXIF does not back up their marker.
+//
+// @Summary List flagged photos
+// @Description Returns marker photos and photos of pending reports whose EXIF says they were taken 500 meters or more
+// @Description from the marker (FAR) or more than two years ago (OLD), or that look like a photo of another marker
+// @Description (DUPLICATE), newest upload first. Admin only.
+// @ID admin-list-flagged-photos
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of photos per page" default(20)
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.FlaggedPhotoList "Flagged photos"
+// @Failure 400 {object} map[string]string "Invalid pagination parameters"
+// @Failure 500 {object} map[string]string "Failed to retrieve flagged photos"
+// @Router /api/v1/admin/photos/flagged [get]
+func (h *AdminHandler) HandleListFlaggedPhotos(c *fiber.Ctx) error {
+	pagination, err := util.ParsePaginationParams(c, &util.PaginationConfig{
+		DefaultPage:       1,
+		DefaultPageSize:   20,
+		PageParamName:     "page",
+		PageSizeParamName: "pageSize",
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pagination parameters"})
+	}
+
+	photos, err := h.AdminFacade.GetFlaggedPhotos(pagination.Page, pagination.PageSize)
+	if err != nil {
+		h.Logger.Error("failed to list flagged photos", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve flagged photos"})
+	}
+
+	return c.JSON(photos)
+}
+
+// HandleDismissPhotoFlags clears the flags of a photo a moderator has checked.
+//
+// @Summary Dismiss photo flags
+// @Description Takes a flagged photo off the list once a moderator found it to be fine. To remove the photo use DELETE /admin/photo instead. Admin only.
+// @ID admin-dismiss-photo-flags
+// @Tags admin
+// @Produce json
+// @Param photoID path int true "Photo ID"
+// @Param source query string false "MARKER (default) or REPORT"
+// @Security ApiKeyAuth
+// @Success 204 "Flags dismissed"
+// @Failure 400 {object} map[string]string "Invalid photo ID or source"
+// @Failure 404 {object} map[string]string "No flagged photo with this ID"
+// @Failure 500 {object} map[string]string "Failed to dismiss flags"
+// @Router /api/v1/admin/photos/{photoID}/flags [delete]
+func (h *AdminHandler) HandleDismissPhotoFlags(c *fiber.Ctx) error {
+	photoID, err := c.ParamsInt("photoID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid photo ID"})
+	}
+	source := strings.ToUpper(c.Query("source", dto.PhotoSourceMarker))
+	if source != dto.PhotoSourceMarker && source != dto.PhotoSourceReport {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "source must be MARKER or REPORT"})
+	}
+
+	if err := h.AdminFacade.DismissPhotoFlags(source, photoID); err != nil {
+		if errors.Is(err, service.ErrFlaggedPhotoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "No flagged photo with this ID"})
+		}
+		h.Logger.Error("failed to dismiss photo flags", zap.Int("photoID", photoID), zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to dismiss flags"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// HandleListDuplicatePhotos lists groups of marker photos that are near-duplicates of each other.
+//
+// @Summary List duplicate photos
+// @Description Groups marker photos whose perceptual hashes differ in at most 5 of 64 bits, oldest photo first.
+// @Description Groups spanning several markers usually mean a photo was reused for a place it does not show.
+// @Description Photos uploaded before hashing was added are only included after POST /admin/photos/hashes/backfill.
+// @Description The groups are cached for 10 minutes and rebuilt when a backfill finishes. Admin only.
+// @ID admin-list-duplicate-photos
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} dto.DuplicatePhotoGroup "Duplicate photo groups"
+// @Failure 500 {object} map[string]string "Failed to retrieve duplicate photos"
+// @Router /api/v1/admin/photos/duplicates [get]
+func (h *AdminHandler) HandleListDuplicatePhotos(c *fiber.Ctx) error {
+	groups, err := h.AdminFacade.GetDuplicatePhotoGroups()
+	if err != nil {
+		h.Logger.Error("failed to list duplicate photos", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve duplicate photos"})
+	}
+
+	return c.JSON(groups)
+}
+
+// HandleBackfillPhotoHashes starts computing the perceptual hashes of photos uploaded before hashing was added.
+//
+// @Summary Backfill photo hashes
+// @Description Downloads every marker photo without a perceptual hash and stores its hash, in the background.
+// @Description The counts are logged when it finishes. Admin only.
+// @ID admin-backfill-photo-hashes
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} map[string]string "Backfill started"
+// @Failure 409 {object} map[string]string "A backfill is already running"
+// @Router /api/v1/admin/photos/hashes/backfill [post]
+func (h *AdminHandler) HandleBackfillPhotoHashes(c *fiber.Ctx) error {
+	if !h.AdminFacade.StartPhotoHashBackfill() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "A backfill is already running"})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Backfill started"})
+}
+
+// HandleCreateFacilityType adds a facility type to the catalog.
+//
+// @Summary Create a facility type
+// @Description Adds a facility type markers can refer to. Category is one of PULL_UP_BAR, PARALLEL_BARS, RINGS,
+// @Description MONKEY_BARS, DIP_STATION, SIT_UP_BENCH or OTHER. Admin only.
+// @ID admin-create-facility-type
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body dto.FacilityTypeRequest true "Facility type"
+// @Security ApiKeyAuth
+// @Success 201 {object} model.FacilityType "Created facility type"
+// @Failure 400 {object} map[string]string "Invalid facility type"
+// @Failure 409 {object} map[string]string "Slug already exists"
+// @Failure 500 {object} map[string]string "Failed to create facility type"
+// @Router /api/v1/admin/facilities [post]
+func (h *AdminHandler) HandleCreateFacilityType(c *fiber.Ctx) error {
+	var req dto.FacilityTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	facility, err := h.AdminFacade.CreateFacilityType(&req)
+	if err != nil {
+		return h.facilityTypeError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(facility)
+}
+
+// HandleUpdateFacilityType replaces a facility type in the catalog.
+//
+// @Summary Update a facility type
+// @Description Replaces the slug, category, names, icon and sort order of a facility type. Markers keep the facility. Admin only.
+// @ID admin-update-facility-type
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param facilityID path int true "Facility ID"
+// @Param request body dto.FacilityTypeRequest true "Facility type"
+// @Security ApiKeyAuth
+// @Success 200 {object} model.FacilityType "Updated facility type"
+// @Failure 400 {object} map[string]string "Invalid facility type"
+// @Failure 404 {object} map[string]string "Facility type not found"
+// @Failure 409 {object} map[string]string "Slug already exists"
+// @Failure 500 {object} map[string]string "Failed to update facility type"
+// @Router /api/v1/admin/facilities/{facilityID} [put]
+func (h *AdminHandler) HandleUpdateFacilityType(c *fiber.Ctx) error {
+	facilityID, err := c.ParamsInt("facilityID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid facility ID"})
+	}
+
+	var req dto.FacilityTypeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	facility, err := h.AdminFacade.UpdateFacilityType(facilityID, &req)
+	if err != nil {
+		return h.facilityTypeError(c, err)
+	}
+
+	return c.JSON(facility)
+}
+
+// HandleDeleteFacilityType removes a facility type no marker uses.
+//
+// @Summary Delete a facility type
+// @Description Removes a facility type from the catalog. Types still set on markers, deleted ones included, cannot be removed. Admin only.
+// @ID admin-delete-facility-type
+// @Tags admin
+// @Produce json
+// @Param facilityID path int true "Facility ID"
+// @Security ApiKeyAuth
+// @Success 204 "Facility type deleted"
+// @Failure 400 {object} map[string]string "Invalid facility ID"
+// @Failure 404 {object} map[string]string "Facility type not found"
+// @Failure 409 {object} map[string]string "Facility type is used by markers"
+// @Failure 500 {object} map[string]string "Failed to delete facility type"
+// @Router /api/v1/admin/facilities/{facilityID} [delete]
+func (h *AdminHandler) HandleDeleteFacilityType(c *fiber.Ctx) error {
+	facilityID, err := c.ParamsInt("facilityID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid facility ID"})
+	}
+
+	if err := h.AdminFacade.DeleteFacilityType(facilityID); err != nil {
+		return h.facilityTypeError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *AdminHandler) facilityTypeError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidFacility):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, service.ErrFacilityNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Facility type not found"})
+	case errors.Is(err, service.ErrDuplicateSlug), errors.Is(err, service.ErrFacilityInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		h.Logger.Error("failed to change facility catalog", zap.Error(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to change facility type"})
+	}
+}
+
 // HandleDeletePhoto deletes a photo for a given marker by its index (sorted by UploadedAt).
 // It expects two query parameters: markerId and photoIdx.
 func (h *AdminHandler) HandleDeletePhoto(c *fiber.Ctx) error {
@@ -602,6 +1092,23 @@ func (h *AdminHandler) HandleDeletePhoto(c *fiber.Ctx) error {
 }
 
 // HandleNextImage mimics Next.js’s image optimization with caching.
+//
+// @Summary Optimized image
+// @Description Resizes an image and serves it as WebP to clients whose Accept header lists image/webp, otherwise in its
+// @Description own format (JPEG for WebP sources). Widths are rounded up to 320, 640, 1280, 1920 or 3840 pixels and never
+// @Description enlarge the image. Marker photos are served from their variants made after upload when one fits.
+// @ID next-image
+// @Tags admin
+// @Produce image/webp
+// @Produce image/jpeg
+// @Param url query string true "Image URL"
+// @Param w query int false "Width in pixels, 0 keeps the original width"
+// @Param q query int false "Quality, 1-100" default(75)
+// @Param Accept header string false "Formats the client can show, e.g. image/webp,image/*"
+// @Success 200 {file} file "The image, cached for a day and varying by Accept"
+// @Failure 500 {object} map[string]string "Failed to fetch, decode or encode the image"
+// @Failure 503 {object} map[string]string "Too many images being optimized"
+// @Router /api/v1/next/image [get]
 func (h *AdminHandler) HandleNextImage(c *fiber.Ctx) error {
 	srcURL := c.Query("url", "")
 	width, err := strconv.Atoi(c.Query("w", "0"))
@@ -613,28 +1120,27 @@ func (h *AdminHandler) HandleNextImage(c *fiber.Ctx) error {
 		quality = 75
 	}
 
-	// Set aggressive timeout for low-resource server
-	c.Context().SetUserValue("timeout", 15*time.Second)
-
-	// Use the request context to respect client disconnection
-	ctx := c.UserContext()
+	// Aggressive timeout for a low-resource server, it covers waiting for a free optimizer slot too
+	ctx, cancel := context.WithTimeout(c.UserContext(), 15*time.Second)
+	defer cancel()
 
 	// Call the service to optimize the image with context
 	resultBytes, contentType, err := h.AdminFacade.OptimizeImageWithContext(ctx, srcURL, width, quality, c.Get("Accept"))
 	if err != nil {
 		// Return 503 for resource exhaustion instead of 500
-		if strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "busy") {
+		if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "busy") {
 			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Service temporarily unavailable"})
 		}
-		// Handle context cancellation
-		if err == context.Canceled || err == context.DeadlineExceeded {
+		// Handle context cancellation, the client went away
+		if errors.Is(err, context.Canceled) {
 			return c.Status(fiber.StatusRequestTimeout).JSON(fiber.Map{"error": "Request cancelled or timed out"})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Add cache headers for CDN/browser caching
+	// Add cache headers for CDN/browser caching, the format depends on Accept
 	c.Set(fiber.HeaderCacheControl, "public, max-age=86400") // 24 hours
+	c.Set(fiber.HeaderVary, fiber.HeaderAccept)
 	c.Set(fiber.HeaderContentType, contentType)
 
 	return c.Send(resultBytes)
@@ -148,7 +148,7 @@ func (h *KakaoBotHandler) HandleKakaoSearchMarkers(c *fiber.Ctx) error {
 		return c.JSON(cacheResponse)
 	}
 
-	response, err := h.BleveSearchService.SearchMarkerAddress(utterance)
+	response, err := h.BleveSearchService.SearchMarkerAddress(utterance, util.FacilityFilter{})
 	if err != nil {
 		return c.Status(fiber.StatusOK).JSON(k.SimpleText{}.Build("잠시 후 다시 시도해주세요.",
 			k.Kakao{
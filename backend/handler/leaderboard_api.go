@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/Alfex4936/chulbong-kr/service"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandleGetMarkerLeaderboard lists the top trainees of a marker.
+//
+// @Summary Get marker leaderboard
+// @Description Ranks users by their check-ins at a marker in the current week, month, or of all time (KST calendar).
+// @Description A check-in scores one point when it is verified, is the first one of the user at the marker that day,
+// @Description and the user could have travelled there from their previous scoring check-in.
+// @ID get-marker-leaderboard
+// @Tags ranking
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Param period query string false "weekly (default), monthly or all"
+// @Param limit query int false "Number of trainees, at most 100" default(50)
+// @Success 200 {object} dto.Leaderboard "Top trainees of the marker"
+// @Failure 400 {object} map[string]string "Invalid marker ID or period"
+// @Failure 500 {object} map[string]string "Failed to retrieve leaderboard"
+// @Router /api/v1/markers/{markerID}/leaderboard [get]
+func (h *MarkerHandler) HandleGetMarkerLeaderboard(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+	period, err := util.ParseLeaderboardPeriod(c.Query("period"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	leaderboard, err := h.MarkerFacadeService.GetMarkerLeaderboard(markerID, period, c.QueryInt("limit", service.DefaultLeaderboardSize))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve leaderboard"})
+	}
+
+	return c.JSON(leaderboard)
+}
+
+// HandleGetRegionLeaderboard lists the top trainees of a city, a province or the whole country.
+//
+// @Summary Get regional leaderboard
+// @Description Ranks users by their check-ins at markers in a city or province, or nationwide when neither is given,
+// @Description in the current week, month, or of all time (KST calendar). Scoring works as for the marker leaderboard.
+// @ID get-region-leaderboard
+// @Tags ranking
+// @Accept json
+// @Produce json
+// @Param province query string false "Province from the address, e.g. 서울 or 경기도"
+// @Param city query string false "City, district or county within the province, e.g. 강남구"
+// @Param period query string false "weekly (default), monthly or all"
+// @Param limit query int false "Number of trainees, at most 100" default(50)
+// @Success 200 {object} dto.Leaderboard "Top trainees of the region"
+// @Failure 400 {object} map[string]string "Invalid region or period"
+// @Failure 500 {object} map[string]string "Failed to retrieve leaderboard"
+// @Router /api/v1/markers/leaderboard [get]
+func (h *MarkerHandler) HandleGetRegionLeaderboard(c *fiber.Ctx) error {
+	period, err := util.ParseLeaderboardPeriod(c.Query("period"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	leaderboard, err := h.MarkerFacadeService.GetRegionLeaderboard(c.Query("province"), c.Query("city"), period, c.QueryInt("limit", service.DefaultLeaderboardSize))
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidLeaderboard) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve leaderboard"})
+	}
+
+	return c.JSON(leaderboard)
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"mime/multipart"
 	"net/url"
@@ -17,6 +19,7 @@ import (
 	"github.com/Alfex4936/chulbong-kr/dto"
 	"github.com/Alfex4936/chulbong-kr/facade"
 	"github.com/Alfex4936/chulbong-kr/middleware"
+	"github.com/Alfex4936/chulbong-kr/model"
 	"github.com/Alfex4936/chulbong-kr/protos"
 	"github.com/Alfex4936/chulbong-kr/service"
 	"github.com/Alfex4936/chulbong-kr/util"
@@ -55,6 +58,8 @@ func NewMarkerHandler(
 }
 
 func RegisterMarkerRoutes(api fiber.Router, handler *MarkerHandler, authMiddleware *middleware.AuthMiddleware) {
+	api.Get("/facilities", handler.HandleListFacilityTypes)
+
 	publicGroup := api.Group("/markers")
 	publicGroup.Use(recover.New(recover.Config{
 		EnableStackTrace: true,
@@ -70,17 +75,25 @@ func RegisterMarkerRoutes(api fiber.Router, handler *MarkerHandler, authMiddlewa
 	{
 		// Public routes with recover middleware
 		publicGroup.Get("", handler.HandleGetAllMarkersLocal)
-		// publicGroup.Get("2", handler.HandleGetAllMarkersLocalMsgp)
-		// publicGroup.Get("-proto", handler.HandleGetAllMarkersProto)
+		publicGroup.Get("/tiles/:z/:x/:y.pbf", handler.HandleGetMarkerTile)
+		publicGroup.Get("/clusters", handler.HandleGetMarkerClusters)
+		publicGroup.Get("/changes", handler.HandleGetMarkerChanges)
 		publicGroup.Get("/new", handler.HandleGetAllNewMarkers)
 		publicGroup.Get("/user/:username", handler.HandleGetMarkersByUsername)
 		publicGroup.Get("/:markerId/details", authMiddleware.VerifySoft, handler.HandleGetMarker)
 		publicGroup.Get("/:markerID/facilities", handler.HandleGetFacilities)
+		publicGroup.Get("/:markerID/revisions", handler.HandleGetMarkerRevisions)
+		publicGroup.Get("/:markerID/revisions/diff", handler.HandleDiffMarkerRevisions)
+		publicGroup.Get("/:markerID/reviews", handler.HandleGetMarkerReviews)
 		publicGroup.Get("/close", handler.HandleFindCloseMarkers)
+		publicGroup.Get("/route", handler.HandlePlanRoute)
+		publicGroup.Get("/viewport", handler.HandleFindMarkersInViewport)
 		publicGroup.Get("/ranking", handler.HandleGetMarkerRanking)
 		publicGroup.Get("/unique-ranking", handler.HandleGetUniqueVisitorCount)
 		// publicGroup.Get("/unique-ranking/all", handler.HandleGetAllUniqueVisitorCount)
 		publicGroup.Get("/area-ranking", handler.HandleGetCurrentAreaMarkerRanking)
+		publicGroup.Get("/leaderboard", handler.HandleGetRegionLeaderboard)
+		publicGroup.Get("/:markerID/leaderboard", handler.HandleGetMarkerLeaderboard)
 		publicGroup.Get("/convert", handler.HandleConvertWGS84ToWCONGNAMUL)
 		publicGroup.Get("/location-check", handler.HandleIsInSouthKorea)
 		publicGroup.Get("/weather", handler.HandleGetWeatherByWGS84)
@@ -101,6 +114,19 @@ func RegisterMarkerRoutes(api fiber.Router, handler *MarkerHandler, authMiddlewa
 			SkipFailedRequests: false,
 		}), handler.HandleSaveOfflineMap2)
 		publicGroup.Get("/rss", handler.HandleRSS)
+		publicGroup.Get("/export", limiter.New(limiter.Config{
+			KeyGenerator: func(c *fiber.Ctx) string {
+				return "export-" + handler.MarkerFacadeService.ChatUtil.GetUserIP(c)
+			},
+			Max:               10,
+			Expiration:        1 * time.Minute,
+			LimiterMiddleware: limiter.SlidingWindow{},
+			LimitReached: func(c *fiber.Ctx) error {
+				c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+				c.Status(429).SendString("Too many requests, please try again later.")
+				return nil
+			},
+		}), handler.HandleExportMarkers)
 		publicGroup.Get("/roadview-date", handler.HandleGetRoadViewPicDate)
 		publicGroup.Get("/new-pictures", handler.HandleGet10NewPictures)
 		publicGroup.Get("/stories", handler.HandleGetAllStories)
@@ -128,6 +154,9 @@ func RegisterMarkerRoutes(api fiber.Router, handler *MarkerHandler, authMiddlewa
 
 		markerGroup.Get("/my", handler.HandleGetUserMarkers)
 		markerGroup.Get("/:markerID/dislike-status", handler.HandleCheckDislikeStatus)
+		markerGroup.Get("/:markerID/reviews/me", handler.HandleGetMyMarkerReview)
+		markerGroup.Get("/check-ins/me", handler.HandleGetMyCheckIns)
+		markerGroup.Get("/check-ins/me/stats", handler.HandleGetMyCheckInStats)
 		// markerGroup.Get("/:markerId", handlers.GetMarker)
 
 		markerGroup.Post("", handler.HandleCreateMarkerWithPhotos)
@@ -136,12 +165,18 @@ func RegisterMarkerRoutes(api fiber.Router, handler *MarkerHandler, authMiddlewa
 		markerGroup.Post("/facilities", handler.HandleSetMarkerFacilities)
 		markerGroup.Post("/:markerID/dislike", handler.HandleLeaveDislike)
 		markerGroup.Post("/:markerID/favorites", handler.HandleAddFavorite)
+		markerGroup.Post("/:markerID/check-ins", handler.HandleCheckIn)
 
 		markerGroup.Put("/:markerID", handler.HandleUpdateMarker)
+		markerGroup.Put("/:markerID/access", handler.HandleUpdateMarkerAccess)
+		markerGroup.Put("/:markerID/reviews", handler.HandleSaveMarkerReview)
+		markerGroup.Post("/:markerID/revisions/:revisionID/rollback", handler.HandleRollbackMarkerRevision)
 
 		markerGroup.Delete("/:markerID", handler.HandleDeleteMarker)
 		markerGroup.Delete("/:markerID/dislike", handler.HandleUndoDislike)
 		markerGroup.Delete("/:markerID/favorites", handler.HandleRemoveFavorite)
+		markerGroup.Delete("/:markerID/reviews", handler.HandleDeleteMarkerReview)
+		markerGroup.Delete("/check-ins/:checkInID", handler.HandleDeleteCheckIn)
 
 		// Story routes
 		markerGroup.Post("/:markerID/stories", handler.HandleAddStory)
@@ -152,25 +187,6 @@ func RegisterMarkerRoutes(api fiber.Router, handler *MarkerHandler, authMiddlewa
 	}
 }
 
-func (h *MarkerHandler) HandleGetAllMarkersProto(c *fiber.Ctx) error {
-	markers, err := h.MarkerFacadeService.GetAllMarkersProto()
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	markerList := &protos.MarkerList{
-		Markers: markers,
-	}
-
-	data, err := proto.Marshal(markerList)
-	if err != nil {
-		return c.Status(500).SendString(err.Error())
-	}
-
-	c.Type("application/protobuf")
-	return c.Send(data)
-}
-
 // HandleGet10NewPictures retrieves the 10 most recently added marker pictures.
 //
 // @Summary Get 10 new marker pictures
@@ -196,12 +212,17 @@ func (h *MarkerHandler) HandleGet10NewPictures(c *fiber.Ctx) error {
 //
 // @Summary Get all markers
 // @Description Retrieves a full list of markers from the cache or database.
+// @Description Pass status (comma separated, e.g. ACTIVE,DAMAGED) to only get markers in those states.
+// @Description Send Accept: application/x-protobuf for a protos.MarkerList (with address, thumbnail and facilities)
+// @Description or Accept: application/msgpack for the list below encoded as MessagePack.
 // @ID get-all-markers
 // @Tags markers-data
 // @Accept json
-// @Produce json
+// @Produce json,application/x-protobuf,application/msgpack
+// @Param status query string false "Marker statuses to include: ACTIVE, DAMAGED, UNDER_CONSTRUCTION, REMOVED"
 // @Security
 // @Success 200 {array} dto.MarkerSimple "List of all markers"
+// @Failure 400 {object} map[string]string "Invalid status filter"
 // @Failure 500 {object} map[string]string "Internal server error when retrieving markers"
 // @Router /api/v1/markers [get]
 func (h *MarkerHandler) HandleGetAllMarkersLocal(c *fiber.Ctx) error {
@@ -209,10 +230,32 @@ func (h *MarkerHandler) HandleGetAllMarkersLocal(c *fiber.Ctx) error {
 	// if !strings.HasSuffix(c.Get("Referer"), ".k-pullup.com") || c.Get("Referer") != "https://www.k-pullup.com/" {
 	// 	return c.Redirect("https://k-pullup.com", fiber.StatusFound) // Use HTTP 302 for standard redirection
 	// }
-	c.Set("Content-type", "application/json")
+	format, contentType := negotiateMarkersFormat(c)
+	c.Vary(fiber.HeaderAccept)
+	c.Set(fiber.HeaderContentType, contentType)
+
+	if statusQuery := c.Query("status"); statusQuery != "" {
+		statuses := make(map[string]struct{})
+		for _, raw := range strings.Split(statusQuery, ",") {
+			status, ok := service.NormalizeMarkerStatus(raw)
+			if !ok {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid status filter"})
+			}
+			statuses[status] = struct{}{}
+		}
+		if format == service.MarkersFormatJSON {
+			return h.sendMarkersByStatus(c, statuses)
+		}
+
+		body, err := h.encodeAllMarkers(format, statuses)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get markers"})
+		}
+		return c.Send(body)
+	}
 
 	// Attempt to fetch cached data first
-	cached, err := h.CacheService.GetAllMarkers() // from Redis, on error will proceed to fetch from DB
+	cached, err := h.CacheService.GetAllMarkersAs(format) // from Redis, on error will proceed to fetch from DB
 	if err == nil && len(cached) > 0 && string(cached) != "null" {
 		// Cache hit, return the cached byte array
 		c.Append("X-Cache", "hit")
@@ -220,64 +263,98 @@ func (h *MarkerHandler) HandleGetAllMarkersLocal(c *fiber.Ctx) error {
 	}
 
 	// Cache miss: Fetch markers from DB
-	markers, err := h.MarkerFacadeService.GetAllMarkers() // Fetch from DB
+	body, err := h.encodeAllMarkers(format, nil)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get markers"})
 	}
 
-	if len(markers) == 0 {
-		// Return empty array instead of null
-		return c.Send([]byte("[]"))
+	// Cache the full list of markers, an empty list is not worth caching
+	// TODO: Stale-While-Revalidate?
+	if len(body) > 0 && string(body) != "[]" {
+		if err := h.CacheService.SetFullMarkersCacheAs(format, body); err != nil {
+			h.logger.Error("Failed to cache full markers", zap.String("format", format), zap.Error(err))
+		}
 	}
 
-	// Marshal the markers to JSON for caching and response
-	markersJSON, err := sonic.ConfigFastest.Marshal(markers)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to encode markers"})
+	return c.Send(body)
+}
+
+// negotiateMarkersFormat picks the marker list encoding from the Accept header, JSON unless a binary format is asked for.
+func negotiateMarkersFormat(c *fiber.Ctx) (format, contentType string) {
+	switch c.Accepts(fiber.MIMEApplicationJSON, "application/x-protobuf", "application/protobuf", "application/msgpack", "application/x-msgpack") {
+	case "application/x-protobuf", "application/protobuf":
+		return service.MarkersFormatProtobuf, "application/x-protobuf"
+	case "application/msgpack", "application/x-msgpack":
+		return service.MarkersFormatMsgpack, "application/msgpack"
 	}
+	return service.MarkersFormatJSON, fiber.MIMEApplicationJSON
+}
 
-	// Cache the full list of markers
-	// TODO: Stale-While-Revalidate?
-	// Verify we're not caching "null" or empty data
-	if len(markersJSON) > 0 && string(markersJSON) != "null" {
-		// Cache the full list of markers
-		err = h.CacheService.SetFullMarkersCache(markersJSON)
+// encodeAllMarkers fetches all markers from the DB, keeps those in statuses (all when nil) and encodes them as format.
+func (h *MarkerHandler) encodeAllMarkers(format string, statuses map[string]struct{}) ([]byte, error) {
+	if format == service.MarkersFormatProtobuf {
+		markers, err := h.MarkerFacadeService.GetAllMarkersProto()
 		if err != nil {
-			h.logger.Error("Failed to cache full markers", zap.Error(err))
+			return nil, err
 		}
+
+		list := &protos.MarkerList{Markers: make([]*protos.Marker, 0, len(markers))}
+		for _, marker := range markers {
+			if statuses == nil || hasMarkerStatus(statuses, marker.Status) {
+				list.Markers = append(list.Markers, marker)
+			}
+		}
+		return proto.Marshal(list)
 	}
 
-	return c.Send(markersJSON)
-}
+	markers, err := h.MarkerFacadeService.GetAllMarkers() // Fetch from DB
+	if err != nil {
+		return nil, err
+	}
 
-func (h *MarkerHandler) HandleGetAllMarkersLocalMsgp(c *fiber.Ctx) error {
-	cached := h.MarkerFacadeService.GetMarkerCache()
-	c.Set("Content-type", "application/json")
+	filtered := make(dto.MarkerSimpleSlice, 0, len(markers))
+	for _, marker := range markers {
+		if statuses == nil || hasMarkerStatus(statuses, marker.Status) {
+			filtered = append(filtered, marker)
+		}
+	}
 
-	if cached != nil || len(cached) != 0 {
-		// If cache is not empty, directly return the cached binary data as JSON
-		c.Append("X-Cache", "hit")
-		return c.Send(cached)
+	if format == service.MarkersFormatMsgpack {
+		return filtered.MarshalMsg(nil)
 	}
+	// Empty array instead of null
+	return sonic.ConfigFastest.Marshal(filtered)
+}
 
-	// Fetch markers if cache is empty
-	markers, err := h.MarkerFacadeService.GetAllMarkers() // []dto.MarkerSimple, err
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get markers"})
+// hasMarkerStatus reports whether status is in statuses, markers without a status count as ACTIVE.
+func hasMarkerStatus(statuses map[string]struct{}, status string) bool {
+	if status == "" {
+		status = service.MarkerStatusActive
 	}
+	_, ok := statuses[status]
+	return ok
+}
 
-	// Marshal the markers to JSON for caching and response
-	markerSlice := dto.MarkerSimpleSlice(markers)
+// sendMarkersByStatus filters the full marker list, served from the cache when possible, down to the given statuses.
+func (h *MarkerHandler) sendMarkersByStatus(c *fiber.Ctx, statuses map[string]struct{}) error {
+	var markers []dto.MarkerSimple
 
-	markersBin, err := markerSlice.MarshalMsg(nil)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to encode markers"})
+	cached, err := h.CacheService.GetAllMarkers()
+	if err != nil || len(cached) == 0 || sonic.Unmarshal(cached, &markers) != nil {
+		markers, err = h.MarkerFacadeService.GetAllMarkers()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get markers"})
+		}
 	}
 
-	// Update cache
-	h.MarkerFacadeService.SetMarkerCache(markersBin)
+	filtered := make([]dto.MarkerSimple, 0, len(markers))
+	for _, marker := range markers {
+		if hasMarkerStatus(statuses, marker.Status) {
+			filtered = append(filtered, marker)
+		}
+	}
 
-	return c.Send(markersBin)
+	return c.JSON(filtered)
 }
 
 // HandleGetAllNewMarkers retrieves a paginated list of newly added markers.
@@ -326,6 +403,7 @@ func (h *MarkerHandler) HandleGetAllNewMarkers(c *fiber.Ctx) error {
 // @Security
 // @Param markerId path int true "Marker ID"
 // @Success 200 {object} model.MarkerWithPhotos "Marker details including photos"
+// @Success 301 {string} string "Marker was merged into another marker, Location points to its details"
 // @Failure 400 {object} map[string]string "Invalid Marker ID"
 // @Failure 404 {object} map[string]string "Marker not found"
 // @Router /api/v1/markers/{markerId}/details [get]
@@ -340,6 +418,10 @@ func (h *MarkerHandler) HandleGetMarker(c *fiber.Ctx) error {
 
 	marker, err := h.MarkerFacadeService.GetMarker(markerID)
 	if err != nil {
+		// Duplicates merged by an admin live on under the surviving marker
+		if survivorID, ok := h.MarkerFacadeService.ResolveMergedMarker(markerID); ok {
+			return c.Redirect(fmt.Sprintf("/api/v1/markers/%d/details", survivorID), fiber.StatusMovedPermanently)
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Marker not found"})
 	}
 
@@ -375,6 +457,9 @@ func (h *MarkerHandler) HandleGetAllMarkersWithAddr(c *fiber.Ctx) error {
 //
 // @Summary Create a new marker
 // @Description Creates a marker with latitude, longitude, description, and optional photos.
+// @Description Photos that are smaller than 320px on their short side, longer than 4:1, almost black or white, or far out of focus
+// @Description are refused with 422, check telling which of RESOLUTION, ASPECT_RATIO, EXPOSURE or BLUR failed.
+// @Description Dark, overexposed or slightly blurry photos are accepted and listed in photoWarnings.
 // @ID create-marker-with-photos
 // @Tags markers
 // @Accept multipart/form-data
@@ -387,6 +472,7 @@ func (h *MarkerHandler) HandleGetAllMarkersWithAddr(c *fiber.Ctx) error {
 // @Success 201 {object} dto.MarkerResponse "Marker created successfully"
 // @Failure 400 {object} map[string]string "Invalid request parameters or form data"
 // @Failure 409 {object} map[string]string "Error during file upload"
+// @Failure 422 {object} map[string]string "A photo failed the quality checks"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router /api/v1/markers [post]
 func (h *MarkerHandler) HandleCreateMarkerWithPhotos(c *fiber.Ctx) error {
@@ -423,6 +509,10 @@ func (h *MarkerHandler) HandleCreateMarkerWithPhotos(c *fiber.Ctx) error {
 		Description: description,
 	}, userID, form)
 	if err != nil {
+		var issue util.PhotoQualityIssue
+		if errors.As(err, &issue) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": issue.Message, "check": issue.Check})
+		}
 		if strings.Contains(err.Error(), "an error during file") {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "an error during file upload"})
 		} else if strings.Contains(err.Error(), "일일 마커 생성 한도") {
@@ -457,7 +547,10 @@ func (h *MarkerHandler) HandleUpdateMarker(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Description contains profanity"})
 	}
 
-	if err := h.MarkerFacadeService.UpdateMarkerDescriptionOnly(markerID, description); err != nil {
+	userID := c.Locals("userID").(int)
+	userRole := c.Locals("role").(string)
+
+	if err := h.MarkerFacadeService.UpdateMarkerDescriptionOnly(markerID, description, userID, userRole); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -467,7 +560,7 @@ func (h *MarkerHandler) HandleUpdateMarker(c *fiber.Ctx) error {
 // HandleDeleteMarker deletes a marker if the user is the owner or an admin.
 //
 // @Summary Delete a marker
-// @Description Allows the authenticated owner or an admin to delete a specific marker.
+// @Description Allows the authenticated owner or an admin to delete a specific marker. The marker is soft-deleted and can be restored by an admin until it is purged.
 // @ID delete-marker
 // @Tags markers
 // @Accept json
@@ -477,6 +570,7 @@ func (h *MarkerHandler) HandleUpdateMarker(c *fiber.Ctx) error {
 // @Success 200 "Marker deleted successfully"
 // @Failure 400 {object} map[string]string "Invalid marker ID"
 // @Failure 403 {object} map[string]string "User is not authorized to delete this marker"
+// @Failure 404 {object} map[string]string "Marker not found"
 // @Failure 500 {object} map[string]string "Failed to delete marker"
 // @Router /api/v1/markers/{markerID} [delete]
 func (h *MarkerHandler) HandleDeleteMarker(c *fiber.Ctx) error {
@@ -494,7 +588,14 @@ func (h *MarkerHandler) HandleDeleteMarker(c *fiber.Ctx) error {
 	// Call the service function to delete the marker, now passing userID as well
 	err = h.MarkerFacadeService.DeleteMarker(userID, markerID, userRole)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete marker"})
+		switch {
+		case errors.Is(err, service.ErrMarkerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Marker not found"})
+		case errors.Is(err, service.ErrUnauthorized):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "User is not authorized to delete this marker"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete marker"})
+		}
 	}
 
 	h.MarkerFacadeService.RemoveMarkerClick(markerID)
@@ -528,6 +629,10 @@ func (h *MarkerHandler) HandleUploadMarkerPhotoToS3(c *fiber.Ctx) error {
 
 	urls, err := h.MarkerFacadeService.UploadMarkerPhotoToS3(markerID, files)
 	if err != nil {
+		var issue util.PhotoQualityIssue
+		if errors.As(err, &issue) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": issue.Message, "check": issue.Check})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to upload photos"})
 	}
 
@@ -813,7 +918,7 @@ func (h *MarkerHandler) HandleRemoveFavorite(c *fiber.Ctx) error {
 // @Produce json
 // @Security
 // @Param markerID path int true "Marker ID"
-// @Success 200 {array} model.Facility "List of facilities at the marker"
+// @Success 200 {array} dto.MarkerFacilityDetail "List of facilities at the marker with their catalog names and icons"
 // @Failure 400 {object} map[string]string "Invalid Marker ID"
 // @Failure 500 {object} map[string]string "Failed to retrieve facilities"
 // @Router /api/v1/markers/{markerID}/facilities [get]
@@ -841,10 +946,30 @@ func (h *MarkerHandler) HandleGetFacilities(c *fiber.Ctx) error {
 	return c.JSON(facilities)
 }
 
+// HandleListFacilityTypes returns the facility catalog.
+//
+// @Summary List facility types
+// @Description Returns every facility type markers can have, with Korean and English names, category and icon, in display order.
+// @ID list-facility-types
+// @Tags markers
+// @Produce json
+// @Success 200 {array} model.FacilityType "Facility catalog"
+// @Failure 500 {object} map[string]string "Failed to retrieve facility types"
+// @Router /api/v1/facilities [get]
+func (h *MarkerHandler) HandleListFacilityTypes(c *fiber.Ctx) error {
+	facilities, err := h.MarkerFacadeService.ListFacilityTypes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve facility types"})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(facilities)
+}
+
 // HandleSetMarkerFacilities sets facilities for a specific marker.
 //
 // @Summary Set marker facilities
-// @Description Assigns a list of facilities to a given marker.
+// @Description Assigns a list of facilities to a given marker. Facility IDs have to be in the catalog, see GET /api/v1/facilities.
 // @ID set-marker-facilities
 // @Tags markers
 // @Accept json
@@ -852,7 +977,7 @@ func (h *MarkerHandler) HandleGetFacilities(c *fiber.Ctx) error {
 // @Param request body dto.FacilityRequest true "Marker ID and facilities"
 // @Security ApiKeyAuth
 // @Success 200 "Facilities set successfully"
-// @Failure 400 {object} map[string]string "Invalid request body"
+// @Failure 400 {object} map[string]string "Invalid request body or unknown facility ID"
 // @Failure 500 {object} map[string]string "Failed to set facilities for marker"
 // @Router /api/v1/markers/facilities [post]
 func (h *MarkerHandler) HandleSetMarkerFacilities(c *fiber.Ctx) error {
@@ -861,13 +986,66 @@ func (h *MarkerHandler) HandleSetMarkerFacilities(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse request"})
 	}
 
-	if err := h.MarkerFacadeService.SetMarkerFacilities(req.MarkerID, req.Facilities); err != nil {
+	userID := c.Locals("userID").(int)
+	userRole := c.Locals("role").(string)
+
+	if err := h.MarkerFacadeService.SetMarkerFacilities(req.MarkerID, req.Facilities, userID, userRole); err != nil {
+		if errors.Is(err, service.ErrUnknownFacility) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to set facilities for marker"})
 	}
 
 	return c.SendStatus(fiber.StatusOK)
 }
 
+// HandleUpdateMarkerAccess replaces the access metadata of a marker.
+//
+// @Summary Update marker access
+// @Description Sets who can use a marker, its weekly opening hours, and whether it is lit at night and indoors.
+// @Description Every field is replaced, leave one out to mark it unknown. Only the owner or an admin can do this.
+// @ID update-marker-access
+// @Tags markers
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Param request body model.MarkerAccess true "Access metadata"
+// @Security ApiKeyAuth
+// @Success 200 {object} model.MarkerAccess "Access metadata as stored"
+// @Failure 400 {object} map[string]string "Invalid marker ID or access metadata"
+// @Failure 403 {object} map[string]string "User is not authorized to update this marker"
+// @Failure 404 {object} map[string]string "Marker not found"
+// @Failure 500 {object} map[string]string "Failed to update marker access"
+// @Router /api/v1/markers/{markerID}/access [put]
+func (h *MarkerHandler) HandleUpdateMarkerAccess(c *fiber.Ctx) error {
+	markerID, err := strconv.Atoi(c.Params("markerID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+
+	var access model.MarkerAccess
+	if err := c.BodyParser(&access); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse request"})
+	}
+
+	userID := c.Locals("userID").(int)
+	userRole := c.Locals("role").(string)
+
+	if err := h.MarkerFacadeService.UpdateMarkerAccess(markerID, &access, userID, userRole); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidAccess):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrUnauthorized):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "User is not authorized to update this marker"})
+		case errors.Is(err, service.ErrMarkerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Marker not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update marker access"})
+	}
+
+	return c.JSON(access)
+}
+
 // UpdateMarkersAddressesHandler handles the request to update all markers' addresses.
 func (h *MarkerHandler) HandleUpdateMarkersAddresses(c *fiber.Ctx) error {
 	updatedMarkers, err := h.MarkerFacadeService.UpdateMarkersAddresses()
@@ -911,6 +1089,193 @@ func (h *MarkerHandler) HandleRSS(c *fiber.Ctx) error {
 	return c.SendString(string(content))
 }
 
+// HandleGetMarkerTile serves markers as a Mapbox Vector Tile.
+//
+// @Summary Get a marker vector tile
+// @Description Returns the markers on tile z/x/y as a Mapbox Vector Tile with a single "markers" layer.
+// @Description Features carry markerId, hasPhoto, count, facilities and status properties.
+// @Description Below zoom 15 nearby markers are thinned to one point, count says how many it stands for.
+// @ID get-marker-tile
+// @Tags markers-data
+// @Produce application/vnd.mapbox-vector-tile
+// @Param z path int true "Zoom level (0-20)"
+// @Param x path int true "Tile column"
+// @Param y path int true "Tile row"
+// @Success 200 {string} string "Vector tile"
+// @Success 204 "No markers on this tile"
+// @Failure 400 {object} map[string]string "Invalid tile coordinates"
+// @Failure 500 {object} map[string]string "Failed to build tile"
+// @Router /api/v1/markers/tiles/{z}/{x}/{y}.pbf [get]
+func (h *MarkerHandler) HandleGetMarkerTile(c *fiber.Ctx) error {
+	z, errZ := strconv.Atoi(c.Params("z"))
+	x, errX := strconv.Atoi(c.Params("x"))
+	y, errY := strconv.Atoi(c.Params("y"))
+	if errZ != nil || errX != nil || errY != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tile coordinates"})
+	}
+
+	tile, err := h.MarkerFacadeService.GetMarkerTile(z, x, y)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidTile) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tile coordinates"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to build tile"})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	if len(tile) == 0 {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	c.Set(fiber.HeaderContentType, "application/vnd.mapbox-vector-tile")
+	return c.Send(tile)
+}
+
+// HandleGetMarkerClusters returns server-side marker clusters for a map view.
+//
+// @Summary Get marker clusters
+// @Description Returns DBSCAN clusters of the markers in the bounding box with their centroid, count and bounds.
+// @Description Lone markers come back as clusters of one with markerId set. Zoom is clamped to 6-16.
+// @ID get-marker-clusters
+// @Tags markers-data
+// @Produce json
+// @Param bbox query string true "Bounding box as minLng,minLat,maxLng,maxLat"
+// @Param zoom query int true "Map zoom level"
+// @Success 200 {object} dto.MarkerClusterResponse "Clusters whose centroid is in the bounding box"
+// @Failure 400 {object} map[string]string "Invalid bbox or zoom"
+// @Failure 503 {object} map[string]string "Clusters are still being computed"
+// @Router /api/v1/markers/clusters [get]
+func (h *MarkerHandler) HandleGetMarkerClusters(c *fiber.Ctx) error {
+	box, err := util.ParseBBox(c.Query("bbox"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	zoom, err := strconv.Atoi(c.Query("zoom"))
+	if err != nil || zoom < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid zoom"})
+	}
+
+	clusters, err := h.MarkerFacadeService.GetMarkerClusters(box, zoom)
+	if err != nil {
+		if errors.Is(err, service.ErrClustersNotReady) {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get clusters"})
+	}
+
+	return c.JSON(clusters)
+}
+
+// HandleGetMarkerChanges returns the markers created, updated or deleted since a cursor.
+//
+// @Summary Get marker changes
+// @Description Delta sync for clients keeping a local copy of GET /api/v1/markers.
+// @Description Upserts hold the current state of changed markers and deleted the IDs to drop, then continue from cursor.
+// @Description Without since, or when since is too old, reset is true: fetch the full list again and continue from the returned cursor.
+// @ID get-marker-changes
+// @Tags markers-data
+// @Produce json
+// @Param since query int false "cursor of the previous response"
+// @Param limit query int false "Changes per page (default: 500, maximum 2000)"
+// @Success 200 {object} dto.MarkerChangesResponse "Changes after since"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/v1/markers/changes [get]
+func (h *MarkerHandler) HandleGetMarkerChanges(c *fiber.Ctx) error {
+	since := int64(-1) // no cursor yet, start with a reset
+	if sinceQuery := c.Query("since"); sinceQuery != "" {
+		parsed, err := strconv.ParseInt(sinceQuery, 10, 64)
+		if err != nil || parsed < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid since"})
+		}
+		since = parsed
+	}
+
+	limit := c.QueryInt("limit", service.DefaultMarkerChangesLimit)
+	if limit < 1 || limit > service.MaxMarkerChangesLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid limit"})
+	}
+
+	changes, err := h.MarkerFacadeService.GetMarkerChanges(since, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get marker changes"})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(changes)
+}
+
+// HandleExportMarkers streams markers as a GeoJSON FeatureCollection, KML or GPX waypoints.
+//
+// @Summary Export markers
+// @Description Streams markers with their address, description, facilities and cover photo URL for hiking and GIS apps.
+// @Description All filters are optional and combine with AND.
+// @ID export-markers
+// @Tags markers-data
+// @Produce application/geo+json,application/vnd.google-earth.kml+xml,application/gpx+xml
+// @Param format query string false "geojson (default), kml or gpx"
+// @Param bbox query string false "Bounding box as minLng,minLat,maxLng,maxLat"
+// @Param province query string false "Province from the address, e.g. 서울 or 경기도"
+// @Param city query string false "City, district or county from the address, e.g. 강남구"
+// @Param facility query string false "Comma separated facility IDs or names with an optional minimum quantity, e.g. 1,2 or chulbong:2"
+// @Param facilityMatch query string false "all (default): every facility is required, any: one of them is enough"
+// @Param access query string false "Comma separated access types: PUBLIC, PRIVATE, RESIDENTS_ONLY"
+// @Param lit query bool false "Only markers that are (true) or are not (false) lit at night"
+// @Param indoor query bool false "Only indoor (true) or outdoor (false) markers"
+// @Param status query string false "Marker statuses to include: ACTIVE, DAMAGED, UNDER_CONSTRUCTION, REMOVED"
+// @Success 200 {string} string "Exported markers"
+// @Failure 400 {object} map[string]string "Invalid filter or format"
+// @Router /api/v1/markers/export [get]
+func (h *MarkerHandler) HandleExportMarkers(c *fiber.Ctx) error {
+	format, err := util.NormalizeExportFormat(c.Query("format"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var filter dto.MarkerExportFilter
+	if bboxQuery := c.Query("bbox"); bboxQuery != "" {
+		box, err := util.ParseBBox(bboxQuery)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		filter.BBox = &box
+	}
+	if filter.Province = strings.TrimSpace(c.Query("province")); filter.Province != "" && !util.IsProvince(filter.Province) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown province"})
+	}
+	if filter.City = strings.TrimSpace(c.Query("city")); filter.City != "" && !util.IsCity(filter.City) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Unknown city"})
+	}
+	if filter.Facilities, err = h.MarkerFacadeService.ParseFacilityRequirements(c.Query("facility"), c.Query("facilityMatch")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if filter.Access, err = service.ParseMarkerAccessFilter(c.Query("access"), c.Query("lit"), c.Query("indoor")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if statusQuery := c.Query("status"); statusQuery != "" {
+		for _, raw := range strings.Split(statusQuery, ",") {
+			status, ok := service.NormalizeMarkerStatus(raw)
+			if !ok {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid status filter"})
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, util.ExportContentType(format)+"; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="k-pullup-markers.%s"`, format))
+
+	// The body is written after the handler returns, errors from here on can only be logged
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		count, err := h.MarkerFacadeService.ExportMarkers(format, filter, w)
+		if err != nil {
+			h.logger.Error("Failed to export markers", zap.String("format", format), zap.Int("written", count), zap.Error(err))
+		}
+		w.Flush()
+	})
+	return nil
+}
+
 // HandleGetAllMarkers handles the HTTP request to get all markers
 func (h *MarkerHandler) HandleRefreshMarkerCache(c *fiber.Ctx) error {
 	// Fetch markers if cache is empty
@@ -925,8 +1290,11 @@ func (h *MarkerHandler) HandleRefreshMarkerCache(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to encode markers"})
 	}
 
-	// Update cache
-	h.MarkerFacadeService.SetMarkerCache(markersJSON)
+	// Update cache, the binary encodings are rebuilt on their next request
+	h.CacheService.InvalidateFullMarkersCache()
+	if err := h.CacheService.SetFullMarkersCache(markersJSON); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to cache markers"})
+	}
 	return c.SendString("refreshed")
 }
 
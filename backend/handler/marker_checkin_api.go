@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/service"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandleCheckIn records a workout of the user at a marker.
+//
+// @Summary Check in at a marker
+// @Description Records that the authenticated user trains at a marker, with an optional workout log of up to 10 exercises.
+// @Description exercise is one of PULL_UP, CHIN_UP, MUSCLE_UP, DIP, PUSH_UP, INVERTED_ROW, HANGING_LEG_RAISE or OTHER, with 1-50 sets of 1-500 reps.
+// @Description When latitude and longitude are sent the check-in is verified if they are within 100 meters of the marker.
+// @Description Only verified check-ins count as visits of the marker. The same marker can be checked in once an hour.
+// @Description ranked tells whether the check-in scored on the leaderboards, at most one check-in per marker and day does.
+// @ID check-in-marker
+// @Tags markers-checkin
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Param request body dto.CheckInRequest true "Position, note and workout log"
+// @Security ApiKeyAuth
+// @Success 201 {object} model.MarkerCheckIn "The recorded check-in"
+// @Failure 400 {object} map[string]string "Invalid marker ID, position, note or workout log"
+// @Failure 404 {object} map[string]string "Marker not found"
+// @Failure 409 {object} map[string]string "Already checked in at this marker within the last hour"
+// @Failure 500 {object} map[string]string "Failed to check in"
+// @Router /api/v1/markers/{markerID}/check-ins [post]
+func (h *MarkerHandler) HandleCheckIn(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+
+	var req dto.CheckInRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse request"})
+	}
+	if profanity, _ := h.MarkerFacadeService.CheckBadWord(req.Note); profanity {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Note contains inappropriate content."})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	checkIn, err := h.MarkerFacadeService.CheckIn(markerID, userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCheckIn):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrMarkerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Marker not found"})
+		case errors.Is(err, service.ErrCheckInTooSoon):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "이미 최근에 체크인한 장소입니다."})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check in"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(checkIn)
+}
+
+// HandleGetMyCheckIns lists the user's check-ins with their workout logs.
+//
+// @Summary Get my check-ins
+// @Description Lists the authenticated user's check-ins with the marker address and workout log, newest first.
+// @ID get-my-check-ins
+// @Tags markers-checkin
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of check-ins per page" default(10)
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.CheckInList "The user's training log"
+// @Failure 400 {object} map[string]string "Invalid pagination parameters"
+// @Failure 500 {object} map[string]string "Failed to retrieve check-ins"
+// @Router /api/v1/markers/check-ins/me [get]
+func (h *MarkerHandler) HandleGetMyCheckIns(c *fiber.Ctx) error {
+	pagination, err := util.ParsePaginationParams(c, &util.PaginationConfig{
+		DefaultPage:       1,
+		DefaultPageSize:   10,
+		PageParamName:     "page",
+		PageSizeParamName: "pageSize",
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pagination parameters"})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	checkIns, err := h.MarkerFacadeService.GetCheckIns(userID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve check-ins"})
+	}
+
+	return c.JSON(checkIns)
+}
+
+// HandleGetMyCheckInStats returns the user's training stats and streaks.
+//
+// @Summary Get my training stats
+// @Description Sums up the authenticated user's check-ins: current and longest streak of consecutive days (KST),
+// @Description days trained, verified visits, distinct markers and sets and reps per exercise.
+// @Description The current streak still counts when the last check-in was yesterday.
+// @ID get-my-check-in-stats
+// @Tags markers-checkin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.CheckInStats "The user's training stats"
+// @Failure 500 {object} map[string]string "Failed to retrieve stats"
+// @Router /api/v1/markers/check-ins/me/stats [get]
+func (h *MarkerHandler) HandleGetMyCheckInStats(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+
+	stats, err := h.MarkerFacadeService.GetCheckInStats(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve stats"})
+	}
+
+	return c.JSON(stats)
+}
+
+// HandleDeleteCheckIn removes one of the user's check-ins.
+//
+// @Summary Delete my check-in
+// @Description Removes a check-in of the authenticated user together with its workout log.
+// @ID delete-check-in
+// @Tags markers-checkin
+// @Accept json
+// @Produce json
+// @Param checkInID path int true "Check-in ID"
+// @Security ApiKeyAuth
+// @Success 204 "Check-in deleted"
+// @Failure 400 {object} map[string]string "Invalid check-in ID"
+// @Failure 404 {object} map[string]string "Check-in not found"
+// @Failure 500 {object} map[string]string "Failed to delete check-in"
+// @Router /api/v1/markers/check-ins/{checkInID} [delete]
+func (h *MarkerHandler) HandleDeleteCheckIn(c *fiber.Ctx) error {
+	checkInID, err := c.ParamsInt("checkInID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid check-in ID"})
+	}
+	userID := c.Locals("userID").(int)
+
+	if err := h.MarkerFacadeService.DeleteCheckIn(checkInID, userID); err != nil {
+		if errors.Is(err, service.ErrCheckInNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Check-in not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete check-in"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
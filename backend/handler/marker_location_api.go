@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/service"
 	"github.com/Alfex4936/chulbong-kr/util"
 	sonic "github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
@@ -28,6 +28,11 @@ const WEATHER_MINUTES = 15 * time.Minute
 // @Param distance query int true "Search radius distance (meters), maximum 50,000m"
 // @Param pageSize query int false "Number of markers per page (default: 4)"
 // @Param page query int true "Page index number (default: 1)"
+// @Param facility query string false "Comma separated facility IDs or names with an optional minimum quantity, e.g. 1,2 or chulbong:2"
+// @Param facilityMatch query string false "all (default): every facility is required, any: one of them is enough"
+// @Param access query string false "Comma separated access types: PUBLIC, PRIVATE, RESIDENTS_ONLY"
+// @Param lit query bool false "Only markers that are (true) or are not (false) lit at night"
+// @Param indoor query bool false "Only indoor (true) or outdoor (false) markers"
 // @Success 200 {object} dto.MarkersClose "Markers found successfully with pagination"
 // @Failure 400 {object} map[string]string "Invalid query or pagination parameters"
 // @Failure 403 {object} map[string]string "Distance cannot exceed 50,000m (50km)"
@@ -44,6 +49,16 @@ func (h *MarkerHandler) HandleFindCloseMarkers(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Distance cannot be greater than 50,000m (50km)"})
 	}
 
+	facilities, err := h.MarkerFacadeService.ParseFacilityRequirements(c.Query("facility"), c.Query("facilityMatch"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	access, err := service.ParseMarkerAccessFilter(c.Query("access"), c.Query("lit"), c.Query("indoor"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	pagination, err := util.ParsePaginationParams(c, &util.PaginationConfig{
 		DefaultPage:       1,
 		DefaultPageSize:   4,
@@ -58,7 +73,7 @@ func (h *MarkerHandler) HandleFindCloseMarkers(c *fiber.Ctx) error {
 	pageSize := pagination.PageSize
 
 	// Generate a cache key based on the query parameters
-	cacheKey := fmt.Sprintf("close_markers:%f:%f:%d:%d:%d", params.Latitude, params.Longitude, params.Distance, page, pageSize)
+	cacheKey := h.CacheService.CloseMarkersCacheKey(params.Latitude, params.Longitude, params.Distance, page, pageSize, facilities, access)
 
 	// Attempt to fetch from cache
 	cachedData, err := h.CacheService.GetCloseMarkersCache(cacheKey)
@@ -69,7 +84,7 @@ func (h *MarkerHandler) HandleFindCloseMarkers(c *fiber.Ctx) error {
 	}
 
 	// Cache miss: Find nearby markers within the specified distance and page
-	markers, total, err := h.MarkerFacadeService.FindClosestNMarkersWithinDistance(params.Latitude, params.Longitude, params.Distance, pageSize, pagination.Offset)
+	markers, total, err := h.MarkerFacadeService.FindClosestNMarkersFiltered(params.Latitude, params.Longitude, params.Distance, pageSize, pagination.Offset, facilities, access)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve markers"})
 	}
@@ -109,10 +124,62 @@ func (h *MarkerHandler) HandleFindCloseMarkers(c *fiber.Ctx) error {
 	return c.Send(responseJSON)
 }
 
+// HandleFindMarkersInViewport retrieves the markers inside the visible map area.
+//
+// @Summary Find markers in a bounding box
+// @Description Retrieves markers inside the bounding box ordered by marker ID, a page at a time.
+// @Description Pass the returned nextCursor as cursor to get the next page, it is absent on the last page.
+// @ID find-markers-in-viewport
+// @Tags markers-data, pagination
+// @Produce json
+// @Param bbox query string true "Bounding box as minLng,minLat,maxLng,maxLat"
+// @Param facility query string false "Comma separated facility IDs or names with an optional minimum quantity, e.g. 1,2 or chulbong:2"
+// @Param facilityMatch query string false "all (default): every facility is required, any: one of them is enough"
+// @Param access query string false "Comma separated access types: PUBLIC, PRIVATE, RESIDENTS_ONLY"
+// @Param lit query bool false "Only markers that are (true) or are not (false) lit at night"
+// @Param indoor query bool false "Only indoor (true) or outdoor (false) markers"
+// @Param thumbnails query bool false "Include the latest photo thumbnail of each marker"
+// @Param cursor query int false "nextCursor of the previous page"
+// @Param limit query int false "Markers per page (default: 200, maximum 1000)"
+// @Success 200 {object} dto.MarkerViewportResponse "Markers in the bounding box"
+// @Failure 400 {object} map[string]string "Invalid query parameters"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /api/v1/markers/viewport [get]
+func (h *MarkerHandler) HandleFindMarkersInViewport(c *fiber.Ctx) error {
+	box, err := util.ParseBBox(c.Query("bbox"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	facilities, err := h.MarkerFacadeService.ParseFacilityRequirements(c.Query("facility"), c.Query("facilityMatch"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	access, err := service.ParseMarkerAccessFilter(c.Query("access"), c.Query("lit"), c.Query("indoor"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	cursor := c.QueryInt("cursor", 0)
+	limit := c.QueryInt("limit", service.DefaultViewportLimit)
+	if cursor < 0 || limit < 1 || limit > service.MaxViewportLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pagination parameters"})
+	}
+
+	response, err := h.MarkerFacadeService.FindMarkersInBBox(box, facilities, access, c.QueryBool("thumbnails"), cursor, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve markers"})
+	}
+
+	return c.JSON(response)
+}
+
 // HandleGetCurrentAreaMarkerRanking retrieves top-ranked markers in the current area.
 //
 // @Summary Get ranked markers in the current area
 // @Description Fetches a list of markers ranked by popularity within a 10km radius from the provided coordinates.
+// @Description With sort=rating markers are ranked by their average review rating instead, markers with fewer than 3 reviews are left out.
 // @ID get-current-area-marker-ranking
 // @Tags ranking
 // @Accept json
@@ -120,6 +187,7 @@ func (h *MarkerHandler) HandleFindCloseMarkers(c *fiber.Ctx) error {
 // @Param latitude query number true "Latitude of the current location"
 // @Param longitude query number true "Longitude of the current location"
 // @Param limit query int false "Number of markers to return (default: 10)"
+// @Param sort query string false "clicks (default) or rating"
 // @Success 200 {array} dto.MarkerWithDistanceAndPhoto "List of ranked markers within the area"
 // @Failure 400 {object} map[string]string "Invalid query parameters"
 // @Failure 500 {object} map[string]string "Failed to retrieve ranked markers"
@@ -136,10 +204,15 @@ func (h *MarkerHandler) HandleGetCurrentAreaMarkerRanking(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid limit"})
 	}
 
+	sortBy, ok := parseRankSort(c, service.RankSortClicks, service.RankSortRating)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sort must be clicks or rating"})
+	}
+
 	// "current area"
 	const currentAreaDistance = 10000 // Meters
 
-	markers, err := h.MarkerFacadeService.FindRankedMarkersInCurrentArea(lat, lng, currentAreaDistance, limit)
+	markers, err := h.MarkerFacadeService.FindRankedMarkersInCurrentArea(lat, lng, currentAreaDistance, limit, sortBy)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve markers"})
 	}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"strings"
+
+	"github.com/Alfex4936/chulbong-kr/service"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -8,15 +11,41 @@ import (
 //
 // @Summary Get marker ranking
 // @Description Fetches the top 50 markers based on click count.
+// @Description With sort=rating the best rated markers with at least 3 reviews are returned instead, with their average and review count.
+// @Description With sort=visits the markers with the most verified check-in visitors over the last 30 days are returned, with their visitor count.
 // @ID get-marker-ranking
 // @Tags ranking
 // @Accept json
 // @Produce json
+// @Param sort query string false "clicks (default), rating or visits"
 // @Security
 // @Success 200 {array} dto.MarkerSimpleWithAddr "List of top-ranked markers"
+// @Success 200 {array} dto.MarkerRatedWithAddr "List of top-rated markers, with sort=rating"
+// @Success 200 {array} dto.MarkerVisitedWithAddr "List of most visited markers, with sort=visits"
+// @Failure 400 {object} map[string]string "Invalid sort"
 // @Failure 500 {object} map[string]string "Failed to retrieve marker ranking"
 // @Router /api/v1/markers/ranking [get]
 func (h *MarkerHandler) HandleGetMarkerRanking(c *fiber.Ctx) error {
+	sortBy, ok := parseRankSort(c, service.RankSortClicks, service.RankSortRating, service.RankSortVisits)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "sort must be clicks, rating or visits"})
+	}
+
+	switch sortBy {
+	case service.RankSortRating:
+		ranking, err := h.MarkerFacadeService.GetTopRatedMarkers(50)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve marker ranking"})
+		}
+		return c.JSON(ranking)
+	case service.RankSortVisits:
+		ranking, err := h.MarkerFacadeService.GetTopVisitedMarkers(50)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve marker ranking"})
+		}
+		return c.JSON(ranking)
+	}
+
 	ranking := h.MarkerFacadeService.GetTopMarkers(50)
 
 	return c.JSON(ranking)
@@ -37,3 +66,15 @@ func (h *MarkerHandler) HandleGetAllUniqueVisitorCount(c *fiber.Ctx) error {
 	count := h.MarkerFacadeService.GetAllUniqueVisitorCounts()
 	return c.JSON(count)
 }
+
+// parseRankSort reads the sort query parameter of the ranking endpoints, clicks when it is absent.
+// It reports false for a sort the endpoint does not offer.
+func parseRankSort(c *fiber.Ctx, allowed ...string) (string, bool) {
+	sortBy := strings.ToLower(c.Query("sort", service.RankSortClicks))
+	for _, a := range allowed {
+		if sortBy == a {
+			return sortBy, true
+		}
+	}
+	return "", false
+}
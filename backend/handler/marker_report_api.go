@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"mime/multipart"
 	"strconv"
+	"strings"
 
 	"github.com/Alfex4936/chulbong-kr/dto"
 	"github.com/Alfex4936/chulbong-kr/middleware"
+	"github.com/Alfex4936/chulbong-kr/model"
 	"github.com/Alfex4936/chulbong-kr/service"
 	"github.com/Alfex4936/chulbong-kr/util"
 	"go.uber.org/zap"
 
+	sonic "github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
@@ -125,13 +128,19 @@ func (h *MarkerHandler) HandleGetMarkerReports(c *fiber.Ctx) error {
 // @Param newLongitude formData number false "Updated longitude (must be within 30 meters)"
 // @Param description formData string true "Report description"
 // @Param doesExist formData boolean false "Indicates if the marker exists (true/false)"
-// @Param photos formData file true "At least one photo required"
+// @Param reportedStatus formData string false "Observed marker status: DAMAGED, UNDER_CONSTRUCTION or REMOVED"
+// @Param accessType formData string false "Observed access: PUBLIC, PRIVATE or RESIDENTS_ONLY"
+// @Param openingHours formData string false "Observed weekly schedule as JSON, e.g. {\"mon\":[{\"open\":\"06:00\",\"close\":\"22:00\"}]}"
+// @Param lit formData boolean false "Whether the marker is lit at night"
+// @Param indoor formData boolean false "Whether the marker is indoors"
+// @Param photos formData file true "At least one photo required, each passing the quality checks of POST /markers"
 // @Security ApiKeyAuth
-// @Success 200 {object} map[string]string "Report created successfully"
+// @Success 200 {object} map[string]interface{} "Report created successfully, with photoWarnings for photos accepted despite quality problems"
 // @Failure 400 {object} map[string]string "Invalid parameters or inappropriate content"
 // @Failure 403 {object} map[string]string "Operations only allowed within South Korea"
 // @Failure 406 {object} map[string]string "New latitude/longitude too far from original location"
 // @Failure 409 {object} map[string]string "Check if the marker exists or upload at least one photo"
+// @Failure 422 {object} map[string]string "A photo failed the quality checks"
 // @Failure 500 {object} map[string]string "Failed to create report"
 // @Router /api/v1/markers/reports [post]
 func (h *MarkerHandler) HandleCreateReport(c *fiber.Ctx) error {
@@ -194,19 +203,46 @@ func (h *MarkerHandler) HandleCreateReport(c *fiber.Ctx) error {
 		}
 	}
 
+	// Optional observed status, a bar reported as gone is always REMOVED
+	var reportedStatus string
+	if statusStr, ok := form.Value["reportedStatus"]; ok && len(statusStr[0]) > 0 {
+		status, valid := service.NormalizeMarkerStatus(statusStr[0])
+		if !valid {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid value for reportedStatus field")
+		}
+		reportedStatus = status
+	}
+	if !doesExist {
+		reportedStatus = service.MarkerStatusRemoved
+	} else if reportedStatus == service.MarkerStatusRemoved {
+		doesExist = false
+	}
+
+	reportedAccess, err := getMarkerAccessFromForm(form)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
 	userID, _ := c.Locals("userID").(int) // userID will be 0 if not logged in
 
-	err = h.MarkerFacadeService.CreateReport(&dto.MarkerReportRequest{
-		MarkerID:     markerID,
-		UserID:       userID,
-		Latitude:     latitude,
-		Longitude:    longitude,
-		NewLatitude:  newLatitude,
-		NewLongitude: newLongitude,
-		Description:  description,
-		DoesExist:    doesExist,
+	photoWarnings, err := h.MarkerFacadeService.CreateReport(&dto.MarkerReportRequest{
+		MarkerID:       markerID,
+		UserID:         userID,
+		Latitude:       latitude,
+		Longitude:      longitude,
+		NewLatitude:    newLatitude,
+		NewLongitude:   newLongitude,
+		Description:    description,
+		DoesExist:      doesExist,
+		ReportedStatus: reportedStatus,
+		ReportedAccess: reportedAccess,
 	}, form)
 	if err != nil {
+		var issue util.PhotoQualityIssue
+		if errors.As(err, &issue) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": issue.Message, "check": issue.Check})
+		}
+
 		var status int
 		var response dto.SimpleErrorResponse
 
@@ -231,6 +267,9 @@ func (h *MarkerHandler) HandleCreateReport(c *fiber.Ctx) error {
 		return c.Status(status).JSON(response)
 	}
 
+	if len(photoWarnings) > 0 {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "report created successfully", "photoWarnings": photoWarnings})
+	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "report created successfully"})
 }
 
@@ -345,3 +384,39 @@ func getNewLatLngFromForm(form *multipart.Form) (float64, float64, error) {
 
 	return latitude, longitude, nil
 }
+
+// getMarkerAccessFromForm reads the optional accessType, openingHours, lit and indoor fields of a report.
+func getMarkerAccessFromForm(form *multipart.Form) (model.MarkerAccess, error) {
+	var access model.MarkerAccess
+	formValue := func(key string) string {
+		if values, ok := form.Value[key]; ok && len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+
+	if accessType := formValue("accessType"); accessType != "" {
+		access.AccessType = &accessType
+	}
+	if openingHours := formValue("openingHours"); openingHours != "" {
+		var hours model.OpeningHours
+		if err := sonic.UnmarshalString(openingHours, &hours); err != nil {
+			return model.MarkerAccess{}, errors.New("invalid value for openingHours field")
+		}
+		access.OpeningHours = &hours
+	}
+	for key, dst := range map[string]**bool{"lit": &access.Lit, "indoor": &access.Indoor} {
+		if value := formValue(key); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return model.MarkerAccess{}, fmt.Errorf("invalid value for %s field", key)
+			}
+			*dst = &b
+		}
+	}
+
+	if err := service.NormalizeMarkerAccess(&access); err != nil {
+		return model.MarkerAccess{}, err
+	}
+	return access, nil
+}
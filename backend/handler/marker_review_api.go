@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/service"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandleGetMarkerReviews lists the reviews of a marker with its average scores.
+//
+// @Summary Get marker reviews
+// @Description Lists the star ratings and condition reviews of a marker, most recently written or edited first.
+// @Description rating holds the averages over all reviews and is absent when the marker has none.
+// @ID get-marker-reviews
+// @Tags markers-review
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of reviews per page" default(10)
+// @Success 200 {object} dto.MarkerReviewList "Reviews of the marker"
+// @Failure 400 {object} map[string]string "Invalid marker ID or pagination parameters"
+// @Failure 500 {object} map[string]string "Failed to retrieve reviews"
+// @Router /api/v1/markers/{markerID}/reviews [get]
+func (h *MarkerHandler) HandleGetMarkerReviews(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+
+	pagination, err := util.ParsePaginationParams(c, &util.PaginationConfig{
+		DefaultPage:       1,
+		DefaultPageSize:   10,
+		PageParamName:     "page",
+		PageSizeParamName: "pageSize",
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pagination parameters"})
+	}
+
+	reviews, err := h.MarkerFacadeService.GetMarkerReviews(markerID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve reviews"})
+	}
+
+	return c.JSON(reviews)
+}
+
+// HandleGetMyMarkerReview returns the review the user left on a marker.
+//
+// @Summary Get my marker review
+// @Description Fetches the authenticated user's review of a marker, to prefill the edit form.
+// @ID get-my-marker-review
+// @Tags markers-review
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} model.MarkerReview "The user's review"
+// @Failure 400 {object} map[string]string "Invalid marker ID"
+// @Failure 404 {object} map[string]string "The user has not reviewed this marker"
+// @Failure 500 {object} map[string]string "Failed to retrieve review"
+// @Router /api/v1/markers/{markerID}/reviews/me [get]
+func (h *MarkerHandler) HandleGetMyMarkerReview(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+	userID := c.Locals("userID").(int)
+
+	review, err := h.MarkerFacadeService.GetMarkerReview(markerID, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrReviewNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Review not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve review"})
+	}
+
+	return c.JSON(review)
+}
+
+// HandleSaveMarkerReview creates or edits the user's review of a marker.
+//
+// @Summary Rate and review a marker
+// @Description Saves a 1-5 star overall rating with optional 1-5 scores for grip, height, stability and surroundings, and an optional comment.
+// @Description A user has at most one review per marker, sending another one replaces it. Dislikes are not affected.
+// @ID save-marker-review
+// @Tags markers-review
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Param request body dto.MarkerReviewRequest true "Scores and comment"
+// @Security ApiKeyAuth
+// @Success 200 {object} model.MarkerReview "The saved review"
+// @Failure 400 {object} map[string]string "Invalid marker ID, scores or comment"
+// @Failure 404 {object} map[string]string "Marker not found"
+// @Failure 500 {object} map[string]string "Failed to save review"
+// @Router /api/v1/markers/{markerID}/reviews [put]
+func (h *MarkerHandler) HandleSaveMarkerReview(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+
+	var req dto.MarkerReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse request"})
+	}
+	if profanity, _ := h.MarkerFacadeService.CheckBadWord(req.Comment); profanity {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Comment contains inappropriate content."})
+	}
+
+	userID := c.Locals("userID").(int)
+
+	review, err := h.MarkerFacadeService.SaveMarkerReview(markerID, userID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidReview):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, service.ErrMarkerNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Marker not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save review"})
+	}
+
+	return c.JSON(review)
+}
+
+// HandleDeleteMarkerReview removes the user's review of a marker.
+//
+// @Summary Delete my marker review
+// @Description Removes the authenticated user's review of a marker, the marker's averages are updated right away.
+// @ID delete-marker-review
+// @Tags markers-review
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Security ApiKeyAuth
+// @Success 204 "Review deleted"
+// @Failure 400 {object} map[string]string "Invalid marker ID"
+// @Failure 404 {object} map[string]string "The user has not reviewed this marker"
+// @Failure 500 {object} map[string]string "Failed to delete review"
+// @Router /api/v1/markers/{markerID}/reviews [delete]
+func (h *MarkerHandler) HandleDeleteMarkerReview(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+	userID := c.Locals("userID").(int)
+
+	if err := h.MarkerFacadeService.DeleteMarkerReview(markerID, userID); err != nil {
+		if errors.Is(err, service.ErrReviewNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Review not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete review"})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
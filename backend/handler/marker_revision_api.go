@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/Alfex4936/chulbong-kr/service"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandleGetMarkerRevisions returns the edit history of a marker, newest first.
+//
+// @Summary Get marker revision history
+// @Description Lists the recorded revisions of a marker with the editor's username and the change source.
+// @ID get-marker-revisions
+// @Tags markers-revision
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of revisions per page" default(10)
+// @Success 200 {object} dto.MarkerRevisionList "Revisions of the marker"
+// @Failure 400 {object} map[string]string "Invalid marker ID or pagination parameters"
+// @Failure 500 {object} map[string]string "Failed to retrieve revisions"
+// @Router /api/v1/markers/{markerID}/revisions [get]
+func (h *MarkerHandler) HandleGetMarkerRevisions(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+
+	pagination, err := util.ParsePaginationParams(c, &util.PaginationConfig{
+		DefaultPage:       1,
+		DefaultPageSize:   10,
+		PageParamName:     "page",
+		PageSizeParamName: "pageSize",
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pagination parameters"})
+	}
+
+	revisions, err := h.MarkerFacadeService.GetMarkerRevisions(markerID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve revisions"})
+	}
+
+	return c.JSON(revisions)
+}
+
+// HandleDiffMarkerRevisions compares two revisions of the same marker.
+//
+// @Summary Diff two marker revisions
+// @Description Returns the fields (location, description, address, facilities) that differ between two revisions.
+// @ID diff-marker-revisions
+// @Tags markers-revision
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Param from query int true "Older revision ID"
+// @Param to query int true "Newer revision ID"
+// @Success 200 {object} dto.MarkerRevisionDiff "Changed fields between the two revisions"
+// @Failure 400 {object} map[string]string "Invalid marker or revision ID"
+// @Failure 404 {object} map[string]string "Revision not found"
+// @Failure 500 {object} map[string]string "Failed to compare revisions"
+// @Router /api/v1/markers/{markerID}/revisions/diff [get]
+func (h *MarkerHandler) HandleDiffMarkerRevisions(c *fiber.Ctx) error {
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+
+	from := c.QueryInt("from", 0)
+	to := c.QueryInt("to", 0)
+	if from <= 0 || to <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from and to revision IDs are required"})
+	}
+
+	diff, err := h.MarkerFacadeService.DiffMarkerRevisions(markerID, from, to)
+	if err != nil {
+		if errors.Is(err, service.ErrRevisionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Revision not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to compare revisions"})
+	}
+
+	return c.JSON(diff)
+}
+
+// HandleRollbackMarkerRevision restores a marker to the state of an earlier revision.
+//
+// @Summary Roll back a marker to a revision
+// @Description Restores location, description, address and facilities from the given revision. Only the marker owner or an admin can roll back.
+// @ID rollback-marker-revision
+// @Tags markers-revision
+// @Accept json
+// @Produce json
+// @Param markerID path int true "Marker ID"
+// @Param revisionID path int true "Revision ID to restore"
+// @Security ApiKeyAuth
+// @Success 200 {object} model.MarkerRevision "The revision recorded for the rollback"
+// @Failure 400 {object} map[string]string "Invalid marker or revision ID"
+// @Failure 403 {object} map[string]string "User is not allowed to roll back this marker"
+// @Failure 404 {object} map[string]string "Marker or revision not found"
+// @Failure 500 {object} map[string]string "Failed to roll back marker"
+// @Router /api/v1/markers/{markerID}/revisions/{revisionID}/rollback [post]
+func (h *MarkerHandler) HandleRollbackMarkerRevision(c *fiber.Ctx) error {
+	userID := c.Locals("userID").(int)
+	userRole := c.Locals("role").(string)
+
+	markerID, err := c.ParamsInt("markerID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid marker ID"})
+	}
+	revisionID, err := c.ParamsInt("revisionID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid revision ID"})
+	}
+
+	revision, err := h.MarkerFacadeService.RollbackMarkerRevision(markerID, revisionID, userID, userRole)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnauthorized):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "마커 작성자 또는 관리자만 되돌릴 수 있습니다."})
+		case errors.Is(err, service.ErrMarkerNotFound), errors.Is(err, service.ErrRevisionNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Marker or revision not found"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to roll back marker"})
+		}
+	}
+
+	return c.JSON(revision)
+}
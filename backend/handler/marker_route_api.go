@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/service"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HandlePlanRoute plans a workout route that visits several markers from a start point.
+//
+// @Summary Plan a workout route
+// @Description Picks the markers closest to the start that pass the facility, access and rating filters and orders them
+// @Description into a short route (nearest neighbor, improved with 2-opt) that does not return to the start.
+// @Description Stops that would make the route longer than distance are left out.
+// @Description minRating only keeps markers with at least 3 reviews averaging that many stars.
+// @Description With format=gpx the route is downloaded as a GPX file instead.
+// @ID plan-marker-route
+// @Tags markers-data
+// @Produce json
+// @Produce application/gpx+xml
+// @Param latitude query number true "Latitude of the start"
+// @Param longitude query number true "Longitude of the start"
+// @Param distance query int false "Maximum route length (meters), at most 50,000m" default(5000)
+// @Param stops query int false "Maximum number of markers to visit, at most 15" default(5)
+// @Param minRating query number false "Minimum average rating, 1-5"
+// @Param facility query string false "Comma separated facility IDs or names with an optional minimum quantity, e.g. 1,2 or chulbong:2"
+// @Param facilityMatch query string false "all (default): every facility is required, any: one of them is enough"
+// @Param access query string false "Comma separated access types: PUBLIC, PRIVATE, RESIDENTS_ONLY"
+// @Param lit query bool false "Only markers that are (true) or are not (false) lit at night"
+// @Param indoor query bool false "Only indoor (true) or outdoor (false) markers"
+// @Param format query string false "json (default) or gpx"
+// @Success 200 {object} dto.MarkerRoute "Stops in visiting order with leg and total lengths in meters"
+// @Failure 400 {object} map[string]string "Invalid start, distance, stops, rating, filter or format"
+// @Failure 500 {object} map[string]string "Failed to plan route"
+// @Router /api/v1/markers/route [get]
+func (h *MarkerHandler) HandlePlanRoute(c *fiber.Ctx) error {
+	var req dto.MarkerRouteRequest
+	if err := c.QueryParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid query parameters"})
+	}
+	if !util.IsInSouthKorea(req.Latitude, req.Longitude) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Start must be in South Korea"})
+	}
+
+	format := c.Query("format", "json")
+	if format != "json" && format != util.ExportFormatGPX {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be json or gpx"})
+	}
+
+	facilities, err := h.MarkerFacadeService.ParseFacilityRequirements(c.Query("facility"), c.Query("facilityMatch"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	access, err := service.ParseMarkerAccessFilter(c.Query("access"), c.Query("lit"), c.Query("indoor"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	route, err := h.MarkerFacadeService.PlanRoute(&req, facilities, access)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRoute) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to plan route"})
+	}
+
+	if format == util.ExportFormatGPX {
+		var buf bytes.Buffer
+		if err := h.MarkerFacadeService.WriteRouteGPX(&buf, route); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to export route"})
+		}
+		c.Set(fiber.HeaderContentType, util.ExportContentType(format)+"; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="k-pullup-route.gpx"`)
+		return c.Send(buf.Bytes())
+	}
+
+	return c.JSON(route)
+}
@@ -27,6 +27,7 @@ import (
 // @Success 201 {object} dto.StoryResponse "Story added successfully"
 // @Failure 400 {object} map[string]string "Invalid marker ID, form data, or missing required fields"
 // @Failure 409 {object} map[string]string "Story already posted"
+// @Failure 422 {object} map[string]string "The photo failed the quality checks of POST /markers"
 // @Failure 500 {object} map[string]string "Failed to add story"
 // @Router /api/v1/markers/{markerID}/stories [post]
 func (h *MarkerHandler) HandleAddStory(c *fiber.Ctx) error {
@@ -68,6 +69,10 @@ func (h *MarkerHandler) HandleAddStory(c *fiber.Ctx) error {
 		if errors.Is(err, service.ErrAlreadyStoryPost) {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Story already posted"}) // 409
 		}
+		var issue util.PhotoQualityIssue
+		if errors.As(err, &issue) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": issue.Message, "check": issue.Check}) // 422
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add story"})
 	}
 
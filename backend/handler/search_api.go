@@ -5,6 +5,7 @@ import (
 
 	"github.com/Alfex4936/chulbong-kr/dto"
 	"github.com/Alfex4936/chulbong-kr/service"
+	"github.com/Alfex4936/chulbong-kr/util"
 	"go.uber.org/zap"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,6 +15,7 @@ import (
 type SearchHandler struct {
 	SearchService      *service.ZincSearchService
 	BleveSearchService *service.BleveSearchService
+	CatalogService     *service.FacilityCatalogService
 	Logger             *zap.Logger
 }
 
@@ -21,11 +23,13 @@ type SearchHandler struct {
 func NewSearchHandler(
 	zinc *service.ZincSearchService,
 	bleve *service.BleveSearchService,
+	catalog *service.FacilityCatalogService,
 	logger *zap.Logger,
 ) *SearchHandler {
 	return &SearchHandler{
 		SearchService:      zinc,
 		BleveSearchService: bleve,
+		CatalogService:     catalog,
 		Logger:             logger,
 	}
 }
@@ -85,8 +89,10 @@ func (h *SearchHandler) HandleSearchMarkerAddress(c *fiber.Ctx) error {
 // @Produce json
 // @Security
 // @Param term query string true "Search term for the marker address"
+// @Param facility query string false "Comma separated facility IDs or names with an optional minimum quantity, e.g. 1,2 or chulbong:2"
+// @Param facilityMatch query string false "all (default): every facility is required, any: one of them is enough"
 // @Success 200 {array} dto.MarkerSearchResponse "List of matching markers"
-// @Failure 400 {object} map[string]string "Search term is required"
+// @Failure 400 {object} map[string]string "Search term is required or invalid facility filter"
 // @Failure 500 {object} map[string]string "Failed to execute search"
 // @Router /api/v1/search/marker [get]
 func (h *SearchHandler) HandleBleveSearchMarkerAddress(c *fiber.Ctx) error {
@@ -98,8 +104,15 @@ func (h *SearchHandler) HandleBleveSearchMarkerAddress(c *fiber.Ctx) error {
 		})
 	}
 
+	facilities, err := util.ParseFacilityRequirements(c.Query("facility"), c.Query("facilityMatch"), h.CatalogService.FacilityNames())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Call the service function
-	response, err := h.BleveSearchService.SearchMarkerAddress(term)
+	response, err := h.BleveSearchService.SearchMarkerAddress(term, facilities)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
@@ -5,6 +5,7 @@ import (
 	"github.com/Alfex4936/chulbong-kr/facade"
 	"github.com/Alfex4936/chulbong-kr/middleware"
 	"github.com/Alfex4936/chulbong-kr/service"
+	"github.com/Alfex4936/chulbong-kr/util"
 	sonic "github.com/bytedance/sonic"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
@@ -58,6 +59,8 @@ func RegisterUserRoutes(api fiber.Router, handler *UserHandler, authMiddleware *
 		userGroup.Use(authMiddleware.Verify)
 		userGroup.Get("/me", authMiddleware.VerifySoft, handler.HandleProfile)
 		userGroup.Get("/favorites", handler.HandleGetFavorites)
+		userGroup.Get("/badges", handler.HandleGetMyBadges)
+		userGroup.Get("/contributions", handler.HandleGetMyContributions)
 		userGroup.Get("/reports", handler.HandleGetMyReports)                          // getting reports that I made
 		userGroup.Get("/reports/for-my-markers", handler.HandleGetReportsForMyMarkers) // getting reports for my markers
 		userGroup.Patch("/me", handler.HandleUpdateUser)
@@ -144,7 +147,7 @@ func (h *UserHandler) HandleDeleteUser(c *fiber.Ctx) error {
 // HandleProfile retrieves the authenticated user's profile.
 //
 // @Summary Get user profile
-// @Description Fetches the profile details of the authenticated user, including statistics and contribution levels.
+// @Description Fetches the profile details of the authenticated user, including statistics, contribution levels and earned badges.
 // @ID get-user-profile
 // @Tags users
 // @Accept json
@@ -189,6 +192,11 @@ func (h *UserHandler) HandleProfile(c *fiber.Ctx) error {
 		user.ContributionLevel = level
 	}
 
+	badges, err := h.UserFacadeService.GetEarnedBadges(userData.UserID)
+	if err == nil {
+		user.Badges = badges
+	}
+
 	// Check adminship
 	if chulbong {
 		user.Chulbong = true
@@ -207,6 +215,75 @@ func (h *UserHandler) HandleProfile(c *fiber.Ctx) error {
 	return c.Send(userProfileData)
 }
 
+// HandleGetMyBadges lists every achievement badge with the authenticated user's progress.
+//
+// @Summary Get my badges
+// @Description Lists all achievement badges, earned ones first in the order they were earned, then the rest with the progress towards their goal.
+// @Description Badges are awarded when markers are created, reports approved, comments posted, stories posted or check-ins verified,
+// @Description and each award is sent as a "Badge" notification.
+// @ID get-user-badges
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} dto.Badge "Badges with progress"
+// @Failure 500 {object} map[string]string "Failed to retrieve badges"
+// @Router /api/v1/users/badges [get]
+func (h *UserHandler) HandleGetMyBadges(c *fiber.Ctx) error {
+	userData, err := h.UserFacadeService.GetUserFromContext(c)
+	if err != nil {
+		return err // fiber err
+	}
+
+	badges, err := h.UserFacadeService.GetUserBadges(userData.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve badges"})
+	}
+
+	return c.JSON(badges)
+}
+
+// HandleGetMyContributions lists the authenticated user's contribution points history.
+//
+// @Summary Get my contribution history
+// @Description Lists the entries of the authenticated user's points ledger, newest first, with the total and level.
+// @Description A created marker earns 10 points and an approved report 5. Points are taken back with a negative entry
+// @Description when the marker is deleted or merged away, or when the report is reversed or deleted.
+// @ID get-user-contributions
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param pageSize query int false "Number of entries per page" default(20)
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.ContributionHistory "Itemized contribution points"
+// @Failure 400 {object} map[string]string "Invalid pagination parameters"
+// @Failure 500 {object} map[string]string "Failed to retrieve contributions"
+// @Router /api/v1/users/contributions [get]
+func (h *UserHandler) HandleGetMyContributions(c *fiber.Ctx) error {
+	pagination, err := util.ParsePaginationParams(c, &util.PaginationConfig{
+		DefaultPage:       1,
+		DefaultPageSize:   20,
+		PageParamName:     "page",
+		PageSizeParamName: "pageSize",
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid pagination parameters"})
+	}
+
+	userData, err := h.UserFacadeService.GetUserFromContext(c)
+	if err != nil {
+		return err // fiber err
+	}
+
+	history, err := h.UserFacadeService.GetContributionHistory(userData.UserID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve contributions"})
+	}
+
+	return c.JSON(history)
+}
+
 // HandleGetFavorites retrieves the authenticated user's favorite markers.
 //
 // @Summary Get user favorite markers
@@ -613,12 +613,15 @@ func main() {
 			NewFiberApp,
 		),
 		fx.Invoke(
+			service.RunStartupMigrations, // must stay first, before any service prepares statements
 			registerHooks,
 			util.RegisterBadWordUtilLifecycle,
 			service.RegisterSchedulerLifecycle,
 			util.RegisterPdfInitLifecycle,
 			service.RegisterMarkerLifecycle,
 			service.RegisterMarkerLocationLifecycle,
+			service.RegisterMarkerClusterLifecycle,
+			service.RegisterPhotoVariantLifecycle,
 			service.RegisterAuthLifecycle,
 			service.RegisteBleveLifecycle,
 			service.RegisterTokenServiceLifecycle,
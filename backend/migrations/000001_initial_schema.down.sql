@@ -0,0 +1,21 @@
+DROP TABLE IF EXISTS visitors;
+DROP TABLE IF EXISTS UserContributions;
+DROP TABLE IF EXISTS Notices;
+DROP TABLE IF EXISTS Notifications;
+DROP TABLE IF EXISTS PasswordResetTokens;
+DROP TABLE IF EXISTS PasswordTokens;
+DROP TABLE IF EXISTS OpaqueTokens;
+DROP TABLE IF EXISTS StoryReports;
+DROP TABLE IF EXISTS Reactions;
+DROP TABLE IF EXISTS Stories;
+DROP TABLE IF EXISTS RestrictedAreas;
+DROP TABLE IF EXISTS MarkerAddressFailures;
+DROP TABLE IF EXISTS ReportPhotos;
+DROP TABLE IF EXISTS Reports;
+DROP TABLE IF EXISTS Comments;
+DROP TABLE IF EXISTS Favorites;
+DROP TABLE IF EXISTS MarkerDislikes;
+DROP TABLE IF EXISTS MarkerFacilities;
+DROP TABLE IF EXISTS Photos;
+DROP TABLE IF EXISTS Markers;
+DROP TABLE IF EXISTS Users;
@@ -0,0 +1,241 @@
+-- Initial k-pullup schema (MySQL 8).
+-- Locations are stored as POINT(lat long) with SRID 4326, read back with ST_X (latitude) and ST_Y (longitude).
+
+CREATE TABLE IF NOT EXISTS Users (
+    UserID       INT AUTO_INCREMENT PRIMARY KEY,
+    Username     VARCHAR(255) NOT NULL,
+    Email        VARCHAR(255) NOT NULL,
+    PasswordHash VARCHAR(255) NULL,
+    Provider     VARCHAR(50)  NULL,
+    ProviderID   VARCHAR(255) NULL,
+    Role         VARCHAR(20)  NOT NULL DEFAULT 'user',
+    CreatedAt    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_users_username (Username),
+    UNIQUE KEY uq_users_email_provider (Email, Provider)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS Markers (
+    MarkerID    INT AUTO_INCREMENT PRIMARY KEY,
+    UserID      INT          NULL,
+    Location    POINT        NOT NULL SRID 4326,
+    Description TEXT         NULL,
+    Address     VARCHAR(255) NULL,
+    CreatedAt   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    SPATIAL INDEX idx_markers_location (Location),
+    INDEX idx_markers_user_created (UserID, CreatedAt),
+    INDEX idx_markers_created (CreatedAt),
+    CONSTRAINT fk_markers_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Photos has no foreign key on purpose: SchedulerService.deleteOrphanedPhotos cleans up rows (and S3 objects) left behind.
+CREATE TABLE IF NOT EXISTS Photos (
+    PhotoID      INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID     INT          NOT NULL,
+    PhotoURL     VARCHAR(512) NOT NULL,
+    ThumbnailURL VARCHAR(512) NULL,
+    Blurhash     VARCHAR(64)  NULL,
+    UploadedAt   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_photos_marker_uploaded (MarkerID, UploadedAt)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS MarkerFacilities (
+    MarkerFacilityID INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID         INT NOT NULL,
+    FacilityID       INT NOT NULL,
+    Quantity         INT NOT NULL DEFAULT 1,
+    UNIQUE KEY uq_marker_facility (MarkerID, FacilityID),
+    CONSTRAINT fk_facilities_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS MarkerDislikes (
+    DislikeID  INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID   INT       NOT NULL,
+    UserID     INT       NOT NULL,
+    DislikedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_dislike_marker_user (MarkerID, UserID),
+    CONSTRAINT fk_dislikes_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE,
+    CONSTRAINT fk_dislikes_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS Favorites (
+    FavoriteID INT AUTO_INCREMENT PRIMARY KEY,
+    UserID     INT       NOT NULL,
+    MarkerID   INT       NOT NULL,
+    CreatedAt  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_favorite_user_marker (UserID, MarkerID),
+    INDEX idx_favorites_marker (MarkerID),
+    CONSTRAINT fk_favorites_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE,
+    CONSTRAINT fk_favorites_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS Comments (
+    CommentID   INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID    INT       NOT NULL,
+    UserID      INT       NOT NULL,
+    CommentText TEXT      NOT NULL,
+    PostedAt    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    DeletedAt   TIMESTAMP NULL,
+    INDEX idx_comments_marker_posted (MarkerID, PostedAt),
+    INDEX idx_comments_user (UserID),
+    CONSTRAINT fk_comments_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE,
+    CONSTRAINT fk_comments_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS Reports (
+    ReportID    INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID    INT         NOT NULL,
+    UserID      INT         NULL,
+    Location    POINT       NOT NULL SRID 4326,
+    NewLocation POINT       NULL SRID 4326,
+    Description TEXT        NULL,
+    DoesExist   BOOLEAN     NOT NULL DEFAULT TRUE,
+    Status      VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    CreatedAt   TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_reports_marker_created (MarkerID, CreatedAt),
+    INDEX idx_reports_user (UserID),
+    INDEX idx_reports_status (Status),
+    CONSTRAINT fk_reports_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE,
+    CONSTRAINT fk_reports_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- ReportPhotos has no foreign key either, see SchedulerService.deleteOrphanedReportPhotos.
+CREATE TABLE IF NOT EXISTS ReportPhotos (
+    PhotoID      INT AUTO_INCREMENT PRIMARY KEY,
+    ReportID     INT          NOT NULL,
+    PhotoURL     VARCHAR(512) NOT NULL,
+    ThumbnailURL VARCHAR(512) NULL,
+    Blurhash     VARCHAR(64)  NULL,
+    UploadedAt   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_report_photos_report (ReportID)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS MarkerAddressFailures (
+    FailureID    INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID     INT          NOT NULL,
+    ErrorMessage TEXT         NULL,
+    URL          VARCHAR(512) NULL,
+    CreatedAt    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_address_failures_marker (MarkerID)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS RestrictedAreas (
+    AreaID  INT AUTO_INCREMENT PRIMARY KEY,
+    Name    VARCHAR(255) NOT NULL,
+    Polygon POLYGON      NOT NULL SRID 4326,
+    SPATIAL INDEX idx_restricted_polygon (Polygon)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS Stories (
+    StoryID   INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID  INT          NOT NULL,
+    UserID    INT          NOT NULL,
+    Caption   VARCHAR(255) NULL,
+    PhotoURL  VARCHAR(512) NOT NULL,
+    Blurhash  VARCHAR(64)  NULL,
+    Address   VARCHAR(255) NULL,
+    CreatedAt TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ExpiresAt TIMESTAMP    NOT NULL,
+    INDEX idx_stories_marker_expires (MarkerID, ExpiresAt),
+    INDEX idx_stories_expires (ExpiresAt),
+    CONSTRAINT fk_stories_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE,
+    CONSTRAINT fk_stories_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS Reactions (
+    ReactionID   INT AUTO_INCREMENT PRIMARY KEY,
+    StoryID      INT         NOT NULL,
+    UserID       INT         NOT NULL,
+    ReactionType VARCHAR(20) NOT NULL,
+    CreatedAt    TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_reaction_story_user (StoryID, UserID),
+    CONSTRAINT fk_reactions_story FOREIGN KEY (StoryID) REFERENCES Stories (StoryID) ON DELETE CASCADE,
+    CONSTRAINT fk_reactions_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS StoryReports (
+    ReportID  INT AUTO_INCREMENT PRIMARY KEY,
+    StoryID   INT          NOT NULL,
+    UserID    INT          NOT NULL,
+    Reason    VARCHAR(255) NULL,
+    CreatedAt TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_story_report_user (StoryID, UserID),
+    CONSTRAINT fk_story_reports_story FOREIGN KEY (StoryID) REFERENCES Stories (StoryID) ON DELETE CASCADE,
+    CONSTRAINT fk_story_reports_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS OpaqueTokens (
+    TokenID     INT AUTO_INCREMENT PRIMARY KEY,
+    UserID      INT          NOT NULL,
+    OpaqueToken VARCHAR(255) NOT NULL,
+    ExpiresAt   TIMESTAMP    NOT NULL,
+    CreatedAt   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_opaque_token (OpaqueToken),
+    INDEX idx_opaque_tokens_user_expires (UserID, ExpiresAt),
+    CONSTRAINT fk_opaque_tokens_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS PasswordTokens (
+    TokenID   INT AUTO_INCREMENT PRIMARY KEY,
+    Token     VARCHAR(255) NOT NULL,
+    Email     VARCHAR(255) NOT NULL,
+    Verified  BOOLEAN      NOT NULL DEFAULT FALSE,
+    ExpiresAt TIMESTAMP    NOT NULL,
+    CreatedAt TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_password_tokens_email (Email)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS PasswordResetTokens (
+    TokenID   INT AUTO_INCREMENT PRIMARY KEY,
+    UserID    INT          NOT NULL,
+    Token     VARCHAR(255) NOT NULL,
+    ExpiresAt TIMESTAMP    NOT NULL,
+    CreatedAt TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_password_reset_user (UserID),
+    INDEX idx_password_reset_token (Token),
+    CONSTRAINT fk_password_reset_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS Notifications (
+    NotificationId   BIGINT AUTO_INCREMENT PRIMARY KEY,
+    UserId           VARCHAR(64)  NOT NULL,
+    NotificationType VARCHAR(50)  NOT NULL,
+    Title            VARCHAR(255) NOT NULL,
+    Message          TEXT         NOT NULL,
+    Metadata         JSON         NULL,
+    Viewed           BOOLEAN      NOT NULL DEFAULT FALSE,
+    CreatedAt        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_notifications_user_viewed (UserId, Viewed),
+    INDEX idx_notifications_viewed_updated (Viewed, UpdatedAt)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS Notices (
+    NoticeID  INT AUTO_INCREMENT PRIMARY KEY,
+    Title     VARCHAR(255) NOT NULL,
+    Content   TEXT         NOT NULL,
+    AuthorID  INT          NULL,
+    Published BOOLEAN      NOT NULL DEFAULT TRUE,
+    CreatedAt TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_notices_author FOREIGN KEY (AuthorID) REFERENCES Users (UserID) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS UserContributions (
+    ContributionID INT AUTO_INCREMENT PRIMARY KEY,
+    UserID         INT         NOT NULL,
+    ActivityType   VARCHAR(50) NOT NULL,
+    Points         INT         NOT NULL DEFAULT 0,
+    CreatedAt      TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_contributions_user (UserID),
+    CONSTRAINT fk_contributions_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS visitors (
+    visitor_id VARCHAR(64) NOT NULL,
+    visit_date DATE        NOT NULL,
+    PRIMARY KEY (visit_date, visitor_id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
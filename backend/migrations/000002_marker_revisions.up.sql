@@ -0,0 +1,17 @@
+-- Every change to a marker's location, description, address or facilities is stored as a full snapshot.
+CREATE TABLE IF NOT EXISTS MarkerRevisions (
+    RevisionID  INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID    INT          NOT NULL,
+    ActorUserID INT          NULL,
+    ReportID    INT          NULL,
+    Source      VARCHAR(20)  NOT NULL,
+    Latitude    DOUBLE       NOT NULL,
+    Longitude   DOUBLE       NOT NULL,
+    Description TEXT         NULL,
+    Address     VARCHAR(255) NULL,
+    Facilities  JSON         NULL,
+    CreatedAt   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_revisions_marker (MarkerID, RevisionID),
+    CONSTRAINT fk_revisions_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE,
+    CONSTRAINT fk_revisions_user FOREIGN KEY (ActorUserID) REFERENCES Users (UserID) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
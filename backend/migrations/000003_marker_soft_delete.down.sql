@@ -0,0 +1,4 @@
+ALTER TABLE MarkerFacilities DROP COLUMN DeletedAt;
+ALTER TABLE Favorites DROP COLUMN DeletedAt;
+ALTER TABLE Photos DROP COLUMN DeletedAt;
+ALTER TABLE Markers DROP INDEX idx_markers_deleted, DROP COLUMN DeletedAt;
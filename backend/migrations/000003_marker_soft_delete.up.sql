@@ -0,0 +1,14 @@
+-- Soft delete for markers. Dependent rows get the marker's DeletedAt so a restore can tell them apart
+-- from rows that were deleted on their own (e.g. a comment removed by its author).
+ALTER TABLE Markers
+    ADD COLUMN DeletedAt TIMESTAMP NULL,
+    ADD INDEX idx_markers_deleted (DeletedAt);
+
+ALTER TABLE Photos
+    ADD COLUMN DeletedAt TIMESTAMP NULL;
+
+ALTER TABLE Favorites
+    ADD COLUMN DeletedAt TIMESTAMP NULL;
+
+ALTER TABLE MarkerFacilities
+    ADD COLUMN DeletedAt TIMESTAMP NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE Reports DROP COLUMN ReportedStatus;
+ALTER TABLE Markers DROP INDEX idx_markers_status, DROP COLUMN Status;
@@ -0,0 +1,8 @@
+-- Marker lifecycle status, changed by approved existence reports (see service.MarkerStatusService).
+ALTER TABLE Markers
+    ADD COLUMN Status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+    ADD INDEX idx_markers_status (Status);
+
+-- What the reporter says the bar looks like now. NULL falls back to DoesExist (TRUE = ACTIVE, FALSE = REMOVED).
+ALTER TABLE Reports
+    ADD COLUMN ReportedStatus VARCHAR(20) NULL;
@@ -0,0 +1,18 @@
+-- Audit trail of duplicate markers merged into a surviving marker. The merged marker row is soft-deleted
+-- with the MERGED status and never purged, each row doubles as the redirect from the old ID.
+CREATE TABLE IF NOT EXISTS MarkerMerges (
+    MergeID          INT AUTO_INCREMENT PRIMARY KEY,
+    SurvivorMarkerID INT          NOT NULL,
+    MergedMarkerID   INT          NOT NULL,
+    MergedByUserID   INT          NULL,
+    OwnerUserID      INT          NULL,
+    Latitude         DOUBLE       NOT NULL,
+    Longitude        DOUBLE       NOT NULL,
+    Description      TEXT         NULL,
+    Address          VARCHAR(255) NULL,
+    Moved            JSON         NULL,
+    CreatedAt        TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_merges_merged (MergedMarkerID),
+    INDEX idx_merges_survivor (SurvivorMarkerID),
+    CONSTRAINT fk_merges_user FOREIGN KEY (MergedByUserID) REFERENCES Users (UserID) ON DELETE SET NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
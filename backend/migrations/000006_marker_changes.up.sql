@@ -0,0 +1,8 @@
+-- Change log for delta sync. Every marker create, update and delete appends a row, Seq is the
+-- client's cursor. Rows only say which marker changed, clients get its current state (or a tombstone).
+CREATE TABLE IF NOT EXISTS MarkerChanges (
+    Seq       BIGINT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID  INT       NOT NULL,
+    ChangedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_marker_changes_changed (ChangedAt)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
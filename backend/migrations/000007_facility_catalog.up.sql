@@ -0,0 +1,22 @@
+-- Server-side catalog of what a MarkerFacilities.FacilityID means. IDs 1 and 2 keep the meaning
+-- clients already hardcode (철봉, 평행봉). MarkerFacilities gets no foreign key, old rows may hold IDs
+-- that were never in the catalog and writes are validated by the API instead.
+CREATE TABLE IF NOT EXISTS Facilities (
+    FacilityID INT AUTO_INCREMENT PRIMARY KEY,
+    Slug       VARCHAR(50)  NOT NULL,
+    Category   VARCHAR(30)  NOT NULL,
+    NameKo     VARCHAR(100) NOT NULL,
+    NameEn     VARCHAR(100) NOT NULL,
+    Icon       VARCHAR(255) NOT NULL DEFAULT '',
+    SortOrder  INT          NOT NULL DEFAULT 0,
+    CreatedAt  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_facilities_slug (Slug)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+INSERT INTO Facilities (FacilityID, Slug, Category, NameKo, NameEn, Icon, SortOrder) VALUES
+    (1, 'chulbong', 'PULL_UP_BAR', '철봉', 'Pull-up bar', 'pull-up-bar', 1),
+    (2, 'pyeong', 'PARALLEL_BARS', '평행봉', 'Parallel bars', 'parallel-bars', 2),
+    (3, 'rings', 'RINGS', '링', 'Rings', 'rings', 3),
+    (4, 'monkey-bars', 'MONKEY_BARS', '구름사다리', 'Monkey bars', 'monkey-bars', 4)
+ON DUPLICATE KEY UPDATE FacilityID = FacilityID;
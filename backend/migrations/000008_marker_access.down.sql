@@ -0,0 +1,12 @@
+ALTER TABLE MarkerRevisions DROP COLUMN Access;
+ALTER TABLE Reports
+    DROP COLUMN ReportedAccessType,
+    DROP COLUMN ReportedOpeningHours,
+    DROP COLUMN ReportedLit,
+    DROP COLUMN ReportedIndoor;
+ALTER TABLE Markers
+    DROP INDEX idx_markers_access_type,
+    DROP COLUMN AccessType,
+    DROP COLUMN OpeningHours,
+    DROP COLUMN Lit,
+    DROP COLUMN Indoor;
@@ -0,0 +1,18 @@
+-- Structured access metadata, NULL means nobody has said yet (see model.MarkerAccess).
+-- OpeningHours is a weekly schedule, {"mon": [{"open": "06:00", "close": "22:00"}], ...}.
+ALTER TABLE Markers
+    ADD COLUMN AccessType   VARCHAR(20) NULL,
+    ADD COLUMN OpeningHours JSON        NULL,
+    ADD COLUMN Lit          BOOLEAN     NULL,
+    ADD COLUMN Indoor       BOOLEAN     NULL,
+    ADD INDEX idx_markers_access_type (AccessType);
+
+-- What the reporter says about access, copied to the marker when the report is approved. NULL leaves it as is.
+ALTER TABLE Reports
+    ADD COLUMN ReportedAccessType   VARCHAR(20) NULL,
+    ADD COLUMN ReportedOpeningHours JSON        NULL,
+    ADD COLUMN ReportedLit          BOOLEAN     NULL,
+    ADD COLUMN ReportedIndoor       BOOLEAN     NULL;
+
+ALTER TABLE MarkerRevisions
+    ADD COLUMN Access JSON NULL;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS MarkerRatings;
+DROP TABLE IF EXISTS MarkerReviews;
@@ -0,0 +1,36 @@
+-- One review per user and marker, edited in place. Rating is the overall score, the dimensions are optional.
+-- All scores are 1 to 5, checked by the API.
+CREATE TABLE IF NOT EXISTS MarkerReviews (
+    ReviewID     INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID     INT       NOT NULL,
+    UserID       INT       NOT NULL,
+    Rating       TINYINT   NOT NULL,
+    Grip         TINYINT   NULL,
+    Height       TINYINT   NULL,
+    Stability    TINYINT   NULL,
+    Surroundings TINYINT   NULL,
+    Comment      TEXT      NULL,
+    CreatedAt    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UpdatedAt    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_reviews_marker_user (MarkerID, UserID),
+    INDEX idx_reviews_marker_updated (MarkerID, UpdatedAt),
+    INDEX idx_reviews_user (UserID),
+    CONSTRAINT fk_reviews_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE,
+    CONSTRAINT fk_reviews_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Averages of MarkerReviews per marker, refreshed whenever a review of the marker changes so details and
+-- rankings never aggregate on read. Markers without reviews have no row, or ReviewCount 0 once their last review is gone.
+CREATE TABLE IF NOT EXISTS MarkerRatings (
+    MarkerID        INT          PRIMARY KEY,
+    ReviewCount     INT          NOT NULL,
+    AvgRating       DECIMAL(3,2) NOT NULL,
+    AvgGrip         DECIMAL(3,2) NULL,
+    AvgHeight       DECIMAL(3,2) NULL,
+    AvgStability    DECIMAL(3,2) NULL,
+    AvgSurroundings DECIMAL(3,2) NULL,
+    UpdatedAt       TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_marker_ratings_rank (AvgRating, ReviewCount),
+    CONSTRAINT fk_marker_ratings_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS CheckInExercises;
+DROP TABLE IF EXISTS MarkerCheckIns;
@@ -0,0 +1,29 @@
+-- A user training at a marker. Latitude and Longitude are where the device was, when it sent a position,
+-- and Verified is set when that was close enough to the marker.
+CREATE TABLE IF NOT EXISTS MarkerCheckIns (
+    CheckInID      INT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID       INT          NOT NULL,
+    UserID         INT          NOT NULL,
+    Latitude       DOUBLE       NULL,
+    Longitude      DOUBLE       NULL,
+    DistanceMeters DOUBLE       NULL,
+    Verified       BOOLEAN      NOT NULL DEFAULT FALSE,
+    Note           VARCHAR(255) NULL,
+    CheckedInAt    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_checkins_user (UserID, CheckedInAt),
+    INDEX idx_checkins_marker (MarkerID, CheckedInAt),
+    CONSTRAINT fk_checkins_marker FOREIGN KEY (MarkerID) REFERENCES Markers (MarkerID) ON DELETE CASCADE,
+    CONSTRAINT fk_checkins_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- The workout log of a check-in, one row per exercise in the order they were logged.
+CREATE TABLE IF NOT EXISTS CheckInExercises (
+    CheckInExerciseID INT AUTO_INCREMENT PRIMARY KEY,
+    CheckInID         INT         NOT NULL,
+    Exercise          VARCHAR(30) NOT NULL,
+    Sets              INT         NOT NULL,
+    Reps              INT         NOT NULL,
+    SortOrder         INT         NOT NULL DEFAULT 0,
+    INDEX idx_checkin_exercises_checkin (CheckInID, SortOrder),
+    CONSTRAINT fk_checkin_exercises_checkin FOREIGN KEY (CheckInID) REFERENCES MarkerCheckIns (CheckInID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS LeaderboardScores;
+
+ALTER TABLE MarkerCheckIns
+    DROP COLUMN Ranked;
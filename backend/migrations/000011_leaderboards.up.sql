@@ -0,0 +1,19 @@
+-- Set on check-ins that scored on the leaderboards, so deleting one takes its point back.
+ALTER TABLE MarkerCheckIns
+    ADD COLUMN Ranked BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- People leaderboards built from ranked check-ins, one point each. Redis keeps them as sorted sets and
+-- rebuilds them from here. Scope is marker, city, province or national and ScopeKey the marker ID,
+-- "<province> <city>", the province or "kr". PeriodKey is the ISO week (2024-W07), month (2024-02) or "all", in KST.
+CREATE TABLE IF NOT EXISTS LeaderboardScores (
+    Scope     VARCHAR(10)  NOT NULL,
+    ScopeKey  VARCHAR(100) NOT NULL,
+    Period    VARCHAR(10)  NOT NULL,
+    PeriodKey VARCHAR(10)  NOT NULL,
+    UserID    INT          NOT NULL,
+    Score     INT          NOT NULL DEFAULT 0,
+    UpdatedAt TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (Scope, ScopeKey, Period, PeriodKey, UserID),
+    INDEX idx_leaderboard_scores_rank (Scope, ScopeKey, Period, PeriodKey, Score),
+    CONSTRAINT fk_leaderboard_scores_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
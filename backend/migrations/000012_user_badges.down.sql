@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS UserActivityDays;
+DROP TABLE IF EXISTS UserBadges;
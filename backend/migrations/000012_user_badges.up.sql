@@ -0,0 +1,18 @@
+-- Achievement badges earned by users. BadgeCode names a rule of the badge engine in service/badge_service.go.
+CREATE TABLE IF NOT EXISTS UserBadges (
+    UserID    INT         NOT NULL,
+    BadgeCode VARCHAR(50) NOT NULL,
+    EarnedAt  TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (UserID, BadgeCode),
+    CONSTRAINT fk_user_badges_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Days (KST) a user did an activity such as posting a story. Stories are deleted when they expire,
+-- so streak badges count from here.
+CREATE TABLE IF NOT EXISTS UserActivityDays (
+    UserID   INT         NOT NULL,
+    Activity VARCHAR(20) NOT NULL,
+    Day      DATE        NOT NULL,
+    PRIMARY KEY (UserID, Activity, Day),
+    CONSTRAINT fk_user_activity_days_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS UserContributionTotals;
+DROP TABLE IF EXISTS ContributionLedger;
@@ -0,0 +1,54 @@
+-- Append-only log of contribution points. An award names the event and the row it was earned for (the marker
+-- or the report); taking points back is a new entry with the negated points that names the entry it revokes.
+-- Reason tells why an entry was written outside the event itself, e.g. MARKER_DELETED, REPORT_REVERSED or RECOMPUTE.
+CREATE TABLE IF NOT EXISTS ContributionLedger (
+    EntryID        BIGINT AUTO_INCREMENT PRIMARY KEY,
+    UserID         INT         NOT NULL,
+    EventType      VARCHAR(50) NOT NULL,
+    ReferenceID    INT         NULL,
+    Points         INT         NOT NULL,
+    Reason         VARCHAR(30) NULL,
+    RevokesEntryID BIGINT      NULL,
+    CreatedAt      TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_contribution_ledger_user (UserID, EntryID),
+    INDEX idx_contribution_ledger_reference (EventType, ReferenceID),
+    UNIQUE KEY uq_contribution_ledger_revokes (RevokesEntryID),
+    CONSTRAINT fk_contribution_ledger_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Sum of each user's ledger, written with every entry and rebuilt by the contributions recompute command.
+CREATE TABLE IF NOT EXISTS UserContributionTotals (
+    UserID    INT       NOT NULL PRIMARY KEY,
+    Points    INT       NOT NULL DEFAULT 0,
+    UpdatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT fk_contribution_totals_user FOREIGN KEY (UserID) REFERENCES Users (UserID) ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+-- Markers and approved reports get the referenced entries contributionRules would award for them (admins earn
+-- nothing), so recomputing finds them in line and deleting a marker from before the ledger revokes its points.
+INSERT INTO ContributionLedger (UserID, EventType, ReferenceID, Points, CreatedAt)
+SELECT m.UserID, 'MARKER_CREATED', m.MarkerID, 10, m.CreatedAt
+FROM Markers m
+JOIN Users u ON m.UserID = u.UserID
+WHERE m.DeletedAt IS NULL AND u.Role <> 'admin'
+ORDER BY m.MarkerID;
+
+INSERT INTO ContributionLedger (UserID, EventType, ReferenceID, Points, CreatedAt)
+SELECT r.UserID, 'REPORT_APPROVED', r.ReportID, 5, r.CreatedAt
+FROM Reports r
+JOIN Users u ON r.UserID = u.UserID
+WHERE r.Status = 'APPROVED' AND u.Role <> 'admin'
+ORDER BY r.ReportID;
+
+-- Other points from the old UserContributions table carry over without a reference, recomputing leaves them
+-- alone. Its marker and report points are replaced by the entries above.
+INSERT INTO ContributionLedger (UserID, EventType, Points, Reason, CreatedAt)
+SELECT UserID, ActivityType, Points, 'LEGACY', CreatedAt
+FROM UserContributions
+WHERE ActivityType NOT IN ('MARKER_CREATED', 'REPORT_APPROVED')
+ORDER BY ContributionID;
+
+INSERT INTO UserContributionTotals (UserID, Points)
+SELECT UserID, SUM(Points)
+FROM ContributionLedger
+GROUP BY UserID;
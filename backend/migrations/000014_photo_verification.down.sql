@@ -0,0 +1,12 @@
+ALTER TABLE ReportPhotos
+    DROP INDEX idx_report_photos_verification_flags,
+    DROP COLUMN TakenAt,
+    DROP COLUMN ExifDistance,
+    DROP COLUMN VerificationScore,
+    DROP COLUMN VerificationFlags;
+ALTER TABLE Photos
+    DROP INDEX idx_photos_verification_flags,
+    DROP COLUMN TakenAt,
+    DROP COLUMN ExifDistance,
+    DROP COLUMN VerificationScore,
+    DROP COLUMN VerificationFlags;
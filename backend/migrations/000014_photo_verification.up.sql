@@ -0,0 +1,16 @@
+-- What the EXIF of an uploaded photo said before it was stripped. Only the distance to the marker is kept,
+-- not the position itself. VerificationScore is 0-100, VerificationFlags a comma separated list (FAR, OLD)
+-- of reasons for a moderator to look at the photo, NULL when there are none.
+ALTER TABLE Photos
+    ADD COLUMN TakenAt           DATETIME         NULL,
+    ADD COLUMN ExifDistance      DOUBLE           NULL,
+    ADD COLUMN VerificationScore TINYINT UNSIGNED NULL,
+    ADD COLUMN VerificationFlags VARCHAR(32)      NULL,
+    ADD INDEX idx_photos_verification_flags (VerificationFlags);
+
+ALTER TABLE ReportPhotos
+    ADD COLUMN TakenAt           DATETIME         NULL,
+    ADD COLUMN ExifDistance      DOUBLE           NULL,
+    ADD COLUMN VerificationScore TINYINT UNSIGNED NULL,
+    ADD COLUMN VerificationFlags VARCHAR(32)      NULL,
+    ADD INDEX idx_report_photos_verification_flags (VerificationFlags);
@@ -0,0 +1,4 @@
+ALTER TABLE ReportPhotos
+    DROP COLUMN PerceptualHash;
+ALTER TABLE Photos
+    DROP COLUMN PerceptualHash;
@@ -0,0 +1,7 @@
+-- dHash of the uploaded image (64 bits), NULL until computed. Near-duplicates differ in a few bits,
+-- so lookups compare BIT_COUNT(PerceptualHash ^ ?) on the candidates the hash bands of 000020 find.
+ALTER TABLE Photos
+    ADD COLUMN PerceptualHash BIGINT UNSIGNED NULL;
+
+ALTER TABLE ReportPhotos
+    ADD COLUMN PerceptualHash BIGINT UNSIGNED NULL;
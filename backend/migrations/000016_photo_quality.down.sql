@@ -0,0 +1,8 @@
+ALTER TABLE ReportPhotos
+    DROP COLUMN QualityScore,
+    DROP COLUMN Sharpness,
+    DROP COLUMN Brightness;
+ALTER TABLE Photos
+    DROP COLUMN QualityScore,
+    DROP COLUMN Sharpness,
+    DROP COLUMN Brightness;
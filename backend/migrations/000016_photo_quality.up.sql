@@ -0,0 +1,11 @@
+-- How good an uploaded photo looks. QualityScore is 0-100, Sharpness the variance of the Laplacian
+-- and Brightness the mean luma (0-255). NULL for photos uploaded before quality checks.
+ALTER TABLE Photos
+    ADD COLUMN QualityScore TINYINT UNSIGNED NULL,
+    ADD COLUMN Sharpness    DOUBLE           NULL,
+    ADD COLUMN Brightness   DOUBLE           NULL;
+
+ALTER TABLE ReportPhotos
+    ADD COLUMN QualityScore TINYINT UNSIGNED NULL,
+    ADD COLUMN Sharpness    DOUBLE           NULL,
+    ADD COLUMN Brightness   DOUBLE           NULL;
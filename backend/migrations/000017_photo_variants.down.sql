@@ -0,0 +1,4 @@
+ALTER TABLE ReportPhotos
+    DROP COLUMN VariantWidths;
+ALTER TABLE Photos
+    DROP COLUMN VariantWidths;
@@ -0,0 +1,7 @@
+-- Comma separated widths (e.g. 320,640,1280) of the resized WebP and JPEG copies stored next to the photo,
+-- NULL for photos uploaded before them or too small for any.
+ALTER TABLE Photos
+    ADD COLUMN VariantWidths VARCHAR(32) NULL;
+
+ALTER TABLE ReportPhotos
+    ADD COLUMN VariantWidths VARCHAR(32) NULL;
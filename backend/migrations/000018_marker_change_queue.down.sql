@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS MarkerChangeSequence;
+DROP TABLE IF EXISTS MarkerChangeQueue;
@@ -0,0 +1,15 @@
+-- Writers queue their changes inside their own transaction and MarkerChangeService numbers them
+-- only after they committed, in a short transaction holding the MarkerChangeSequence row.
+-- A Seq is therefore never visible before every lower Seq is.
+CREATE TABLE IF NOT EXISTS MarkerChangeQueue (
+    QueueID  BIGINT AUTO_INCREMENT PRIMARY KEY,
+    MarkerID INT NOT NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS MarkerChangeSequence (
+    ID      TINYINT PRIMARY KEY,
+    LastSeq BIGINT NOT NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
+
+INSERT IGNORE INTO MarkerChangeSequence (ID, LastSeq)
+SELECT 1, COALESCE(MAX(Seq), 0) FROM MarkerChanges;
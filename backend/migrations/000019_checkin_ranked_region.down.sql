@@ -0,0 +1,3 @@
+ALTER TABLE MarkerCheckIns
+    DROP COLUMN RankedCity,
+    DROP COLUMN RankedProvince;
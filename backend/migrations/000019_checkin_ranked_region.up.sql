@@ -0,0 +1,6 @@
+-- The province and city board keys a ranked check-in scored on, '' when the marker had no address then.
+-- Deleting the check-in takes the point back from exactly these boards, even after the marker's address changed.
+-- NULL for check-ins ranked before this migration, those fall back to the marker's current address.
+ALTER TABLE MarkerCheckIns
+    ADD COLUMN RankedProvince VARCHAR(50)  NULL,
+    ADD COLUMN RankedCity     VARCHAR(100) NULL;
@@ -0,0 +1,9 @@
+ALTER TABLE Photos
+    DROP INDEX idx_photos_hash_band3,
+    DROP INDEX idx_photos_hash_band2,
+    DROP INDEX idx_photos_hash_band1,
+    DROP INDEX idx_photos_hash_band0,
+    DROP COLUMN HashBand3,
+    DROP COLUMN HashBand2,
+    DROP COLUMN HashBand1,
+    DROP COLUMN HashBand0;
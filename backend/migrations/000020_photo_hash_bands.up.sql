@@ -0,0 +1,15 @@
+-- The 16-bit bands of PerceptualHash (see util.HashBands), indexed so a near-duplicate lookup only compares
+-- the photos sharing a band value with the new one instead of every hashed photo.
+ALTER TABLE Photos
+    ADD COLUMN HashBand0 SMALLINT UNSIGNED AS (PerceptualHash >> 48) VIRTUAL,
+    ADD COLUMN HashBand1 SMALLINT UNSIGNED AS ((PerceptualHash >> 32) & 0xFFFF) VIRTUAL,
+    ADD COLUMN HashBand2 SMALLINT UNSIGNED AS ((PerceptualHash >> 16) & 0xFFFF) VIRTUAL,
+    ADD COLUMN HashBand3 SMALLINT UNSIGNED AS (PerceptualHash & 0xFFFF) VIRTUAL,
+    ADD INDEX idx_photos_hash_band0 (HashBand0),
+    ADD INDEX idx_photos_hash_band1 (HashBand1),
+    ADD INDEX idx_photos_hash_band2 (HashBand2),
+    ADD INDEX idx_photos_hash_band3 (HashBand3);
+
+-- Blank images hashed before near-uniform ones were skipped, the hash backfill looks at them again.
+UPDATE Photos SET PerceptualHash = NULL WHERE PerceptualHash = 0;
+UPDATE ReportPhotos SET PerceptualHash = NULL WHERE PerceptualHash = 0;
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL schema files.
+//
+// Files are named <version>_<name>.up.sql / <version>_<name>.down.sql and are applied in version order
+// by service.MigrationService.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// ContributionEntry corresponds to the ContributionLedger table, one change of a user's contribution points.
+type ContributionEntry struct {
+	CreatedAt      time.Time `json:"createdAt" db:"CreatedAt"`
+	ReferenceID    *int      `json:"referenceId,omitempty" db:"ReferenceID"` // the marker or report the points are for
+	Reason         *string   `json:"reason,omitempty" db:"Reason"`
+	RevokesEntryID *int64    `json:"revokesEntryId,omitempty" db:"RevokesEntryID"`
+	EventType      string    `json:"eventType" db:"EventType"`
+	EntryID        int64     `json:"entryId" db:"EntryID"`
+	UserID         int       `json:"userId" db:"UserID"`
+	Points         int       `json:"points" db:"Points"` // negative when points are taken back
+}
@@ -6,3 +6,14 @@ type Facility struct {
 	MarkerID   int `db:"MarkerID" json:"markerId"`
 	Quantity   int `db:"Quantity" json:"quantity"`
 }
+
+// FacilityType is a catalog entry describing what a FacilityID stands for.
+type FacilityType struct {
+	FacilityID int    `db:"FacilityID" json:"facilityId"`
+	Slug       string `db:"Slug" json:"slug"`
+	Category   string `db:"Category" json:"category"`
+	NameKo     string `db:"NameKo" json:"nameKo"`
+	NameEn     string `db:"NameEn" json:"nameEn"`
+	Icon       string `db:"Icon" json:"icon"`
+	SortOrder  int    `db:"SortOrder" json:"sortOrder"`
+}
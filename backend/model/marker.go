@@ -12,6 +12,7 @@ type Marker struct {
 	UserID      *int      `json:"userId" db:"UserID"`
 	Description string    `json:"description" db:"Description"`
 	Address     *string   `json:"address" db:"Address"`
+	Status      string    `json:"status,omitempty" db:"Status"`
 }
 
 // MarkerWithPhoto includes information about the marker and its associated photo.
@@ -22,11 +23,14 @@ type MarkerWithPhoto struct {
 
 type MarkerWithPhotos struct {
 	Marker
-	Photos        []Photo `json:"photos,omitempty"`
-	Username      string  `json:"username,omitempty"`
-	DislikeCount  int     `json:"dislikeCount,omitempty"`
-	FavoriteCount int     `json:"favCount,omitempty"`
-	IsChulbong    bool    `json:"isChulbong,omitempty"`
-	Disliked      bool    `json:"disliked"`
-	Favorited     bool    `json:"favorited,omitempty"`
+	Access        MarkerAccess  `json:"access"`
+	Rating        *MarkerRating `json:"rating,omitempty"` // nil until the marker is reviewed
+	Photos        []Photo       `json:"photos,omitempty"`
+	Username      string        `json:"username,omitempty"`
+	DislikeCount  int           `json:"dislikeCount,omitempty"`
+	FavoriteCount int           `json:"favCount,omitempty"`
+	VisitorCount  int           `json:"visitorCount,omitempty"` // users with a verified check-in
+	IsChulbong    bool          `json:"isChulbong,omitempty"`
+	Disliked      bool          `json:"disliked"`
+	Favorited     bool          `json:"favorited,omitempty"`
 }
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+
+	"github.com/goccy/go-json"
+)
+
+// MarkerAccess tells who can use a marker and when. Nil fields are unknown.
+type MarkerAccess struct {
+	AccessType   *string       `json:"accessType,omitempty" db:"AccessType"` // PUBLIC, PRIVATE or RESIDENTS_ONLY
+	OpeningHours *OpeningHours `json:"openingHours,omitempty" db:"OpeningHours"`
+	Lit          *bool         `json:"lit,omitempty" db:"Lit"` // lit at night
+	Indoor       *bool         `json:"indoor,omitempty" db:"Indoor"`
+}
+
+// OpeningPeriod is one opening of a day as "HH:MM". A Close before Open runs past midnight, "24:00" closes at midnight.
+type OpeningPeriod struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// OpeningHours is a weekly schedule keyed by mon, tue, wed, thu, fri, sat and sun, days left out are closed.
+// It is stored as a JSON column.
+type OpeningHours map[string][]OpeningPeriod
+
+func (h OpeningHours) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *OpeningHours) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return errors.New("unsupported type for OpeningHours")
+	}
+}
+
+// AccessSnapshot is MarkerAccess stored as a single JSON column in a revision.
+// Revisions from before access was tracked have NULL there, which is why they hold a *AccessSnapshot.
+type AccessSnapshot MarkerAccess
+
+func (a AccessSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *AccessSnapshot) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = AccessSnapshot{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return errors.New("unsupported type for AccessSnapshot")
+	}
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// MarkerCheckIn corresponds to the MarkerCheckIns table, a workout of a user at a marker.
+type MarkerCheckIn struct {
+	CheckedInAt    time.Time         `json:"checkedInAt" db:"CheckedInAt"`
+	Latitude       *float64          `json:"latitude,omitempty" db:"Latitude"`
+	Longitude      *float64          `json:"longitude,omitempty" db:"Longitude"`
+	DistanceMeters *float64          `json:"distanceMeters,omitempty" db:"DistanceMeters"` // from the marker, when a position was sent
+	Note           *string           `json:"note,omitempty" db:"Note"`
+	Exercises      []CheckInExercise `json:"exercises"`
+	CheckInID      int               `json:"checkInId" db:"CheckInID"`
+	MarkerID       int               `json:"markerId" db:"MarkerID"`
+	UserID         int               `json:"userId" db:"UserID"`
+	Verified       bool              `json:"verified" db:"Verified"`
+	Ranked         bool              `json:"ranked" db:"Ranked"` // scored on the leaderboards
+}
+
+// CheckInExercise is one exercise of a check-in's workout log.
+type CheckInExercise struct {
+	CheckInID int    `json:"-" db:"CheckInID"`
+	Exercise  string `json:"exercise" db:"Exercise"`
+	Sets      int    `json:"sets" db:"Sets"`
+	Reps      int    `json:"reps" db:"Reps"` // per set
+}
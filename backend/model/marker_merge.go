@@ -0,0 +1,57 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// MarkerMerge corresponds to the MarkerMerges table in the database.
+// It keeps a snapshot of a marker that was merged into SurvivorMarkerID as a duplicate.
+type MarkerMerge struct {
+	CreatedAt        time.Time   `json:"createdAt" db:"CreatedAt"`
+	Moved            MergedCount `json:"moved" db:"Moved"`
+	MergedByUserID   *int        `json:"mergedByUserId,omitempty" db:"MergedByUserID"`
+	OwnerUserID      *int        `json:"ownerUserId,omitempty" db:"OwnerUserID"`
+	Address          *string     `json:"address,omitempty" db:"Address"`
+	Latitude         float64     `json:"latitude" db:"Latitude"`
+	Longitude        float64     `json:"longitude" db:"Longitude"`
+	MergeID          int         `json:"mergeId" db:"MergeID"`
+	SurvivorMarkerID int         `json:"survivorMarkerId" db:"SurvivorMarkerID"`
+	MergedMarkerID   int         `json:"mergedMarkerId" db:"MergedMarkerID"`
+	Description      string      `json:"description" db:"Description"`
+}
+
+// MergedCount is how many rows of each kind were moved onto the survivor, stored as a JSON column.
+type MergedCount struct {
+	Photos     int64 `json:"photos"`
+	Comments   int64 `json:"comments"`
+	Favorites  int64 `json:"favorites"`
+	Dislikes   int64 `json:"dislikes"`
+	Reviews    int64 `json:"reviews"`
+	CheckIns   int64 `json:"checkIns"`
+	Stories    int64 `json:"stories"`
+	Reports    int64 `json:"reports"`
+	Facilities int64 `json:"facilities"`
+	Clicks     int64 `json:"clicks"`
+}
+
+func (m MergedCount) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *MergedCount) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = MergedCount{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return errors.New("unsupported type for MergedCount")
+	}
+}
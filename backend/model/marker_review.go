@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// MarkerReview corresponds to the MarkerReviews table, a user's scores for a marker from 1 to 5.
+// Rating is the overall score, the dimensions are nil when the reviewer left them out.
+type MarkerReview struct {
+	CreatedAt    time.Time `json:"createdAt" db:"CreatedAt"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"UpdatedAt"`
+	Grip         *int      `json:"grip,omitempty" db:"Grip"`
+	Height       *int      `json:"height,omitempty" db:"Height"`
+	Stability    *int      `json:"stability,omitempty" db:"Stability"`
+	Surroundings *int      `json:"surroundings,omitempty" db:"Surroundings"`
+	Comment      *string   `json:"comment,omitempty" db:"Comment"`
+	ReviewID     int       `json:"reviewId" db:"ReviewID"`
+	MarkerID     int       `json:"markerId" db:"MarkerID"`
+	UserID       int       `json:"userId" db:"UserID"`
+	Rating       int       `json:"rating" db:"Rating"`
+}
+
+// MarkerRating corresponds to the MarkerRatings table, the review averages of one marker.
+// A dimension average is nil when no review scored it.
+type MarkerRating struct {
+	AvgGrip         *float64 `json:"grip,omitempty" db:"AvgGrip"`
+	AvgHeight       *float64 `json:"height,omitempty" db:"AvgHeight"`
+	AvgStability    *float64 `json:"stability,omitempty" db:"AvgStability"`
+	AvgSurroundings *float64 `json:"surroundings,omitempty" db:"AvgSurroundings"`
+	AvgRating       float64  `json:"average" db:"AvgRating"`
+	ReviewCount     int      `json:"reviewCount" db:"ReviewCount"`
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// MarkerRevision corresponds to the MarkerRevisions table in the database.
+// Each row is a full snapshot of the marker right after a change.
+type MarkerRevision struct {
+	CreatedAt   time.Time        `json:"createdAt" db:"CreatedAt"`
+	Facilities  FacilitySnapshot `json:"facilities" db:"Facilities"`
+	Access      *AccessSnapshot  `json:"access" db:"Access"` // nil for revisions written before access was tracked
+	ActorUserID *int             `json:"actorUserId,omitempty" db:"ActorUserID"`
+	ReportID    *int             `json:"reportId,omitempty" db:"ReportID"`
+	Address     *string          `json:"address,omitempty" db:"Address"`
+	Latitude    float64          `json:"latitude" db:"Latitude"`
+	Longitude   float64          `json:"longitude" db:"Longitude"`
+	RevisionID  int              `json:"revisionId" db:"RevisionID"`
+	MarkerID    int              `json:"markerId" db:"MarkerID"`
+	Source      string           `json:"source" db:"Source"`
+	Description string           `json:"description" db:"Description"`
+}
+
+// FacilityCount is a facility and its quantity as stored in a revision snapshot.
+type FacilityCount struct {
+	FacilityID int `json:"facilityId" db:"FacilityID"`
+	Quantity   int `json:"quantity" db:"Quantity"`
+}
+
+// FacilitySnapshot is stored as a JSON column.
+type FacilitySnapshot []FacilityCount
+
+func (f FacilitySnapshot) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	return json.Marshal(f)
+}
+
+func (f *FacilitySnapshot) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*f = FacilitySnapshot{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return errors.New("unsupported type for FacilitySnapshot")
+	}
+}
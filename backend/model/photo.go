@@ -11,4 +11,16 @@ type Photo struct {
 	MarkerID     int       `json:"markerId" db:"MarkerID"`
 	PhotoURL     string    `json:"photoUrl" db:"PhotoURL"`
 	ThumbnailURL *string   `json:"thumbnailUrl,omitempty" db:"ThumbnailURL"`
+	QualityScore *int      `json:"qualityScore,omitempty" db:"QualityScore"` // 0-100, nil for photos from before quality checks
+
+	// Resized copies for srcset, none for photos from before them
+	Variants      []PhotoVariant `json:"variants,omitempty" db:"-"`
+	VariantWidths *string        `json:"-" db:"VariantWidths"`
+}
+
+// PhotoVariant is a resized copy of a photo.
+type PhotoVariant struct {
+	URL   string `json:"url"`
+	Type  string `json:"type"` // image/webp or image/jpeg
+	Width int    `json:"width"`
 }
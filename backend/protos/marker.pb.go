@@ -1,6 +1,6 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.28.1
+// 	protoc-gen-go v1.36.8
 // 	protoc        v5.26.1
 // source: protos/marker.proto
 
@@ -11,6 +11,7 @@ import (
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -21,22 +22,24 @@ const (
 )
 
 type Marker struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MarkerId      int32                  `protobuf:"varint,1,opt,name=markerId,proto3" json:"markerId,omitempty"`
+	Latitude      float64                `protobuf:"fixed64,2,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,3,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	Address       string                 `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	HasPhoto      bool                   `protobuf:"varint,5,opt,name=hasPhoto,proto3" json:"hasPhoto,omitempty"`
+	Thumbnail     string                 `protobuf:"bytes,6,opt,name=thumbnail,proto3" json:"thumbnail,omitempty"` // latest photo's thumbnail, empty without photos
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`       // ACTIVE, DAMAGED, UNDER_CONSTRUCTION or REMOVED
+	Facilities    []*Facility            `protobuf:"bytes,8,rep,name=facilities,proto3" json:"facilities,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	MarkerId  int32   `protobuf:"varint,1,opt,name=markerId,proto3" json:"markerId,omitempty" db:"MarkerID"`
-	Latitude  float64 `protobuf:"fixed64,2,opt,name=latitude,proto3" json:"latitude,omitempty" db:"Latitude"`
-	Longitude float64 `protobuf:"fixed64,3,opt,name=longitude,proto3" json:"longitude,omitempty" db:"Longitude"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Marker) Reset() {
 	*x = Marker{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_protos_marker_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_protos_marker_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *Marker) String() string {
@@ -47,7 +50,7 @@ func (*Marker) ProtoMessage() {}
 
 func (x *Marker) ProtoReflect() protoreflect.Message {
 	mi := &file_protos_marker_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -83,21 +86,105 @@ func (x *Marker) GetLongitude() float64 {
 	return 0
 }
 
-type MarkerList struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+func (x *Marker) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Marker) GetHasPhoto() bool {
+	if x != nil {
+		return x.HasPhoto
+	}
+	return false
+}
+
+func (x *Marker) GetThumbnail() string {
+	if x != nil {
+		return x.Thumbnail
+	}
+	return ""
+}
+
+func (x *Marker) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Marker) GetFacilities() []*Facility {
+	if x != nil {
+		return x.Facilities
+	}
+	return nil
+}
+
+type Facility struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FacilityId    int32                  `protobuf:"varint,1,opt,name=facilityId,proto3" json:"facilityId,omitempty"` // 1: 철봉, 2: 평행봉
+	Quantity      int32                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
 	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-	Markers []*Marker `protobuf:"bytes,1,rep,name=markers,proto3" json:"markers,omitempty"`
+func (x *Facility) Reset() {
+	*x = Facility{}
+	mi := &file_protos_marker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *MarkerList) Reset() {
-	*x = MarkerList{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_protos_marker_proto_msgTypes[1]
+func (x *Facility) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Facility) ProtoMessage() {}
+
+func (x *Facility) ProtoReflect() protoreflect.Message {
+	mi := &file_protos_marker_proto_msgTypes[1]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Facility.ProtoReflect.Descriptor instead.
+func (*Facility) Descriptor() ([]byte, []int) {
+	return file_protos_marker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Facility) GetFacilityId() int32 {
+	if x != nil {
+		return x.FacilityId
+	}
+	return 0
+}
+
+func (x *Facility) GetQuantity() int32 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type MarkerList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Markers       []*Marker              `protobuf:"bytes,1,rep,name=markers,proto3" json:"markers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkerList) Reset() {
+	*x = MarkerList{}
+	mi := &file_protos_marker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *MarkerList) String() string {
@@ -107,8 +194,8 @@ func (x *MarkerList) String() string {
 func (*MarkerList) ProtoMessage() {}
 
 func (x *MarkerList) ProtoReflect() protoreflect.Message {
-	mi := &file_protos_marker_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+	mi := &file_protos_marker_proto_msgTypes[2]
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -120,7 +207,7 @@ func (x *MarkerList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MarkerList.ProtoReflect.Descriptor instead.
 func (*MarkerList) Descriptor() ([]byte, []int) {
-	return file_protos_marker_proto_rawDescGZIP(), []int{1}
+	return file_protos_marker_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *MarkerList) GetMarkers() []*Marker {
@@ -132,46 +219,55 @@ func (x *MarkerList) GetMarkers() []*Marker {
 
 var File_protos_marker_proto protoreflect.FileDescriptor
 
-var file_protos_marker_proto_rawDesc = []byte{
-	0x0a, 0x13, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x72, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x5e, 0x0a, 0x06, 0x4d, 0x61, 0x72, 0x6b, 0x65, 0x72, 0x12,
-	0x1a, 0x0a, 0x08, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x05, 0x52, 0x08, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c,
-	0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x6c,
-	0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69,
-	0x74, 0x75, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x6c, 0x6f, 0x6e, 0x67,
-	0x69, 0x74, 0x75, 0x64, 0x65, 0x22, 0x2f, 0x0a, 0x0a, 0x4d, 0x61, 0x72, 0x6b, 0x65, 0x72, 0x4c,
-	0x69, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x07, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x07, 0x2e, 0x4d, 0x61, 0x72, 0x6b, 0x65, 0x72, 0x52, 0x07, 0x6d,
-	0x61, 0x72, 0x6b, 0x65, 0x72, 0x73, 0x42, 0x1b, 0x5a, 0x19, 0x63, 0x68, 0x75, 0x6c, 0x62, 0x6f,
-	0x6e, 0x67, 0x2d, 0x6b, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x3b, 0x6d, 0x61, 0x72,
-	0x6b, 0x65, 0x72, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
-}
+const file_protos_marker_proto_rawDesc = "" +
+	"\n" +
+	"\x13protos/marker.proto\"\xf5\x01\n" +
+	"\x06Marker\x12\x1a\n" +
+	"\bmarkerId\x18\x01 \x01(\x05R\bmarkerId\x12\x1a\n" +
+	"\blatitude\x18\x02 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x03 \x01(\x01R\tlongitude\x12\x18\n" +
+	"\aaddress\x18\x04 \x01(\tR\aaddress\x12\x1a\n" +
+	"\bhasPhoto\x18\x05 \x01(\bR\bhasPhoto\x12\x1c\n" +
+	"\tthumbnail\x18\x06 \x01(\tR\tthumbnail\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\x12)\n" +
+	"\n" +
+	"facilities\x18\b \x03(\v2\t.FacilityR\n" +
+	"facilities\"F\n" +
+	"\bFacility\x12\x1e\n" +
+	"\n" +
+	"facilityId\x18\x01 \x01(\x05R\n" +
+	"facilityId\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x05R\bquantity\"/\n" +
+	"\n" +
+	"MarkerList\x12!\n" +
+	"\amarkers\x18\x01 \x03(\v2\a.MarkerR\amarkersB0Z.github.com/Alfex4936/chulbong-kr/protos;protosb\x06proto3"
 
 var (
 	file_protos_marker_proto_rawDescOnce sync.Once
-	file_protos_marker_proto_rawDescData = file_protos_marker_proto_rawDesc
+	file_protos_marker_proto_rawDescData []byte
 )
 
 func file_protos_marker_proto_rawDescGZIP() []byte {
 	file_protos_marker_proto_rawDescOnce.Do(func() {
-		file_protos_marker_proto_rawDescData = protoimpl.X.CompressGZIP(file_protos_marker_proto_rawDescData)
+		file_protos_marker_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_protos_marker_proto_rawDesc), len(file_protos_marker_proto_rawDesc)))
 	})
 	return file_protos_marker_proto_rawDescData
 }
 
-var file_protos_marker_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
-var file_protos_marker_proto_goTypes = []interface{}{
+var file_protos_marker_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_protos_marker_proto_goTypes = []any{
 	(*Marker)(nil),     // 0: Marker
-	(*MarkerList)(nil), // 1: MarkerList
+	(*Facility)(nil),   // 1: Facility
+	(*MarkerList)(nil), // 2: MarkerList
 }
 var file_protos_marker_proto_depIdxs = []int32{
-	0, // 0: MarkerList.markers:type_name -> Marker
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1, // 0: Marker.facilities:type_name -> Facility
+	0, // 1: MarkerList.markers:type_name -> Marker
+	2, // [2:2] is the sub-list for method output_type
+	2, // [2:2] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_protos_marker_proto_init() }
@@ -179,39 +275,13 @@ func file_protos_marker_proto_init() {
 	if File_protos_marker_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_protos_marker_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Marker); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_protos_marker_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MarkerList); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_protos_marker_proto_rawDesc,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_protos_marker_proto_rawDesc), len(file_protos_marker_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -220,7 +290,6 @@ func file_protos_marker_proto_init() {
 		MessageInfos:      file_protos_marker_proto_msgTypes,
 	}.Build()
 	File_protos_marker_proto = out.File
-	file_protos_marker_proto_rawDesc = nil
 	file_protos_marker_proto_goTypes = nil
 	file_protos_marker_proto_depIdxs = nil
 }
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/dto/notification"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+
+	sonic "github.com/bytedance/sonic"
+)
+
+// Domain events badge rules are evaluated on
+const (
+	EventMarkerCreated  = "MARKER_CREATED"
+	EventReportApproved = "REPORT_APPROVED"
+	EventCommentPosted  = "COMMENT_POSTED"
+	EventStoryPosted    = "STORY_POSTED"
+	EventCheckedIn      = "CHECKED_IN"
+)
+
+const (
+	countUserMarkersQuery         = "SELECT COUNT(*) FROM Markers WHERE UserID = ? AND DeletedAt IS NULL"
+	countUserApprovedReportsQuery = "SELECT COUNT(*) FROM Reports WHERE UserID = ? AND Status = 'APPROVED'"
+	countUserCommentsQuery        = "SELECT COUNT(*) FROM Comments WHERE UserID = ? AND DeletedAt IS NULL"
+	countUserVerifiedVisitsQuery  = "SELECT COUNT(*) FROM MarkerCheckIns WHERE UserID = ? AND Verified = TRUE"
+	selectVisitedProvincesQuery   = `
+SELECT DISTINCT SUBSTRING_INDEX(m.Address, ' ', 1)
+FROM MarkerCheckIns c
+JOIN Markers m ON c.MarkerID = m.MarkerID
+WHERE c.UserID = ? AND c.Verified = TRUE AND m.Address IS NOT NULL AND m.Address <> ''`
+
+	insertActivityDayQuery  = "INSERT IGNORE INTO UserActivityDays (UserID, Activity, Day) VALUES (?, ?, ?)"
+	selectActivityDaysQuery = "SELECT Day FROM UserActivityDays WHERE UserID = ? AND Activity = ?"
+
+	selectUserBadgesQuery     = "SELECT BadgeCode, EarnedAt FROM UserBadges WHERE UserID = ? ORDER BY EarnedAt, BadgeCode"
+	selectUserBadgeCodesQuery = "SELECT BadgeCode FROM UserBadges WHERE UserID = ?"
+	insertUserBadgeQuery      = "INSERT IGNORE INTO UserBadges (UserID, BadgeCode) VALUES (?, ?)"
+)
+
+// badgeRule declares a badge: it is earned once progress reaches Goal, checked whenever one of Events happens.
+type badgeRule struct {
+	Code        string
+	Name        string
+	Description string
+	Events      []string
+	Goal        int
+	progress    func(s *BadgeService, userID int) (int, error)
+}
+
+// badgeRules are all badges in the order they are shown. Codes are stored in UserBadges, never rename one.
+var badgeRules = []badgeRule{
+	{
+		Code: "FIRST_MARKER", Name: "첫 철봉", Description: "처음으로 철봉 위치를 등록했어요.",
+		Events: []string{EventMarkerCreated}, Goal: 1, progress: countProgress(countUserMarkersQuery),
+	},
+	{
+		Code: "MARKERS_10", Name: "철봉 개척자", Description: "철봉 위치를 10개 등록했어요.",
+		Events: []string{EventMarkerCreated}, Goal: 10, progress: countProgress(countUserMarkersQuery),
+	},
+	{
+		Code: "FIRST_REPORT", Name: "첫 제보", Description: "처음으로 정보 수정 제안이 승인됐어요.",
+		Events: []string{EventReportApproved}, Goal: 1, progress: countProgress(countUserApprovedReportsQuery),
+	},
+	{
+		Code: "REPORTS_10", Name: "꼼꼼한 제보자", Description: "정보 수정 제안이 10번 승인됐어요.",
+		Events: []string{EventReportApproved}, Goal: 10, progress: countProgress(countUserApprovedReportsQuery),
+	},
+	{
+		Code: "FIRST_COMMENT", Name: "첫 댓글", Description: "처음으로 댓글을 남겼어요.",
+		Events: []string{EventCommentPosted}, Goal: 1, progress: countProgress(countUserCommentsQuery),
+	},
+	{
+		Code: "COMMENTS_50", Name: "수다쟁이", Description: "댓글을 50개 남겼어요.",
+		Events: []string{EventCommentPosted}, Goal: 50, progress: countProgress(countUserCommentsQuery),
+	},
+	{
+		Code: "FIRST_CHECK_IN", Name: "첫 출석", Description: "처음으로 철봉에서 인증된 체크인을 했어요.",
+		Events: []string{EventCheckedIn}, Goal: 1, progress: countProgress(countUserVerifiedVisitsQuery),
+	},
+	{
+		Code: "PROVINCES_5", Name: "전국 유랑", Description: "5개 시·도의 철봉에서 인증된 체크인을 했어요.",
+		Events: []string{EventCheckedIn}, Goal: 5, progress: (*BadgeService).countVisitedProvinces,
+	},
+	{
+		Code: "STORY_STREAK_30", Name: "한 달 개근", Description: "30일 연속으로 스토리를 올렸어요.",
+		Events: []string{EventStoryPosted}, Goal: 30, progress: streakProgress(EventStoryPosted),
+	},
+}
+
+// BadgeService awards achievement badges, on top of contribution levels, as users act on the map.
+type BadgeService struct {
+	DB                  *sqlx.DB
+	NotificationService *NotificationService
+	CacheService        *MarkerCacheService
+	Logger              *zap.Logger
+}
+
+func NewBadgeService(db *sqlx.DB, notificationService *NotificationService, cacheService *MarkerCacheService, logger *zap.Logger) *BadgeService {
+	return &BadgeService{
+		DB:                  db,
+		NotificationService: notificationService,
+		CacheService:        cacheService,
+		Logger:              logger,
+	}
+}
+
+// Publish evaluates the badges of event for the user in the background, after the caller has committed the change.
+func (s *BadgeService) Publish(event string, userID int) {
+	if userID <= 0 {
+		return
+	}
+	go func() {
+		if err := s.HandleEvent(event, userID); err != nil {
+			s.Logger.Error("Failed to evaluate badges", zap.String("event", event), zap.Int("userID", userID), zap.Error(err))
+		}
+	}()
+}
+
+// HandleEvent records the day of event and awards the user every badge of event whose goal is now reached.
+func (s *BadgeService) HandleEvent(event string, userID int) error {
+	if _, err := s.DB.Exec(insertActivityDayQuery, userID, event, activityDay(time.Now())); err != nil {
+		return fmt.Errorf("recording activity day: %w", err)
+	}
+
+	earned, err := s.earnedBadgeCodes(userID)
+	if err != nil {
+		return err
+	}
+
+	awarded := false
+	for _, rule := range pendingBadgeRules(event, earned) {
+		progress, err := rule.progress(s, userID)
+		if err != nil {
+			return fmt.Errorf("evaluating badge %s: %w", rule.Code, err)
+		}
+		if !rule.reached(progress) {
+			continue
+		}
+
+		res, err := s.DB.Exec(insertUserBadgeQuery, userID, rule.Code)
+		if err != nil {
+			return fmt.Errorf("awarding badge %s: %w", rule.Code, err)
+		}
+		// Another event may have awarded it in the meantime
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		awarded = true
+		s.notify(userID, rule)
+	}
+
+	if awarded {
+		s.CacheService.ResetUserProfileCache(userID)
+	}
+	return nil
+}
+
+// GetUserBadges lists every badge with the user's progress, earned ones first in the order they were earned.
+func (s *BadgeService) GetUserBadges(userID int) ([]dto.Badge, error) {
+	badges, err := s.GetEarnedBadges(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	earned := make(map[string]bool, len(badges))
+	for _, badge := range badges {
+		earned[badge.Code] = true
+	}
+	for _, rule := range badgeRules {
+		if earned[rule.Code] {
+			continue
+		}
+		progress, err := rule.progress(s, userID)
+		if err != nil {
+			return nil, fmt.Errorf("evaluating badge %s: %w", rule.Code, err)
+		}
+		badge := rule.badge()
+		badge.Progress = min(progress, rule.Goal)
+		badges = append(badges, badge)
+	}
+	return badges, nil
+}
+
+// GetEarnedBadges lists the badges the user has earned in the order they were earned, for the profile.
+func (s *BadgeService) GetEarnedBadges(userID int) ([]dto.Badge, error) {
+	var rows []userBadgeRow
+	if err := s.DB.Select(&rows, selectUserBadgesQuery, userID); err != nil {
+		return nil, fmt.Errorf("fetching user badges: %w", err)
+	}
+	return earnedBadges(rows), nil
+}
+
+type userBadgeRow struct {
+	EarnedAt  time.Time `db:"EarnedAt"`
+	BadgeCode string    `db:"BadgeCode"`
+}
+
+// earnedBadges turns UserBadges rows into badges, skipping codes that are no longer declared.
+func earnedBadges(rows []userBadgeRow) []dto.Badge {
+	badges := make([]dto.Badge, 0, len(rows))
+	for _, row := range rows {
+		rule, ok := findBadgeRule(row.BadgeCode)
+		if !ok {
+			continue // a retired badge
+		}
+		badge := rule.badge()
+		badge.Earned = true
+		badge.EarnedAt = &row.EarnedAt
+		badge.Progress = rule.Goal
+		badges = append(badges, badge)
+	}
+	return badges
+}
+
+// pendingBadgeRules are the rules event can still award, those the user has not earned yet.
+func pendingBadgeRules(event string, earned map[string]bool) []badgeRule {
+	var rules []badgeRule
+	for _, rule := range badgeRules {
+		if !earned[rule.Code] && rule.handles(event) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (s *BadgeService) earnedBadgeCodes(userID int) (map[string]bool, error) {
+	var codes []string
+	if err := s.DB.Select(&codes, selectUserBadgeCodesQuery, userID); err != nil {
+		return nil, fmt.Errorf("fetching user badges: %w", err)
+	}
+
+	earned := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		earned[code] = true
+	}
+	return earned, nil
+}
+
+func (s *BadgeService) notify(userID int, rule badgeRule) {
+	metadata, _ := sonic.Marshal(notification.NotificationBadgeMetadata{
+		BadgeCode: rule.Code,
+		UserId:    userID,
+	})
+	message := fmt.Sprintf("'%s' 배지를 획득했습니다! %s", rule.Name, rule.Description)
+	if err := s.NotificationService.PostNotification(strconv.Itoa(userID), NotificationTypeBadge, "sys", message, metadata); err != nil {
+		s.Logger.Error("Failed to post badge notification", zap.String("badge", rule.Code), zap.Int("userID", userID), zap.Error(err))
+	}
+}
+
+// countVisitedProvinces counts the provinces the user has verified check-ins in, however their addresses spell them.
+func (s *BadgeService) countVisitedProvinces(userID int) (int, error) {
+	var names []string
+	if err := s.DB.Select(&names, selectVisitedProvincesQuery, userID); err != nil {
+		return 0, fmt.Errorf("fetching visited provinces: %w", err)
+	}
+
+	provinces := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			provinces[standardizeProvinceForDB(name)] = struct{}{}
+		}
+	}
+	return len(provinces), nil
+}
+
+func (r badgeRule) handles(event string) bool {
+	for _, e := range r.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (r badgeRule) reached(progress int) bool {
+	return progress >= r.Goal
+}
+
+func (r badgeRule) badge() dto.Badge {
+	return dto.Badge{
+		Code:        r.Code,
+		Name:        r.Name,
+		Description: r.Description,
+		Goal:        r.Goal,
+	}
+}
+
+// countProgress measures a badge by a COUNT query over the user's rows.
+func countProgress(query string) func(s *BadgeService, userID int) (int, error) {
+	return func(s *BadgeService, userID int) (int, error) {
+		var count int
+		if err := s.DB.Get(&count, query, userID); err != nil {
+			return 0, err
+		}
+		return count, nil
+	}
+}
+
+// streakProgress measures a badge by the user's longest run of consecutive days with activity.
+func streakProgress(activity string) func(s *BadgeService, userID int) (int, error) {
+	return func(s *BadgeService, userID int) (int, error) {
+		var days []time.Time
+		if err := s.DB.Select(&days, selectActivityDaysQuery, userID, activity); err != nil {
+			return 0, err
+		}
+		return longestActivityStreak(days, time.Now()), nil
+	}
+}
+
+// activityDay is the KST date an activity at t is recorded under.
+func activityDay(t time.Time) string {
+	return t.In(util.KST).Format("2006-01-02")
+}
+
+// longestActivityStreak is the longest run of consecutive recorded days.
+// Days are stored as KST dates, they come back as midnights in UTC and must be counted there.
+func longestActivityStreak(days []time.Time, now time.Time) int {
+	return util.CalculateStreak(days, now, time.UTC).Longest
+}
+
+func findBadgeRule(code string) (badgeRule, bool) {
+	for _, rule := range badgeRules {
+		if rule.Code == code {
+			return rule, true
+		}
+	}
+	return badgeRule{}, false
+}
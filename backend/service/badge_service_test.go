@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ruleCodes(rules []badgeRule) []string {
+	codes := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		codes = append(codes, rule.Code)
+	}
+	return codes
+}
+
+func TestBadgeRuleHandles(t *testing.T) {
+	rule, ok := findBadgeRule("FIRST_MARKER")
+	require.True(t, ok)
+	assert.True(t, rule.handles(EventMarkerCreated))
+	assert.False(t, rule.handles(EventCommentPosted))
+	assert.False(t, rule.handles(""))
+
+	_, ok = findBadgeRule("NO_SUCH_BADGE")
+	assert.False(t, ok)
+}
+
+func TestBadgeRuleReached(t *testing.T) {
+	rule, ok := findBadgeRule("MARKERS_10")
+	require.True(t, ok)
+	assert.False(t, rule.reached(0))
+	assert.False(t, rule.reached(9))
+	assert.True(t, rule.reached(10))
+	assert.True(t, rule.reached(11))
+}
+
+func TestPendingBadgeRules(t *testing.T) {
+	assert.Equal(t, []string{"FIRST_MARKER", "MARKERS_10"}, ruleCodes(pendingBadgeRules(EventMarkerCreated, nil)))
+	assert.Equal(t, []string{"FIRST_CHECK_IN", "PROVINCES_5"}, ruleCodes(pendingBadgeRules(EventCheckedIn, nil)))
+
+	// Earned badges are not evaluated again
+	earned := map[string]bool{"FIRST_MARKER": true}
+	assert.Equal(t, []string{"MARKERS_10"}, ruleCodes(pendingBadgeRules(EventMarkerCreated, earned)))
+	earned["MARKERS_10"] = true
+	assert.Empty(t, pendingBadgeRules(EventMarkerCreated, earned))
+
+	assert.Empty(t, pendingBadgeRules("UNKNOWN_EVENT", nil))
+}
+
+func TestEarnedBadges(t *testing.T) {
+	first := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+
+	badges := earnedBadges([]userBadgeRow{
+		{EarnedAt: first, BadgeCode: "FIRST_COMMENT"},
+		{EarnedAt: first, BadgeCode: "RETIRED_BADGE"},
+		{EarnedAt: second, BadgeCode: "MARKERS_10"},
+	})
+	require.Len(t, badges, 2)
+
+	assert.Equal(t, "FIRST_COMMENT", badges[0].Code)
+	assert.True(t, badges[0].Earned)
+	assert.Equal(t, first, *badges[0].EarnedAt)
+	assert.Equal(t, 1, badges[0].Progress)
+
+	assert.Equal(t, "MARKERS_10", badges[1].Code)
+	assert.Equal(t, second, *badges[1].EarnedAt)
+	assert.Equal(t, 10, badges[1].Goal)
+	assert.Equal(t, 10, badges[1].Progress)
+
+	assert.Empty(t, earnedBadges(nil))
+}
+
+func TestActivityDay(t *testing.T) {
+	// 15:30 UTC on the 9th is already the 10th in KST
+	assert.Equal(t, "2024-03-10", activityDay(time.Date(2024, time.March, 9, 15, 30, 0, 0, time.UTC)))
+	assert.Equal(t, "2024-03-09", activityDay(time.Date(2024, time.March, 9, 14, 59, 0, 0, time.UTC)))
+}
+
+func TestLongestActivityStreak(t *testing.T) {
+	// Stored KST dates as the driver returns them
+	day := func(d int) time.Time {
+		return time.Date(2024, time.March, d, 0, 0, 0, 0, time.UTC)
+	}
+	// 00:30 KST on the 11th, still the 10th in UTC
+	now := time.Date(2024, time.March, 10, 15, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, 0, longestActivityStreak(nil, now))
+
+	// Today's KST date is counted although it is ahead of the UTC date
+	assert.Equal(t, 3, longestActivityStreak([]time.Time{day(9), day(10), day(11)}, now))
+
+	// The dates are not shifted into KST, which would merge or split days
+	assert.Equal(t, 2, longestActivityStreak([]time.Time{day(1), day(2), day(4)}, now))
+
+	// Activity recorded late at night UTC lands on the next KST date and continues the run
+	recorded, err := time.Parse("2006-01-02", activityDay(time.Date(2024, time.March, 2, 16, 0, 0, 0, time.UTC)))
+	require.NoError(t, err)
+	assert.Equal(t, 3, longestActivityStreak([]time.Time{day(1), day(2), recorded}, now))
+
+	// Across a month boundary
+	assert.Equal(t, 2, longestActivityStreak([]time.Time{day(0), day(1)}, now))
+}
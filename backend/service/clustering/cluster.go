@@ -1,15 +1,22 @@
-package main
+// Package clustering groups markers with a grid-accelerated DBSCAN over Haversine distances.
+package clustering
 
 import (
-	"fmt"
 	"math"
+	"sort"
+	"sync/atomic"
 )
 
+const metersPerDegree = 111000.0
+
 type Point struct {
+	ID        int
 	Latitude  float64
 	Longitude float64
 	ClusterID int // 0: unvisited, -1: noise, >0: cluster ID
 	Address   string
+
+	neighbors int // size of the eps-neighborhood including the point itself
 }
 
 type Grid struct {
@@ -24,9 +31,12 @@ func NewGrid(cellSize float64) *Grid {
 	}
 }
 
+func (g *Grid) cell(p *Point) (int, int) {
+	return int(math.Floor(p.Longitude / g.CellSize)), int(math.Floor(p.Latitude / g.CellSize))
+}
+
 func (g *Grid) Insert(p *Point) {
-	xIdx := int(p.Longitude / g.CellSize)
-	yIdx := int(p.Latitude / g.CellSize)
+	xIdx, yIdx := g.cell(p)
 
 	if _, ok := g.Cells[xIdx]; !ok {
 		g.Cells[xIdx] = make(map[int][]*Point)
@@ -34,17 +44,43 @@ func (g *Grid) Insert(p *Point) {
 	g.Cells[xIdx][yIdx] = append(g.Cells[xIdx][yIdx], p)
 }
 
+func (g *Grid) Remove(p *Point) {
+	xIdx, yIdx := g.cell(p)
+
+	cell := g.Cells[xIdx][yIdx]
+	for i, cp := range cell {
+		if cp == p {
+			cell[i] = cell[len(cell)-1]
+			cell = cell[:len(cell)-1]
+			break
+		}
+	}
+
+	if len(cell) == 0 {
+		delete(g.Cells[xIdx], yIdx)
+		if len(g.Cells[xIdx]) == 0 {
+			delete(g.Cells, xIdx)
+		}
+		return
+	}
+	g.Cells[xIdx][yIdx] = cell
+}
+
 func (g *Grid) GetNeighbors(p *Point, eps float64) []*Point {
-	epsDeg := eps / 111000.0 // Convert meters to degrees
-	cellRadius := int(math.Ceil(epsDeg / g.CellSize))
+	epsDeg := eps / metersPerDegree // Convert meters to degrees
+	cellRadiusY := int(math.Ceil(epsDeg / g.CellSize))
+	// A degree of longitude shrinks with the latitude, so more columns are needed for the same distance
+	cellRadiusX := cellRadiusY
+	if cosLat := math.Cos(p.Latitude * math.Pi / 180); cosLat > 0.01 {
+		cellRadiusX = int(math.Ceil(epsDeg / cosLat / g.CellSize))
+	}
 
-	xIdx := int(p.Longitude / g.CellSize)
-	yIdx := int(p.Latitude / g.CellSize)
+	xIdx, yIdx := g.cell(p)
 
 	neighbors := []*Point{}
 
-	for dx := -cellRadius; dx <= cellRadius; dx++ {
-		for dy := -cellRadius; dy <= cellRadius; dy++ {
+	for dx := -cellRadiusX; dx <= cellRadiusX; dx++ {
+		for dy := -cellRadiusY; dy <= cellRadiusY; dy++ {
 			nx := xIdx + dx
 			ny := yIdx + dy
 
@@ -93,6 +129,7 @@ func expandCluster(grid *Grid, p *Point, neighbors []*Point, clusterID int, eps
 		if current.ClusterID == 0 { // Unvisited
 			current.ClusterID = clusterID
 			nNeighbors := grid.GetNeighbors(current, eps)
+			current.neighbors = len(nNeighbors)
 			if len(nNeighbors) >= minPts {
 				queue = append(queue, nNeighbors...)
 			}
@@ -104,52 +141,399 @@ func expandCluster(grid *Grid, p *Point, neighbors []*Point, clusterID int, eps
 
 // DBSCAN performs DBSCAN clustering on the points
 func DBSCAN(points []*Point, eps float64, minPts int) {
-	clusterID := 0
-	epsDeg := eps / 111000.0 // Convert meters to degrees
-	cellSize := epsDeg       // Use epsDeg as cell size
-
-	grid := NewGrid(cellSize)
+	grid := NewGrid(eps / metersPerDegree) // Use epsDeg as cell size
 
 	for _, p := range points {
 		grid.Insert(p)
 	}
 
+	clusterID := 0
+	scan(grid, points, eps, minPts, &clusterID)
+}
+
+// scan labels every unvisited point in points, new clusters continue numbering after *clusterID.
+func scan(grid *Grid, points []*Point, eps float64, minPts int, clusterID *int) {
 	for _, p := range points {
 		if p.ClusterID != 0 {
 			continue
 		}
 		neighbors := grid.GetNeighbors(p, eps)
+		p.neighbors = len(neighbors)
 		if len(neighbors) < minPts {
 			p.ClusterID = -1 // Mark as noise
 		} else {
-			clusterID++
-			expandCluster(grid, p, neighbors, clusterID, eps, minPts)
+			*clusterID++
+			expandCluster(grid, p, neighbors, *clusterID, eps, minPts)
 		}
 	}
 }
 
-func main() {
-	points := []*Point{
-		{Latitude: 37.55808862059195, Longitude: 126.95976545165765, Address: "서울 서대문구 북아현동 884"},
-		{Latitude: 37.568166, Longitude: 126.974102, Address: "서울 중구 정동 1-76"},
-		{Latitude: 37.568661, Longitude: 126.972375, Address: "서울 종로구 신문로2가 171"},
-		{Latitude: 37.56885, Longitude: 126.972064, Address: "서울 종로구 신문로2가 171"},
-		{Latitude: 37.56589411615361, Longitude: 126.96930309974685, Address: "서울 중구 순화동 1-1"},
-		{Latitude: 37.57838984677184, Longitude: 126.98853202207196, Address: "서울 종로구 원서동 181"},
-		{Latitude: 37.57318309415514, Longitude: 126.95501424473001, Address: "서울 서대문구 현저동 101"},
-		{Latitude: 37.5541479820707, Longitude: 126.98370331932351, Address: "서울 중구 회현동1가 산 1-2"},
-		{Latitude: 37.58411863798303, Longitude: 126.97246285644356, Address: "서울 종로구 궁정동 17-3"},
-		{Latitude: 36.33937565888829, Longitude: 127.41575408006757, Address: "대전 중구 선화동 223"},
-		{Latitude: 36.346176003613984, Longitude: 127.41482385609581, Address: "대전 대덕구 오정동 496-1"},
-	}
+// Cluster summarizes one group of points. Noise points are reported as clusters of one.
+type Cluster struct {
+	Latitude  float64 // centroid
+	Longitude float64
+	MinLat    float64
+	MinLng    float64
+	MaxLat    float64
+	MaxLng    float64
+	Count     int
+	PointID   int // the point's ID when Count is 1
+}
 
-	eps := 5000.0 // Epsilon in meters
-	minPts := 2   // Minimum number of points to form a dense region
+// Index keeps DBSCAN labels up to date while points come and go. Every point remembers the size
+// of its neighborhood, so a change only looks at the points within eps of it: an insert joins or
+// merges the clusters around it, a removal searches the cluster from the points next to it until
+// the searches meet again. Only a cluster that really splits is walked, and only its smaller part.
+// Insert and Remove must not run concurrently with anything else.
+type Index struct {
+	grid      *Grid
+	points    map[int]*Point
+	members   map[int]map[*Point]struct{} // cluster ID -> its points
+	eps       float64
+	minPts    int
+	clusterID int
 
-	// Will group points within a region of 500 meters
-	DBSCAN(points, eps, minPts)
+	clusters atomic.Pointer[[]Cluster] // summaries, nil when they need rebuilding
+}
 
+// NewIndex clusters points with the given radius in meters and minimum neighborhood size.
+func NewIndex(points []*Point, eps float64, minPts int) *Index {
+	idx := &Index{
+		grid:    NewGrid(eps / metersPerDegree),
+		points:  make(map[int]*Point, len(points)),
+		members: make(map[int]map[*Point]struct{}),
+		eps:     eps,
+		minPts:  minPts,
+	}
+	for _, p := range points {
+		p.ClusterID = 0
+		idx.points[p.ID] = p
+		idx.grid.Insert(p)
+	}
+	scan(idx.grid, points, eps, minPts, &idx.clusterID)
 	for _, p := range points {
-		fmt.Printf("Point at (%f, %f), Address: %s, Cluster ID: %d\n", p.Latitude, p.Longitude, p.Address, p.ClusterID)
+		if p.ClusterID > 0 {
+			idx.addMember(p)
+		}
+	}
+	return idx
+}
+
+func (idx *Index) Len() int {
+	return len(idx.points)
+}
+
+func (idx *Index) isCore(p *Point) bool {
+	return p.neighbors >= idx.minPts
+}
+
+func (idx *Index) addMember(p *Point) {
+	members, ok := idx.members[p.ClusterID]
+	if !ok {
+		members = make(map[*Point]struct{})
+		idx.members[p.ClusterID] = members
+	}
+	members[p] = struct{}{}
+}
+
+// label moves p to cluster id, -1 makes it noise.
+func (idx *Index) label(p *Point, id int) {
+	if p.ClusterID == id {
+		return
+	}
+	if members, ok := idx.members[p.ClusterID]; ok {
+		delete(members, p)
+		if len(members) == 0 {
+			delete(idx.members, p.ClusterID)
+		}
+	}
+	p.ClusterID = id
+	if id > 0 {
+		idx.addMember(p)
+	}
+}
+
+// merge joins clusters a and b and returns the ID of the result, a is 0 when there is nothing to join yet.
+// The smaller cluster is relabeled.
+func (idx *Index) merge(a, b int) int {
+	if a == 0 || a == b {
+		return b
+	}
+	if len(idx.members[a]) < len(idx.members[b]) {
+		a, b = b, a
+	}
+	for p := range idx.members[b] {
+		p.ClusterID = a
+		idx.members[a][p] = struct{}{}
+	}
+	delete(idx.members, b)
+	return a
+}
+
+// relabelBorder attaches a non-core point to a cluster next to it, or marks it as noise.
+func (idx *Index) relabelBorder(p *Point) {
+	for _, n := range idx.grid.GetNeighbors(p, idx.eps) {
+		if idx.isCore(n) {
+			idx.label(p, n.ClusterID)
+			return
+		}
+	}
+	idx.label(p, -1)
+}
+
+// Insert adds a point, or moves it when the ID is already indexed.
+func (idx *Index) Insert(p *Point) {
+	if old, ok := idx.points[p.ID]; ok {
+		if old.Latitude == p.Latitude && old.Longitude == p.Longitude {
+			return
+		}
+		idx.Remove(p.ID)
+	}
+
+	p.ClusterID = -1
+	idx.points[p.ID] = p
+	idx.grid.Insert(p)
+	idx.clusters.Store(nil)
+
+	neighbors := idx.grid.GetNeighbors(p, idx.eps)
+	p.neighbors = len(neighbors)
+
+	// Only p and the points that just reached minPts can link clusters that were apart
+	var promoted []*Point
+	if idx.isCore(p) {
+		promoted = append(promoted, p)
+	}
+	for _, n := range neighbors {
+		if n == p {
+			continue
+		}
+		n.neighbors++
+		if n.neighbors == idx.minPts {
+			promoted = append(promoted, n)
+		}
+	}
+
+	if len(promoted) == 0 {
+		idx.relabelBorder(p)
+		return
+	}
+
+	pending := make(map[*Point]struct{}, len(promoted))
+	for _, c := range promoted {
+		pending[c] = struct{}{}
+	}
+	for _, c := range promoted {
+		delete(pending, c)
+		around := neighbors
+		if c != p {
+			around = idx.grid.GetNeighbors(c, idx.eps)
+		}
+
+		// c is core now, so every cluster with a core point around it becomes one
+		target := 0
+		for _, n := range around {
+			if _, later := pending[n]; n == c || later || !idx.isCore(n) {
+				continue
+			}
+			target = idx.merge(target, n.ClusterID)
+		}
+		if target == 0 {
+			idx.clusterID++
+			target = idx.clusterID
+		}
+
+		idx.label(c, target)
+		for _, n := range around {
+			if !idx.isCore(n) && n.ClusterID <= 0 {
+				idx.label(n, target)
+			}
+		}
 	}
 }
+
+// Remove drops a point. Its cluster may shrink, split or dissolve into noise.
+func (idx *Index) Remove(id int) {
+	p, ok := idx.points[id]
+	if !ok {
+		return
+	}
+	delete(idx.points, id)
+	idx.grid.Remove(p)
+	idx.label(p, -1)
+	idx.clusters.Store(nil)
+
+	neighbors := idx.grid.GetNeighbors(p, idx.eps)
+	var demoted []*Point
+	for _, n := range neighbors {
+		n.neighbors--
+		if n.neighbors == idx.minPts-1 {
+			demoted = append(demoted, n)
+		}
+	}
+
+	// A border or noise point holds nothing together
+	if !idx.isCore(p) && len(demoted) == 0 {
+		return
+	}
+
+	// Any part of a cluster that lost its link through p or a demoted point has a core point next to them
+	touched := make(map[*Point]struct{}, len(neighbors))
+	for _, n := range neighbors {
+		touched[n] = struct{}{}
+	}
+	for _, d := range demoted {
+		for _, n := range idx.grid.GetNeighbors(d, idx.eps) {
+			touched[n] = struct{}{}
+		}
+	}
+
+	seeds := make(map[int][]*Point)
+	for n := range touched {
+		if idx.isCore(n) {
+			seeds[n.ClusterID] = append(seeds[n.ClusterID], n)
+		}
+	}
+	for clusterID, s := range seeds {
+		idx.split(clusterID, s)
+	}
+
+	for n := range touched {
+		if !idx.isCore(n) {
+			idx.relabelBorder(n)
+		}
+	}
+}
+
+// split gives every part of cluster id that is no longer density-connected its own ID.
+// The cluster is searched from all seeds at the same pace and searches that meet are joined,
+// so a search that runs out of points before meeting the others has found a separate part.
+// The last part standing keeps id, which is usually the largest.
+func (idx *Index) split(id int, seeds []*Point) {
+	if len(seeds) < 2 {
+		return
+	}
+
+	parent := make([]int, len(seeds))
+	owner := make(map[*Point]int)
+	queues := make([][]*Point, len(seeds))
+	cores := make([][]*Point, len(seeds))
+	borders := make([][]*Point, len(seeds))
+	for i, s := range seeds {
+		parent[i] = i
+		owner[s] = i
+		queues[i] = []*Point{s}
+		cores[i] = []*Point{s}
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	active := len(seeds)
+	for active > 1 {
+		for i := range seeds {
+			if active == 1 {
+				break
+			}
+			if parent[i] != i || cores[i] == nil {
+				continue // joined another search or already done
+			}
+
+			if len(queues[i]) == 0 {
+				idx.clusterID++
+				for _, c := range cores[i] {
+					idx.label(c, idx.clusterID)
+				}
+				for _, b := range borders[i] {
+					if b.ClusterID == id {
+						idx.label(b, idx.clusterID)
+					}
+				}
+				cores[i], borders[i] = nil, nil
+				active--
+				continue
+			}
+
+			current := queues[i][0]
+			queues[i] = queues[i][1:]
+			for _, n := range idx.grid.GetNeighbors(current, idx.eps) {
+				if !idx.isCore(n) {
+					borders[i] = append(borders[i], n)
+					continue
+				}
+				j, seen := owner[n]
+				if !seen {
+					owner[n] = i
+					queues[i] = append(queues[i], n)
+					cores[i] = append(cores[i], n)
+					continue
+				}
+				if r := find(j); r != i {
+					parent[r] = i
+					queues[i] = append(queues[i], queues[r]...)
+					cores[i] = append(cores[i], cores[r]...)
+					borders[i] = append(borders[i], borders[r]...)
+					queues[r], cores[r], borders[r] = nil, nil, nil
+					active--
+				}
+			}
+		}
+	}
+}
+
+// Clusters returns the summary of every cluster and noise point, largest first.
+// Concurrent calls are safe as long as nothing is inserted or removed meanwhile.
+func (idx *Index) Clusters() []Cluster {
+	if cached := idx.clusters.Load(); cached != nil {
+		return *cached
+	}
+
+	byID := make(map[int]*Cluster)
+	clusters := make([]Cluster, 0)
+	for _, p := range idx.points {
+		if p.ClusterID <= 0 {
+			clusters = append(clusters, Cluster{
+				Latitude: p.Latitude, Longitude: p.Longitude,
+				MinLat: p.Latitude, MinLng: p.Longitude, MaxLat: p.Latitude, MaxLng: p.Longitude,
+				Count: 1, PointID: p.ID,
+			})
+			continue
+		}
+
+		c, ok := byID[p.ClusterID]
+		if !ok {
+			c = &Cluster{MinLat: p.Latitude, MinLng: p.Longitude, MaxLat: p.Latitude, MaxLng: p.Longitude, PointID: p.ID}
+			byID[p.ClusterID] = c
+		}
+		c.Count++
+		c.Latitude += p.Latitude
+		c.Longitude += p.Longitude
+		c.MinLat = math.Min(c.MinLat, p.Latitude)
+		c.MinLng = math.Min(c.MinLng, p.Longitude)
+		c.MaxLat = math.Max(c.MaxLat, p.Latitude)
+		c.MaxLng = math.Max(c.MaxLng, p.Longitude)
+	}
+
+	for _, c := range byID {
+		c.Latitude /= float64(c.Count)
+		c.Longitude /= float64(c.Count)
+		if c.Count > 1 {
+			c.PointID = 0
+		}
+		clusters = append(clusters, *c)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].PointID < clusters[j].PointID
+	})
+
+	idx.clusters.Store(&clusters)
+	return clusters
+}
@@ -0,0 +1,196 @@
+package clustering
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func samplePoints() []*Point {
+	return []*Point{
+		{ID: 1, Latitude: 37.55808862059195, Longitude: 126.95976545165765, Address: "서울 서대문구 북아현동 884"},
+		{ID: 2, Latitude: 37.568166, Longitude: 126.974102, Address: "서울 중구 정동 1-76"},
+		{ID: 3, Latitude: 37.568661, Longitude: 126.972375, Address: "서울 종로구 신문로2가 171"},
+		{ID: 4, Latitude: 37.56885, Longitude: 126.972064, Address: "서울 종로구 신문로2가 171"},
+		{ID: 5, Latitude: 37.56589411615361, Longitude: 126.96930309974685, Address: "서울 중구 순화동 1-1"},
+		{ID: 6, Latitude: 37.57838984677184, Longitude: 126.98853202207196, Address: "서울 종로구 원서동 181"},
+		{ID: 7, Latitude: 37.57318309415514, Longitude: 126.95501424473001, Address: "서울 서대문구 현저동 101"},
+		{ID: 8, Latitude: 37.5541479820707, Longitude: 126.98370331932351, Address: "서울 중구 회현동1가 산 1-2"},
+		{ID: 9, Latitude: 37.58411863798303, Longitude: 126.97246285644356, Address: "서울 종로구 궁정동 17-3"},
+		{ID: 10, Latitude: 36.33937565888829, Longitude: 127.41575408006757, Address: "대전 중구 선화동 223"},
+		{ID: 11, Latitude: 36.346176003613984, Longitude: 127.41482385609581, Address: "대전 대덕구 오정동 496-1"},
+	}
+}
+
+// partition maps every point ID to the IDs it shares a cluster with, independent of cluster numbering.
+func partition(points []*Point) map[int][]int {
+	members := make(map[int][]int)
+	for _, p := range points {
+		if p.ClusterID > 0 {
+			members[p.ClusterID] = append(members[p.ClusterID], p.ID)
+		}
+	}
+	groups := make(map[int][]int)
+	for _, p := range points {
+		if p.ClusterID > 0 {
+			groups[p.ID] = members[p.ClusterID]
+		} else {
+			groups[p.ID] = []int{p.ID}
+		}
+	}
+	return groups
+}
+
+func TestDBSCAN(t *testing.T) {
+	points := samplePoints()
+
+	// Group points within 5km
+	DBSCAN(points, 5000, 2)
+
+	seoul := points[0].ClusterID
+	daejeon := points[9].ClusterID
+	assert.Positive(t, seoul)
+	assert.Positive(t, daejeon)
+	assert.NotEqual(t, seoul, daejeon)
+	for _, p := range points[:9] {
+		assert.Equal(t, seoul, p.ClusterID, p.Address)
+	}
+	assert.Equal(t, daejeon, points[10].ClusterID)
+}
+
+func TestIndexMatchesDBSCAN(t *testing.T) {
+	const eps = 800.0
+
+	full := samplePoints()
+	DBSCAN(full, eps, 2)
+
+	idx := NewIndex(nil, eps, 2)
+	incremental := samplePoints()
+	for _, p := range incremental {
+		idx.Insert(p)
+	}
+	assert.Equal(t, partition(full), partition(incremental))
+	assert.Equal(t, len(full), idx.Len())
+}
+
+func TestIndexRemoveAndMove(t *testing.T) {
+	points := samplePoints()
+	idx := NewIndex(points[9:], 5000, 2)
+
+	clusters := idx.Clusters()
+	require.Len(t, clusters, 1)
+	assert.Equal(t, 2, clusters[0].Count)
+	assert.InDelta(t, (36.33937565888829+36.346176003613984)/2, clusters[0].Latitude, 1e-9)
+	assert.Zero(t, clusters[0].PointID)
+
+	// One of the two Daejeon markers goes away, the other is left on its own
+	idx.Remove(10)
+	clusters = idx.Clusters()
+	require.Len(t, clusters, 1)
+	assert.Equal(t, 1, clusters[0].Count)
+	assert.Equal(t, 11, clusters[0].PointID)
+
+	// Moving it to Seoul next to a new marker there forms a new cluster
+	idx.Insert(&Point{ID: 11, Latitude: 37.568661, Longitude: 126.972375})
+	idx.Insert(&Point{ID: 12, Latitude: 37.56885, Longitude: 126.972064})
+	clusters = idx.Clusters()
+	require.Len(t, clusters, 1)
+	assert.Equal(t, 2, clusters[0].Count)
+	assert.InDelta(t, 37.568661, clusters[0].MinLat, 1e-9)
+	assert.InDelta(t, 37.56885, clusters[0].MaxLat, 1e-9)
+}
+
+// assertConsistent checks idx against the DBSCAN definition: core points that reach each other share
+// a cluster and no other, border points belong to a cluster next to them and the rest is noise.
+func assertConsistent(t *testing.T, idx *Index) {
+	t.Helper()
+
+	fresh := make([]*Point, 0, len(idx.points))
+	byID := make(map[int]*Point, len(idx.points))
+	for _, p := range idx.points {
+		q := &Point{ID: p.ID, Latitude: p.Latitude, Longitude: p.Longitude}
+		fresh = append(fresh, q)
+		byID[p.ID] = q
+	}
+	DBSCAN(fresh, idx.eps, idx.minPts)
+
+	clusterOf := make(map[int]int) // fresh cluster -> index cluster
+	for _, p := range idx.points {
+		neighbors := idx.grid.GetNeighbors(p, idx.eps)
+		require.Equal(t, len(neighbors), p.neighbors, "neighbor count of %d", p.ID)
+
+		if idx.isCore(p) {
+			require.Positive(t, p.ClusterID, "core point %d", p.ID)
+			want := byID[p.ID].ClusterID
+			if got, ok := clusterOf[want]; ok {
+				require.Equal(t, got, p.ClusterID, "core point %d", p.ID)
+			} else {
+				clusterOf[want] = p.ClusterID
+			}
+			continue
+		}
+
+		expected := -1
+		for _, n := range neighbors {
+			if idx.isCore(n) {
+				expected = 0
+				if n.ClusterID == p.ClusterID {
+					expected = p.ClusterID
+					break
+				}
+			}
+		}
+		require.Equal(t, expected, p.ClusterID, "border or noise point %d", p.ID)
+	}
+
+	// Different fresh clusters must not share an index cluster
+	seen := make(map[int]bool)
+	for _, id := range clusterOf {
+		require.False(t, seen[id], "clusters merged")
+		seen[id] = true
+	}
+
+	members := 0
+	for id, m := range idx.members {
+		for p := range m {
+			require.Equal(t, id, p.ClusterID)
+		}
+		members += len(m)
+	}
+	clustered := 0
+	for _, p := range idx.points {
+		if p.ClusterID > 0 {
+			clustered++
+		}
+	}
+	require.Equal(t, clustered, members)
+}
+
+func TestIndexRandomChanges(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomPoint := func(id int) *Point {
+		// A small area so clusters keep forming, splitting and merging
+		return &Point{ID: id, Latitude: 37.5 + rng.Float64()*0.05, Longitude: 127 + rng.Float64()*0.05}
+	}
+
+	for _, minPts := range []int{2, 4} {
+		initial := make([]*Point, 150)
+		for i := range initial {
+			initial[i] = randomPoint(i + 1)
+		}
+		idx := NewIndex(initial, 400, minPts)
+		assertConsistent(t, idx)
+
+		for step := 0; step < 600; step++ {
+			id := rng.Intn(300) + 1
+			if rng.Intn(3) == 0 {
+				idx.Remove(id)
+			} else {
+				idx.Insert(randomPoint(id)) // a move when the ID exists
+			}
+			assertConsistent(t, idx)
+		}
+	}
+}
@@ -11,7 +11,7 @@ import (
 )
 
 const (
-	markerCheckQuery   = "SELECT EXISTS(SELECT 1 FROM Markers WHERE MarkerID = ?)"
+	markerCheckQuery   = "SELECT EXISTS(SELECT 1 FROM Markers WHERE MarkerID = ? AND DeletedAt IS NULL)"
 	commentCountQuery  = "SELECT COUNT(*) FROM Comments WHERE MarkerID = ? AND UserID = ? AND DeletedAt IS NULL"
 	insertCommentQuery = "INSERT INTO Comments (MarkerID, UserID, CommentText, PostedAt, UpdatedAt) VALUES (?, ?, ?, ?, ?)"
 	updateCommentQuery = "UPDATE Comments SET CommentText = ?, UpdatedAt = NOW() WHERE CommentID = ? AND UserID = ? AND DeletedAt IS NULL"
@@ -43,12 +43,14 @@ WHERE C.MarkerID = ? AND C.DeletedAt IS NULL`
 type MarkerCommentService struct {
 	DB           *sqlx.DB
 	RedisService *RedisService
+	BadgeService *BadgeService
 }
 
-func NewMarkerCommentService(db *sqlx.DB, redisService *RedisService) *MarkerCommentService {
+func NewMarkerCommentService(db *sqlx.DB, redisService *RedisService, badgeService *BadgeService) *MarkerCommentService {
 	return &MarkerCommentService{
 		DB:           db,
 		RedisService: redisService,
+		BadgeService: badgeService,
 	}
 }
 
@@ -142,6 +144,8 @@ func (s *MarkerCommentService) CreateComment(markerID, userID int, userName, com
 		go util.SendSlackNewComment(markerID, userID, userName, commentText)
 	}
 
+	s.BadgeService.Publish(EventCommentPosted, userID)
+
 	return &comment, nil
 }
 
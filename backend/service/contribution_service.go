@@ -0,0 +1,338 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Why a ledger entry was written outside the event itself
+const (
+	ContributionReasonMarkerRestored = "MARKER_RESTORED"
+	ContributionReasonMarkerDeleted  = "MARKER_DELETED"
+	ContributionReasonMarkerMerged   = "MARKER_MERGED"
+	ContributionReasonReportReversed = "REPORT_REVERSED"
+	ContributionReasonReportDeleted  = "REPORT_DELETED"
+	ContributionReasonRecompute      = "RECOMPUTE"
+)
+
+const (
+	// Admins import and fix markers in bulk, they do not earn points for it
+	contributionMarkerEarnersQuery = `
+SELECT m.UserID, m.MarkerID AS ReferenceID
+FROM Markers m
+JOIN Users u ON m.UserID = u.UserID
+WHERE m.DeletedAt IS NULL AND u.Role <> 'admin'`
+	contributionReportEarnersQuery = `
+SELECT r.UserID, r.ReportID AS ReferenceID
+FROM Reports r
+JOIN Users u ON r.UserID = u.UserID
+WHERE r.Status = 'APPROVED' AND u.Role <> 'admin'`
+
+	// Awards that have not been revoked yet. Legacy entries have no reference and are never reconciled.
+	selectActiveContributionsQuery = `
+SELECT l.EntryID, l.UserID, l.ReferenceID, l.Points
+FROM ContributionLedger l
+WHERE l.EventType = ? AND l.ReferenceID IS NOT NULL AND l.RevokesEntryID IS NULL
+	AND NOT EXISTS (SELECT 1 FROM ContributionLedger r WHERE r.RevokesEntryID = l.EntryID)`
+	selectActiveContributionsForReferenceQuery = selectActiveContributionsQuery + " AND l.ReferenceID = ? FOR UPDATE"
+
+	insertContributionQuery = `
+INSERT INTO ContributionLedger (UserID, EventType, ReferenceID, Points, Reason, RevokesEntryID)
+VALUES (?, ?, ?, ?, NULLIF(?, ''), ?)`
+	addContributionTotalQuery = `
+INSERT INTO UserContributionTotals (UserID, Points) VALUES (?, ?)
+ON DUPLICATE KEY UPDATE Points = Points + VALUES(Points)`
+
+	getContributionTotalQuery = "SELECT COALESCE(MAX(Points), 0) FROM UserContributionTotals WHERE UserID = ?"
+	countContributionsQuery   = "SELECT COUNT(*) FROM ContributionLedger WHERE UserID = ?"
+	selectContributionsQuery  = `
+SELECT EntryID, UserID, EventType, ReferenceID, Points, Reason, RevokesEntryID, CreatedAt
+FROM ContributionLedger
+WHERE UserID = ?
+ORDER BY EntryID DESC
+LIMIT ? OFFSET ?`
+
+	selectContributionTotalsQuery  = "SELECT UserID, Points FROM UserContributionTotals"
+	deleteContributionTotalsQuery  = "DELETE FROM UserContributionTotals"
+	rebuildContributionTotalsQuery = `
+INSERT INTO UserContributionTotals (UserID, Points)
+SELECT UserID, SUM(Points) FROM ContributionLedger GROUP BY UserID`
+)
+
+// contributionRule declares how many points an event is worth. earnersQuery selects UserID and ReferenceID
+// of every row that should hold the points right now, the ledger is reconciled against it.
+type contributionRule struct {
+	Event           string
+	Points          int
+	earnersQuery    string
+	referenceColumn string
+}
+
+var contributionRules = []contributionRule{
+	{Event: EventMarkerCreated, Points: 10, earnersQuery: contributionMarkerEarnersQuery, referenceColumn: "m.MarkerID"},
+	{Event: EventReportApproved, Points: 5, earnersQuery: contributionReportEarnersQuery, referenceColumn: "r.ReportID"},
+}
+
+type contributionEarner struct {
+	UserID      int `db:"UserID"`
+	ReferenceID int `db:"ReferenceID"`
+}
+
+// ContributionService keeps the points ledger behind contribution levels.
+type ContributionService struct {
+	DB           *sqlx.DB
+	CacheService *MarkerCacheService
+	Logger       *zap.Logger
+}
+
+func NewContributionService(db *sqlx.DB, cacheService *MarkerCacheService, logger *zap.Logger) *ContributionService {
+	return &ContributionService{
+		DB:           db,
+		CacheService: cacheService,
+		Logger:       logger,
+	}
+}
+
+// SyncTx brings the ledger entries of event for one marker or report in line with the rule, inside tx after
+// the caller changed the row: it awards points the row now earns and revokes points it no longer does.
+// reason is stored on the entries it writes, empty for the event itself. It returns the users whose total changed.
+func (s *ContributionService) SyncTx(tx *sqlx.Tx, event string, referenceID int, reason string) ([]int, error) {
+	rule, ok := findContributionRule(event)
+	if !ok {
+		return nil, fmt.Errorf("no contribution rule for %s", event)
+	}
+
+	var earners []contributionEarner
+	if err := tx.Select(&earners, rule.earnersQuery+" AND "+rule.referenceColumn+" = ?", referenceID); err != nil {
+		return nil, fmt.Errorf("fetching contribution earners: %w", err)
+	}
+	var active []model.ContributionEntry
+	if err := tx.Select(&active, selectActiveContributionsForReferenceQuery, event, referenceID); err != nil {
+		return nil, fmt.Errorf("fetching contributions: %w", err)
+	}
+
+	users, _, _, err := reconcileContributionsTx(tx, rule, earners, active, reason)
+	return users, err
+}
+
+// InvalidateProfiles drops the cached profiles of users, call it once the SyncTx transaction is committed.
+func (s *ContributionService) InvalidateProfiles(userIDs []int) {
+	for _, userID := range userIDs {
+		s.CacheService.ResetUserProfileCache(userID)
+	}
+}
+
+// GetTotal returns the user's contribution points.
+func (s *ContributionService) GetTotal(userID int) (int, error) {
+	var total int
+	if err := s.DB.Get(&total, getContributionTotalQuery, userID); err != nil {
+		return 0, fmt.Errorf("fetching contribution total: %w", err)
+	}
+	return total, nil
+}
+
+// GetHistory lists the user's ledger entries, newest first, so they can see why their score changed.
+func (s *ContributionService) GetHistory(userID, page, pageSize int) (*dto.ContributionHistory, error) {
+	offset := (page - 1) * pageSize
+
+	total, err := s.GetTotal(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := s.DB.Get(&count, countContributionsQuery, userID); err != nil {
+		return nil, fmt.Errorf("counting contributions: %w", err)
+	}
+
+	entries := make([]model.ContributionEntry, 0, pageSize)
+	if err := s.DB.Select(&entries, selectContributionsQuery, userID, pageSize, offset); err != nil {
+		return nil, fmt.Errorf("fetching contributions: %w", err)
+	}
+
+	return &dto.ContributionHistory{
+		Entries:           entries,
+		ContributionLevel: getLevelName(total),
+		ContributionCount: total,
+		CurrentPage:       page,
+		TotalPages:        int(math.Ceil(float64(count) / float64(pageSize))),
+		TotalEntries:      count,
+	}, nil
+}
+
+// Recompute reconciles the whole ledger with the current rules and rebuilds every user's total from it.
+// Entries are only ever added, so each correction shows up in the history with reason RECOMPUTE.
+// With dryRun the same work is done and rolled back, the result tells what would change.
+func (s *ContributionService) Recompute(dryRun bool) (*dto.ContributionRecompute, error) {
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	before, err := contributionTotalsTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.ContributionRecompute{
+		Rules:  make([]dto.ContributionRuleRecompute, 0, len(contributionRules)),
+		DryRun: dryRun,
+	}
+	for _, rule := range contributionRules {
+		var earners []contributionEarner
+		if err := tx.Select(&earners, rule.earnersQuery); err != nil {
+			return nil, fmt.Errorf("fetching %s earners: %w", rule.Event, err)
+		}
+		var active []model.ContributionEntry
+		if err := tx.Select(&active, selectActiveContributionsQuery+" FOR UPDATE", rule.Event); err != nil {
+			return nil, fmt.Errorf("fetching %s contributions: %w", rule.Event, err)
+		}
+
+		_, awarded, revoked, err := reconcileContributionsTx(tx, rule, earners, active, ContributionReasonRecompute)
+		if err != nil {
+			return nil, err
+		}
+		result.Rules = append(result.Rules, dto.ContributionRuleRecompute{
+			EventType: rule.Event,
+			Points:    rule.Points,
+			Awarded:   awarded,
+			Revoked:   revoked,
+		})
+	}
+
+	// Totals are rebuilt from scratch, which also repairs any drift between them and the ledger
+	if _, err := tx.Exec(deleteContributionTotalsQuery); err != nil {
+		return nil, fmt.Errorf("clearing contribution totals: %w", err)
+	}
+	if _, err := tx.Exec(rebuildContributionTotalsQuery); err != nil {
+		return nil, fmt.Errorf("rebuilding contribution totals: %w", err)
+	}
+	after, err := contributionTotalsTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []int
+	for userID, points := range after {
+		if before[userID] != points {
+			changed = append(changed, userID)
+		}
+	}
+	for userID, points := range before {
+		if _, ok := after[userID]; !ok && points != 0 {
+			changed = append(changed, userID)
+		}
+	}
+	result.UsersChanged = len(changed)
+
+	if dryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+
+	s.InvalidateProfiles(changed)
+	return result, nil
+}
+
+// contributionPlan is what reconciling one rule writes: revocations of entries and awards to earners.
+type contributionPlan struct {
+	Revoke []model.ContributionEntry
+	Award  []contributionEarner
+}
+
+// planContributions revokes active entries no earner backs any more (or that are worth a different
+// amount than the rule now says, or that duplicate another entry) and awards every earner without an active entry.
+func planContributions(rule contributionRule, earners []contributionEarner, active []model.ContributionEntry) contributionPlan {
+	var plan contributionPlan
+	held := make(map[contributionEarner]bool, len(active))
+
+	expected := make(map[contributionEarner]bool, len(earners))
+	for _, e := range earners {
+		expected[e] = true
+	}
+
+	for _, entry := range active {
+		key := contributionEarner{UserID: entry.UserID, ReferenceID: *entry.ReferenceID}
+		if expected[key] && entry.Points == rule.Points && !held[key] {
+			held[key] = true
+			continue
+		}
+		plan.Revoke = append(plan.Revoke, entry)
+	}
+
+	for _, e := range earners {
+		if held[e] {
+			continue
+		}
+		held[e] = true
+		plan.Award = append(plan.Award, e)
+	}
+	return plan
+}
+
+// reconcileContributionsTx writes the entries planContributions asks for and returns the users whose total changed.
+func reconcileContributionsTx(tx *sqlx.Tx, rule contributionRule, earners []contributionEarner, active []model.ContributionEntry, reason string) (users []int, awarded, revoked int, err error) {
+	plan := planContributions(rule, earners, active)
+	touched := make(map[int]bool)
+
+	for _, entry := range plan.Revoke {
+		if err := addContributionTx(tx, entry.UserID, rule.Event, *entry.ReferenceID, -entry.Points, reason, &entry.EntryID); err != nil {
+			return nil, 0, 0, err
+		}
+		touched[entry.UserID] = true
+	}
+	for _, e := range plan.Award {
+		if err := addContributionTx(tx, e.UserID, rule.Event, e.ReferenceID, rule.Points, reason, nil); err != nil {
+			return nil, 0, 0, err
+		}
+		touched[e.UserID] = true
+	}
+
+	for userID := range touched {
+		users = append(users, userID)
+	}
+	return users, len(plan.Award), len(plan.Revoke), nil
+}
+
+func addContributionTx(tx *sqlx.Tx, userID int, event string, referenceID, points int, reason string, revokesEntryID *int64) error {
+	if _, err := tx.Exec(insertContributionQuery, userID, event, referenceID, points, reason, revokesEntryID); err != nil {
+		return fmt.Errorf("inserting contribution: %w", err)
+	}
+	if _, err := tx.Exec(addContributionTotalQuery, userID, points); err != nil {
+		return fmt.Errorf("updating contribution total: %w", err)
+	}
+	return nil
+}
+
+func contributionTotalsTx(tx *sqlx.Tx) (map[int]int, error) {
+	var rows []struct {
+		UserID int `db:"UserID"`
+		Points int `db:"Points"`
+	}
+	if err := tx.Select(&rows, selectContributionTotalsQuery); err != nil {
+		return nil, fmt.Errorf("fetching contribution totals: %w", err)
+	}
+
+	totals := make(map[int]int, len(rows))
+	for _, row := range rows {
+		totals[row.UserID] = row.Points
+	}
+	return totals, nil
+}
+
+func findContributionRule(event string) (contributionRule, bool) {
+	for _, rule := range contributionRules {
+		if rule.Event == event {
+			return rule, true
+		}
+	}
+	return contributionRule{}, false
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanContributions(t *testing.T) {
+	rule := contributionRule{Event: EventMarkerCreated, Points: 10}
+
+	entry := func(entryID int64, userID, referenceID, points int) model.ContributionEntry {
+		return model.ContributionEntry{
+			EntryID:     entryID,
+			UserID:      userID,
+			EventType:   EventMarkerCreated,
+			ReferenceID: &referenceID,
+			Points:      points,
+		}
+	}
+	earner := func(userID, referenceID int) contributionEarner {
+		return contributionEarner{UserID: userID, ReferenceID: referenceID}
+	}
+	entryIDs := func(entries []model.ContributionEntry) []int64 {
+		var ids []int64
+		for _, e := range entries {
+			ids = append(ids, e.EntryID)
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name       string
+		earners    []contributionEarner
+		active     []model.ContributionEntry
+		wantRevoke []int64
+		wantAward  []contributionEarner
+	}{
+		{"nothing to do", nil, nil, nil, nil},
+		{"in line", []contributionEarner{earner(1, 100)}, []model.ContributionEntry{entry(1, 1, 100, 10)}, nil, nil},
+		{"award a new earner", []contributionEarner{earner(1, 100), earner(2, 200)}, []model.ContributionEntry{
+			entry(1, 1, 100, 10),
+		}, nil, []contributionEarner{earner(2, 200)}},
+		{"revoke a row that no longer earns", nil, []model.ContributionEntry{entry(1, 1, 100, 10)}, []int64{1}, nil},
+		{"revoke when the row changed hands", []contributionEarner{earner(2, 100)}, []model.ContributionEntry{
+			entry(1, 1, 100, 10),
+		}, []int64{1}, []contributionEarner{earner(2, 100)}},
+		{"changed points are revoked and awarded again", []contributionEarner{earner(1, 100)}, []model.ContributionEntry{
+			entry(1, 1, 100, 5),
+		}, []int64{1}, []contributionEarner{earner(1, 100)}},
+		{"duplicate entries keep only the first", []contributionEarner{earner(1, 100)}, []model.ContributionEntry{
+			entry(1, 1, 100, 10),
+			entry(2, 1, 100, 10),
+		}, []int64{2}, nil},
+		{"a duplicate earner is awarded once", []contributionEarner{earner(1, 100), earner(1, 100)}, nil,
+			nil, []contributionEarner{earner(1, 100)}},
+		{"the entry with the current points is kept", []contributionEarner{earner(1, 100)}, []model.ContributionEntry{
+			entry(1, 1, 100, 5),
+			entry(2, 1, 100, 10),
+		}, []int64{1}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planContributions(rule, tt.earners, tt.active)
+			assert.Equal(t, tt.wantRevoke, entryIDs(plan.Revoke))
+			assert.Equal(t, tt.wantAward, plan.Award)
+		})
+	}
+}
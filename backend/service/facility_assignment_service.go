@@ -0,0 +1,142 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// cost: 0.70, access_type: ref
+	getMarkerFacilityDetailsQuery = `
+SELECT mf.FacilityID, mf.MarkerID, mf.Quantity,
+	COALESCE(f.Slug, '') AS Slug,
+	COALESCE(f.Category, '') AS Category,
+	COALESCE(f.NameKo, '') AS NameKo,
+	COALESCE(f.NameEn, '') AS NameEn,
+	COALESCE(f.Icon, '') AS Icon
+FROM MarkerFacilities mf
+LEFT JOIN Facilities f ON f.FacilityID = mf.FacilityID
+WHERE mf.MarkerID = ? AND mf.DeletedAt IS NULL
+ORDER BY COALESCE(f.SortOrder, 0), mf.FacilityID`
+
+	deleteMarkerFacilitiesQuery = "DELETE FROM MarkerFacilities WHERE MarkerID = ?"
+	insertMarkerFacilityQuery   = "INSERT INTO MarkerFacilities (FacilityID, MarkerID, Quantity) VALUES (?, ?, ?)"
+
+	// One util.FacilityRequirement, m is the Markers alias of the query it is added to
+	facilityRequirementCondition = `EXISTS (
+		SELECT 1 FROM MarkerFacilities f
+		WHERE f.MarkerID = m.MarkerID AND f.FacilityID = ? AND f.Quantity >= ? AND f.DeletedAt IS NULL
+	)`
+)
+
+// FacilityAssignmentService manages which catalog facilities a marker has and how many of each.
+type FacilityAssignmentService struct {
+	DB *sqlx.DB
+
+	CacheService       *MarkerCacheService
+	RevisionService    *MarkerRevisionService
+	CatalogService     *FacilityCatalogService
+	BleveSearchService *BleveSearchService
+	Logger             *zap.Logger
+}
+
+func NewFacilityAssignmentService(
+	db *sqlx.DB,
+	cacheService *MarkerCacheService,
+	revisionService *MarkerRevisionService,
+	catalogService *FacilityCatalogService,
+	bleveSearchService *BleveSearchService,
+	logger *zap.Logger) *FacilityAssignmentService {
+	return &FacilityAssignmentService{
+		DB:                 db,
+		CacheService:       cacheService,
+		RevisionService:    revisionService,
+		CatalogService:     catalogService,
+		BleveSearchService: bleveSearchService,
+		Logger:             logger,
+	}
+}
+
+// GetFacilitiesByMarkerID retrieves facilities for a given marker ID with their catalog names and icons.
+func (s *FacilityAssignmentService) GetFacilitiesByMarkerID(markerID int) ([]dto.MarkerFacilityDetail, error) {
+	facilities := make([]dto.MarkerFacilityDetail, 0)
+	err := s.DB.Select(&facilities, getMarkerFacilityDetailsQuery, markerID)
+	if err != nil {
+		return nil, err
+	}
+	return facilities, nil
+}
+
+// SetMarkerFacilities replaces the facilities of a marker, every FacilityID has to be in the catalog.
+func (s *FacilityAssignmentService) SetMarkerFacilities(markerID int, facilities []dto.FacilityQuantity, actor RevisionActor) error {
+	facilityIDs := make([]int, len(facilities))
+	for i, fq := range facilities {
+		facilityIDs[i] = fq.FacilityID
+	}
+	if err := s.CatalogService.ValidateFacilityIDs(facilityIDs); err != nil {
+		return err
+	}
+
+	err := s.RevisionService.Track(markerID, actor, func(tx *sqlx.Tx) error {
+		return replaceMarkerFacilitiesTx(tx, markerID, facilities)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.CacheService.InvalidateFacilities(markerID)
+	s.CacheService.InvalidateMarkerTiles()
+	// The protobuf and msgpack marker lists carry the facilities of every marker
+	if err := s.CacheService.InvalidateFullMarkersCache(); err != nil {
+		s.Logger.Warn("Failed to invalidate the marker list cache", zap.Int("markerID", markerID), zap.Error(err))
+	}
+
+	// Search results are filtered on the facilities in the index
+	if err := s.BleveSearchService.ReindexMarker(markerID); err != nil {
+		s.Logger.Error("Failed to reindex marker facilities", zap.Int("markerID", markerID), zap.Error(err))
+	}
+
+	return nil
+}
+
+// replaceMarkerFacilitiesTx swaps the facilities of a marker within tx, the caller validates them and tracks the revision.
+// MarkerRevisionService restores facilities through it too, it cannot depend on this service.
+func replaceMarkerFacilitiesTx(tx *sqlx.Tx, markerID int, facilities []dto.FacilityQuantity) error {
+	if _, err := tx.Exec(deleteMarkerFacilitiesQuery, markerID); err != nil {
+		return err
+	}
+	return insertMarkerFacilitiesTx(tx, markerID, facilities)
+}
+
+func insertMarkerFacilitiesTx(tx *sqlx.Tx, markerID int, facilities []dto.FacilityQuantity) error {
+	for _, fq := range facilities {
+		if _, err := tx.Exec(insertMarkerFacilityQuery, fq.FacilityID, markerID, fq.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// facilityFilterCondition turns filter into an AND condition on the Markers alias m, it is empty for an empty filter.
+func facilityFilterCondition(filter util.FacilityFilter) (string, []any) {
+	if filter.IsEmpty() {
+		return "", nil
+	}
+
+	conditions := make([]string, len(filter.Requirements))
+	args := make([]any, 0, 2*len(filter.Requirements))
+	for i, r := range filter.Requirements {
+		conditions[i] = facilityRequirementCondition
+		args = append(args, r.FacilityID, r.MinQuantity)
+	}
+
+	separator := "\n\tAND "
+	if filter.MatchAny {
+		separator = "\n\tOR "
+	}
+	return "\nAND (" + strings.Join(conditions, separator) + ")", args
+}
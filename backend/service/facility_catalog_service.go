@@ -0,0 +1,267 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Facility categories, a catalog entry is one concrete kind of equipment within a category
+const (
+	FacilityCategoryPullUpBar    = "PULL_UP_BAR"
+	FacilityCategoryParallelBars = "PARALLEL_BARS"
+	FacilityCategoryRings        = "RINGS"
+	FacilityCategoryMonkeyBars   = "MONKEY_BARS"
+	FacilityCategoryDipStation   = "DIP_STATION"
+	FacilityCategorySitUpBench   = "SIT_UP_BENCH"
+	FacilityCategoryOther        = "OTHER"
+)
+
+const (
+	selectFacilityTypesQuery = "SELECT FacilityID, Slug, Category, NameKo, NameEn, Icon, SortOrder FROM Facilities ORDER BY SortOrder, FacilityID"
+	selectFacilityTypeQuery  = "SELECT FacilityID, Slug, Category, NameKo, NameEn, Icon, SortOrder FROM Facilities WHERE FacilityID = ?"
+	selectFacilityIDsQuery   = "SELECT FacilityID FROM Facilities WHERE FacilityID IN (?)"
+
+	insertFacilityTypeQuery = "INSERT INTO Facilities (Slug, Category, NameKo, NameEn, Icon, SortOrder) VALUES (?, ?, ?, ?, ?, ?)"
+	updateFacilityTypeQuery = "UPDATE Facilities SET Slug = ?, Category = ?, NameKo = ?, NameEn = ?, Icon = ?, SortOrder = ? WHERE FacilityID = ?"
+	deleteFacilityTypeQuery = "DELETE FROM Facilities WHERE FacilityID = ?"
+
+	// Soft-deleted markers count too, they can still be restored
+	countFacilityUsageQuery = "SELECT COUNT(*) FROM MarkerFacilities WHERE FacilityID = ?"
+
+	// Catalog edits on other instances show up in names after this long
+	facilityNamesTTL = time.Minute
+)
+
+var (
+	ErrFacilityNotFound = errors.New("facility not found")
+	ErrUnknownFacility  = errors.New("unknown facility")
+	ErrInvalidFacility  = errors.New("invalid facility")
+	ErrFacilityInUse    = errors.New("facility is used by markers")
+	ErrDuplicateSlug    = errors.New("facility slug already exists")
+
+	facilityCategories = map[string]struct{}{
+		FacilityCategoryPullUpBar:    {},
+		FacilityCategoryParallelBars: {},
+		FacilityCategoryRings:        {},
+		FacilityCategoryMonkeyBars:   {},
+		FacilityCategoryDipStation:   {},
+		FacilityCategorySitUpBench:   {},
+		FacilityCategoryOther:        {},
+	}
+
+	facilitySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// FacilityCatalogService manages the catalog of facility types markers refer to by FacilityID.
+type FacilityCatalogService struct {
+	DB           *sqlx.DB
+	CacheService *MarkerCacheService
+	Logger       *zap.Logger
+
+	namesMu       sync.Mutex
+	names         util.FacilityNames
+	namesLoadedAt time.Time
+}
+
+func NewFacilityCatalogService(db *sqlx.DB, cacheService *MarkerCacheService, logger *zap.Logger) *FacilityCatalogService {
+	return &FacilityCatalogService{
+		DB:           db,
+		CacheService: cacheService,
+		Logger:       logger,
+	}
+}
+
+// ListFacilityTypes returns the whole catalog in display order.
+func (s *FacilityCatalogService) ListFacilityTypes() ([]model.FacilityType, error) {
+	facilities := make([]model.FacilityType, 0)
+	if err := s.DB.Select(&facilities, selectFacilityTypesQuery); err != nil {
+		return nil, fmt.Errorf("fetching facility catalog: %w", err)
+	}
+	return facilities, nil
+}
+
+// FacilityNames resolves the slugs and Korean and English names of the catalog, plus util.BuiltinFacilityNames.
+// When the catalog cannot be read the built-in names are still there.
+func (s *FacilityCatalogService) FacilityNames() util.FacilityNames {
+	s.namesMu.Lock()
+	defer s.namesMu.Unlock()
+
+	if s.names != nil && time.Since(s.namesLoadedAt) < facilityNamesTTL {
+		return s.names
+	}
+
+	facilities, err := s.ListFacilityTypes()
+	if err != nil {
+		s.Logger.Warn("Failed to load facility names", zap.Error(err))
+		if s.names != nil {
+			return s.names
+		}
+		return util.BuiltinFacilityNames
+	}
+
+	names := make(util.FacilityNames, len(util.BuiltinFacilityNames)+3*len(facilities))
+	for name, facilityID := range util.BuiltinFacilityNames {
+		names[name] = facilityID
+	}
+	for _, f := range facilities {
+		for _, name := range []string{f.Slug, f.NameKo, f.NameEn} {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				names[name] = f.FacilityID
+			}
+		}
+	}
+
+	s.names, s.namesLoadedAt = names, time.Now()
+	return names
+}
+
+// resetFacilityNames makes the next FacilityNames call read the catalog again.
+func (s *FacilityCatalogService) resetFacilityNames() {
+	s.namesMu.Lock()
+	s.names = nil
+	s.namesMu.Unlock()
+}
+
+func (s *FacilityCatalogService) GetFacilityType(facilityID int) (*model.FacilityType, error) {
+	var facility model.FacilityType
+	if err := s.DB.Get(&facility, selectFacilityTypeQuery, facilityID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFacilityNotFound
+		}
+		return nil, fmt.Errorf("fetching facility %d: %w", facilityID, err)
+	}
+	return &facility, nil
+}
+
+func (s *FacilityCatalogService) CreateFacilityType(req *dto.FacilityTypeRequest) (*model.FacilityType, error) {
+	if err := normalizeFacilityTypeRequest(req); err != nil {
+		return nil, err
+	}
+
+	res, err := s.DB.Exec(insertFacilityTypeQuery, req.Slug, req.Category, req.NameKo, req.NameEn, req.Icon, req.SortOrder)
+	if err != nil {
+		if me, ok := err.(*mysql.MySQLError); ok && me.Number == 1062 {
+			return nil, ErrDuplicateSlug
+		}
+		return nil, fmt.Errorf("inserting facility: %w", err)
+	}
+	facilityID, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLastInsertID, err)
+	}
+
+	s.resetFacilityNames()
+	return s.GetFacilityType(int(facilityID))
+}
+
+// UpdateFacilityType replaces a catalog entry. Markers keep their FacilityID, so they show the new names right away.
+func (s *FacilityCatalogService) UpdateFacilityType(facilityID int, req *dto.FacilityTypeRequest) (*model.FacilityType, error) {
+	if err := normalizeFacilityTypeRequest(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetFacilityType(facilityID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.DB.Exec(updateFacilityTypeQuery, req.Slug, req.Category, req.NameKo, req.NameEn, req.Icon, req.SortOrder, facilityID); err != nil {
+		if me, ok := err.(*mysql.MySQLError); ok && me.Number == 1062 {
+			return nil, ErrDuplicateSlug
+		}
+		return nil, fmt.Errorf("updating facility %d: %w", facilityID, err)
+	}
+
+	s.resetFacilityNames()
+	s.invalidateMarkerFacilities()
+	return s.GetFacilityType(facilityID)
+}
+
+// DeleteFacilityType removes a catalog entry no marker uses.
+func (s *FacilityCatalogService) DeleteFacilityType(facilityID int) error {
+	var usage int
+	if err := s.DB.Get(&usage, countFacilityUsageQuery, facilityID); err != nil {
+		return fmt.Errorf("counting facility usage: %w", err)
+	}
+	if usage > 0 {
+		return fmt.Errorf("facility %d is set on %d markers: %w", facilityID, usage, ErrFacilityInUse)
+	}
+
+	res, err := s.DB.Exec(deleteFacilityTypeQuery, facilityID)
+	if err != nil {
+		return fmt.Errorf("deleting facility %d: %w", facilityID, err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return ErrFacilityNotFound
+	}
+
+	s.resetFacilityNames()
+	s.invalidateMarkerFacilities()
+	return nil
+}
+
+// ValidateFacilityIDs returns ErrUnknownFacility when any of facilityIDs is not in the catalog.
+func (s *FacilityCatalogService) ValidateFacilityIDs(facilityIDs []int) error {
+	if len(facilityIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(selectFacilityIDsQuery, facilityIDs)
+	if err != nil {
+		return fmt.Errorf("building facility query: %w", err)
+	}
+	var known []int
+	if err := s.DB.Select(&known, s.DB.Rebind(query), args...); err != nil {
+		return fmt.Errorf("fetching facility IDs: %w", err)
+	}
+
+	knownSet := make(map[int]struct{}, len(known))
+	for _, id := range known {
+		knownSet[id] = struct{}{}
+	}
+	for _, id := range facilityIDs {
+		if _, ok := knownSet[id]; !ok {
+			return fmt.Errorf("facility %d: %w", id, ErrUnknownFacility)
+		}
+	}
+	return nil
+}
+
+// invalidateMarkerFacilities drops the cached per-marker facility lists, they embed catalog names.
+func (s *FacilityCatalogService) invalidateMarkerFacilities() {
+	if err := s.CacheService.RedisService.ResetAllCache("facilities:*"); err != nil {
+		s.Logger.Warn("Failed to invalidate marker facilities cache", zap.Error(err))
+	}
+}
+
+func normalizeFacilityTypeRequest(req *dto.FacilityTypeRequest) error {
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	req.Category = strings.ToUpper(strings.TrimSpace(req.Category))
+	req.NameKo = strings.TrimSpace(req.NameKo)
+	req.NameEn = strings.TrimSpace(req.NameEn)
+	req.Icon = strings.TrimSpace(req.Icon)
+
+	switch {
+	case !facilitySlugPattern.MatchString(req.Slug) || len(req.Slug) > 50:
+		return fmt.Errorf("%w: slug must be lowercase letters, digits and dashes", ErrInvalidFacility)
+	case req.NameKo == "" || req.NameEn == "":
+		return fmt.Errorf("%w: nameKo and nameEn are required", ErrInvalidFacility)
+	case len(req.NameKo) > 100 || len(req.NameEn) > 100 || len(req.Icon) > 255:
+		return fmt.Errorf("%w: name or icon too long", ErrInvalidFacility)
+	}
+	if _, ok := facilityCategories[req.Category]; !ok {
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidFacility, req.Category)
+	}
+	return nil
+}
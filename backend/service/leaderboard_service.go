@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"github.com/redis/rueidis"
+	"go.uber.org/zap"
+)
+
+// What a leaderboard ranks trainees within
+const (
+	LeaderboardScopeMarker   = "marker"
+	LeaderboardScopeCity     = "city"
+	LeaderboardScopeProvince = "province"
+	LeaderboardScopeNational = "national"
+
+	leaderboardNationalKey = "kr"
+)
+
+const (
+	DefaultLeaderboardSize = 50
+	MaxLeaderboardSize     = 100
+
+	// A ranked check-in that would need a faster trip from the previous one was sent from a spoofed position
+	maxTravelSpeedKmh = 150
+
+	// Redis keeps a leaderboard this long after it was loaded from MySQL, increments in between are applied to both
+	leaderboardCacheTTL = 24 * time.Hour
+
+	leaderboardKeyPrefix = "leaderboard:"
+
+	// Only one check-in per marker and day scores, however many sets were done in between
+	rankedCheckInTodayQuery = `
+SELECT EXISTS(
+	SELECT 1 FROM MarkerCheckIns
+	WHERE UserID = ? AND MarkerID = ? AND Ranked AND CheckedInAt >= ? AND CheckInID <> ?
+)`
+	previousRankedCheckInQuery = `
+SELECT c.CheckedInAt, ST_X(m.Location) AS Latitude, ST_Y(m.Location) AS Longitude
+FROM MarkerCheckIns c
+JOIN Markers m ON c.MarkerID = m.MarkerID
+WHERE c.UserID = ? AND c.Ranked AND c.CheckInID <> ?
+ORDER BY c.CheckedInAt DESC
+LIMIT 1`
+	setCheckInRankedQuery = "UPDATE MarkerCheckIns SET Ranked = TRUE, RankedProvince = ?, RankedCity = ? WHERE CheckInID = ?"
+
+	addLeaderboardScoreQuery = `
+INSERT INTO LeaderboardScores (Scope, ScopeKey, Period, PeriodKey, UserID, Score)
+VALUES (?, ?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE Score = Score + VALUES(Score)`
+	selectLeaderboardScoresQuery = `
+SELECT s.UserID, s.Score, u.Username
+FROM LeaderboardScores s
+JOIN Users u ON s.UserID = u.UserID
+WHERE s.Scope = ? AND s.ScopeKey = ? AND s.Period = ? AND s.PeriodKey = ? AND s.Score > 0
+ORDER BY s.Score DESC, s.UserID`
+	selectLeaderboardUsernamesQuery = "SELECT UserID, Username FROM Users WHERE UserID IN (?)"
+
+	// Scores of a merged marker are added to the survivor's
+	mergeMarkerLeaderboardQuery = `
+INSERT INTO LeaderboardScores (Scope, ScopeKey, Period, PeriodKey, UserID, Score)
+SELECT Scope, ?, Period, PeriodKey, UserID, Score
+FROM LeaderboardScores
+WHERE Scope = 'marker' AND ScopeKey = ?
+ON DUPLICATE KEY UPDATE Score = LeaderboardScores.Score + VALUES(Score)`
+	deleteMarkerLeaderboardQuery = "DELETE FROM LeaderboardScores WHERE Scope = 'marker' AND ScopeKey = ?"
+)
+
+// A missing key is left alone, it is rebuilt from MySQL on the next read and would otherwise start from the increment
+var incrementExistingScoreScript = rueidis.NewLuaScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return redis.call('ZINCRBY', KEYS[1], ARGV[1], ARGV[2])
+end
+return false`)
+
+var ErrInvalidLeaderboard = errors.New("invalid leaderboard")
+
+// Leaderboard identifies one board, the weekly board of a province for instance.
+type Leaderboard struct {
+	Scope     string
+	ScopeKey  string
+	Period    string
+	PeriodKey string
+}
+
+func (b Leaderboard) redisKey() string {
+	return leaderboardKeyPrefix + b.Scope + ":" + b.ScopeKey + ":" + b.Period + ":" + b.PeriodKey
+}
+
+// LeaderboardService ranks trainees by their check-ins, per marker, city, province and nationwide.
+// Scores are persisted in LeaderboardScores and served from Redis sorted sets.
+type LeaderboardService struct {
+	DB     *sqlx.DB
+	Redis  *RedisService
+	Logger *zap.Logger
+}
+
+func NewLeaderboardService(db *sqlx.DB, redis *RedisService, logger *zap.Logger) *LeaderboardService {
+	return &LeaderboardService{
+		DB:     db,
+		Redis:  redis,
+		Logger: logger,
+	}
+}
+
+// NewMarkerLeaderboard returns the board of a marker for the current window of period.
+func NewMarkerLeaderboard(markerID int, period string) Leaderboard {
+	return Leaderboard{
+		Scope:     LeaderboardScopeMarker,
+		ScopeKey:  strconv.Itoa(markerID),
+		Period:    period,
+		PeriodKey: util.LeaderboardPeriodKey(period, time.Now(), util.KST),
+	}
+}
+
+// NewRegionLeaderboard returns the board of a city, a province or, when both are empty, the whole country
+// for the current window of period. A city needs its province since names like 중구 repeat.
+func NewRegionLeaderboard(province, city, period string) (Leaderboard, error) {
+	board := Leaderboard{
+		Scope:     LeaderboardScopeNational,
+		ScopeKey:  leaderboardNationalKey,
+		Period:    period,
+		PeriodKey: util.LeaderboardPeriodKey(period, time.Now(), util.KST),
+	}
+
+	province = strings.TrimSpace(province)
+	city = strings.TrimSpace(city)
+	switch {
+	case province == "" && city != "":
+		return Leaderboard{}, fmt.Errorf("%w: city needs its province", ErrInvalidLeaderboard)
+	case province == "":
+		return board, nil
+	case !util.IsProvince(province):
+		return Leaderboard{}, fmt.Errorf("%w: unknown province", ErrInvalidLeaderboard)
+	}
+
+	board.Scope, board.ScopeKey = LeaderboardScopeProvince, standardizeProvinceForDB(province)
+	if city != "" {
+		board.Scope, board.ScopeKey = LeaderboardScopeCity, board.ScopeKey+" "+city
+	}
+	return board, nil
+}
+
+// checkInRegion returns the province and city board keys a check-in at a marker with address scores on,
+// empty when the address does not have them.
+func checkInRegion(address string) (province, city string) {
+	parts := strings.Fields(address)
+	if len(parts) > 0 {
+		province = standardizeProvinceForDB(parts[0])
+	}
+	if len(parts) > 1 {
+		city = province + " " + parts[1]
+	}
+	return province, city
+}
+
+// checkInLeaderboards lists every board a check-in at a marker in the region of checkInRegion counts on.
+func checkInLeaderboards(markerID int, province, city string, checkedInAt time.Time) []Leaderboard {
+	scopes := [][2]string{
+		{LeaderboardScopeMarker, strconv.Itoa(markerID)},
+		{LeaderboardScopeNational, leaderboardNationalKey},
+	}
+	if province != "" {
+		scopes = append(scopes, [2]string{LeaderboardScopeProvince, province})
+	}
+	if city != "" {
+		scopes = append(scopes, [2]string{LeaderboardScopeCity, city})
+	}
+
+	boards := make([]Leaderboard, 0, len(scopes)*len(util.LeaderboardPeriods))
+	for _, scope := range scopes {
+		for _, period := range util.LeaderboardPeriods {
+			boards = append(boards, Leaderboard{
+				Scope:     scope[0],
+				ScopeKey:  scope[1],
+				Period:    period,
+				PeriodKey: util.LeaderboardPeriodKey(period, checkedInAt, util.KST),
+			})
+		}
+	}
+	return boards
+}
+
+// RankCheckInTx decides inside tx whether a verified check-in scores and, if it does, marks it ranked with
+// its region and adds its point in MySQL. It returns the boards to pass to IncrementScores once tx is committed,
+// nil if it does not score.
+func (s *LeaderboardService) RankCheckInTx(tx *sqlx.Tx, checkInID, markerID, userID int, address string, checkedInAt time.Time, latitude, longitude float64) ([]Leaderboard, error) {
+	// Until tx ends no other check-in of the user can pass the once-a-day check below, CheckIn holds the lock already
+	if err := lockCheckInUserTx(tx, userID); err != nil {
+		return nil, err
+	}
+
+	var rankedToday bool
+	if err := tx.Get(&rankedToday, rankedCheckInTodayQuery, userID, markerID, util.StartOfDay(checkedInAt, util.KST), checkInID); err != nil {
+		return nil, fmt.Errorf("checking ranked check-ins of the day: %w", err)
+	}
+	if rankedToday {
+		return nil, nil
+	}
+
+	var previous struct {
+		CheckedInAt time.Time `db:"CheckedInAt"`
+		Latitude    float64   `db:"Latitude"`
+		Longitude   float64   `db:"Longitude"`
+	}
+	err := tx.Get(&previous, previousRankedCheckInQuery, userID, checkInID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, fmt.Errorf("fetching previous ranked check-in: %w", err)
+	default:
+		distance := util.CalculateDistanceApproximately(previous.Latitude, previous.Longitude, latitude, longitude)
+		if !util.IsPlausibleTravel(distance, checkedInAt.Sub(previous.CheckedInAt), maxTravelSpeedKmh) {
+			s.Logger.Warn("Check-in left off the leaderboards, implausible travel",
+				zap.Int("checkInID", checkInID), zap.Int("userID", userID), zap.Float64("meters", distance))
+			return nil, nil
+		}
+	}
+
+	province, city := checkInRegion(address)
+	if _, err := tx.Exec(setCheckInRankedQuery, province, city, checkInID); err != nil {
+		return nil, fmt.Errorf("marking check-in ranked: %w", err)
+	}
+	boards := checkInLeaderboards(markerID, province, city, checkedInAt)
+	if err := addScoresTx(tx, boards, userID, 1); err != nil {
+		return nil, err
+	}
+	return boards, nil
+}
+
+// UnrankCheckInTx takes back the point of a ranked check-in that is being deleted inside tx, from the boards
+// of the region it was ranked in. It returns the boards to pass to IncrementScores with -1 once tx is committed.
+func (s *LeaderboardService) UnrankCheckInTx(tx *sqlx.Tx, markerID, userID int, province, city string, checkedInAt time.Time) ([]Leaderboard, error) {
+	boards := checkInLeaderboards(markerID, province, city, checkedInAt)
+	if err := addScoresTx(tx, boards, userID, -1); err != nil {
+		return nil, err
+	}
+	return boards, nil
+}
+
+func addScoresTx(tx *sqlx.Tx, boards []Leaderboard, userID, delta int) error {
+	for _, b := range boards {
+		if _, err := tx.Exec(addLeaderboardScoreQuery, b.Scope, b.ScopeKey, b.Period, b.PeriodKey, userID, delta); err != nil {
+			return fmt.Errorf("updating leaderboard score: %w", err)
+		}
+	}
+	return nil
+}
+
+// IncrementScores applies a committed score change to the boards cached in Redis.
+func (s *LeaderboardService) IncrementScores(boards []Leaderboard, userID, delta int) {
+	ctx := context.Background()
+	member := strconv.Itoa(userID)
+	for _, b := range boards {
+		err := incrementExistingScoreScript.Exec(ctx, s.Redis.Core.Client, []string{b.redisKey()}, []string{strconv.Itoa(delta), member}).Error()
+		if err != nil && !rueidis.IsRedisNil(err) {
+			// The cached board is off until it expires, drop it so the next read starts from MySQL
+			s.Logger.Error("Error incrementing leaderboard score", zap.Error(err), zap.String("key", b.redisKey()))
+			_ = s.Redis.ResetCache(b.redisKey())
+		}
+	}
+}
+
+// MergeMarkerScoresTx adds the marker boards of mergedID to those of survivorID inside tx.
+func (s *LeaderboardService) MergeMarkerScoresTx(tx *sqlx.Tx, survivorID, mergedID int) error {
+	if _, err := tx.Exec(mergeMarkerLeaderboardQuery, strconv.Itoa(survivorID), strconv.Itoa(mergedID)); err != nil {
+		return fmt.Errorf("merging marker leaderboards: %w", err)
+	}
+	if _, err := tx.Exec(deleteMarkerLeaderboardQuery, strconv.Itoa(mergedID)); err != nil {
+		return fmt.Errorf("deleting merged marker leaderboards: %w", err)
+	}
+	return nil
+}
+
+// ResetMarkerBoards drops the cached boards of a marker, they are rebuilt from MySQL on the next read.
+func (s *LeaderboardService) ResetMarkerBoards(markerID int) {
+	pattern := leaderboardKeyPrefix + LeaderboardScopeMarker + ":" + strconv.Itoa(markerID) + ":*"
+	if err := s.Redis.ResetAllCache(pattern); err != nil {
+		s.Logger.Error("Error resetting marker leaderboards", zap.Error(err), zap.Int("markerID", markerID))
+	}
+}
+
+// GetLeaderboard returns the top limit trainees of a board, from Redis when it is cached there.
+func (s *LeaderboardService) GetLeaderboard(board Leaderboard, limit int) (*dto.Leaderboard, error) {
+	if limit < 1 || limit > MaxLeaderboardSize {
+		limit = DefaultLeaderboardSize
+	}
+
+	entries, cached, err := s.cachedLeaderboard(board, limit)
+	if err != nil {
+		// Redis being down should not take the leaderboards with it
+		s.Logger.Error("Error reading leaderboard from Redis", zap.Error(err), zap.String("key", board.redisKey()))
+	}
+	if !cached {
+		if entries, err = s.loadLeaderboard(board, limit); err != nil {
+			return nil, err
+		}
+	}
+	rankEntries(entries)
+
+	result := &dto.Leaderboard{
+		Scope:     board.Scope,
+		Period:    board.Period,
+		PeriodKey: board.PeriodKey,
+		Entries:   entries,
+	}
+	switch board.Scope {
+	case LeaderboardScopeMarker:
+		result.MarkerID, _ = strconv.Atoi(board.ScopeKey)
+	case LeaderboardScopeCity, LeaderboardScopeProvince:
+		result.Region = board.ScopeKey
+	}
+	return result, nil
+}
+
+// cachedLeaderboard reads the top of a board from Redis, cached is false when Redis does not have it.
+func (s *LeaderboardService) cachedLeaderboard(board Leaderboard, limit int) ([]dto.LeaderboardEntry, bool, error) {
+	ctx := context.Background()
+	client := s.Redis.Core.Client
+
+	scores, err := client.Do(ctx, client.B().Zrevrangebyscore().
+		Key(board.redisKey()).
+		Max("+inf").
+		Min("(0").
+		Withscores().
+		Limit(0, int64(limit)).
+		Build()).AsZScores()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(scores) == 0 {
+		exists, err := client.Do(ctx, client.B().Exists().Key(board.redisKey()).Build()).AsInt64()
+		return []dto.LeaderboardEntry{}, err == nil && exists == 1, err
+	}
+
+	userIDs := make([]int, 0, len(scores))
+	for _, score := range scores {
+		if userID, err := strconv.Atoi(score.Member); err == nil {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	usernames, err := s.usernames(userIDs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	entries := make([]dto.LeaderboardEntry, 0, len(scores))
+	for _, score := range scores {
+		userID, _ := strconv.Atoi(score.Member)
+		username, ok := usernames[userID]
+		if !ok {
+			continue // deleted since, their rows went with them in MySQL
+		}
+		entries = append(entries, dto.LeaderboardEntry{UserID: userID, Username: username, Score: int(score.Score)})
+	}
+	return entries, true, nil
+}
+
+// loadLeaderboard reads a whole board from MySQL, caches it in Redis and returns its top limit trainees.
+func (s *LeaderboardService) loadLeaderboard(board Leaderboard, limit int) ([]dto.LeaderboardEntry, error) {
+	entries := make([]dto.LeaderboardEntry, 0, limit)
+	if err := s.DB.Select(&entries, selectLeaderboardScoresQuery, board.Scope, board.ScopeKey, board.Period, board.PeriodKey); err != nil {
+		return nil, fmt.Errorf("fetching leaderboard: %w", err)
+	}
+
+	if len(entries) > 0 {
+		ctx := context.Background()
+		client := s.Redis.Core.Client
+
+		zadd := client.B().Zadd().Key(board.redisKey()).ScoreMember()
+		for _, e := range entries {
+			zadd = zadd.ScoreMember(float64(e.Score), strconv.Itoa(e.UserID))
+		}
+		cmds := rueidis.Commands{
+			zadd.Build(),
+			client.B().Expire().Key(board.redisKey()).Seconds(int64(leaderboardCacheTTL / time.Second)).Build(),
+		}
+		for _, resp := range client.DoMulti(ctx, cmds...) {
+			if err := resp.Error(); err != nil {
+				s.Logger.Error("Error caching leaderboard", zap.Error(err), zap.String("key", board.redisKey()))
+				break
+			}
+		}
+	}
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func (s *LeaderboardService) usernames(userIDs []int) (map[int]string, error) {
+	usernames := make(map[int]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return usernames, nil
+	}
+
+	query, args, err := sqlx.In(selectLeaderboardUsernamesQuery, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("building usernames query: %w", err)
+	}
+	var users []struct {
+		UserID   int    `db:"UserID"`
+		Username string `db:"Username"`
+	}
+	if err := s.DB.Select(&users, s.DB.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("fetching usernames: %w", err)
+	}
+	for _, u := range users {
+		usernames[u.UserID] = u.Username
+	}
+	return usernames, nil
+}
+
+// rankEntries numbers entries sorted by score, ties share the rank of the first of them (1, 2, 2, 4).
+func rankEntries(entries []dto.LeaderboardEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Score != entries[j].Score {
+			return entries[i].Score > entries[j].Score
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	for i := range entries {
+		if i > 0 && entries[i].Score == entries[i-1].Score {
+			entries[i].Rank = entries[i-1].Rank
+		} else {
+			entries[i].Rank = i + 1
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Who may use a marker
+const (
+	AccessPublic        = "PUBLIC"
+	AccessPrivate       = "PRIVATE"
+	AccessResidentsOnly = "RESIDENTS_ONLY" // apartment complexes, dormitories and the like
+)
+
+const (
+	// More openings than this in a single day is a typo rather than a schedule
+	maxOpeningPeriodsPerDay = 4
+
+	updateMarkerAccessQuery = "UPDATE Markers SET AccessType = ?, OpeningHours = ?, Lit = ?, Indoor = ?, UpdatedAt = NOW() WHERE MarkerID = ?"
+)
+
+var (
+	ErrInvalidAccess = errors.New("invalid access metadata")
+
+	markerAccessTypes = []string{AccessPublic, AccessPrivate, AccessResidentsOnly}
+	openingHoursDays  = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+)
+
+// MarkerAccessService keeps the structured access metadata of markers: who can use them, when, and in what conditions.
+type MarkerAccessService struct {
+	DB              *sqlx.DB
+	CacheService    *MarkerCacheService
+	RevisionService *MarkerRevisionService
+	Logger          *zap.Logger
+}
+
+func NewMarkerAccessService(db *sqlx.DB, cacheService *MarkerCacheService, revisionService *MarkerRevisionService, logger *zap.Logger) *MarkerAccessService {
+	return &MarkerAccessService{
+		DB:              db,
+		CacheService:    cacheService,
+		RevisionService: revisionService,
+		Logger:          logger,
+	}
+}
+
+// NormalizeAccessType upper-cases s and reports whether it is a known access type.
+func NormalizeAccessType(s string) (string, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for _, accessType := range markerAccessTypes {
+		if s == accessType {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// ParseMarkerAccessFilter reads the access, lit and indoor query parameters, access is a comma separated list of access types.
+func ParseMarkerAccessFilter(accessTypes, lit, indoor string) (dto.MarkerAccessFilter, error) {
+	var filter dto.MarkerAccessFilter
+
+	seen := make(map[string]struct{})
+	for _, raw := range strings.Split(accessTypes, ",") {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		accessType, ok := NormalizeAccessType(raw)
+		if !ok {
+			return dto.MarkerAccessFilter{}, fmt.Errorf("%w: access must be one of %s", ErrInvalidAccess, strings.Join(markerAccessTypes, ", "))
+		}
+		if _, ok := seen[accessType]; !ok {
+			seen[accessType] = struct{}{}
+			filter.AccessTypes = append(filter.AccessTypes, accessType)
+		}
+	}
+	sort.Strings(filter.AccessTypes)
+
+	var err error
+	if filter.Lit, err = parseOptionalBool(lit); err != nil {
+		return dto.MarkerAccessFilter{}, fmt.Errorf("%w: lit must be true or false", ErrInvalidAccess)
+	}
+	if filter.Indoor, err = parseOptionalBool(indoor); err != nil {
+		return dto.MarkerAccessFilter{}, fmt.Errorf("%w: indoor must be true or false", ErrInvalidAccess)
+	}
+	return filter, nil
+}
+
+func parseOptionalBool(s string) (*bool, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// UpdateMarkerAccess replaces the access metadata of a marker, only its owner or an admin may do so.
+func (s *MarkerAccessService) UpdateMarkerAccess(markerID int, access *model.MarkerAccess, userID int, userRole string) error {
+	if err := NormalizeMarkerAccess(access); err != nil {
+		return err
+	}
+
+	var ownerID sql.NullInt64
+	if err := s.DB.Get(&ownerID, getAllMarkersByUserQuery, markerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMarkerNotFound
+		}
+		return fmt.Errorf("checking marker ownership: %w", err)
+	}
+	if userRole != "admin" && int(ownerID.Int64) != userID {
+		return ErrUnauthorized
+	}
+
+	err := s.RevisionService.Track(markerID, RevisionByUser(userID, userRole), func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(updateMarkerAccessQuery, access.AccessType, access.OpeningHours, access.Lit, access.Indoor, markerID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("updating marker access: %w", err)
+	}
+
+	s.CacheService.RemoveMarkerCache(markerID)
+	return nil
+}
+
+// NormalizeMarkerAccess validates access in place, upper-casing the access type and dropping an empty schedule.
+func NormalizeMarkerAccess(access *model.MarkerAccess) error {
+	if access.AccessType != nil {
+		accessType, ok := NormalizeAccessType(*access.AccessType)
+		if !ok {
+			return fmt.Errorf("%w: accessType must be one of %s", ErrInvalidAccess, strings.Join(markerAccessTypes, ", "))
+		}
+		access.AccessType = &accessType
+	}
+
+	if access.OpeningHours == nil {
+		return nil
+	}
+	if len(*access.OpeningHours) == 0 {
+		access.OpeningHours = nil
+		return nil
+	}
+	for day, periods := range *access.OpeningHours {
+		if !isOpeningHoursDay(day) {
+			return fmt.Errorf("%w: unknown day %q, use %s", ErrInvalidAccess, day, strings.Join(openingHoursDays, ", "))
+		}
+		if len(periods) > maxOpeningPeriodsPerDay {
+			return fmt.Errorf("%w: at most %d periods a day", ErrInvalidAccess, maxOpeningPeriodsPerDay)
+		}
+		for _, p := range periods {
+			if !isClockTime(p.Open, false) || !isClockTime(p.Close, true) || p.Open == p.Close {
+				return fmt.Errorf("%w: invalid period %s-%s on %s, use HH:MM", ErrInvalidAccess, p.Open, p.Close, day)
+			}
+		}
+	}
+	return nil
+}
+
+func isOpeningHoursDay(day string) bool {
+	for _, d := range openingHoursDays {
+		if day == d {
+			return true
+		}
+	}
+	return false
+}
+
+// isClockTime reports whether s is a 24-hour "HH:MM", "24:00" only counts as a closing time.
+func isClockTime(s string, closing bool) bool {
+	if closing && s == "24:00" {
+		return true
+	}
+	_, err := time.Parse("15:04", s)
+	return err == nil && len(s) == len("15:04")
+}
+
+// accessFilterCondition turns filter into an AND condition on the Markers alias m, it is empty for an empty filter.
+func accessFilterCondition(filter dto.MarkerAccessFilter) (string, []any) {
+	var sb strings.Builder
+	var args []any
+
+	if len(filter.AccessTypes) > 0 {
+		sb.WriteString("\nAND m.AccessType IN (?" + strings.Repeat(", ?", len(filter.AccessTypes)-1) + ")")
+		for _, accessType := range filter.AccessTypes {
+			args = append(args, accessType)
+		}
+	}
+	if filter.Lit != nil {
+		sb.WriteString("\nAND m.Lit = ?")
+		args = append(args, *filter.Lit)
+	}
+	if filter.Indoor != nil {
+		sb.WriteString("\nAND m.Indoor = ?")
+		args = append(args, *filter.Indoor)
+	}
+	return sb.String(), args
+}
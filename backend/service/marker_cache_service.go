@@ -9,7 +9,7 @@ import (
 
 	"github.com/Alfex4936/chulbong-kr/dto"
 	"github.com/Alfex4936/chulbong-kr/dto/kakao"
-	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/Alfex4936/chulbong-kr/util"
 	sonic "github.com/bytedance/sonic"
 	gocache "github.com/eko/gocache/lib/v4/cache"
 	"github.com/eko/gocache/lib/v4/store"
@@ -20,9 +20,25 @@ import (
 
 // control redis cache related to markers
 
+const (
+	// Encodings of GET /markers, each cached on its own and invalidated together
+	MarkersFormatJSON     = "json"
+	MarkersFormatProtobuf = "protobuf"
+	MarkersFormatMsgpack  = "msgpack"
+
+	allMarkersKey = "all_markers"
+
+	// Tile keys carry a generation, invalidating every tile is one INCR and old generations expire with the TTL
+	markerTileKey           = "marker_tiles:%d:%d:%d:%d"
+	markerTileGenerationKey = "marker_tiles:generation"
+	// Tiles are invalidated on marker changes, the TTL only covers changes that bypass this service
+	markerTileTTL = time.Hour
+)
+
 type MarkerCacheService struct {
 	MarkerWeatherCache *gocache.Cache[[]byte]
 	RedisService       *RedisService
+	ClusterService     *MarkerClusterService
 
 	LocalCacheStorage *ristretto_store.RistrettoStore
 }
@@ -31,11 +47,13 @@ func NewMarkerCacheService(
 
 	localCacheStorage *ristretto_store.RistrettoStore,
 	redisService *RedisService,
+	clusterService *MarkerClusterService,
 ) *MarkerCacheService {
 	byteCache := gocache.New[[]byte](localCacheStorage)
 
 	return &MarkerCacheService{
 		RedisService:       redisService,
+		ClusterService:     clusterService,
 		MarkerWeatherCache: byteCache,
 	}
 }
@@ -55,40 +73,64 @@ func RegisterMarkerCacheService(lifecycle fx.Lifecycle, service *MarkerCacheServ
 // func
 
 func (s *MarkerCacheService) GetAllMarkers() ([]byte, error) {
+	return s.GetAllMarkersAs(MarkersFormatJSON)
+}
+
+// GetAllMarkersAs returns the cached full marker list in one of the MarkersFormat encodings.
+func (s *MarkerCacheService) GetAllMarkersAs(format string) ([]byte, error) {
 	// Retrieve the cached markers from Redis as a byte array
 	ctx := context.Background()
-	getCmd := s.RedisService.Core.Client.B().Get().Key("all_markers").Build()
+	getCmd := s.RedisService.Core.Client.B().Get().Key(allMarkersCacheKey(format)).Build()
 
 	result, err := s.RedisService.Core.Client.Do(ctx, getCmd).AsBytes()
 	if err != nil {
 		return nil, err // Cache miss or error
 	}
-	
+
 	// Check for null or empty values
 	if len(result) == 0 || string(result) == "null" {
 		// Invalidate this problematic cache entry
 		s.InvalidateFullMarkersCache()
 		return nil, fmt.Errorf("invalid cache data")
 	}
-	
+
 	return result, nil
 }
 
 // Set the full cache (all markers as a byte array)
 func (s *MarkerCacheService) SetFullMarkersCache(markersJSON []byte) error {
+	return s.SetFullMarkersCacheAs(MarkersFormatJSON, markersJSON)
+}
+
+// SetFullMarkersCacheAs caches the full marker list encoded as format.
+func (s *MarkerCacheService) SetFullMarkersCacheAs(format string, markers []byte) error {
 	// Validate that we're not storing empty or null data
-	if len(markersJSON) == 0 || string(markersJSON) == "null" {
+	if len(markers) == 0 || string(markers) == "null" {
 		return fmt.Errorf("attempted to cache empty or null markers data")
 	}
 
 	ctx := context.Background()
-	setCmd := s.RedisService.Core.Client.B().Set().Key("all_markers").Value(rueidis.BinaryString(markersJSON)).Ex(time.Hour * 24).Build()
+	setCmd := s.RedisService.Core.Client.B().Set().Key(allMarkersCacheKey(format)).Value(rueidis.BinaryString(markers)).Ex(time.Hour * 24).Build()
 	return s.RedisService.Core.Client.Do(ctx, setCmd).Error()
 }
 
-// Invalidate full cache
+// Invalidate full cache, in every encoding
 func (s *MarkerCacheService) InvalidateFullMarkersCache() error {
-	return s.RedisService.ResetCache("all_markers")
+	ctx := context.Background()
+	delCmd := s.RedisService.Core.Client.B().Del().Key(
+		allMarkersCacheKey(MarkersFormatJSON),
+		allMarkersCacheKey(MarkersFormatProtobuf),
+		allMarkersCacheKey(MarkersFormatMsgpack),
+	).Build()
+	return s.RedisService.Core.Client.Do(ctx, delCmd).Error()
+}
+
+// allMarkersCacheKey keeps JSON on the original key so caches written before binary formats existed stay valid.
+func allMarkersCacheKey(format string) string {
+	if format == MarkersFormatJSON {
+		return allMarkersKey
+	}
+	return allMarkersKey + ":" + format
 }
 
 // Set individual marker in Redis
@@ -109,6 +151,10 @@ func (s *MarkerCacheService) RemoveMarkerCache(markerID int) error {
 }
 
 func (s *MarkerCacheService) AddMarker(markerID int, marker dto.MarkerSimple) error {
+	// Tiles and clusters first, the marker cache below is only written if it is not there yet
+	s.InvalidateMarkerTiles()
+	s.ClusterService.MarkerChanged(markerID)
+
 	// Cache the individual marker
 	if err := s.SetMarkerCache(markerID, marker); err != nil {
 		return err
@@ -123,6 +169,9 @@ func (s *MarkerCacheService) AddMarker(markerID int, marker dto.MarkerSimple) er
 }
 
 func (s *MarkerCacheService) UpdateMarker(markerID int, marker dto.MarkerSimple) error {
+	s.InvalidateMarkerTiles()
+	s.ClusterService.MarkerChanged(markerID)
+
 	// Update the individual marker cache
 	if err := s.SetMarkerCache(markerID, marker); err != nil {
 		return err
@@ -141,6 +190,38 @@ func (s *MarkerCacheService) RemoveMarker(markerID int) {
 
 	// Invalidate the full markers cache
 	s.InvalidateFullMarkersCache()
+
+	s.InvalidateMarkerTiles()
+	s.ClusterService.MarkerChanged(markerID)
+}
+
+// GetMarkerTile returns a cached vector tile, an empty tile is a hit as well.
+// The returned generation is the one a tile built after a miss has to be stored under with SetMarkerTile,
+// so a tile read from the DB before an invalidation is never cached as current.
+func (s *MarkerCacheService) GetMarkerTile(z, x, y int) ([]byte, int64, error) {
+	ctx := context.Background()
+	client := s.RedisService.Core.Client
+
+	generation, err := client.Do(ctx, client.B().Get().Key(markerTileGenerationKey).Build()).AsInt64()
+	if err != nil && !rueidis.IsRedisNil(err) {
+		return nil, 0, err
+	}
+
+	tile, err := client.Do(ctx, client.B().Get().Key(fmt.Sprintf(markerTileKey, generation, z, x, y)).Build()).AsBytes()
+	return tile, generation, err
+}
+
+func (s *MarkerCacheService) SetMarkerTile(generation int64, z, x, y int, tile []byte) error {
+	ctx := context.Background()
+	setCmd := s.RedisService.Core.Client.B().Set().Key(fmt.Sprintf(markerTileKey, generation, z, x, y)).Value(rueidis.BinaryString(tile)).Ex(markerTileTTL).Build()
+	return s.RedisService.Core.Client.Do(ctx, setCmd).Error()
+}
+
+// InvalidateMarkerTiles drops every cached vector tile by moving on to the next tile generation
+func (s *MarkerCacheService) InvalidateMarkerTiles() error {
+	ctx := context.Background()
+	incrCmd := s.RedisService.Core.Client.B().Incr().Key(markerTileGenerationKey).Build()
+	return s.RedisService.Core.Client.Do(ctx, incrCmd).Error()
 }
 
 // AddMarkerIDToSet adds a marker ID to the Redis set "all_markers_set"
@@ -281,7 +362,7 @@ func (s *MarkerCacheService) RemoveMarkerFromFavorites(userID int, markerID int)
 
 // facilities
 // AddFacilitiesCache adds facilities data for a specific marker to the cache
-func (s *MarkerCacheService) AddFacilitiesCache(markerID int, facilities []model.Facility) error {
+func (s *MarkerCacheService) AddFacilitiesCache(markerID int, facilities []dto.MarkerFacilityDetail) error {
 	// Cache the facilities data
 	facilitiesJSON, err := sonic.Marshal(facilities)
 	if err != nil {
@@ -291,14 +372,14 @@ func (s *MarkerCacheService) AddFacilitiesCache(markerID int, facilities []model
 }
 
 // GetFacilitiesCache retrieves the facilities data for a specific marker from the cache
-func (s *MarkerCacheService) GetFacilitiesCache(markerID int) (*[]model.Facility, error) {
+func (s *MarkerCacheService) GetFacilitiesCache(markerID int) (*[]dto.MarkerFacilityDetail, error) {
 	var facilitiesData []byte
 	err := s.RedisService.GetCacheEntry(fmt.Sprintf("facilities:%d", markerID), &facilitiesData)
 	if err != nil || len(facilitiesData) == 0 {
 		return nil, err
 	}
 
-	var facilities []model.Facility
+	var facilities []dto.MarkerFacilityDetail
 	if err := sonic.Unmarshal(facilitiesData, &facilities); err != nil {
 		return nil, err
 	}
@@ -425,7 +506,19 @@ func (s *MarkerCacheService) GetWcongCache(latitude, longitude float64) (*kakao.
 }
 
 // close
-// GetUserProfileCache retrieves the cached user profile from Redis as byte data.
+// CloseMarkersCacheKey is the cache key of one page of close markers, filtered pages get their own keys.
+func (s *MarkerCacheService) CloseMarkersCacheKey(lat, lng float64, distance, page, pageSize int, facilities util.FacilityFilter, access dto.MarkerAccessFilter) string {
+	cacheKey := fmt.Sprintf("close_markers:%f:%f:%d:%d:%d", lat, lng, distance, page, pageSize)
+	if !facilities.IsEmpty() {
+		cacheKey += ":" + facilities.CacheKey()
+	}
+	if !access.IsEmpty() {
+		cacheKey += ":" + access.CacheKey()
+	}
+	return cacheKey
+}
+
+// GetCloseMarkersCache retrieves a page of close markers cached under CloseMarkersCacheKey as byte data.
 func (s *MarkerCacheService) GetCloseMarkersCache(cacheKey string) ([]byte, error) {
 	ctx := context.Background()
 	getCmd := s.RedisService.Core.Client.B().Get().Key(cacheKey).Build()
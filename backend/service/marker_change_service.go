@@ -0,0 +1,233 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultMarkerChangesLimit = 500
+	MaxMarkerChangesLimit     = 2000
+
+	// how long change rows are kept, clients with an older cursor are told to reset
+	markerChangeRetentionDays = 30
+
+	// queued changes numbered per sequencing transaction
+	markerChangeSequenceBatch = 1000
+
+	queueMarkerChangeQuery = "INSERT INTO MarkerChangeQueue (MarkerID) VALUES (?)"
+
+	hasQueuedMarkerChangesQuery = "SELECT EXISTS (SELECT 1 FROM MarkerChangeQueue)"
+
+	lockMarkerChangeSequenceQuery = "SELECT LastSeq FROM MarkerChangeSequence WHERE ID = 1 FOR UPDATE"
+
+	// A plain read, rows of transactions that have not committed yet are not seen and wait for a later round
+	getQueuedMarkerChangesQuery = "SELECT QueueID, MarkerID FROM MarkerChangeQueue ORDER BY QueueID LIMIT ?"
+
+	insertMarkerChangeQuery = "INSERT INTO MarkerChanges (Seq, MarkerID) VALUES (?, ?)"
+
+	deleteQueuedMarkerChangesQuery = "DELETE FROM MarkerChangeQueue WHERE QueueID IN (?)"
+
+	updateMarkerChangeSequenceQuery = "UPDATE MarkerChangeSequence SET LastSeq = ? WHERE ID = 1"
+
+	getMarkerChangeBoundsQuery = `
+SELECT COALESCE(MIN(Seq), 0) AS MinSeq, COALESCE(MAX(Seq), 0) AS MaxSeq
+FROM MarkerChanges`
+
+	getMarkerChangesQuery = `
+SELECT Seq, MarkerID FROM MarkerChanges
+WHERE Seq > ?
+ORDER BY Seq
+LIMIT ?`
+
+	getChangedMarkersQuery = `
+SELECT m.MarkerID,
+	ST_X(m.Location) AS Latitude,
+	ST_Y(m.Location) AS Longitude,
+	EXISTS (SELECT 1 FROM Photos p WHERE p.MarkerID = m.MarkerID AND p.DeletedAt IS NULL) AS HasPhoto,
+	m.Status
+FROM Markers m
+WHERE m.MarkerID IN (?) AND m.DeletedAt IS NULL`
+
+	// The newest row is always kept, it is what tells a caught-up client apart from one that fell behind
+	pruneMarkerChangesQuery = `
+DELETE FROM MarkerChanges
+WHERE ChangedAt < NOW() - INTERVAL ? DAY
+	AND Seq < (SELECT MaxSeq FROM (SELECT MAX(Seq) AS MaxSeq FROM MarkerChanges) latest)`
+)
+
+type markerChangeRow struct {
+	Seq      int64 `db:"Seq"`
+	MarkerID int   `db:"MarkerID"`
+}
+
+type queuedMarkerChangeRow struct {
+	QueueID  int64 `db:"QueueID"`
+	MarkerID int   `db:"MarkerID"`
+}
+
+// MarkerChangeService serves the marker change log so clients can keep a local copy of all markers in sync.
+type MarkerChangeService struct {
+	DB     *sqlx.DB
+	Logger *zap.Logger
+}
+
+func NewMarkerChangeService(db *sqlx.DB, logger *zap.Logger) *MarkerChangeService {
+	return &MarkerChangeService{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+// recordMarkerChanges queues the markers for the change log inside tx.
+// They get their Seq once tx has committed, see sequenceChanges.
+func recordMarkerChanges(tx *sqlx.Tx, markerIDs ...int) error {
+	for _, markerID := range markerIDs {
+		if _, err := tx.Exec(queueMarkerChangeQuery, markerID); err != nil {
+			return fmt.Errorf("recording marker change: %w", err)
+		}
+	}
+	return nil
+}
+
+// sequenceChanges moves committed changes from the queue to the change log.
+// Seq used to be taken at insert, so a long transaction could commit a Seq below a cursor clients
+// had already moved past. Numbering after the commit, one sequencing transaction at a time,
+// hands out Seq in commit order instead.
+func (s *MarkerChangeService) sequenceChanges() error {
+	var queued bool
+	if err := s.DB.Get(&queued, hasQueuedMarkerChangesQuery); err != nil {
+		return fmt.Errorf("checking queued marker changes: %w", err)
+	}
+	if !queued {
+		return nil
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Taken before the first plain read, so the snapshot includes whatever the previous holder numbered
+	var lastSeq int64
+	if err := tx.Get(&lastSeq, lockMarkerChangeSequenceQuery); err != nil {
+		return fmt.Errorf("locking marker change sequence: %w", err)
+	}
+
+	var rows []queuedMarkerChangeRow
+	if err := tx.Select(&rows, getQueuedMarkerChangesQuery, markerChangeSequenceBatch); err != nil {
+		return fmt.Errorf("fetching queued marker changes: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	queueIDs := make([]int64, len(rows))
+	for i, row := range rows {
+		lastSeq++
+		if _, err := tx.Exec(insertMarkerChangeQuery, lastSeq, row.MarkerID); err != nil {
+			return fmt.Errorf("inserting marker change: %w", err)
+		}
+		queueIDs[i] = row.QueueID
+	}
+
+	query, args, err := sqlx.In(deleteQueuedMarkerChangesQuery, queueIDs)
+	if err != nil {
+		return fmt.Errorf("building queue delete query: %w", err)
+	}
+	if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("deleting queued marker changes: %w", err)
+	}
+	if _, err := tx.Exec(updateMarkerChangeSequenceQuery, lastSeq); err != nil {
+		return fmt.Errorf("updating marker change sequence: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetChanges returns the markers changed after since, at most limit change rows at a time.
+// A negative since, or one that points at pruned or unknown changes, results in a reset.
+func (s *MarkerChangeService) GetChanges(since int64, limit int) (*dto.MarkerChangesResponse, error) {
+	// Whatever is not numbered yet is picked up by the next call
+	if err := s.sequenceChanges(); err != nil {
+		s.Logger.Warn("Failed to sequence marker changes", zap.Error(err))
+	}
+
+	var bounds struct {
+		MinSeq int64 `db:"MinSeq"`
+		MaxSeq int64 `db:"MaxSeq"`
+	}
+	if err := s.DB.Get(&bounds, getMarkerChangeBoundsQuery); err != nil {
+		return nil, fmt.Errorf("fetching marker change bounds: %w", err)
+	}
+
+	response := &dto.MarkerChangesResponse{
+		Upserts: make([]dto.MarkerSimple, 0),
+		Deleted: make([]int, 0),
+		Cursor:  since,
+	}
+
+	// Rows up to MinSeq-1 may have been pruned, and a cursor past MaxSeq was not handed out by this database
+	if since < 0 || (bounds.MinSeq > 0 && since < bounds.MinSeq-1) || since > bounds.MaxSeq {
+		response.Reset = true
+		response.Cursor = bounds.MaxSeq
+		return response, nil
+	}
+
+	var rows []markerChangeRow
+	if err := s.DB.Select(&rows, getMarkerChangesQuery, since, limit+1); err != nil {
+		return nil, fmt.Errorf("fetching marker changes: %w", err)
+	}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		response.HasMore = true
+	}
+	if len(rows) == 0 {
+		return response, nil
+	}
+	response.Cursor = rows[len(rows)-1].Seq
+
+	// A marker changed several times is sent once, with its current state
+	markerIDs := make([]int, 0, len(rows))
+	seen := make(map[int]struct{}, len(rows))
+	for _, row := range rows {
+		if _, ok := seen[row.MarkerID]; !ok {
+			seen[row.MarkerID] = struct{}{}
+			markerIDs = append(markerIDs, row.MarkerID)
+		}
+	}
+
+	query, args, err := sqlx.In(getChangedMarkersQuery, markerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("building changed markers query: %w", err)
+	}
+	if err := s.DB.Select(&response.Upserts, s.DB.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("fetching changed markers: %w", err)
+	}
+
+	// Whatever is not there anymore was deleted or merged away
+	live := make(map[int]struct{}, len(response.Upserts))
+	for _, m := range response.Upserts {
+		live[m.MarkerID] = struct{}{}
+	}
+	for _, markerID := range markerIDs {
+		if _, ok := live[markerID]; !ok {
+			response.Deleted = append(response.Deleted, markerID)
+		}
+	}
+
+	return response, nil
+}
+
+// PruneChanges deletes change rows older than retentionDays.
+func (s *MarkerChangeService) PruneChanges(retentionDays int) (int64, error) {
+	res, err := s.DB.Exec(pruneMarkerChangesQuery, retentionDays)
+	if err != nil {
+		return 0, fmt.Errorf("pruning marker changes: %w", err)
+	}
+	return res.RowsAffected()
+}
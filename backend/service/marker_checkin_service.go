@@ -0,0 +1,401 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Exercises a workout log can hold
+const (
+	ExercisePullUp          = "PULL_UP"
+	ExerciseChinUp          = "CHIN_UP"
+	ExerciseMuscleUp        = "MUSCLE_UP"
+	ExerciseDip             = "DIP"
+	ExercisePushUp          = "PUSH_UP"
+	ExerciseInvertedRow     = "INVERTED_ROW"
+	ExerciseHangingLegRaise = "HANGING_LEG_RAISE"
+	ExerciseOther           = "OTHER"
+)
+
+const (
+	// A check-in sent from within this distance of the marker counts as a verified visit
+	CheckInVerifyRadius = 100.0 // meters
+
+	// The same marker can only be checked in once in this window, so a double tap does not count twice
+	checkInCooldownMinutes = 60
+
+	maxCheckInExercises = 10
+	maxExerciseSets     = 50
+	maxExerciseReps     = 500
+	maxCheckInNote      = 255 // characters
+
+	getCheckInMarkerQuery = "SELECT ST_X(Location) AS Latitude, ST_Y(Location) AS Longitude, COALESCE(Address, '') AS Address FROM Markers WHERE MarkerID = ? AND DeletedAt IS NULL"
+	// Check-ins of one user run one at a time, so two requests cannot both pass the cooldown check
+	lockCheckInUserQuery = "SELECT UserID FROM Users WHERE UserID = ? FOR UPDATE"
+	recentCheckInQuery   = `
+SELECT EXISTS(
+	SELECT 1 FROM MarkerCheckIns
+	WHERE UserID = ? AND MarkerID = ? AND CheckedInAt > NOW() - INTERVAL ? MINUTE
+)`
+
+	insertCheckInQuery = `
+INSERT INTO MarkerCheckIns (MarkerID, UserID, Latitude, Longitude, DistanceMeters, Verified, Note)
+VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''))`
+	insertCheckInExerciseQuery = "INSERT INTO CheckInExercises (CheckInID, Exercise, Sets, Reps, SortOrder) VALUES (?, ?, ?, ?, ?)"
+	deleteCheckInQuery         = "DELETE FROM MarkerCheckIns WHERE CheckInID = ? AND UserID = ?"
+	getDeletedCheckInQuery     = `
+SELECT c.MarkerID, c.Ranked, c.RankedProvince, c.RankedCity, c.CheckedInAt, COALESCE(m.Address, '') AS Address
+FROM MarkerCheckIns c
+JOIN Markers m ON c.MarkerID = m.MarkerID
+WHERE c.CheckInID = ? AND c.UserID = ?
+FOR UPDATE`
+
+	selectCheckInQuery = `
+SELECT CheckInID, MarkerID, UserID, Latitude, Longitude, DistanceMeters, Verified, Ranked, Note, CheckedInAt
+FROM MarkerCheckIns
+WHERE CheckInID = ?`
+	countUserCheckInsQuery  = "SELECT COUNT(*) FROM MarkerCheckIns WHERE UserID = ?"
+	selectUserCheckInsQuery = `
+SELECT c.CheckInID, c.MarkerID, c.UserID, c.Latitude, c.Longitude, c.DistanceMeters, c.Verified, c.Ranked, c.Note, c.CheckedInAt,
+	m.Address
+FROM MarkerCheckIns c
+JOIN Markers m ON c.MarkerID = m.MarkerID
+WHERE c.UserID = ?
+ORDER BY c.CheckedInAt DESC, c.CheckInID DESC
+LIMIT ? OFFSET ?`
+	selectCheckInExercisesQuery = `
+SELECT CheckInID, Exercise, Sets, Reps
+FROM CheckInExercises
+WHERE CheckInID IN (?)
+ORDER BY CheckInID, SortOrder`
+
+	selectCheckInTimesQuery  = "SELECT CheckedInAt FROM MarkerCheckIns WHERE UserID = ?"
+	selectCheckInTotalsQuery = `
+SELECT COUNT(*) AS TotalCheckIns,
+	COALESCE(SUM(Verified), 0) AS VerifiedCheckIns,
+	COUNT(DISTINCT MarkerID) AS MarkersVisited,
+	MAX(CheckedInAt) AS LastCheckInAt
+FROM MarkerCheckIns
+WHERE UserID = ?`
+	selectExerciseTotalsQuery = `
+SELECT e.Exercise, SUM(e.Sets) AS Sets, SUM(e.Sets * e.Reps) AS Reps, COUNT(DISTINCT e.CheckInID) AS Workouts
+FROM CheckInExercises e
+JOIN MarkerCheckIns c ON e.CheckInID = c.CheckInID
+WHERE c.UserID = ?
+GROUP BY e.Exercise
+ORDER BY Reps DESC`
+)
+
+var (
+	ErrInvalidCheckIn  = errors.New("invalid check-in")
+	ErrCheckInTooSoon  = errors.New("already checked in at this marker recently")
+	ErrCheckInNotFound = errors.New("check-in not found")
+
+	checkInExercises = []string{
+		ExercisePullUp, ExerciseChinUp, ExerciseMuscleUp, ExerciseDip,
+		ExercisePushUp, ExerciseInvertedRow, ExerciseHangingLegRaise, ExerciseOther,
+	}
+)
+
+// MarkerCheckInService records workouts of users at markers, the visited signal next to anonymous clicks.
+type MarkerCheckInService struct {
+	DB                 *sqlx.DB
+	CacheService       *MarkerCacheService
+	LeaderboardService *LeaderboardService
+	BadgeService       *BadgeService
+	Logger             *zap.Logger
+}
+
+func NewMarkerCheckInService(db *sqlx.DB, cacheService *MarkerCacheService, leaderboardService *LeaderboardService, badgeService *BadgeService, logger *zap.Logger) *MarkerCheckInService {
+	return &MarkerCheckInService{
+		DB:                 db,
+		CacheService:       cacheService,
+		LeaderboardService: leaderboardService,
+		BadgeService:       badgeService,
+		Logger:             logger,
+	}
+}
+
+// CheckIn records that the user trains at a marker. It is verified when the request carries a position
+// within CheckInVerifyRadius of the marker.
+func (s *MarkerCheckInService) CheckIn(markerID, userID int, req *dto.CheckInRequest) (*model.MarkerCheckIn, error) {
+	if err := normalizeCheckInRequest(req); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	var marker struct {
+		Address   string  `db:"Address"`
+		Latitude  float64 `db:"Latitude"`
+		Longitude float64 `db:"Longitude"`
+	}
+	if err := tx.Get(&marker, getCheckInMarkerQuery, markerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMarkerNotFound
+		}
+		return nil, fmt.Errorf("fetching marker: %w", err)
+	}
+
+	if err := lockCheckInUserTx(tx, userID); err != nil {
+		return nil, err
+	}
+
+	var recent bool
+	if err := tx.Get(&recent, recentCheckInQuery, userID, markerID, checkInCooldownMinutes); err != nil {
+		return nil, fmt.Errorf("checking recent check-ins: %w", err)
+	}
+	if recent {
+		return nil, ErrCheckInTooSoon
+	}
+
+	var distance *float64
+	verified := false
+	if req.Latitude != nil {
+		d := util.CalculateDistanceApproximately(*req.Latitude, *req.Longitude, marker.Latitude, marker.Longitude)
+		distance = &d
+		verified = d <= CheckInVerifyRadius
+	}
+
+	res, err := tx.Exec(insertCheckInQuery, markerID, userID, req.Latitude, req.Longitude, distance, verified, req.Note)
+	if err != nil {
+		return nil, fmt.Errorf("inserting check-in: %w", err)
+	}
+	checkInID, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrLastInsertID, err)
+	}
+	for i, e := range req.Exercises {
+		if _, err := tx.Exec(insertCheckInExerciseQuery, checkInID, e.Exercise, e.Sets, e.Reps, i); err != nil {
+			return nil, fmt.Errorf("inserting check-in exercise: %w", err)
+		}
+	}
+
+	var checkIn model.MarkerCheckIn
+	if err := tx.Get(&checkIn, selectCheckInQuery, checkInID); err != nil {
+		return nil, fmt.Errorf("fetching check-in: %w", err)
+	}
+
+	// Only verified check-ins can score on the leaderboards
+	var boards []Leaderboard
+	if verified {
+		boards, err = s.LeaderboardService.RankCheckInTx(tx, checkIn.CheckInID, markerID, userID, marker.Address, checkIn.CheckedInAt, marker.Latitude, marker.Longitude)
+		if err != nil {
+			return nil, err
+		}
+		checkIn.Ranked = boards != nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+
+	// A verified visit shows up in the visitor count of the marker details
+	if verified {
+		s.CacheService.RemoveMarkerCache(markerID)
+		s.BadgeService.Publish(EventCheckedIn, userID)
+	}
+	if checkIn.Ranked {
+		s.LeaderboardService.IncrementScores(boards, userID, 1)
+	}
+
+	checkIn.Exercises = make([]model.CheckInExercise, len(req.Exercises))
+	for i, e := range req.Exercises {
+		e.CheckInID = int(checkInID)
+		checkIn.Exercises[i] = e
+	}
+	return &checkIn, nil
+}
+
+// lockCheckInUserTx takes the user's row lock until tx ends.
+func lockCheckInUserTx(tx *sqlx.Tx, userID int) error {
+	var lockedID int
+	if err := tx.Get(&lockedID, lockCheckInUserQuery, userID); err != nil {
+		return fmt.Errorf("locking user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// DeleteCheckIn removes one of the user's own check-ins with its workout log, taking back its leaderboard point.
+func (s *MarkerCheckInService) DeleteCheckIn(checkInID, userID int) error {
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	var checkIn struct {
+		CheckedInAt    time.Time      `db:"CheckedInAt"`
+		RankedProvince sql.NullString `db:"RankedProvince"`
+		RankedCity     sql.NullString `db:"RankedCity"`
+		Address        string         `db:"Address"`
+		MarkerID       int            `db:"MarkerID"`
+		Ranked         bool           `db:"Ranked"`
+	}
+	if err := tx.Get(&checkIn, getDeletedCheckInQuery, checkInID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCheckInNotFound
+		}
+		return fmt.Errorf("fetching check-in: %w", err)
+	}
+
+	if _, err := tx.Exec(deleteCheckInQuery, checkInID, userID); err != nil {
+		return fmt.Errorf("deleting check-in: %w", err)
+	}
+	var boards []Leaderboard
+	if checkIn.Ranked {
+		province, city := checkIn.RankedProvince.String, checkIn.RankedCity.String
+		if !checkIn.RankedProvince.Valid {
+			// Ranked before the region was stored, the current address is the best guess
+			province, city = checkInRegion(checkIn.Address)
+		}
+		if boards, err = s.LeaderboardService.UnrankCheckInTx(tx, checkIn.MarkerID, userID, province, city, checkIn.CheckedInAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+
+	if checkIn.Ranked {
+		s.LeaderboardService.IncrementScores(boards, userID, -1)
+	}
+	return nil
+}
+
+// GetCheckIns lists the user's check-ins with their workout logs, newest first.
+func (s *MarkerCheckInService) GetCheckIns(userID, page, pageSize int) (*dto.CheckInList, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := s.DB.Get(&total, countUserCheckInsQuery, userID); err != nil {
+		return nil, fmt.Errorf("counting check-ins: %w", err)
+	}
+
+	checkIns := make([]dto.CheckInWithMarker, 0, pageSize)
+	if err := s.DB.Select(&checkIns, selectUserCheckInsQuery, userID, pageSize, offset); err != nil {
+		return nil, fmt.Errorf("fetching check-ins: %w", err)
+	}
+
+	if len(checkIns) > 0 {
+		checkInIDs := make([]int, len(checkIns))
+		byID := make(map[int]*dto.CheckInWithMarker, len(checkIns))
+		for i := range checkIns {
+			checkIns[i].Exercises = make([]model.CheckInExercise, 0)
+			checkInIDs[i] = checkIns[i].CheckInID
+			byID[checkIns[i].CheckInID] = &checkIns[i]
+		}
+
+		query, args, err := sqlx.In(selectCheckInExercisesQuery, checkInIDs)
+		if err != nil {
+			return nil, fmt.Errorf("building check-in exercises query: %w", err)
+		}
+		var exercises []model.CheckInExercise
+		if err := s.DB.Select(&exercises, s.DB.Rebind(query), args...); err != nil {
+			return nil, fmt.Errorf("fetching check-in exercises: %w", err)
+		}
+		for _, e := range exercises {
+			if checkIn, ok := byID[e.CheckInID]; ok {
+				checkIn.Exercises = append(checkIn.Exercises, e)
+			}
+		}
+	}
+
+	return &dto.CheckInList{
+		CheckIns:      checkIns,
+		CurrentPage:   page,
+		TotalPages:    int(math.Ceil(float64(total) / float64(pageSize))),
+		TotalCheckIns: total,
+	}, nil
+}
+
+// GetCheckInStats sums up the user's training: streaks, visits and totals per exercise.
+func (s *MarkerCheckInService) GetCheckInStats(userID int) (*dto.CheckInStats, error) {
+	var totals struct {
+		LastCheckInAt    sql.NullTime `db:"LastCheckInAt"`
+		TotalCheckIns    int          `db:"TotalCheckIns"`
+		VerifiedCheckIns int          `db:"VerifiedCheckIns"`
+		MarkersVisited   int          `db:"MarkersVisited"`
+	}
+	if err := s.DB.Get(&totals, selectCheckInTotalsQuery, userID); err != nil {
+		return nil, fmt.Errorf("fetching check-in totals: %w", err)
+	}
+
+	var times []time.Time
+	if err := s.DB.Select(&times, selectCheckInTimesQuery, userID); err != nil {
+		return nil, fmt.Errorf("fetching check-in times: %w", err)
+	}
+	streak := util.CalculateStreak(times, time.Now(), util.KST)
+
+	exercises := make([]dto.ExerciseTotal, 0)
+	if err := s.DB.Select(&exercises, selectExerciseTotalsQuery, userID); err != nil {
+		return nil, fmt.Errorf("fetching exercise totals: %w", err)
+	}
+
+	stats := &dto.CheckInStats{
+		Exercises:        exercises,
+		CurrentStreak:    streak.Current,
+		LongestStreak:    streak.Longest,
+		ActiveDays:       streak.Days,
+		TotalCheckIns:    totals.TotalCheckIns,
+		VerifiedCheckIns: totals.VerifiedCheckIns,
+		MarkersVisited:   totals.MarkersVisited,
+	}
+	if totals.LastCheckInAt.Valid {
+		stats.LastCheckInAt = &totals.LastCheckInAt.Time
+	}
+	return stats, nil
+}
+
+func normalizeCheckInRequest(req *dto.CheckInRequest) error {
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return fmt.Errorf("%w: latitude and longitude go together", ErrInvalidCheckIn)
+	}
+
+	req.Note = strings.TrimSpace(req.Note)
+	if utf8.RuneCountInString(req.Note) > maxCheckInNote {
+		return fmt.Errorf("%w: note is longer than %d characters", ErrInvalidCheckIn, maxCheckInNote)
+	}
+
+	if len(req.Exercises) > maxCheckInExercises {
+		return fmt.Errorf("%w: at most %d exercises per check-in", ErrInvalidCheckIn, maxCheckInExercises)
+	}
+	for i := range req.Exercises {
+		e := &req.Exercises[i]
+		exercise, ok := normalizeExercise(e.Exercise)
+		if !ok {
+			return fmt.Errorf("%w: exercise must be one of %s", ErrInvalidCheckIn, strings.Join(checkInExercises, ", "))
+		}
+		e.Exercise = exercise
+		if e.Sets < 1 || e.Sets > maxExerciseSets || e.Reps < 1 || e.Reps > maxExerciseReps {
+			return fmt.Errorf("%w: %s needs 1-%d sets of 1-%d reps", ErrInvalidCheckIn, exercise, maxExerciseSets, maxExerciseReps)
+		}
+	}
+	return nil
+}
+
+func normalizeExercise(s string) (string, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for _, exercise := range checkInExercises {
+		if s == exercise {
+			return s, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/service/clustering"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// Zoom levels clusters are kept for, Korea fits the screen around MinClusterZoom
+	// and past MaxClusterZoom clients draw the markers themselves
+	MinClusterZoom = 6
+	MaxClusterZoom = 16
+
+	// Markers closer than this on screen are grouped, in 256px tile pixels
+	clusterRadiusPixels = 60
+	clusterMinPoints    = 2
+	// Ground resolution is computed at this latitude, the middle of Korea
+	clusterReferenceLat = 36.5
+
+	// Markers changed in one go are read back in chunks of this size
+	clusterUpdateChunk = 500
+
+	getClusterMarkersQuery = `
+SELECT MarkerID, ST_X(Location) AS Latitude, ST_Y(Location) AS Longitude
+FROM Markers
+WHERE DeletedAt IS NULL`
+
+	getClusterMarkersByIDQuery = getClusterMarkersQuery + ` AND MarkerID IN (?)`
+)
+
+var ErrClustersNotReady = errors.New("marker clusters are still being computed")
+
+// MarkerClusterService keeps a DBSCAN clustering of all markers per zoom level in memory.
+// It is built once at startup and updated marker by marker through MarkerCacheService.
+//
+// Every change goes through a single worker. Callers only report which marker changed and the
+// worker reads its current row back, so the indexes end up matching the DB whatever order the
+// reports arrive in, and changes reported while a rebuild runs are replayed onto its result.
+type MarkerClusterService struct {
+	DB     *sqlx.DB
+	Logger *zap.Logger
+
+	mu      sync.RWMutex
+	indexes map[int]*clustering.Index // zoom -> clusters, nil until the first build
+
+	queueMu sync.Mutex
+	changed map[int]struct{} // markers reported since the worker last took them
+	rebuild bool
+	wake    chan struct{}
+	stop    chan struct{}
+}
+
+func NewMarkerClusterService(db *sqlx.DB, logger *zap.Logger) *MarkerClusterService {
+	return &MarkerClusterService{
+		DB:      db,
+		Logger:  logger,
+		changed: make(map[int]struct{}),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+}
+
+func RegisterMarkerClusterLifecycle(lifecycle fx.Lifecycle, service *MarkerClusterService) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			// Low zooms take a while with every marker in one neighborhood, don't hold up the server
+			go service.run()
+			service.Rebuild()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			close(service.stop)
+			return nil
+		},
+	})
+}
+
+// clusterEps is the clustering radius in meters for zoom.
+func clusterEps(zoom int) float64 {
+	metersPerPixel := 156543.03392 * math.Cos(clusterReferenceLat*math.Pi/180) / math.Exp2(float64(zoom))
+	return clusterRadiusPixels * metersPerPixel
+}
+
+// Rebuild schedules clustering every marker from scratch for all zoom levels.
+// The current clusters keep being served until the new ones are ready.
+func (s *MarkerClusterService) Rebuild() {
+	s.queueMu.Lock()
+	s.rebuild = true
+	s.queueMu.Unlock()
+	s.signal()
+}
+
+// MarkerChanged schedules markerID to be added, moved or removed in every zoom level,
+// depending on what the DB holds for it by then. Call it after the change is committed.
+func (s *MarkerClusterService) MarkerChanged(markerID int) {
+	s.queueMu.Lock()
+	s.changed[markerID] = struct{}{}
+	s.queueMu.Unlock()
+	s.signal()
+}
+
+func (s *MarkerClusterService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default: // the worker has a wake-up pending already
+	}
+}
+
+// run is the worker applying rebuilds and changes one batch at a time.
+func (s *MarkerClusterService) run() {
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		}
+
+		s.queueMu.Lock()
+		changed, rebuild := s.changed, s.rebuild
+		s.changed, s.rebuild = make(map[int]struct{}), false
+		s.queueMu.Unlock()
+
+		// The rebuild reads the markers after the batch was taken, so replaying the batch onto it
+		// is harmless, and anything reported from now on is in the next batch
+		if rebuild {
+			if err := s.rebuildIndexes(); err != nil {
+				s.Logger.Error("Failed to build marker clusters", zap.Error(err))
+			}
+		}
+		if len(changed) > 0 {
+			if err := s.applyChanges(changed); err != nil {
+				s.Logger.Error("Failed to update marker clusters", zap.Int("markers", len(changed)), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *MarkerClusterService) rebuildIndexes() error {
+	var markers []dto.MarkerSimple
+	if err := s.DB.Select(&markers, getClusterMarkersQuery); err != nil {
+		return fmt.Errorf("fetching markers for clustering: %w", err)
+	}
+
+	indexes := make(map[int]*clustering.Index, MaxClusterZoom-MinClusterZoom+1)
+	for zoom := MinClusterZoom; zoom <= MaxClusterZoom; zoom++ {
+		// Every zoom level labels its own copy of the points
+		points := make([]*clustering.Point, len(markers))
+		for i, m := range markers {
+			points[i] = &clustering.Point{ID: m.MarkerID, Latitude: m.Latitude, Longitude: m.Longitude}
+		}
+		indexes[zoom] = clustering.NewIndex(points, clusterEps(zoom), clusterMinPoints)
+	}
+
+	s.mu.Lock()
+	s.indexes = indexes
+	s.mu.Unlock()
+
+	s.Logger.Info("Marker clusters built", zap.Int("markers", len(markers)))
+	return nil
+}
+
+// applyChanges reads the changed markers back and inserts the live ones, the rest are removed.
+func (s *MarkerClusterService) applyChanges(changed map[int]struct{}) error {
+	markerIDs := make([]int, 0, len(changed))
+	for markerID := range changed {
+		markerIDs = append(markerIDs, markerID)
+	}
+
+	live := make(map[int]dto.MarkerSimple, len(markerIDs))
+	for start := 0; start < len(markerIDs); start += clusterUpdateChunk {
+		chunk := markerIDs[start:min(start+clusterUpdateChunk, len(markerIDs))]
+		query, args, err := sqlx.In(getClusterMarkersByIDQuery, chunk)
+		if err != nil {
+			return fmt.Errorf("building cluster markers query: %w", err)
+		}
+		var markers []dto.MarkerSimple
+		if err := s.DB.Select(&markers, s.DB.Rebind(query), args...); err != nil {
+			return fmt.Errorf("fetching changed markers for clustering: %w", err)
+		}
+		for _, m := range markers {
+			live[m.MarkerID] = m
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, idx := range s.indexes {
+		for _, markerID := range markerIDs {
+			if m, ok := live[markerID]; ok {
+				idx.Insert(&clustering.Point{ID: markerID, Latitude: m.Latitude, Longitude: m.Longitude})
+			} else {
+				idx.Remove(markerID)
+			}
+		}
+	}
+	return nil
+}
+
+// GetClusters returns the clusters at zoom whose centroid lies in box.
+// Bounds always cover the whole cluster, even where it reaches outside box.
+func (s *MarkerClusterService) GetClusters(box util.BBox, zoom int) (*dto.MarkerClusterResponse, error) {
+	zoom = max(MinClusterZoom, min(MaxClusterZoom, zoom))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx, ok := s.indexes[zoom]
+	if !ok {
+		return nil, ErrClustersNotReady
+	}
+
+	response := &dto.MarkerClusterResponse{Zoom: zoom, Clusters: make([]dto.MarkerCluster, 0)}
+	for _, c := range idx.Clusters() {
+		if c.Latitude < box.MinLat || c.Latitude > box.MaxLat || c.Longitude < box.MinLng || c.Longitude > box.MaxLng {
+			continue
+		}
+		response.Total += c.Count
+		response.Clusters = append(response.Clusters, dto.MarkerCluster{
+			Latitude:  c.Latitude,
+			Longitude: c.Longitude,
+			Count:     c.Count,
+			MarkerID:  c.PointID,
+			Bounds: dto.MarkerClusterBounds{
+				MinLat: c.MinLat,
+				MinLng: c.MinLng,
+				MaxLat: c.MaxLat,
+				MaxLng: c.MaxLng,
+			},
+		})
+	}
+
+	return response, nil
+}
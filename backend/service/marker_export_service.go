@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// Exports stream straight from the DB, this only guards against a stuck client holding the query open
+	markerExportTimeout = 5 * time.Minute
+
+	exportMarkersQuery = `
+SELECT m.MarkerID,
+	ST_X(m.Location) AS Latitude,
+	ST_Y(m.Location) AS Longitude,
+	COALESCE(m.Description, '') AS Description,
+	COALESCE(m.Address, '') AS Address,
+	m.Status,
+	COALESCE((
+		SELECT p.PhotoURL
+		FROM Photos p
+		WHERE p.MarkerID = m.MarkerID AND p.DeletedAt IS NULL
+		ORDER BY ` + preferredPhotoOrder + `
+		LIMIT 1
+	), '') AS CoverPhotoURL,
+	COALESCE((
+		SELECT GROUP_CONCAT(CONCAT(f.FacilityID, ':', f.Quantity) ORDER BY f.FacilityID SEPARATOR ';')
+		FROM MarkerFacilities f
+		WHERE f.MarkerID = m.MarkerID AND f.Quantity > 0 AND f.DeletedAt IS NULL
+	), '') AS Facilities
+FROM Markers m
+WHERE m.DeletedAt IS NULL`
+
+	exportBBoxCondition     = " AND ST_X(m.Location) BETWEEN ? AND ? AND ST_Y(m.Location) BETWEEN ? AND ?"
+	exportProvinceCondition = " AND m.Address LIKE CONCAT(?, ' %')"
+	exportCityCondition     = " AND CONCAT(' ', m.Address, ' ') LIKE CONCAT('% ', ?, ' %')"
+	exportStatusCondition   = " AND m.Status IN (?)"
+)
+
+type exportMarkerRow struct {
+	MarkerID      int     `db:"MarkerID"`
+	Latitude      float64 `db:"Latitude"`
+	Longitude     float64 `db:"Longitude"`
+	Description   string  `db:"Description"`
+	Address       string  `db:"Address"`
+	Status        string  `db:"Status"`
+	CoverPhotoURL string  `db:"CoverPhotoURL"`
+	Facilities    string  `db:"Facilities"`
+}
+
+// MarkerExportService writes markers out as GeoJSON, KML or GPX for map and GIS apps.
+type MarkerExportService struct {
+	DB     *sqlx.DB
+	Logger *zap.Logger
+}
+
+func NewMarkerExportService(db *sqlx.DB, logger *zap.Logger) *MarkerExportService {
+	return &MarkerExportService{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+// ExportMarkers streams every marker matching filter to w in format, row by row, and returns how many were written.
+func (s *MarkerExportService) ExportMarkers(format string, filter dto.MarkerExportFilter, w io.Writer) (int, error) {
+	query, args, err := buildExportMarkersQuery(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), markerExportTimeout)
+	defer cancel()
+
+	rows, err := s.DB.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("querying markers for export: %w", err)
+	}
+	defer rows.Close()
+
+	enc, err := util.NewMarkerExportEncoder(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for rows.Next() {
+		var row exportMarkerRow
+		if err := rows.StructScan(&row); err != nil {
+			return count, fmt.Errorf("scanning exported marker: %w", err)
+		}
+
+		facilities, err := util.ParseFacilityQuantities(row.Facilities)
+		if err != nil {
+			// Only the facility columns are affected, the marker itself is still worth exporting
+			s.Logger.Warn("Skipping unreadable facilities in export", zap.Int("markerID", row.MarkerID), zap.Error(err))
+		}
+
+		if err := enc.Encode(util.ExportedMarker{
+			Facilities:    facilities,
+			Latitude:      row.Latitude,
+			Longitude:     row.Longitude,
+			MarkerID:      row.MarkerID,
+			Description:   row.Description,
+			Address:       row.Address,
+			Status:        row.Status,
+			CoverPhotoURL: row.CoverPhotoURL,
+		}); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("reading markers for export: %w", err)
+	}
+
+	return count, enc.Close()
+}
+
+func buildExportMarkersQuery(filter dto.MarkerExportFilter) (string, []any, error) {
+	var sb strings.Builder
+	var args []any
+
+	sb.WriteString(exportMarkersQuery)
+
+	if box := filter.BBox; box != nil {
+		// Location is POINT(lat long), ST_X is the latitude
+		sb.WriteString(exportBBoxCondition)
+		args = append(args, box.MinLat, box.MaxLat, box.MinLng, box.MaxLng)
+	}
+	if filter.Province != "" {
+		sb.WriteString(exportProvinceCondition)
+		args = append(args, standardizeProvinceForDB(filter.Province))
+	}
+	if filter.City != "" {
+		sb.WriteString(exportCityCondition)
+		args = append(args, filter.City)
+	}
+	facilityCondition, facilityArgs := facilityFilterCondition(filter.Facilities)
+	sb.WriteString(facilityCondition)
+	args = append(args, facilityArgs...)
+	accessCondition, accessArgs := accessFilterCondition(filter.Access)
+	sb.WriteString(accessCondition)
+	args = append(args, accessArgs...)
+	if len(filter.Statuses) > 0 {
+		sb.WriteString(exportStatusCondition)
+		args = append(args, filter.Statuses)
+	}
+	sb.WriteString(" ORDER BY m.MarkerID")
+
+	if len(filter.Statuses) == 0 {
+		return sb.String(), args, nil
+	}
+	query, args, err := sqlx.In(sb.String(), args...)
+	if err != nil {
+		return "", nil, fmt.Errorf("building export query: %w", err)
+	}
+	return query, args, nil
+}
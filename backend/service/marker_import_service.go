@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxImportRows keeps a single import inside the request timeout
+	maxImportRows = 5000
+	// Rows of the same file closer than this are treated as the same bar, like CheckMarkerValidity does for the DB
+	importProximityMeters = 10
+)
+
+var ErrTooManyImportRows = fmt.Errorf("import files are limited to %d markers", maxImportRows)
+
+// MarkerImportService creates markers in bulk from GeoJSON, CSV and KML files.
+type MarkerImportService struct {
+	DB             *sqlx.DB
+	ManageService  *MarkerManageService
+	RedisService   *RedisService
+	CacheService   *MarkerCacheService
+	CatalogService *FacilityCatalogService
+	Logger         *zap.Logger
+}
+
+func NewMarkerImportService(
+	db *sqlx.DB,
+	manageService *MarkerManageService,
+	redisService *RedisService,
+	cacheService *MarkerCacheService,
+	catalogService *FacilityCatalogService,
+	logger *zap.Logger,
+) *MarkerImportService {
+	return &MarkerImportService{
+		DB:             db,
+		ManageService:  manageService,
+		RedisService:   redisService,
+		CacheService:   cacheService,
+		CatalogService: catalogService,
+		Logger:         logger,
+	}
+}
+
+// ImportMarkers validates every row of the file with CheckMarkerValidity and inserts the valid ones for userID.
+// With dryRun nothing is written and the report shows which rows would be inserted.
+func (s *MarkerImportService) ImportMarkers(format string, r io.Reader, userID int, dryRun bool) (*dto.MarkerImportReport, error) {
+	rows, err := util.ParseMarkerImport(format, r, s.CatalogService.FacilityNames())
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > maxImportRows {
+		return nil, ErrTooManyImportRows
+	}
+
+	report := &dto.MarkerImportReport{
+		Results: make([]dto.MarkerImportResult, 0, len(rows)),
+		Format:  format,
+		DryRun:  dryRun,
+		Total:   len(rows),
+	}
+
+	var accepted []dto.MarkerImportResult
+	for _, row := range rows {
+		result := dto.MarkerImportResult{
+			Facilities:  facilityQuantities(row.Facilities),
+			Latitude:    row.Latitude,
+			Longitude:   row.Longitude,
+			Row:         row.Row,
+			Description: row.Description,
+		}
+
+		if reason := s.validateImportRow(row, accepted); reason != "" {
+			result.Status = dto.ImportStatusRejected
+			result.Reason = reason
+			report.Rejected++
+			report.Results = append(report.Results, result)
+			continue
+		}
+
+		result.Status = dto.ImportStatusAccepted
+		if !dryRun {
+			markerID, err := s.insertImportedMarker(result, userID)
+			if err != nil {
+				s.Logger.Error("Failed to insert imported marker", zap.Int("row", row.Row), zap.Error(err))
+				result.Status = dto.ImportStatusRejected
+				result.Reason = "failed to save marker"
+				report.Rejected++
+				report.Results = append(report.Results, result)
+				continue
+			}
+			result.MarkerID = markerID
+			result.Status = dto.ImportStatusImported
+		}
+
+		accepted = append(accepted, result)
+		report.Accepted++
+		report.Results = append(report.Results, result)
+	}
+
+	if !dryRun && report.Accepted > 0 {
+		s.ManageService.ClearCache()
+		s.CacheService.InvalidateFullMarkersCache()
+		s.CacheService.InvalidateMarkerTiles()
+		s.CacheService.RemoveUserMarker(userID, 0)
+
+		// One rebuild is cheaper than reclustering around thousands of new markers one by one
+		s.CacheService.ClusterService.Rebuild()
+
+		for _, result := range accepted {
+			s.processImportedMarkerAsync(result)
+		}
+
+		s.Logger.Info("Markers imported",
+			zap.String("format", format),
+			zap.Int("userID", userID),
+			zap.Int("imported", report.Accepted),
+			zap.Int("rejected", report.Rejected))
+	}
+
+	return report, nil
+}
+
+// validateImportRow returns why a row cannot be imported, or "" when it can.
+func (s *MarkerImportService) validateImportRow(row util.ImportedMarker, accepted []dto.MarkerImportResult) string {
+	if row.Err != nil {
+		return row.Err.Error()
+	}
+
+	facilityIDs := make([]int, 0, len(row.Facilities))
+	for facilityID, quantity := range row.Facilities {
+		if quantity < 0 {
+			return fmt.Sprintf("negative quantity for facility %d", facilityID)
+		}
+		facilityIDs = append(facilityIDs, facilityID)
+	}
+	// Checked here rather than on insert so a dry run reports them too
+	sort.Ints(facilityIDs)
+	if err := s.CatalogService.ValidateFacilityIDs(facilityIDs); err != nil {
+		if errors.Is(err, ErrUnknownFacility) {
+			return err.Error()
+		}
+		s.Logger.Error("Failed to validate imported facilities", zap.Int("row", row.Row), zap.Error(err))
+		return "failed to check facilities"
+	}
+
+	for _, other := range accepted {
+		if util.CalculateDistanceApproximately(row.Latitude, row.Longitude, other.Latitude, other.Longitude) <= importProximityMeters {
+			return fmt.Sprintf("duplicate of row %d in this file", other.Row)
+		}
+	}
+
+	if fErr := s.ManageService.CheckMarkerValidity(row.Latitude, row.Longitude, row.Description); fErr != nil {
+		return fErr.Message
+	}
+
+	return ""
+}
+
+func (s *MarkerImportService) insertImportedMarker(result dto.MarkerImportResult, userID int) (int, error) {
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(insertMarkerQuery, userID, formatPoint(result.Latitude, result.Longitude), result.Description)
+	if err != nil {
+		return 0, fmt.Errorf("inserting marker: %w", err)
+	}
+	markerID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrLastInsertID, err)
+	}
+
+	if err := insertMarkerFacilitiesTx(tx, int(markerID), result.Facilities); err != nil {
+		return 0, fmt.Errorf("inserting facilities: %w", err)
+	}
+	if err := recordMarkerChanges(tx, int(markerID)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+
+	return int(markerID), nil
+}
+
+// processImportedMarkerAsync looks up the address and indexes the marker like a normal creation,
+// without the notifications and chat broadcasts, which would flood for a whole dataset.
+func (s *MarkerImportService) processImportedMarkerAsync(result dto.MarkerImportResult) {
+	markerID := int64(result.MarkerID)
+
+	s.ManageService.workerPool.Submit(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+
+		if err := s.RedisService.AddGeoMarker(strconv.FormatInt(markerID, 10), result.Latitude, result.Longitude); err != nil {
+			s.Logger.Error("Failed to add imported marker to geo set", zap.Int64("markerID", markerID), zap.Error(err))
+		}
+
+		address := s.ManageService.fetchAddressWithRetries(ctx, markerID, result.Latitude, result.Longitude)
+		if address == "" {
+			return
+		}
+
+		if err := s.ManageService.updateMarkerAddress(markerID, address); err != nil {
+			s.Logger.Error("Failed to update marker address", zap.Int64("markerID", markerID), zap.Error(err))
+			return
+		}
+
+		s.ManageService.indexMarkerForSearch(markerID, address)
+	})
+}
+
+// facilityQuantities turns the parsed facility map into a stable, zero-free list.
+func facilityQuantities(facilities map[int]int) []dto.FacilityQuantity {
+	list := make([]dto.FacilityQuantity, 0, len(facilities))
+	for facilityID, quantity := range facilities {
+		if quantity == 0 {
+			continue
+		}
+		list = append(list, dto.FacilityQuantity{FacilityID: facilityID, Quantity: quantity})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].FacilityID < list[j].FacilityID })
+	return list
+}
@@ -13,14 +13,14 @@ const (
 	insertDislikeQuery = "INSERT INTO MarkerDislikes (MarkerID, UserID) VALUES (?, ?) ON DUPLICATE KEY UPDATE DislikedAt=VALUES(DislikedAt)"
 	deleteDislikeQuery = "DELETE FROM MarkerDislikes WHERE UserID = ? AND MarkerID = ?"
 	checkDislikeQuery  = "SELECT EXISTS(SELECT 1 FROM MarkerDislikes WHERE UserID = ? AND MarkerID = ?)"
-	checkFavQuery      = "SELECT EXISTS(SELECT 1 FROM Favorites WHERE UserID = ? AND MarkerID = ?)"
+	checkFavQuery      = "SELECT EXISTS(SELECT 1 FROM Favorites WHERE UserID = ? AND MarkerID = ? AND DeletedAt IS NULL)"
 	// access_type: ref, query_cost: 0.95
-	countFavQuery         = "SELECT COUNT(*) FROM Favorites WHERE UserID = ?"
+	countFavQuery         = "SELECT COUNT(*) FROM Favorites WHERE UserID = ? AND DeletedAt IS NULL"
 	insertFavQuery        = "INSERT INTO Favorites (UserID, MarkerID) VALUES (?, ?)"
 	checkMarkerOwnerQuery = "SELECT UserID FROM Markers WHERE MarkerID = ?"
 	deleteFavQuery        = "DELETE FROM Favorites WHERE UserID = ? AND MarkerID = ?"
 
-	getMarkersAfterIDQuery = "SELECT ST_X(Location) AS Latitude, ST_Y(Location) AS Longitude, Address, MarkerID, COALESCE(U.Username, '알 수 없는 사용자') AS Username, M.UserID FROM Markers M LEFT JOIN Users U ON M.UserID = U.UserID WHERE MarkerID > ? ORDER BY MarkerID ASC"
+	getMarkersAfterIDQuery = "SELECT ST_X(Location) AS Latitude, ST_Y(Location) AS Longitude, Address, MarkerID, COALESCE(U.Username, '알 수 없는 사용자') AS Username, M.UserID FROM Markers M LEFT JOIN Users U ON M.UserID = U.UserID WHERE MarkerID > ? AND M.DeletedAt IS NULL ORDER BY MarkerID ASC"
 )
 
 type MarkerInteractService struct {
@@ -32,7 +32,7 @@ const (
 SELECT EXISTS (
     SELECT 1 
     FROM Markers
-    WHERE ST_Within(Location, ST_Buffer(ST_GeomFromText(?, 4326), ?))
+    WHERE ST_Within(Location, ST_Buffer(ST_GeomFromText(?, 4326), ?)) AND DeletedAt IS NULL
 ) AS Nearby;
 `
 
@@ -66,9 +66,10 @@ WHERE MBRContains(
         4326), 
     Location)
   AND ST_Distance_Sphere(Location, ST_GeomFromText(?, 4326)) <= ?
+  AND DeletedAt IS NULL
 ORDER BY distance ASC`
 
-	findClosestMarkersWithThumbnailQuery = `
+	findClosestMarkersWithThumbnailWhere = `
 SELECT m.MarkerID, 
        ST_X(m.Location) AS Latitude, 
        ST_Y(m.Location) AS Longitude, 
@@ -80,10 +81,12 @@ FROM Markers m
 LEFT JOIN (
     SELECT p1.MarkerID, p1.PhotoURL, p1.ThumbnailURL
     FROM Photos p1
-    WHERE p1.UploadedAt = (
-        SELECT MAX(p2.UploadedAt) 
-        FROM Photos p2 
-        WHERE p1.MarkerID = p2.MarkerID
+    WHERE p1.DeletedAt IS NULL AND p1.PhotoID = (
+        SELECT p.PhotoID
+        FROM Photos p
+        WHERE p.MarkerID = p1.MarkerID AND p.DeletedAt IS NULL
+        ORDER BY ` + preferredPhotoOrder + `
+        LIMIT 1
     )
 ) p ON m.MarkerID = p.MarkerID
 WHERE MBRContains(
@@ -99,9 +102,44 @@ WHERE MBRContains(
         4326), 
     Location)
 AND ST_Distance_Sphere(Location, ST_GeomFromText(?, 4326)) <= ?
+AND m.DeletedAt IS NULL`
+	findClosestMarkersWithThumbnailOrder = `
 GROUP BY m.MarkerID
 ORDER BY distance ASC
 LIMIT ? OFFSET ?`
+	findClosestMarkersWithThumbnailQuery = findClosestMarkersWithThumbnailWhere + findClosestMarkersWithThumbnailOrder
+
+	getAreaMarkerRatingsQuery = "SELECT MarkerID, AvgRating FROM MarkerRatings WHERE MarkerID IN (?) AND ReviewCount >= ?"
+)
+
+const (
+	// Page size of the viewport query when the client does not ask for one, and the most it can ask for
+	DefaultViewportLimit = 200
+	MaxViewportLimit     = 1000
+
+	findMarkersInBBoxSelect = `
+SELECT m.MarkerID,
+	ST_X(m.Location) AS Latitude,
+	ST_Y(m.Location) AS Longitude,
+	m.Status,
+	EXISTS (SELECT 1 FROM Photos p WHERE p.MarkerID = m.MarkerID AND p.DeletedAt IS NULL) AS HasPhoto`
+	findMarkersInBBoxThumbnail = `,
+	(
+		SELECT COALESCE(p.ThumbnailURL, p.PhotoURL)
+		FROM Photos p
+		WHERE p.MarkerID = m.MarkerID AND p.DeletedAt IS NULL
+		ORDER BY ` + preferredPhotoOrder + `
+		LIMIT 1
+	) AS Thumbnail`
+	// MBRContains keeps this on the spatial index, the polygon is in the same lat long order as Location
+	findMarkersInBBoxWhere = `
+FROM Markers m
+WHERE MBRContains(ST_GeomFromText(?, 4326), m.Location)
+	AND m.DeletedAt IS NULL
+	AND m.MarkerID > ?`
+	findMarkersInBBoxOrder = `
+ORDER BY m.MarkerID
+LIMIT ?`
 )
 
 type PooledMarkers struct {
@@ -200,8 +238,64 @@ func (s *MarkerLocationService) IsMarkerInRestrictedArea(lat, long float64) (str
 	return name, true, nil
 }
 
+// FindMarkersInBBox returns up to limit markers inside box with a MarkerID above cursor that pass facilities and access.
+// NextCursor is set when there are more markers to fetch.
+func (s *MarkerLocationService) FindMarkersInBBox(box util.BBox, facilities util.FacilityFilter, access dto.MarkerAccessFilter, withThumbnails bool, cursor, limit int) (*dto.MarkerViewportResponse, error) {
+	if limit <= 0 {
+		limit = DefaultViewportLimit
+	}
+	limit = min(limit, MaxViewportLimit)
+
+	var sb strings.Builder
+	sb.WriteString(findMarkersInBBoxSelect)
+	if withThumbnails {
+		sb.WriteString(findMarkersInBBoxThumbnail)
+	}
+	sb.WriteString(findMarkersInBBoxWhere)
+	args := []any{formatBBoxPolygon(box), cursor}
+	facilityCondition, facilityArgs := facilityFilterCondition(facilities)
+	sb.WriteString(facilityCondition)
+	args = append(args, facilityArgs...)
+	accessCondition, accessArgs := accessFilterCondition(access)
+	sb.WriteString(accessCondition)
+	args = append(args, accessArgs...)
+	sb.WriteString(findMarkersInBBoxOrder)
+	// One extra row tells whether there is a next page
+	args = append(args, limit+1)
+
+	markers := make([]dto.MarkerViewport, 0, limit+1)
+	if err := s.DB.Select(&markers, sb.String(), args...); err != nil {
+		return nil, fmt.Errorf("fetching markers in bbox: %w", err)
+	}
+
+	response := &dto.MarkerViewportResponse{Markers: markers}
+	if len(markers) > limit {
+		response.Markers = markers[:limit]
+		response.NextCursor = markers[limit-1].MarkerID
+	}
+	return response, nil
+}
+
+// formatBBoxPolygon writes box as a closed WKT polygon in the POINT(lat long) order formatPoint uses.
+func formatBBoxPolygon(box util.BBox) string {
+	corner := func(lat, lng float64) string {
+		return strconv.FormatFloat(lat, 'f', -1, 64) + " " + strconv.FormatFloat(lng, 'f', -1, 64)
+	}
+	return "POLYGON((" +
+		corner(box.MinLat, box.MinLng) + "," +
+		corner(box.MinLat, box.MaxLng) + "," +
+		corner(box.MaxLat, box.MaxLng) + "," +
+		corner(box.MaxLat, box.MinLng) + "," +
+		corner(box.MinLat, box.MinLng) + "))"
+}
+
 // FindClosestNMarkersWithinDistance
 func (s *MarkerLocationService) FindClosestNMarkersWithinDistance(lat, long float64, distance, pageSize, offset int) ([]dto.MarkerWithDistanceAndPhoto, int, error) {
+	return s.FindClosestNMarkersFiltered(lat, long, distance, pageSize, offset, util.FacilityFilter{}, dto.MarkerAccessFilter{})
+}
+
+// FindClosestNMarkersFiltered is FindClosestNMarkersWithinDistance for markers that pass facilities and access.
+func (s *MarkerLocationService) FindClosestNMarkersFiltered(lat, long float64, distance, pageSize, offset int, facilities util.FacilityFilter, access dto.MarkerAccessFilter) ([]dto.MarkerWithDistanceAndPhoto, int, error) {
 	// Calculate bounding box more efficiently
 	radLat := lat * util.RadiansPerDegree
 
@@ -222,8 +316,7 @@ func (s *MarkerLocationService) FindClosestNMarkersWithinDistance(lat, long floa
 
 	pooledMarkers := markerPool.Get().(*PooledMarkers)
 
-	err := s.FindCloseMarkersStmt.Select(
-		&pooledMarkers.Markers,
+	args := []any{
 		point,
 		minLon, minLat,
 		maxLon, minLat,
@@ -232,9 +325,20 @@ func (s *MarkerLocationService) FindClosestNMarkersWithinDistance(lat, long floa
 		minLon, minLat,
 		point,
 		distance,
-		pageSize, // LIMIT
-		offset,   // OFFSET
-	)
+	}
+
+	var err error
+	if facilities.IsEmpty() && access.IsEmpty() {
+		err = s.FindCloseMarkersStmt.Select(&pooledMarkers.Markers, append(args, pageSize, offset)...) // LIMIT, OFFSET
+	} else {
+		// Filtered queries vary too much to be worth a prepared statement each
+		facilityCondition, facilityArgs := facilityFilterCondition(facilities)
+		accessCondition, accessArgs := accessFilterCondition(access)
+		args = append(append(args, facilityArgs...), accessArgs...)
+		err = s.DB.Select(&pooledMarkers.Markers,
+			findClosestMarkersWithThumbnailWhere+facilityCondition+accessCondition+findClosestMarkersWithThumbnailOrder,
+			append(args, pageSize, offset)...)
+	}
 	if err != nil {
 		pooledMarkers.Release()
 		return nil, 0, errors.New("error fetching nearby markers")
@@ -246,7 +350,8 @@ func (s *MarkerLocationService) FindClosestNMarkersWithinDistance(lat, long floa
 	return pooledMarkers.Markers, len(pooledMarkers.Markers), nil
 }
 
-func (s *MarkerLocationService) FindRankedMarkersInCurrentArea(lat, long float64, distance, limit int) ([]dto.MarkerWithDistanceAndPhoto, error) {
+// FindRankedMarkersInCurrentArea ranks the markers close to lat, long by clicks or, with RankSortRating, by their average rating.
+func (s *MarkerLocationService) FindRankedMarkersInCurrentArea(lat, long float64, distance, limit int, sortBy string) ([]dto.MarkerWithDistanceAndPhoto, error) {
 	// Predefine capacity for slices based on known limits to avoid multiple allocations
 	nearbyMarkers, total, err := s.FindClosestNMarkersWithinDistance(lat, long, distance, limit, 0)
 	if err != nil {
@@ -256,6 +361,10 @@ func (s *MarkerLocationService) FindRankedMarkersInCurrentArea(lat, long float64
 		return nil, nil // Return nil to signify no markers, reducing slice allocation
 	}
 
+	if sortBy == RankSortRating {
+		return s.rankMarkersByRating(nearbyMarkers, limit)
+	}
+
 	markerIDs := make([]string, len(nearbyMarkers))
 	for i, marker := range nearbyMarkers {
 		markerIDs[i] = strconv.Itoa(marker.MarkerID)
@@ -290,6 +399,61 @@ func (s *MarkerLocationService) FindRankedMarkersInCurrentArea(lat, long float64
 	return rankedMarkers[:limit], nil
 }
 
+// rankMarkersByRating keeps the markers with at least MinReviewRank reviews, best rated first.
+func (s *MarkerLocationService) rankMarkersByRating(markers []dto.MarkerWithDistanceAndPhoto, limit int) ([]dto.MarkerWithDistanceAndPhoto, error) {
+	byID, err := s.fetchMarkerRatings(markers)
+	if err != nil {
+		return nil, err
+	}
+	if len(byID) == 0 {
+		return nil, nil
+	}
+
+	rankedMarkers := make([]dto.MarkerWithDistanceAndPhoto, 0, len(byID))
+	for _, marker := range markers {
+		if rating, ok := byID[marker.MarkerID]; ok {
+			marker.Rating = &rating
+			rankedMarkers = append(rankedMarkers, marker)
+		}
+	}
+
+	// Closer markers win ties, markers came in by distance
+	sort.SliceStable(rankedMarkers, func(i, j int) bool {
+		return *rankedMarkers[i].Rating > *rankedMarkers[j].Rating
+	})
+
+	if limit > len(rankedMarkers) {
+		limit = len(rankedMarkers)
+	}
+	return rankedMarkers[:limit], nil
+}
+
+// fetchMarkerRatings returns the average rating of the markers that have at least MinReviewRank reviews, by marker ID.
+func (s *MarkerLocationService) fetchMarkerRatings(markers []dto.MarkerWithDistanceAndPhoto) (map[int]float64, error) {
+	markerIDs := make([]int, len(markers))
+	for i, marker := range markers {
+		markerIDs[i] = marker.MarkerID
+	}
+
+	query, args, err := sqlx.In(getAreaMarkerRatingsQuery, markerIDs, MinReviewRank)
+	if err != nil {
+		return nil, fmt.Errorf("building rating query: %w", err)
+	}
+	var ratings []struct {
+		MarkerID  int     `db:"MarkerID"`
+		AvgRating float64 `db:"AvgRating"`
+	}
+	if err := s.DB.Select(&ratings, s.DB.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("fetching marker ratings: %w", err)
+	}
+
+	byID := make(map[int]float64, len(ratings))
+	for _, r := range ratings {
+		byID[r.MarkerID] = r.AvgRating
+	}
+	return byID, nil
+}
+
 // GoogleGeoResponse struct to parse the Google Maps Geocoding API response
 type GoogleGeoResponse struct {
 	Results []struct {
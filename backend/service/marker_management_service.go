@@ -5,11 +5,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"image"
 	"io"
+	"math"
 	"mime/multipart"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -40,7 +44,31 @@ SELECT
 	ST_X(Location) AS Latitude,
 	ST_Y(Location) AS Longitude
 FROM 
-	Markers;`
+	Markers
+WHERE 
+	DeletedAt IS NULL;`
+
+	// Everything binary clients get in one row per marker, facilities as "1:2;2:1"
+	getAllMarkersProtoQuery = `
+SELECT m.MarkerID,
+	ST_X(m.Location) AS Latitude,
+	ST_Y(m.Location) AS Longitude,
+	COALESCE(m.Address, '') AS Address,
+	m.Status,
+	COALESCE((
+		SELECT COALESCE(p.ThumbnailURL, p.PhotoURL)
+		FROM Photos p
+		WHERE p.MarkerID = m.MarkerID AND p.DeletedAt IS NULL
+		ORDER BY ` + preferredPhotoOrder + `
+		LIMIT 1
+	), '') AS Thumbnail,
+	COALESCE((
+		SELECT GROUP_CONCAT(CONCAT(f.FacilityID, ':', f.Quantity) ORDER BY f.FacilityID SEPARATOR ';')
+		FROM MarkerFacilities f
+		WHERE f.MarkerID = m.MarkerID AND f.Quantity > 0 AND f.DeletedAt IS NULL
+	), '') AS Facilities
+FROM Markers m
+WHERE m.DeletedAt IS NULL`
 
 	getAllSimpleMarkersPhotoExistenceQuery = `
 SELECT 
@@ -50,11 +78,14 @@ SELECT
     CASE 
         WHEN COUNT(p.PhotoID) > 0 THEN TRUE 
         ELSE FALSE 
-    END AS HasPhoto
+    END AS HasPhoto,
+    m.Status
 FROM 
     Markers m
 LEFT JOIN 
-    Photos p ON m.MarkerID = p.MarkerID
+    Photos p ON m.MarkerID = p.MarkerID AND p.DeletedAt IS NULL
+WHERE 
+    m.DeletedAt IS NULL
 GROUP BY 
     m.MarkerID;`
 
@@ -67,6 +98,8 @@ SELECT
 	UserID
 FROM 
 	Markers
+WHERE 
+	DeletedAt IS NULL
 ORDER BY 
 	CreatedAt DESC
 LIMIT ? OFFSET ?;`
@@ -83,10 +116,23 @@ SELECT
 	M.CreatedAt,
 	M.UpdatedAt,
 	M.Address,
+	M.Status,
+	M.AccessType,
+	M.OpeningHours,
+	M.Lit,
+	M.Indoor,
 	COALESCE(D.DislikeCount, 0) AS DislikeCount,
-	COALESCE(F.FavoriteCount, 0) AS FavoriteCount
+	COALESCE(F.FavoriteCount, 0) AS FavoriteCount,
+	COALESCE(R.ReviewCount, 0) AS ReviewCount,
+	COALESCE(R.AvgRating, 0) AS AvgRating,
+	R.AvgGrip,
+	R.AvgHeight,
+	R.AvgStability,
+	R.AvgSurroundings,
+	(SELECT COUNT(DISTINCT C.UserID) FROM MarkerCheckIns C WHERE C.MarkerID = M.MarkerID AND C.Verified) AS VisitorCount
 FROM Markers M
 LEFT JOIN Users U ON M.UserID = U.UserID
+LEFT JOIN MarkerRatings R ON M.MarkerID = R.MarkerID
 LEFT JOIN (
 	SELECT
 		MarkerID,
@@ -100,12 +146,16 @@ LEFT JOIN (
 		MarkerID,
 		COUNT(*) AS FavoriteCount
 	FROM Favorites
-	WHERE MarkerID = ?
+	WHERE MarkerID = ? AND DeletedAt IS NULL
 	GROUP BY MarkerID
 ) F ON M.MarkerID = F.MarkerID
-WHERE M.MarkerID = ?`
+WHERE M.MarkerID = ? AND M.DeletedAt IS NULL`
 
-	getAllPhotosForMarkerQuery = "SELECT * FROM Photos WHERE MarkerID = ? ORDER BY UploadedAt DESC"
+	getAllPhotosForMarkerQuery = "SELECT PhotoID, MarkerID, PhotoURL, ThumbnailURL, VariantWidths, QualityScore, UploadedAt FROM Photos p WHERE MarkerID = ? AND DeletedAt IS NULL ORDER BY " + preferredPhotoOrder
+
+	// Better photos first, in steps of 25 quality points so recent photos still come first among similar ones.
+	// Photos from before quality checks count as average. Photos must be aliased p.
+	preferredPhotoOrder = "ROUND(COALESCE(p.QualityScore, 50) / 25) DESC, p.UploadedAt DESC"
 
 	// Query to select markers created by a specific user with LIMIT and OFFSET for pagination
 	getMarkersByUserQuery = `
@@ -119,7 +169,7 @@ SELECT
 FROM 
     Markers M
 WHERE 
-    M.UserID = ?
+    M.UserID = ? AND M.DeletedAt IS NULL
 ORDER BY 
     M.CreatedAt DESC
 LIMIT ? OFFSET ?`
@@ -135,28 +185,62 @@ LIMIT ? OFFSET ?`
 	FROM 
 		Markers M
 	WHERE 
-		M.MarkerID = ?`
+		M.MarkerID = ? AND M.DeletedAt IS NULL`
 
 	// Query to get the total count of markers for the user
-	getTotalCountofMarkerQuery = "SELECT COUNT(DISTINCT Markers.MarkerID) FROM Markers WHERE Markers.UserID = ?"
+	getTotalCountofMarkerQuery = "SELECT COUNT(DISTINCT Markers.MarkerID) FROM Markers WHERE Markers.UserID = ? AND Markers.DeletedAt IS NULL"
 
 	insertMarkerQuery = "INSERT INTO Markers (UserID, Location, Description, CreatedAt, UpdatedAt) VALUES (?, ST_PointFromText(?, 4326), ?, NOW(), NOW())"
 	insertPhotoQuery  = "INSERT INTO Photos (MarkerID, PhotoURL, UploadedAt) VALUES (?, ?, NOW())"
 	// insertPhotoWithThumbQuery = "INSERT INTO Photos (MarkerID, PhotoURL, ThumbnailURL, UploadedAt) VALUES (?, ?, ?, NOW())"
 	insertPhotoWithThumbQuery = "INSERT INTO Photos (MarkerID, PhotoURL, ThumbnailURL, Blurhash, UploadedAt) VALUES (?, ?, ?, ?, NOW())"
+	insertUploadedPhotoQuery  = `
+INSERT INTO Photos (MarkerID, PhotoURL, ThumbnailURL, PerceptualHash, QualityScore, Sharpness, Brightness, VerificationFlags, UploadedAt)
+VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NOW())`
+	insertVerifiedPhotoQuery = `
+INSERT INTO Photos (MarkerID, PhotoURL, ThumbnailURL, Blurhash, PerceptualHash, QualityScore, Sharpness, Brightness,
+	TakenAt, ExifDistance, VerificationScore, VerificationFlags, UploadedAt)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''), NOW())`
 
 	deleteMarkerQuery = "DELETE FROM Markers WHERE MarkerID = ?"
 
+	// Soft delete stamps the marker and its dependent rows with the same DeletedAt,
+	// so a restore only brings back rows that were removed together with the marker.
+	softDeleteMarkerQuery     = "UPDATE Markers SET DeletedAt = NOW() WHERE MarkerID = ? AND DeletedAt IS NULL"
+	softDeletePhotosQuery     = "UPDATE Photos p JOIN Markers m ON p.MarkerID = m.MarkerID SET p.DeletedAt = m.DeletedAt WHERE m.MarkerID = ? AND p.DeletedAt IS NULL"
+	softDeleteCommentsQuery   = "UPDATE Comments c JOIN Markers m ON c.MarkerID = m.MarkerID SET c.DeletedAt = m.DeletedAt WHERE m.MarkerID = ? AND c.DeletedAt IS NULL"
+	softDeleteFavoritesQuery  = "UPDATE Favorites f JOIN Markers m ON f.MarkerID = m.MarkerID SET f.DeletedAt = m.DeletedAt WHERE m.MarkerID = ? AND f.DeletedAt IS NULL"
+	softDeleteFacilitiesQuery = "UPDATE MarkerFacilities mf JOIN Markers m ON mf.MarkerID = m.MarkerID SET mf.DeletedAt = m.DeletedAt WHERE m.MarkerID = ? AND mf.DeletedAt IS NULL"
+
+	restorePhotosQuery     = "UPDATE Photos p JOIN Markers m ON p.MarkerID = m.MarkerID SET p.DeletedAt = NULL WHERE m.MarkerID = ? AND p.DeletedAt = m.DeletedAt"
+	restoreCommentsQuery   = "UPDATE Comments c JOIN Markers m ON c.MarkerID = m.MarkerID SET c.DeletedAt = NULL WHERE m.MarkerID = ? AND c.DeletedAt = m.DeletedAt"
+	restoreFavoritesQuery  = "UPDATE Favorites f JOIN Markers m ON f.MarkerID = m.MarkerID SET f.DeletedAt = NULL WHERE m.MarkerID = ? AND f.DeletedAt = m.DeletedAt"
+	restoreFacilitiesQuery = "UPDATE MarkerFacilities mf JOIN Markers m ON mf.MarkerID = m.MarkerID SET mf.DeletedAt = NULL WHERE m.MarkerID = ? AND mf.DeletedAt = m.DeletedAt"
+	restoreMarkerQuery     = "UPDATE Markers SET DeletedAt = NULL WHERE MarkerID = ? AND DeletedAt IS NOT NULL"
+
+	// Merged markers live on as redirects (see MarkerMergeService), they are neither restored nor purged
+	getDeletedMarkerQuery = "SELECT MarkerID, ST_X(Location) AS Latitude, ST_Y(Location) AS Longitude, COALESCE(Address, '') AS Address FROM Markers WHERE MarkerID = ? AND DeletedAt IS NOT NULL AND Status <> 'MERGED'"
+
+	getDeletedMarkersQuery = `
+SELECT MarkerID, ST_X(Location) AS Latitude, ST_Y(Location) AS Longitude, COALESCE(Description, '') AS Description, Address, UserID, DeletedAt
+FROM Markers
+WHERE DeletedAt IS NOT NULL AND Status <> 'MERGED'
+ORDER BY DeletedAt DESC
+LIMIT ? OFFSET ?`
+	countDeletedMarkersQuery = "SELECT COUNT(*) FROM Markers WHERE DeletedAt IS NOT NULL AND Status <> 'MERGED'"
+
+	getExpiredDeletedMarkersQuery = "SELECT MarkerID FROM Markers WHERE DeletedAt IS NOT NULL AND Status <> 'MERGED' AND DeletedAt < NOW() - INTERVAL ? DAY"
+
 	insertMarkerFailureQuery = "INSERT INTO MarkerAddressFailures (MarkerID, ErrorMessage, URL) VALUES (?, ?, ?)"
 
 	// UNION
 	//     SELECT PhotoURL FROM ReportPhotos WHERE PhotoURL
 	getAllPhotosQuery = "SELECT PhotoURL FROM Photos WHERE PhotoURL IS NOT NULL"
 
-	updateMarkerQuery     = "UPDATE Markers SET Latitude = ?, Longitude = ?, Description = ?, UpdatedAt = NOW() WHERE MarkerID = ?"
+	updateMarkerQuery     = "UPDATE Markers SET Location = ST_PointFromText(?, 4326), Description = ?, UpdatedAt = NOW() WHERE MarkerID = ?"
 	updateMarkerDescQuery = "UPDATE Markers SET Description = ?, UpdatedAt = NOW() WHERE MarkerID = ?"
 
-	getAllMarkersByUserQuery = "SELECT UserID FROM Markers WHERE MarkerID = ?"
+	getAllMarkersByUserQuery = "SELECT UserID FROM Markers WHERE MarkerID = ? AND DeletedAt IS NULL"
 	getPhotosForMarkerQuery  = "SELECT PhotoURL FROM Photos WHERE MarkerID = ?"
 
 	deletePhotoQuery = "DELETE FROM Photos WHERE MarkerID = ?"
@@ -166,22 +250,24 @@ LIMIT ? OFFSET ?`
 	findCloseMarkersAdminQuery = `
 SELECT MarkerID, ST_X(Location) AS Latitude, ST_Y(Location) AS Longitude, Description, ST_Distance_Sphere(Location, ST_GeomFromText(?, 4326)) AS distance, Address
 FROM Markers
-WHERE ST_Distance_Sphere(Location, ST_GeomFromText(?, 4326)) <= ?
+WHERE ST_Distance_Sphere(Location, ST_GeomFromText(?, 4326)) <= ? AND DeletedAt IS NULL
 ORDER BY distance ASC`
 
-	generateRSSQuery = "SELECT MarkerID, UpdatedAt, Address FROM Markers ORDER BY UpdatedAt DESC"
+	generateRSSQuery = "SELECT MarkerID, UpdatedAt, Address FROM Markers WHERE DeletedAt IS NULL ORDER BY UpdatedAt DESC"
 
 	// getNewTop10PicturesQuery      = "SELECT MarkerID, COALESCE(ThumbnailURL, PhotoURL) AS PhotoURL FROM Photos GROUP BY MarkerID, PhotoURL ORDER BY MAX(UploadedAt) DESC LIMIT 10"
-	getNewTop10PicturesQuery      = "SELECT MarkerID, COALESCE(ThumbnailURL, PhotoURL) AS PhotoURL, COALESCE(Blurhash, '') AS Blurhash FROM Photos WHERE (MarkerID, UploadedAt) IN ( SELECT MarkerID, MAX(UploadedAt) FROM Photos GROUP BY MarkerID ) ORDER BY UploadedAt DESC LIMIT 10"
+	getNewTop10PicturesQuery      = "SELECT MarkerID, COALESCE(ThumbnailURL, PhotoURL) AS PhotoURL, COALESCE(Blurhash, '') AS Blurhash FROM Photos WHERE (MarkerID, UploadedAt) IN ( SELECT MarkerID, MAX(UploadedAt) FROM Photos WHERE DeletedAt IS NULL GROUP BY MarkerID ) ORDER BY UploadedAt DESC LIMIT 10"
 	getNewTop10PicturesExtraQuery = `
 SELECT p.MarkerID, COALESCE(p.ThumbnailURL, p.PhotoURL) As PhotoURL, m.Address, ST_X(m.Location) AS Latitude, ST_Y(m.Location) AS Longitude
 FROM Photos p
 JOIN (
     SELECT MarkerID, MAX(UploadedAt) AS LatestUpload
     FROM Photos
+    WHERE DeletedAt IS NULL
     GROUP BY MarkerID
 ) sub ON p.MarkerID = sub.MarkerID AND p.UploadedAt = sub.LatestUpload
 LEFT JOIN Markers m ON p.MarkerID = m.MarkerID
+WHERE m.DeletedAt IS NULL
 ORDER BY p.UploadedAt DESC
 LIMIT 10`
 )
@@ -210,7 +296,12 @@ type MarkerManageService struct {
 	byteCache  *gocache.Cache[[]byte]
 	workerPool *workerpool.WorkerPool
 
-	CacheService *MarkerCacheService
+	CacheService    *MarkerCacheService
+	RevisionService *MarkerRevisionService
+	BadgeService    *BadgeService
+	VariantService  *PhotoVariantService
+
+	ContributionService *ContributionService
 
 	GetMarkerStmt             *sqlx.Stmt
 	GetAllPhotosForMarkerStmt *sqlx.Stmt
@@ -233,6 +324,11 @@ type MarkerManageServiceParams struct {
 	LocalCacheStorage  *ristretto_store.RistrettoStore
 	Logger             *zap.Logger
 	CacheService       *MarkerCacheService
+	RevisionService    *MarkerRevisionService
+	BadgeService       *BadgeService
+	VariantService     *PhotoVariantService
+
+	ContributionService *ContributionService
 }
 
 // NewMarkerManageService creates a new instance of MarkerManageService.
@@ -265,7 +361,12 @@ func NewMarkerManageService(p MarkerManageServiceParams) *MarkerManageService {
 		GetNewTop10PicturesStmt:   getNewTop10PicturesStmt,
 		GenerateRSSQueryStmt:      generateRSSQueryStmt,
 
-		CacheService: p.CacheService,
+		CacheService:    p.CacheService,
+		RevisionService: p.RevisionService,
+		BadgeService:    p.BadgeService,
+		VariantService:  p.VariantService,
+
+		ContributionService: p.ContributionService,
 	}
 }
 
@@ -303,7 +404,8 @@ func (s *MarkerManageService) SetCache(mjson []byte) {
 }
 
 func (s *MarkerManageService) ClearCache() {
-	s.RedisService.ResetCache(s.RedisService.RedisConfig.AllMarkersKey)
+	// Drops the JSON, protobuf and msgpack encodings together
+	s.CacheService.InvalidateFullMarkersCache()
 	// ctx := context.Background()
 	// s.byteCache.Delete(ctx, "allMarkers")
 }
@@ -491,7 +593,11 @@ func (s *MarkerManageService) logAddressFetchFailure(markerID int64, latitude, l
 func (s *MarkerManageService) updateMarkerAddress(markerID int64, address string) error {
 	standardizedAddress := standardizeAddress(address)
 
-	if _, err := s.DB.Exec(updateAddressQuery, standardizedAddress, markerID); err != nil {
+	err := s.RevisionService.Track(int(markerID), RevisionBySystem(), func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(updateAddressQuery, standardizedAddress, markerID)
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update address: %w", err)
 	}
 
@@ -569,20 +675,28 @@ func (s *MarkerManageService) GetMarker(markerID int) (*model.MarkerWithPhotos,
 		return nil, err
 	}
 
-	// Fetch all photos for this marker by descending order of upload time
+	// Fetch all photos for this marker, better and newer photos first
 	var photos []model.Photo
 	err = s.GetAllPhotosForMarkerStmt.Select(&photos, markerID)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching photos: %w", err)
 	}
+	for i := range photos {
+		photos[i].Variants = photoVariants(&photos[i])
+	}
 
 	// Assemble the final structure
 	markersWithPhotos := model.MarkerWithPhotos{
 		Marker:        markersWithUsernames.Marker,
+		Access:        markersWithUsernames.MarkerAccess,
 		Photos:        photos,
 		Username:      markersWithUsernames.Username,
 		DislikeCount:  markersWithUsernames.DislikeCount,
 		FavoriteCount: markersWithUsernames.FavoriteCount,
+		VisitorCount:  markersWithUsernames.VisitorCount,
+	}
+	if markersWithUsernames.ReviewCount > 0 {
+		markersWithPhotos.Rating = &markersWithUsernames.MarkerRating
 	}
 
 	// PublishMarkerUpdate(fmt.Sprintf("user: %s", markersWithPhotos.Username))
@@ -590,6 +704,26 @@ func (s *MarkerManageService) GetMarker(markerID int) (*model.MarkerWithPhotos,
 	return &markersWithPhotos, nil
 }
 
+// photoVariants lists the resized copies of a photo, smallest first, WebP before JPEG for each width.
+func photoVariants(photo *model.Photo) []model.PhotoVariant {
+	if photo.VariantWidths == nil {
+		return nil
+	}
+
+	widths := util.ParseVariantWidths(*photo.VariantWidths)
+	variants := make([]model.PhotoVariant, 0, 2*len(widths))
+	for _, width := range widths {
+		for _, ext := range []string{util.PhotoVariantWebP, util.PhotoVariantJPEG} {
+			variants = append(variants, model.PhotoVariant{
+				URL:   util.PhotoVariantKey(photo.PhotoURL, width, ext),
+				Type:  getContentType(ext),
+				Width: width,
+			})
+		}
+	}
+	return variants
+}
+
 // GetAllMarkersWithAddr fetches all markers and returns only those with an address not found or empty.
 func (s *MarkerManageService) GetAllMarkersWithAddr() ([]dto.MarkerSimpleWithAddr, error) {
 	var markers []dto.MarkerSimpleWithAddr
@@ -616,6 +750,34 @@ func (s *MarkerManageService) GetAllMarkersWithAddr() ([]dto.MarkerSimpleWithAdd
 	return filteredMarkers, nil
 }
 
+// UpdateMarkersAddresses fetches all markers, updates their addresses using an external API, and returns the updated list.
+func (s *MarkerManageService) UpdateMarkersAddresses() ([]dto.MarkerSimpleWithAddr, error) {
+	var markers []dto.MarkerSimpleWithAddr
+	err := s.DB.Select(&markers, getAllSimpleMarkersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching markers: %w", err)
+	}
+
+	for i := range markers {
+		address, err := s.MarkerLocationService.FacilityService.FetchAddressFromAPI(markers[i].Latitude, markers[i].Longitude)
+		if err != nil || address == "" {
+			// If there's an error fetching the address or the address is not found, skip updating this marker.
+			continue
+		}
+
+		markers[i].Address = address
+		err = s.RevisionService.Track(markers[i].MarkerID, RevisionBySystem(), func(tx *sqlx.Tx) error {
+			_, err := tx.Exec(updateAddressQuery, address, markers[i].MarkerID)
+			return err
+		})
+		if err != nil {
+			s.Logger.Error("Failed to update marker address", zap.Int("markerID", markers[i].MarkerID), zap.Error(err))
+		}
+	}
+
+	return markers, nil
+}
+
 func (s *MarkerManageService) GetAllMarkersByUserWithPagination(userID, page, pageSize int) ([]dto.MarkerSimpleWithDescription, int, error) {
 	offset := (page - 1) * pageSize
 
@@ -789,6 +951,20 @@ func (s *MarkerManageService) CreateMarkerWithPhotos(ctx context.Context, marker
 		files = files[:5] // Limit to 5 files
 	}
 
+	// Quality warnings of the photos that were accepted
+	var warningsMu sync.Mutex
+	var photoWarnings []dto.PhotoWarning
+
+	// Photos already in S3 are deleted again when the marker is not created
+	var uploadedMu sync.Mutex
+	var uploadedURLs []string
+	committed := false
+	defer func() {
+		if !committed {
+			s.S3Service.DeleteUploadedFiles(uploadedURLs)
+		}
+	}()
+
 	var wg sync.WaitGroup
 	wg.Add(len(files))
 
@@ -804,7 +980,7 @@ func (s *MarkerManageService) CreateMarkerWithPhotos(ctx context.Context, marker
 			defer taskCancel()
 
 			// Upload the file to S3 using a context-aware method.
-			fileURL, thumbnailURL, err := s.S3Service.UploadFileToS3WithContext(perTaskCtx, folder, fileHeader, true)
+			uploaded, err := s.S3Service.UploadFile(perTaskCtx, folder, fileHeader, true)
 			if err != nil {
 				select {
 				case errorChan <- fmt.Errorf("S3 upload failed: %w", err):
@@ -812,6 +988,9 @@ func (s *MarkerManageService) CreateMarkerWithPhotos(ctx context.Context, marker
 				}
 				return
 			}
+			uploadedMu.Lock()
+			uploadedURLs = append(uploadedURLs, uploaded.URL)
+			uploadedMu.Unlock()
 
 			file, _ := fileHeader.Open()
 			defer file.Close()
@@ -829,14 +1008,30 @@ func (s *MarkerManageService) CreateMarkerWithPhotos(ctx context.Context, marker
 			}
 			blurhashString := util.EncodeBlurHashImage(img, 6, 5)
 
+			// The uploaded copy has no EXIF any more, the original still tells where it was taken
+			verification := util.VerifyPhotoLocation(util.ReadPhotoExif(bytes.NewReader(rawBytes)), markerDto.Latitude, markerDto.Longitude, time.Now())
+			if isDuplicateUpload(s.DB, s.Logger, int(markerID), uploaded) {
+				verification.Flags = append(verification.Flags, util.PhotoFlagDuplicate)
+			}
+
 			// Insert photo into the database
-			if _, err := tx.Exec(insertPhotoWithThumbQuery, markerID, fileURL, thumbnailURL, blurhashString); err != nil {
+			qualityScore, sharpness, brightness := uploaded.qualityColumns()
+			if _, err := tx.Exec(insertVerifiedPhotoQuery, markerID, uploaded.URL, uploaded.ThumbnailURL,
+				blurhashString, uploaded.PerceptualHash,
+				qualityScore, sharpness, brightness,
+				verification.TakenAt, verification.Distance, verification.Score, verification.FlagList()); err != nil {
 				select {
 				case errorChan <- err:
 				default:
 				}
 				return
 			}
+
+			if warnings := uploaded.Warnings(fileHeader.Filename); len(warnings) > 0 {
+				warningsMu.Lock()
+				photoWarnings = append(photoWarnings, warnings...)
+				warningsMu.Unlock()
+			}
 		})
 	}
 
@@ -847,13 +1042,27 @@ func (s *MarkerManageService) CreateMarkerWithPhotos(ctx context.Context, marker
 	// Check for errors
 	if err, ok := <-errorChan; ok {
 		tx.Rollback()
-		return nil, fmt.Errorf("encountered an error during file upload or DB operation: %v", err)
+		return nil, fmt.Errorf("encountered an error during file upload or DB operation: %w", err)
+	}
+
+	if err := recordMarkerChanges(tx, int(markerID)); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	contributors, err := s.ContributionService.SyncTx(tx, EventMarkerCreated, int(markerID), "")
+	if err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
 	// Commit the transaction after all operations succeed
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("could not commit transaction: %w", err)
 	}
+	committed = true
+	s.ContributionService.InvalidateProfiles(contributors)
+	s.VariantService.Enqueue(uploadedURLs...)
 
 	// Increment the daily marker creation count for the user
 	go func() {
@@ -871,6 +1080,15 @@ func (s *MarkerManageService) CreateMarkerWithPhotos(ctx context.Context, marker
 	// go s.MarkerLocationService.Redis.ResetAllCache(fmt.Sprintf("userMarkers:%d:page:*", userID))
 	go s.CacheService.RemoveUserMarker(userID, int(markerID))
 	go s.CacheService.InvalidateFullMarkersCache()
+	go s.CacheService.AddMarker(int(markerID), dto.MarkerSimple{
+		Latitude:  markerDto.Latitude,
+		Longitude: markerDto.Longitude,
+		MarkerID:  int(markerID),
+		HasPhoto:  len(files) > 0,
+		Status:    MarkerStatusActive,
+	})
+
+	s.BadgeService.Publish(EventMarkerCreated, userID)
 
 	// Construct and return the response
 	return &dto.MarkerResponse{
@@ -879,6 +1097,7 @@ func (s *MarkerManageService) CreateMarkerWithPhotos(ctx context.Context, marker
 		Longitude:   markerDto.Longitude,
 		Description: markerDto.Description,
 		// PhotoURLs:   photoURLs,
+		PhotoWarnings: photoWarnings,
 	}, nil
 }
 
@@ -906,15 +1125,20 @@ func (s *MarkerManageService) FetchAllPhotoURLsFromDB() ([]string, error) {
 }
 
 // UpdateMarker updates an existing marker's latitude, longitude, and description
-func (s *MarkerManageService) UpdateMarker(marker *model.Marker) error {
-	_, err := s.DB.Exec(updateMarkerQuery, marker.Latitude, marker.Longitude, marker.Description, marker.MarkerID)
-	return err
+func (s *MarkerManageService) UpdateMarker(marker *model.Marker, actor RevisionActor) error {
+	return s.RevisionService.Track(marker.MarkerID, actor, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(updateMarkerQuery, formatPoint(marker.Latitude, marker.Longitude), marker.Description, marker.MarkerID)
+		return err
+	})
 }
 
-func (s *MarkerManageService) UpdateMarkerDescriptionOnly(markerID int, description string) error {
-	_, err := s.DB.Exec(updateMarkerDescQuery, description, markerID)
+func (s *MarkerManageService) UpdateMarkerDescriptionOnly(markerID int, description string, actor RevisionActor) error {
+	err := s.RevisionService.Track(markerID, actor, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(updateMarkerDescQuery, description, markerID)
+		return err
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, ErrMarkerNotFound) {
 			return fmt.Errorf("no marker found with markerID %d", markerID)
 		}
 		return fmt.Errorf("error updating a marker: %w", err)
@@ -923,22 +1147,21 @@ func (s *MarkerManageService) UpdateMarkerDescriptionOnly(markerID int, descript
 	return nil
 }
 
+// DeleteMarker soft-deletes a marker together with its photos, comments, favorites and facilities.
+// The rows stay in the database until PurgeDeletedMarkers removes them, so an admin can still restore the marker.
 func (s *MarkerManageService) DeleteMarker(userID, markerID int, userRole string) error {
-	// Precheck user authorization and fetch photo URLs before transaction
+	// Precheck user authorization before transaction
 	var ownerID sql.NullInt64
-	var photoURLs []string
 	err := s.DB.Get(&ownerID, getAllMarkersByUserQuery, markerID)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMarkerNotFound
+		}
 		return fmt.Errorf("checking marker ownership: %w", err)
 	}
 
 	if userRole != "admin" && int(ownerID.Int64) != userID {
-		return fmt.Errorf("user %d is not authorized to delete marker %d", userID, markerID)
-	}
-
-	err = s.DB.Select(&photoURLs, getPhotosForMarkerQuery, markerID)
-	if err != nil {
-		return fmt.Errorf("fetching photos: %w", err)
+		return fmt.Errorf("user %d is not authorized to delete marker %d: %w", userID, markerID, ErrUnauthorized)
 	}
 
 	// Start a transaction
@@ -946,39 +1169,153 @@ func (s *MarkerManageService) DeleteMarker(userID, markerID int, userRole string
 	if err != nil {
 		return fmt.Errorf("starting transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Delete photos from database
-	if _, err := tx.Exec(deletePhotoQuery, markerID); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("deleting photos: %w", err)
+	// The marker has to be stamped first, dependent rows copy its DeletedAt
+	if _, err := tx.Exec(softDeleteMarkerQuery, markerID); err != nil {
+		return fmt.Errorf("deleting marker: %w", err)
 	}
 
-	// Delete the marker
-	if _, err := tx.Exec(deleteMarkerQuery, markerID); err != nil {
-		tx.Rollback()
-		return fmt.Errorf("deleting marker: %w", err)
+	for _, query := range []string{softDeletePhotosQuery, softDeleteCommentsQuery, softDeleteFavoritesQuery, softDeleteFacilitiesQuery} {
+		if _, err := tx.Exec(query, markerID); err != nil {
+			return fmt.Errorf("deleting marker dependents: %w", err)
+		}
+	}
+	if err := recordMarkerChanges(tx, markerID); err != nil {
+		return err
+	}
+	contributors, err := s.ContributionService.SyncTx(tx, EventMarkerCreated, markerID, ContributionReasonMarkerDeleted)
+	if err != nil {
+		return err
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("committing transaction: %w", err)
 	}
+	s.ContributionService.InvalidateProfiles(contributors)
+
+	s.ClearCache()
+	s.CacheService.RemoveMarker(markerID)
+	s.RedisService.RemoveGeoMarker(strconv.Itoa(markerID))
+	s.BleveSearchService.DeleteMarkerIndex(markerID)
+
+	return nil
+}
+
+// RestoreMarker brings back a soft-deleted marker and the dependent rows that were deleted with it.
+func (s *MarkerManageService) RestoreMarker(markerID int) (*dto.MarkerSimpleWithAddr, error) {
+	var marker dto.MarkerSimpleWithAddr
+	if err := s.DB.Get(&marker, getDeletedMarkerQuery, markerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMarkerNotFound
+		}
+		return nil, fmt.Errorf("fetching deleted marker: %w", err)
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Dependents are matched against the marker's DeletedAt, so the marker is restored last
+	for _, query := range []string{restorePhotosQuery, restoreCommentsQuery, restoreFavoritesQuery, restoreFacilitiesQuery, restoreMarkerQuery} {
+		if _, err := tx.Exec(query, markerID); err != nil {
+			return nil, fmt.Errorf("restoring marker: %w", err)
+		}
+	}
+	if err := recordMarkerChanges(tx, markerID); err != nil {
+		return nil, err
+	}
+	contributors, err := s.ContributionService.SyncTx(tx, EventMarkerCreated, markerID, ContributionReasonMarkerRestored)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing transaction: %w", err)
+	}
+	s.ContributionService.InvalidateProfiles(contributors)
+
+	s.ClearCache()
+	s.CacheService.InvalidateFacilities(markerID)
+	s.CacheService.AddMarker(markerID, dto.MarkerSimple{Latitude: marker.Latitude, Longitude: marker.Longitude, MarkerID: markerID})
+	s.RedisService.AddGeoMarker(strconv.Itoa(markerID), marker.Latitude, marker.Longitude)
+	if marker.Address != "" {
+		s.indexMarkerForSearch(int64(markerID), marker.Address)
+	}
+
+	return &marker, nil
+}
+
+// GetDeletedMarkers lists soft-deleted markers, most recently deleted first.
+func (s *MarkerManageService) GetDeletedMarkers(page, pageSize int) (*dto.DeletedMarkerList, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := s.DB.Get(&total, countDeletedMarkersQuery); err != nil {
+		return nil, fmt.Errorf("counting deleted markers: %w", err)
+	}
+
+	markers := make([]dto.DeletedMarker, 0, pageSize)
+	if err := s.DB.Select(&markers, getDeletedMarkersQuery, pageSize, offset); err != nil {
+		return nil, fmt.Errorf("fetching deleted markers: %w", err)
+	}
+
+	return &dto.DeletedMarkerList{
+		Markers:      markers,
+		CurrentPage:  page,
+		TotalPages:   int(math.Ceil(float64(total) / float64(pageSize))),
+		TotalMarkers: total,
+	}, nil
+}
+
+// PurgeDeletedMarkers permanently deletes markers that have been soft-deleted for longer than retentionDays,
+// along with their photos in S3. Comments, favorites and facilities go with the marker through ON DELETE CASCADE.
+func (s *MarkerManageService) PurgeDeletedMarkers(retentionDays int) (int, error) {
+	var markerIDs []int
+	if err := s.DB.Select(&markerIDs, getExpiredDeletedMarkersQuery, retentionDays); err != nil {
+		return 0, fmt.Errorf("fetching expired deleted markers: %w", err)
+	}
+
+	purged := 0
+	for _, markerID := range markerIDs {
+		var photoURLs []string
+		if err := s.DB.Select(&photoURLs, getPhotosForMarkerQuery, markerID); err != nil {
+			return purged, fmt.Errorf("fetching photos of marker %d: %w", markerID, err)
+		}
+
+		tx, err := s.DB.Beginx()
+		if err != nil {
+			return purged, fmt.Errorf("starting transaction: %w", err)
+		}
+
+		if _, err := tx.Exec(deletePhotoQuery, markerID); err != nil {
+			tx.Rollback()
+			return purged, fmt.Errorf("deleting photos of marker %d: %w", markerID, err)
+		}
+		if _, err := tx.Exec(deleteMarkerQuery, markerID); err != nil {
+			tx.Rollback()
+			return purged, fmt.Errorf("deleting marker %d: %w", markerID, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return purged, fmt.Errorf("committing transaction: %w", err)
+		}
+		purged++
 
-	// Delete photos from S3 in a goroutine
-	go func(photoURLs []string) {
 		for _, photoURL := range photoURLs {
 			if err := s.S3Service.DeleteDataFromS3(photoURL); err != nil {
 				s.Logger.Error("Failed to delete photo from S3", zap.String("photoURL", photoURL), zap.Error(err))
 			}
 		}
-	}(photoURLs)
-
-	s.ClearCache()
-	s.BleveSearchService.DeleteMarkerIndex(markerID)
+	}
 
-	return nil
+	return purged, nil
 }
 
+// UploadMarkerPhotoToS3 adds photos to a marker. Nothing is added when one of them fails, the photos already
+// uploaded are deleted again; a photo that fails the quality checks returns a util.PhotoQualityIssue.
 func (s *MarkerManageService) UploadMarkerPhotoToS3(markerID int, files []*multipart.FileHeader) ([]string, error) {
 	// Begin a transaction for database operations
 	tx, err := s.DB.Beginx()
@@ -991,20 +1328,32 @@ func (s *MarkerManageService) UploadMarkerPhotoToS3(markerID int, files []*multi
 	folder := fmt.Sprintf("markers/%d", markerID)
 
 	picUrls := make([]string, 0)
+	committed := false
+	defer func() {
+		if !committed {
+			s.S3Service.DeleteUploadedFiles(picUrls)
+		}
+	}()
+
 	// Process file uploads from the multipart form
 	for _, file := range files {
-		fileURL, thumbnailURL, err := s.S3Service.UploadFileToS3(folder, file, true)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		uploaded, err := s.S3Service.UploadFile(ctx, folder, file, true)
+		cancel()
 		if err != nil {
-			fmt.Printf("Failed to upload file to S3: %v\n", err)
-			continue // Skip this file and continue with the next
+			return nil, fmt.Errorf("uploading %s: %w", file.Filename, err)
 		}
-		picUrls = append(picUrls, fileURL)
+		picUrls = append(picUrls, uploaded.URL)
+
+		var flags string
+		if isDuplicateUpload(s.DB, s.Logger, markerID, uploaded) {
+			flags = util.PhotoFlagDuplicate
+		}
+
 		// Associate each photo with the marker in the database
-		if _, err := tx.Exec(insertPhotoWithThumbQuery, markerID, fileURL, thumbnailURL); err != nil {
-			// Attempt to delete the uploaded file from S3
-			if delErr := s.S3Service.DeleteDataFromS3(fileURL); delErr != nil {
-				fmt.Printf("Also failed to delete the file from S3: %v\n", delErr)
-			}
+		qualityScore, sharpness, brightness := uploaded.qualityColumns()
+		if _, err := tx.Exec(insertUploadedPhotoQuery, markerID, uploaded.URL, uploaded.ThumbnailURL, uploaded.PerceptualHash,
+			qualityScore, sharpness, brightness, flags); err != nil {
 			return nil, err
 		}
 	}
@@ -1013,11 +1362,19 @@ func (s *MarkerManageService) UploadMarkerPhotoToS3(markerID int, files []*multi
 	if _, err := tx.Exec(updateTimeMarkerQuery, markerID); err != nil {
 		return nil, err
 	}
+	if err := recordMarkerChanges(tx, markerID); err != nil {
+		return nil, err
+	}
 
 	// If no errors, commit the transaction
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
+	committed = true
+	s.VariantService.Enqueue(picUrls...)
+
+	// Tiles draw markers with photos differently
+	s.CacheService.InvalidateMarkerTiles()
 
 	return picUrls, nil
 }
@@ -1070,13 +1427,50 @@ func (s *MarkerManageService) GenerateRSS() (string, error) {
 	return generateRSS(markers)
 }
 
+type markerProtoRow struct {
+	MarkerID   int32   `db:"MarkerID"`
+	Latitude   float64 `db:"Latitude"`
+	Longitude  float64 `db:"Longitude"`
+	Address    string  `db:"Address"`
+	Status     string  `db:"Status"`
+	Thumbnail  string  `db:"Thumbnail"`
+	Facilities string  `db:"Facilities"`
+}
+
+// GetAllMarkersProto returns every marker with its address, latest thumbnail and facilities for protobuf clients.
 func (s *MarkerManageService) GetAllMarkersProto() ([]*protos.Marker, error) {
-	var markers []*protos.Marker
-	err := s.DB.Select(&markers, getAllSimpleMarkersQuery)
+	var rows []markerProtoRow
+	err := s.DB.Select(&rows, getAllMarkersProtoQuery)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching markers: %w", err)
 	}
 
+	markers := make([]*protos.Marker, 0, len(rows))
+	for _, row := range rows {
+		marker := &protos.Marker{
+			MarkerId:  row.MarkerID,
+			Latitude:  row.Latitude,
+			Longitude: row.Longitude,
+			Address:   row.Address,
+			HasPhoto:  row.Thumbnail != "",
+			Thumbnail: row.Thumbnail,
+			Status:    row.Status,
+		}
+
+		facilities, err := util.ParseFacilityQuantities(row.Facilities)
+		if err != nil {
+			s.Logger.Warn("Skipping unreadable facilities", zap.Int32("markerID", row.MarkerID), zap.Error(err))
+		}
+		for facilityID, quantity := range facilities {
+			marker.Facilities = append(marker.Facilities, &protos.Facility{FacilityId: int32(facilityID), Quantity: int32(quantity)})
+		}
+		sort.Slice(marker.Facilities, func(i, j int) bool {
+			return marker.Facilities[i].FacilityId < marker.Facilities[j].FacilityId
+		})
+
+		markers = append(markers, marker)
+	}
+
 	return markers, nil
 }
 
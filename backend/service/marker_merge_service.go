@@ -0,0 +1,410 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	lockMergeMarkerQuery = `
+SELECT MarkerID, UserID, ST_X(Location) AS Latitude, ST_Y(Location) AS Longitude, COALESCE(Description, '') AS Description, Address
+FROM Markers
+WHERE MarkerID = ? AND DeletedAt IS NULL
+FOR UPDATE`
+
+	// Rows that can simply change owner
+	movePhotosQuery   = "UPDATE Photos SET MarkerID = ? WHERE MarkerID = ?"
+	moveCommentsQuery = "UPDATE Comments SET MarkerID = ? WHERE MarkerID = ?"
+	moveStoriesQuery  = "UPDATE Stories SET MarkerID = ? WHERE MarkerID = ?"
+	moveReportsQuery  = "UPDATE Reports SET MarkerID = ? WHERE MarkerID = ?"
+	moveCheckInsQuery = "UPDATE MarkerCheckIns SET MarkerID = ? WHERE MarkerID = ?"
+
+	// A user who favorited (or disliked, or reviewed) both markers keeps the survivor's row, IGNORE skips the duplicate
+	// and the leftover is deleted afterwards.
+	moveFavoritesQuery = "UPDATE IGNORE Favorites SET MarkerID = ? WHERE MarkerID = ?"
+	moveDislikesQuery  = "UPDATE IGNORE MarkerDislikes SET MarkerID = ? WHERE MarkerID = ?"
+	moveReviewsQuery   = "UPDATE IGNORE MarkerReviews SET MarkerID = ? WHERE MarkerID = ?"
+
+	// Facilities both markers have keep the larger quantity, the rest move over
+	mergeFacilityQuantitiesQuery = `
+UPDATE MarkerFacilities s
+JOIN MarkerFacilities d ON d.FacilityID = s.FacilityID
+SET s.Quantity = GREATEST(s.Quantity, d.Quantity)
+WHERE s.MarkerID = ? AND d.MarkerID = ? AND s.DeletedAt IS NULL AND d.DeletedAt IS NULL`
+	moveFacilitiesQuery = "UPDATE IGNORE MarkerFacilities SET MarkerID = ? WHERE MarkerID = ? AND DeletedAt IS NULL"
+
+	// Markers that were merged into the one being merged now point straight to the new survivor
+	repointMarkerMergesQuery = "UPDATE MarkerMerges SET SurvivorMarkerID = ? WHERE SurvivorMarkerID = ?"
+
+	insertMarkerMergeQuery = `
+INSERT INTO MarkerMerges (SurvivorMarkerID, MergedMarkerID, MergedByUserID, OwnerUserID, Latitude, Longitude, Description, Address, Moved)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	selectMarkerMergeQuery = `
+SELECT MergeID, SurvivorMarkerID, MergedMarkerID, MergedByUserID, OwnerUserID, Latitude, Longitude,
+	COALESCE(Description, '') AS Description, Address, Moved, CreatedAt
+FROM MarkerMerges
+WHERE MergeID = ?`
+
+	selectMarkerMergesQuery = `
+SELECT MergeID, SurvivorMarkerID, MergedMarkerID, MergedByUserID, OwnerUserID, Latitude, Longitude,
+	COALESCE(Description, '') AS Description, Address, Moved, CreatedAt
+FROM MarkerMerges
+ORDER BY MergeID DESC
+LIMIT ? OFFSET ?`
+
+	countMarkerMergesQuery = "SELECT COUNT(*) FROM MarkerMerges"
+
+	resolveMergedMarkerQuery = `
+SELECT mm.SurvivorMarkerID
+FROM MarkerMerges mm
+JOIN Markers m ON m.MarkerID = mm.SurvivorMarkerID
+WHERE mm.MergedMarkerID = ? AND m.DeletedAt IS NULL`
+
+	// Rows the survivor already had a copy of are all that is left, the merged marker itself is only soft-deleted
+	// so its revision history stays behind the redirect. PurgeDeletedMarkers skips MERGED markers.
+	deleteMergedFavoritesQuery  = "DELETE FROM Favorites WHERE MarkerID = ?"
+	deleteMergedDislikesQuery   = "DELETE FROM MarkerDislikes WHERE MarkerID = ?"
+	deleteMergedReviewsQuery    = "DELETE FROM MarkerReviews WHERE MarkerID = ?"
+	deleteMergedRatingQuery     = "DELETE FROM MarkerRatings WHERE MarkerID = ?"
+	deleteMergedFacilitiesQuery = "DELETE FROM MarkerFacilities WHERE MarkerID = ?"
+	softDeleteMergedMarkerQuery = "UPDATE Markers SET DeletedAt = NOW(), Status = 'MERGED' WHERE MarkerID = ?"
+)
+
+var (
+	ErrInvalidMerge = errors.New("invalid marker merge")
+)
+
+type mergeMarkerRow struct {
+	UserID      *int    `db:"UserID"`
+	Address     *string `db:"Address"`
+	Latitude    float64 `db:"Latitude"`
+	Longitude   float64 `db:"Longitude"`
+	MarkerID    int     `db:"MarkerID"`
+	Description string  `db:"Description"`
+}
+
+// MarkerMergeService folds duplicate markers into a surviving marker.
+type MarkerMergeService struct {
+	DB *sqlx.DB
+
+	RevisionService    *MarkerRevisionService
+	StatusService      *MarkerStatusService
+	CacheService       *MarkerCacheService
+	BleveSearchService *BleveSearchService
+	RedisService       *RedisService
+	ChatService        *ChatService
+	LeaderboardService *LeaderboardService
+
+	ContributionService *ContributionService
+
+	Logger *zap.Logger
+}
+
+type MarkerMergeServiceParams struct {
+	fx.In
+
+	DB                 *sqlx.DB
+	RevisionService    *MarkerRevisionService
+	StatusService      *MarkerStatusService
+	CacheService       *MarkerCacheService
+	BleveSearchService *BleveSearchService
+	RedisService       *RedisService
+	ChatService        *ChatService
+	LeaderboardService *LeaderboardService
+	Logger             *zap.Logger
+
+	ContributionService *ContributionService
+}
+
+func NewMarkerMergeService(p MarkerMergeServiceParams) *MarkerMergeService {
+	return &MarkerMergeService{
+		DB:                 p.DB,
+		RevisionService:    p.RevisionService,
+		StatusService:      p.StatusService,
+		CacheService:       p.CacheService,
+		BleveSearchService: p.BleveSearchService,
+		RedisService:       p.RedisService,
+		ChatService:        p.ChatService,
+		LeaderboardService: p.LeaderboardService,
+		Logger:             p.Logger,
+
+		ContributionService: p.ContributionService,
+	}
+}
+
+// MergeMarkers moves photos, comments, favorites, dislikes, stories, reports and facilities of mergedIDs onto
+// survivorID and soft-deletes the merged markers with the MERGED status. Each merged marker leaves a MarkerMerges row behind,
+// which is both the audit record and the redirect from its old ID.
+func (s *MarkerMergeService) MergeMarkers(survivorID int, mergedIDs []int, adminID int) (*dto.MarkerMergeResult, error) {
+	mergedIDs = uniqueMergeIDs(survivorID, mergedIDs)
+	if len(mergedIDs) == 0 {
+		return nil, fmt.Errorf("no markers to merge into %d: %w", survivorID, ErrInvalidMerge)
+	}
+
+	// Scores are read up front for the audit record, they are moved in Redis after the commit
+	clicks := make(map[int]int64, len(mergedIDs))
+	for _, mergedID := range mergedIDs {
+		clicks[mergedID] = s.clickScore(mergedID)
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	var survivor mergeMarkerRow
+	if err := tx.Get(&survivor, lockMergeMarkerQuery, survivorID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMarkerNotFound
+		}
+		return nil, fmt.Errorf("locking survivor marker: %w", err)
+	}
+
+	mergeIDs := make([]int64, 0, len(mergedIDs))
+	actor := RevisionByUser(adminID, "admin")
+	err = s.RevisionService.TrackTx(tx, survivorID, actor, func() error {
+		for _, mergedID := range mergedIDs {
+			mergeID, err := s.mergeOneTx(tx, survivorID, mergedID, adminID, clicks[mergedID])
+			if err != nil {
+				return err
+			}
+			mergeIDs = append(mergeIDs, mergeID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Reports came along with the duplicates, they may change what the survivor looks like now
+	if _, _, err := s.StatusService.ReevaluateTx(tx, survivorID); err != nil {
+		return nil, err
+	}
+
+	merges := make([]model.MarkerMerge, 0, len(mergeIDs))
+	for _, mergeID := range mergeIDs {
+		var merge model.MarkerMerge
+		if err := tx.Get(&merge, selectMarkerMergeQuery, mergeID); err != nil {
+			return nil, fmt.Errorf("fetching merge record: %w", err)
+		}
+		merges = append(merges, merge)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+
+	for _, mergedID := range mergedIDs {
+		s.cleanupMergedMarker(survivorID, mergedID)
+	}
+	for _, merge := range merges {
+		if merge.OwnerUserID != nil {
+			s.CacheService.ResetUserProfileCache(*merge.OwnerUserID)
+		}
+	}
+
+	s.CacheService.RemoveMarkerCache(survivorID)
+	s.CacheService.InvalidateFacilities(survivorID)
+	s.CacheService.InvalidateFullMarkersCache()
+	if survivor.Address != nil && *survivor.Address != "" {
+		if err := s.BleveSearchService.InsertMarkerIndex(dto.MarkerIndexData{MarkerID: survivorID, Address: *survivor.Address}); err != nil {
+			s.Logger.Error("Failed to reindex survivor marker", zap.Int("markerID", survivorID), zap.Error(err))
+		}
+	}
+
+	s.Logger.Info("Markers merged",
+		zap.Int("survivorID", survivorID),
+		zap.Ints("mergedIDs", mergedIDs),
+		zap.Int("adminID", adminID))
+
+	return &dto.MarkerMergeResult{
+		Merges:           merges,
+		SurvivorMarkerID: survivorID,
+	}, nil
+}
+
+// mergeOneTx moves everything of mergedID onto survivorID and records the merge.
+func (s *MarkerMergeService) mergeOneTx(tx *sqlx.Tx, survivorID, mergedID, adminID int, clicks int64) (int64, error) {
+	var merged mergeMarkerRow
+	if err := tx.Get(&merged, lockMergeMarkerQuery, mergedID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("marker %d: %w", mergedID, ErrMarkerNotFound)
+		}
+		return 0, fmt.Errorf("locking marker %d: %w", mergedID, err)
+	}
+
+	moved := model.MergedCount{Clicks: clicks}
+	for _, step := range []struct {
+		query string
+		count *int64
+	}{
+		{movePhotosQuery, &moved.Photos},
+		{moveCommentsQuery, &moved.Comments},
+		{moveStoriesQuery, &moved.Stories},
+		{moveReportsQuery, &moved.Reports},
+		{moveFavoritesQuery, &moved.Favorites},
+		{moveDislikesQuery, &moved.Dislikes},
+		{moveReviewsQuery, &moved.Reviews},
+		{moveCheckInsQuery, &moved.CheckIns},
+	} {
+		res, err := tx.Exec(step.query, survivorID, mergedID)
+		if err != nil {
+			return 0, fmt.Errorf("moving rows of marker %d: %w", mergedID, err)
+		}
+		*step.count, _ = res.RowsAffected()
+	}
+
+	if moved.Reviews > 0 {
+		if err := refreshMarkerRatingTx(tx, survivorID); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.LeaderboardService.MergeMarkerScoresTx(tx, survivorID, mergedID); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(mergeFacilityQuantitiesQuery, survivorID, mergedID); err != nil {
+		return 0, fmt.Errorf("merging facilities of marker %d: %w", mergedID, err)
+	}
+	res, err := tx.Exec(moveFacilitiesQuery, survivorID, mergedID)
+	if err != nil {
+		return 0, fmt.Errorf("moving facilities of marker %d: %w", mergedID, err)
+	}
+	moved.Facilities, _ = res.RowsAffected()
+
+	if _, err := tx.Exec(repointMarkerMergesQuery, survivorID, mergedID); err != nil {
+		return 0, fmt.Errorf("repointing earlier merges: %w", err)
+	}
+
+	res, err = tx.Exec(insertMarkerMergeQuery, survivorID, mergedID, adminID, merged.UserID,
+		merged.Latitude, merged.Longitude, merged.Description, merged.Address, moved)
+	if err != nil {
+		return 0, fmt.Errorf("recording merge of marker %d: %w", mergedID, err)
+	}
+	mergeID, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrLastInsertID, err)
+	}
+
+	for _, query := range []string{
+		deleteMergedFavoritesQuery, deleteMergedDislikesQuery, deleteMergedReviewsQuery,
+		deleteMergedRatingQuery, deleteMergedFacilitiesQuery, softDeleteMergedMarkerQuery,
+	} {
+		if _, err := tx.Exec(query, mergedID); err != nil {
+			return 0, fmt.Errorf("deleting marker %d: %w", mergedID, err)
+		}
+	}
+	if err := recordMarkerChanges(tx, mergedID); err != nil {
+		return 0, err
+	}
+	// A duplicate does not earn its creator points
+	if _, err := s.ContributionService.SyncTx(tx, EventMarkerCreated, mergedID, ContributionReasonMarkerMerged); err != nil {
+		return 0, err
+	}
+
+	return mergeID, nil
+}
+
+// cleanupMergedMarker moves the Redis state of a merged marker (click rank, chat room, leaderboards) onto the survivor
+// and drops it from the geo set and the search index.
+func (s *MarkerMergeService) cleanupMergedMarker(survivorID, mergedID int) {
+	ctx := context.Background()
+	client := s.RedisService.Core.Client
+	survivor, merged := strconv.Itoa(survivorID), strconv.Itoa(mergedID)
+
+	if score := s.clickScore(mergedID); score > 0 {
+		if err := client.Do(ctx, client.B().Zincrby().Key("marker_clicks").Increment(float64(score)).Member(survivor).Build()).Error(); err != nil {
+			s.Logger.Error("Failed to move marker clicks", zap.Int("markerID", mergedID), zap.Error(err))
+		}
+	}
+	client.Do(ctx, client.B().Zrem().Key("marker_clicks").Member(merged).Build())
+
+	// Chat history is kept by merging the message sets, people still in the old room are told where to go
+	survivorRoom := fmt.Sprintf("chat:room:%s:messages", survivor)
+	mergedRoom := fmt.Sprintf("chat:room:%s:messages", merged)
+	if err := client.Do(ctx, client.B().Zunionstore().Destination(survivorRoom).Numkeys(2).Key(survivorRoom, mergedRoom).Build()).Error(); err != nil {
+		s.Logger.Error("Failed to merge chat history", zap.Int("markerID", mergedID), zap.Error(err))
+	}
+	client.Do(ctx, client.B().Del().Key(mergedRoom).Build())
+	s.ChatService.BroadcastRawMessageToRoom(merged, fmt.Sprintf("이 철봉은 %s번 철봉으로 통합되었습니다.", survivor))
+
+	if err := s.RedisService.RemoveGeoMarker(merged); err != nil {
+		s.Logger.Error("Failed to remove merged marker from geo set", zap.Int("markerID", mergedID), zap.Error(err))
+	}
+	if err := s.BleveSearchService.DeleteMarkerIndex(mergedID); err != nil {
+		s.Logger.Error("Failed to remove merged marker from search index", zap.Int("markerID", mergedID), zap.Error(err))
+	}
+	s.CacheService.RemoveMarker(mergedID)
+	s.CacheService.InvalidateFacilities(mergedID)
+	s.LeaderboardService.ResetMarkerBoards(survivorID)
+	s.LeaderboardService.ResetMarkerBoards(mergedID)
+}
+
+func (s *MarkerMergeService) clickScore(markerID int) int64 {
+	client := s.RedisService.Core.Client
+	score, err := client.Do(context.Background(), client.B().Zscore().Key("marker_clicks").Member(strconv.Itoa(markerID)).Build()).AsFloat64()
+	if err != nil {
+		return 0
+	}
+	return int64(score)
+}
+
+// ResolveMergedMarker returns the marker that markerID was merged into.
+func (s *MarkerMergeService) ResolveMergedMarker(markerID int) (int, bool) {
+	var survivorID int
+	if err := s.DB.Get(&survivorID, resolveMergedMarkerQuery, markerID); err != nil {
+		return 0, false
+	}
+	return survivorID, true
+}
+
+// GetMarkerMerges lists merge records, newest first.
+func (s *MarkerMergeService) GetMarkerMerges(page, pageSize int) (*dto.MarkerMergeList, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := s.DB.Get(&total, countMarkerMergesQuery); err != nil {
+		return nil, fmt.Errorf("counting merges: %w", err)
+	}
+
+	merges := make([]model.MarkerMerge, 0, pageSize)
+	if err := s.DB.Select(&merges, selectMarkerMergesQuery, pageSize, offset); err != nil {
+		return nil, fmt.Errorf("fetching merges: %w", err)
+	}
+
+	return &dto.MarkerMergeList{
+		Merges:      merges,
+		CurrentPage: page,
+		TotalPages:  int(math.Ceil(float64(total) / float64(pageSize))),
+		TotalMerges: total,
+	}, nil
+}
+
+// uniqueMergeIDs drops duplicates, non-positive IDs and the survivor itself.
+func uniqueMergeIDs(survivorID int, ids []int) []int {
+	seen := make(map[int]struct{}, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if id <= 0 || id == survivorID {
+			continue
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
+}
@@ -29,8 +29,47 @@ SELECT
 	Address
 FROM 
 	Markers
-WHERE MarkerID IN (?)
+WHERE MarkerID IN (?) AND DeletedAt IS NULL
 ORDER BY FIELD(MarkerID, ?)`
+
+	getTopRatedMarkersQuery = `
+SELECT
+	m.MarkerID,
+	ST_X(m.Location) AS Latitude,
+	ST_Y(m.Location) AS Longitude,
+	COALESCE(m.Address, '') AS Address,
+	r.AvgRating,
+	r.ReviewCount
+FROM MarkerRatings r
+JOIN Markers m ON m.MarkerID = r.MarkerID
+WHERE r.ReviewCount >= ? AND m.DeletedAt IS NULL
+ORDER BY r.AvgRating DESC, r.ReviewCount DESC, m.MarkerID
+LIMIT ?`
+
+	// Verified check-ins only, a visit from the sofa does not count
+	getTopVisitedMarkersQuery = `
+SELECT
+	m.MarkerID,
+	ST_X(m.Location) AS Latitude,
+	ST_Y(m.Location) AS Longitude,
+	COALESCE(m.Address, '') AS Address,
+	COUNT(DISTINCT c.UserID) AS Visitors
+FROM MarkerCheckIns c
+JOIN Markers m ON m.MarkerID = c.MarkerID
+WHERE c.Verified AND c.CheckedInAt > NOW() - INTERVAL ? DAY AND m.DeletedAt IS NULL
+GROUP BY m.MarkerID
+ORDER BY Visitors DESC, m.MarkerID
+LIMIT ?`
+)
+
+// What the ranking endpoints order markers by
+const (
+	RankSortClicks = "clicks"
+	RankSortRating = "rating"
+	RankSortVisits = "visits"
+
+	// The visits ranking counts the visitors of this many recent days
+	RankVisitDays = 30
 )
 
 type MarkerRankService struct {
@@ -212,6 +251,32 @@ func (s *MarkerRankService) GetTopMarkers(limit int) []dto.MarkerSimpleWithAddr
 	return markerRanks
 }
 
+// GetTopRatedMarkers returns the best rated markers with at least MinReviewRank reviews.
+func (s *MarkerRankService) GetTopRatedMarkers(limit int) ([]dto.MarkerRatedWithAddr, error) {
+	if limit < 3 {
+		limit = 5
+	}
+
+	markers := make([]dto.MarkerRatedWithAddr, 0, limit)
+	if err := s.DB.Select(&markers, getTopRatedMarkersQuery, MinReviewRank, limit); err != nil {
+		return nil, fmt.Errorf("fetching top rated markers: %w", err)
+	}
+	return markers, nil
+}
+
+// GetTopVisitedMarkers returns the markers with the most users checked in on the spot over the last RankVisitDays days.
+func (s *MarkerRankService) GetTopVisitedMarkers(limit int) ([]dto.MarkerVisitedWithAddr, error) {
+	if limit < 3 {
+		limit = 5
+	}
+
+	markers := make([]dto.MarkerVisitedWithAddr, 0, limit)
+	if err := s.DB.Select(&markers, getTopVisitedMarkersQuery, RankVisitDays, limit); err != nil {
+		return nil, fmt.Errorf("fetching top visited markers: %w", err)
+	}
+	return markers, nil
+}
+
 func (s *MarkerRankService) RemoveMarkerClick(markerID int) error {
 	ctx := context.Background()
 
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	MinReviewScore = 1
+	MaxReviewScore = 5
+
+	// A marker needs this many reviews before rating rankings list it, so one 5-star review does not top the chart
+	MinReviewRank = 3
+
+	maxReviewCommentLength = 500 // characters
+
+	upsertReviewQuery = `
+INSERT INTO MarkerReviews (MarkerID, UserID, Rating, Grip, Height, Stability, Surroundings, Comment)
+VALUES (?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
+ON DUPLICATE KEY UPDATE
+	Rating = VALUES(Rating),
+	Grip = VALUES(Grip),
+	Height = VALUES(Height),
+	Stability = VALUES(Stability),
+	Surroundings = VALUES(Surroundings),
+	Comment = VALUES(Comment)`
+	deleteReviewQuery = "DELETE FROM MarkerReviews WHERE MarkerID = ? AND UserID = ?"
+
+	selectReviewQuery = `
+SELECT ReviewID, MarkerID, UserID, Rating, Grip, Height, Stability, Surroundings, Comment, CreatedAt, UpdatedAt
+FROM MarkerReviews
+WHERE MarkerID = ? AND UserID = ?`
+	countReviewsQuery  = "SELECT COUNT(*) FROM MarkerReviews WHERE MarkerID = ?"
+	selectReviewsQuery = `
+SELECT r.ReviewID, r.MarkerID, r.UserID, r.Rating, r.Grip, r.Height, r.Stability, r.Surroundings, r.Comment,
+	r.CreatedAt, r.UpdatedAt, COALESCE(u.Username, '탈퇴한 사용자') AS Username
+FROM MarkerReviews r
+LEFT JOIN Users u ON r.UserID = u.UserID
+WHERE r.MarkerID = ?
+ORDER BY r.UpdatedAt DESC, r.ReviewID DESC
+LIMIT ? OFFSET ?`
+
+	selectMarkerRatingQuery = `
+SELECT ReviewCount, AvgRating, AvgGrip, AvgHeight, AvgStability, AvgSurroundings
+FROM MarkerRatings
+WHERE MarkerID = ? AND ReviewCount > 0`
+
+	// The aggregate is rebuilt from scratch in one statement, a marker whose last review is gone keeps a row with ReviewCount 0
+	refreshMarkerRatingQuery = `
+INSERT INTO MarkerRatings (MarkerID, ReviewCount, AvgRating, AvgGrip, AvgHeight, AvgStability, AvgSurroundings)
+SELECT ?, COUNT(*), COALESCE(AVG(Rating), 0), AVG(Grip), AVG(Height), AVG(Stability), AVG(Surroundings)
+FROM MarkerReviews
+WHERE MarkerID = ?
+ON DUPLICATE KEY UPDATE
+	ReviewCount = VALUES(ReviewCount),
+	AvgRating = VALUES(AvgRating),
+	AvgGrip = VALUES(AvgGrip),
+	AvgHeight = VALUES(AvgHeight),
+	AvgStability = VALUES(AvgStability),
+	AvgSurroundings = VALUES(AvgSurroundings)`
+)
+
+var (
+	ErrInvalidReview  = errors.New("invalid review")
+	ErrReviewNotFound = errors.New("review not found")
+)
+
+// MarkerReviewService keeps star ratings and condition reviews of markers, next to the dislike signal of MarkerInteractService.
+type MarkerReviewService struct {
+	DB           *sqlx.DB
+	CacheService *MarkerCacheService
+	Logger       *zap.Logger
+}
+
+func NewMarkerReviewService(db *sqlx.DB, cacheService *MarkerCacheService, logger *zap.Logger) *MarkerReviewService {
+	return &MarkerReviewService{
+		DB:           db,
+		CacheService: cacheService,
+		Logger:       logger,
+	}
+}
+
+// SaveReview creates the user's review of a marker or replaces the one they left before.
+func (s *MarkerReviewService) SaveReview(markerID, userID int, req *dto.MarkerReviewRequest) (*model.MarkerReview, error) {
+	if err := normalizeReviewRequest(req); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.Get(&exists, markerCheckQuery, markerID); err != nil {
+		return nil, fmt.Errorf("checking marker: %w", err)
+	}
+	if !exists {
+		return nil, ErrMarkerNotFound
+	}
+
+	if _, err := tx.Exec(upsertReviewQuery, markerID, userID, req.Rating, req.Grip, req.Height, req.Stability, req.Surroundings, req.Comment); err != nil {
+		return nil, fmt.Errorf("saving review: %w", err)
+	}
+	if err := refreshMarkerRatingTx(tx, markerID); err != nil {
+		return nil, err
+	}
+
+	var review model.MarkerReview
+	if err := tx.Get(&review, selectReviewQuery, markerID, userID); err != nil {
+		return nil, fmt.Errorf("fetching saved review: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+
+	s.CacheService.RemoveMarkerCache(markerID)
+	return &review, nil
+}
+
+// DeleteReview removes the user's review of a marker.
+func (s *MarkerReviewService) DeleteReview(markerID, userID int) error {
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(deleteReviewQuery, markerID, userID)
+	if err != nil {
+		return fmt.Errorf("deleting review: %w", err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return ErrReviewNotFound
+	}
+	if err := refreshMarkerRatingTx(tx, markerID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+
+	s.CacheService.RemoveMarkerCache(markerID)
+	return nil
+}
+
+// GetReview fetches the review a user left on a marker.
+func (s *MarkerReviewService) GetReview(markerID, userID int) (*model.MarkerReview, error) {
+	var review model.MarkerReview
+	if err := s.DB.Get(&review, selectReviewQuery, markerID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("fetching review: %w", err)
+	}
+	return &review, nil
+}
+
+// GetReviews lists the reviews of a marker, most recently written or edited first, along with its averages.
+func (s *MarkerReviewService) GetReviews(markerID, page, pageSize int) (*dto.MarkerReviewList, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := s.DB.Get(&total, countReviewsQuery, markerID); err != nil {
+		return nil, fmt.Errorf("counting reviews: %w", err)
+	}
+
+	reviews := make([]dto.MarkerReviewWithUsername, 0, pageSize)
+	if err := s.DB.Select(&reviews, selectReviewsQuery, markerID, pageSize, offset); err != nil {
+		return nil, fmt.Errorf("fetching reviews: %w", err)
+	}
+
+	rating, err := s.GetMarkerRating(markerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.MarkerReviewList{
+		Reviews:      reviews,
+		Rating:       rating,
+		CurrentPage:  page,
+		TotalPages:   int(math.Ceil(float64(total) / float64(pageSize))),
+		TotalReviews: total,
+	}, nil
+}
+
+// GetMarkerRating returns the review averages of a marker, nil when it has no reviews.
+func (s *MarkerReviewService) GetMarkerRating(markerID int) (*model.MarkerRating, error) {
+	var rating model.MarkerRating
+	if err := s.DB.Get(&rating, selectMarkerRatingQuery, markerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching marker rating: %w", err)
+	}
+	return &rating, nil
+}
+
+// refreshMarkerRatingTx recomputes the MarkerRatings row of a marker from its reviews inside tx.
+func refreshMarkerRatingTx(tx *sqlx.Tx, markerID int) error {
+	if _, err := tx.Exec(refreshMarkerRatingQuery, markerID, markerID); err != nil {
+		return fmt.Errorf("refreshing marker rating: %w", err)
+	}
+	return nil
+}
+
+func normalizeReviewRequest(req *dto.MarkerReviewRequest) error {
+	if !isReviewScore(req.Rating) {
+		return fmt.Errorf("%w: rating must be between %d and %d", ErrInvalidReview, MinReviewScore, MaxReviewScore)
+	}
+	for name, score := range map[string]*int{
+		"grip":         req.Grip,
+		"height":       req.Height,
+		"stability":    req.Stability,
+		"surroundings": req.Surroundings,
+	} {
+		if score != nil && !isReviewScore(*score) {
+			return fmt.Errorf("%w: %s must be between %d and %d", ErrInvalidReview, name, MinReviewScore, MaxReviewScore)
+		}
+	}
+
+	req.Comment = strings.TrimSpace(req.Comment)
+	if utf8.RuneCountInString(req.Comment) > maxReviewCommentLength {
+		return fmt.Errorf("%w: comment is longer than %d characters", ErrInvalidReview, maxReviewCommentLength)
+	}
+	return nil
+}
+
+func isReviewScore(score int) bool {
+	return score >= MinReviewScore && score <= MaxReviewScore
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// Where a marker change came from
+	RevisionSourceCreate   = "CREATE" // baseline snapshot taken before the first tracked change
+	RevisionSourceOwner    = "OWNER_EDIT"
+	RevisionSourceReport   = "REPORT"
+	RevisionSourceAdmin    = "ADMIN"
+	RevisionSourceRollback = "ROLLBACK"
+	RevisionSourceSystem   = "SYSTEM" // address lookups and other background updates
+)
+
+const (
+	// FOR UPDATE serializes concurrent edits of the same marker until the revision is written
+	selectMarkerSnapshotQuery = `
+SELECT
+	ST_X(Location) AS Latitude,
+	ST_Y(Location) AS Longitude,
+	COALESCE(Description, '') AS Description,
+	Address,
+	UserID,
+	CreatedAt,
+	AccessType,
+	OpeningHours,
+	Lit,
+	Indoor
+FROM Markers
+WHERE MarkerID = ?
+FOR UPDATE`
+
+	selectFacilitySnapshotQuery = "SELECT FacilityID, Quantity FROM MarkerFacilities WHERE MarkerID = ? ORDER BY FacilityID"
+
+	selectLatestRevisionQuery = `
+SELECT RevisionID, MarkerID, ActorUserID, ReportID, Source, Latitude, Longitude, COALESCE(Description, '') AS Description, Address, Facilities, Access, CreatedAt
+FROM MarkerRevisions
+WHERE MarkerID = ?
+ORDER BY RevisionID DESC
+LIMIT 1`
+
+	selectRevisionQuery = `
+SELECT RevisionID, MarkerID, ActorUserID, ReportID, Source, Latitude, Longitude, COALESCE(Description, '') AS Description, Address, Facilities, Access, CreatedAt
+FROM MarkerRevisions
+WHERE RevisionID = ? AND MarkerID = ?`
+
+	selectRevisionsQuery = `
+SELECT R.RevisionID, R.MarkerID, R.ActorUserID, R.ReportID, R.Source, R.Latitude, R.Longitude,
+	COALESCE(R.Description, '') AS Description, R.Address, R.Facilities, R.Access, R.CreatedAt, U.Username
+FROM MarkerRevisions R
+LEFT JOIN Users U ON R.ActorUserID = U.UserID
+WHERE R.MarkerID = ?
+ORDER BY R.RevisionID DESC
+LIMIT ? OFFSET ?`
+
+	countRevisionsQuery = "SELECT COUNT(*) FROM MarkerRevisions WHERE MarkerID = ?"
+
+	insertRevisionQuery = `
+INSERT INTO MarkerRevisions (MarkerID, ActorUserID, ReportID, Source, Latitude, Longitude, Description, Address, Facilities, Access, CreatedAt)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, COALESCE(?, NOW()))`
+
+	rollbackMarkerQuery = `
+UPDATE Markers
+SET Location = ST_PointFromText(?, 4326), Description = ?, Address = ?, UpdatedAt = NOW()
+WHERE MarkerID = ?`
+
+	rollbackMarkerAccessQuery = "UPDATE Markers SET AccessType = ?, OpeningHours = ?, Lit = ?, Indoor = ? WHERE MarkerID = ?"
+)
+
+var (
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// RevisionActor tells who changed a marker and through which path.
+type RevisionActor struct {
+	UserID   *int
+	ReportID *int
+	Source   string
+}
+
+// RevisionByUser returns the actor for a direct edit, ADMIN when the user has the admin role.
+func RevisionByUser(userID int, role string) RevisionActor {
+	source := RevisionSourceOwner
+	if role == "admin" {
+		source = RevisionSourceAdmin
+	}
+	return RevisionActor{UserID: &userID, Source: source}
+}
+
+// RevisionBySystem is the actor for background updates such as address lookups.
+func RevisionBySystem() RevisionActor {
+	return RevisionActor{Source: RevisionSourceSystem}
+}
+
+type markerSnapshotRow struct {
+	model.MarkerAccess
+	CreatedAt   sql.NullTime `db:"CreatedAt"`
+	UserID      *int         `db:"UserID"`
+	Address     *string      `db:"Address"`
+	Latitude    float64      `db:"Latitude"`
+	Longitude   float64      `db:"Longitude"`
+	Description string       `db:"Description"`
+}
+
+type MarkerRevisionService struct {
+	DB                 *sqlx.DB
+	CacheService       *MarkerCacheService
+	BleveSearchService *BleveSearchService
+	Logger             *zap.Logger
+}
+
+func NewMarkerRevisionService(db *sqlx.DB, cacheService *MarkerCacheService, bleveSearchService *BleveSearchService, logger *zap.Logger) *MarkerRevisionService {
+	return &MarkerRevisionService{
+		DB:                 db,
+		CacheService:       cacheService,
+		BleveSearchService: bleveSearchService,
+		Logger:             logger,
+	}
+}
+
+// TrackTx runs apply inside tx and records the marker state afterwards as a new revision.
+// Markers that predate revision tracking get a baseline snapshot of their state before apply,
+// so the first change can always be diffed and rolled back.
+func (s *MarkerRevisionService) TrackTx(tx *sqlx.Tx, markerID int, actor RevisionActor, apply func() error) error {
+	before, err := s.snapshotTx(tx, markerID)
+	if err != nil {
+		return err
+	}
+
+	latest, err := s.latestRevisionTx(tx, markerID)
+	if err != nil {
+		return err
+	}
+
+	if latest == nil {
+		before.Source = RevisionSourceCreate
+		before.ActorUserID = nil
+		if err := s.insertRevisionTx(tx, before); err != nil {
+			return fmt.Errorf("recording baseline revision: %w", err)
+		}
+		latest = before
+	}
+
+	if err := apply(); err != nil {
+		return err
+	}
+
+	// Photos, facilities and status are not part of the snapshot, so every tracked change goes to the change log
+	if err := recordMarkerChanges(tx, markerID); err != nil {
+		return err
+	}
+
+	after, err := s.snapshotTx(tx, markerID)
+	if err != nil {
+		return err
+	}
+
+	// Nothing tracked changed (e.g. the same description was saved again)
+	if sameSnapshot(latest, after) {
+		return nil
+	}
+
+	after.Source = actor.Source
+	after.ActorUserID = actor.UserID
+	after.ReportID = actor.ReportID
+	if err := s.insertRevisionTx(tx, after); err != nil {
+		return fmt.Errorf("recording revision: %w", err)
+	}
+
+	return nil
+}
+
+// Track is TrackTx with its own transaction.
+func (s *MarkerRevisionService) Track(markerID int, actor RevisionActor, apply func(tx *sqlx.Tx) error) error {
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	if err := s.TrackTx(tx, markerID, actor, func() error { return apply(tx) }); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+	return nil
+}
+
+// GetRevisions lists revisions of a marker, newest first.
+func (s *MarkerRevisionService) GetRevisions(markerID, page, pageSize int) (*dto.MarkerRevisionList, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := s.DB.Get(&total, countRevisionsQuery, markerID); err != nil {
+		return nil, fmt.Errorf("counting revisions: %w", err)
+	}
+
+	revisions := make([]dto.MarkerRevisionWithUsername, 0, pageSize)
+	if err := s.DB.Select(&revisions, selectRevisionsQuery, markerID, pageSize, offset); err != nil {
+		return nil, fmt.Errorf("fetching revisions: %w", err)
+	}
+
+	return &dto.MarkerRevisionList{
+		Revisions:      revisions,
+		CurrentPage:    page,
+		TotalPages:     int(math.Ceil(float64(total) / float64(pageSize))),
+		TotalRevisions: total,
+	}, nil
+}
+
+// GetRevision fetches a single revision of a marker.
+func (s *MarkerRevisionService) GetRevision(markerID, revisionID int) (*model.MarkerRevision, error) {
+	var revision model.MarkerRevision
+	if err := s.DB.Get(&revision, selectRevisionQuery, revisionID, markerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("fetching revision: %w", err)
+	}
+	return &revision, nil
+}
+
+// DiffRevisions compares two revisions of the same marker field by field.
+func (s *MarkerRevisionService) DiffRevisions(markerID, fromRevisionID, toRevisionID int) (*dto.MarkerRevisionDiff, error) {
+	from, err := s.GetRevision(markerID, fromRevisionID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetRevision(markerID, toRevisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.MarkerRevisionDiff{
+		MarkerID:       markerID,
+		FromRevisionID: fromRevisionID,
+		ToRevisionID:   toRevisionID,
+		Changes:        diffSnapshots(from, to),
+	}, nil
+}
+
+// RollbackToRevision restores the location, description, address, access and facilities of an earlier revision.
+// The rollback itself is recorded as a new revision, so it can be undone the same way, and that revision is returned.
+func (s *MarkerRevisionService) RollbackToRevision(markerID, revisionID, userID int, userRole string) (*model.MarkerRevision, error) {
+	var ownerID sql.NullInt64
+	if err := s.DB.Get(&ownerID, getAllMarkersByUserQuery, markerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMarkerNotFound
+		}
+		return nil, fmt.Errorf("checking marker ownership: %w", err)
+	}
+	if userRole != "admin" && int(ownerID.Int64) != userID {
+		return nil, ErrUnauthorized
+	}
+
+	target, err := s.GetRevision(markerID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+	}
+	defer tx.Rollback()
+
+	actor := RevisionActor{UserID: &userID, Source: RevisionSourceRollback}
+	err = s.TrackTx(tx, markerID, actor, func() error {
+		if _, err := tx.Exec(rollbackMarkerQuery, formatPoint(target.Latitude, target.Longitude), target.Description, target.Address, markerID); err != nil {
+			return fmt.Errorf("restoring marker: %w", err)
+		}
+		// A revision from before access was tracked knows nothing about it, the current access stays
+		if access := target.Access; access != nil {
+			if _, err := tx.Exec(rollbackMarkerAccessQuery, access.AccessType, access.OpeningHours, access.Lit, access.Indoor, markerID); err != nil {
+				return fmt.Errorf("restoring access: %w", err)
+			}
+		}
+		facilities := make([]dto.FacilityQuantity, len(target.Facilities))
+		for i, f := range target.Facilities {
+			facilities[i] = dto.FacilityQuantity{FacilityID: f.FacilityID, Quantity: f.Quantity}
+		}
+		if err := replaceMarkerFacilitiesTx(tx, markerID, facilities); err != nil {
+			return fmt.Errorf("restoring facilities: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The marker row is still locked, so this is the revision the rollback wrote,
+	// or the current one when the marker already looked like the target
+	restored, err := s.latestRevisionTx(tx, markerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+	}
+
+	s.CacheService.InvalidateFullMarkersCache()
+	s.CacheService.InvalidateFacilities(markerID)
+	s.CacheService.RemoveMarkerCache(markerID)
+	s.CacheService.UpdateMarker(markerID, dto.MarkerSimple{Latitude: target.Latitude, Longitude: target.Longitude, MarkerID: markerID})
+	if target.Address != nil && *target.Address != "" {
+		if err := s.BleveSearchService.InsertMarkerIndex(dto.MarkerIndexData{MarkerID: markerID, Address: *target.Address}); err != nil {
+			s.Logger.Error("Failed to reindex marker after rollback", zap.Int("markerID", markerID), zap.Error(err))
+		}
+	}
+
+	return restored, nil
+}
+
+func (s *MarkerRevisionService) snapshotTx(tx *sqlx.Tx, markerID int) (*model.MarkerRevision, error) {
+	var row markerSnapshotRow
+	if err := tx.Get(&row, selectMarkerSnapshotQuery, markerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMarkerNotFound
+		}
+		return nil, fmt.Errorf("fetching marker snapshot: %w", err)
+	}
+
+	facilities := make(model.FacilitySnapshot, 0)
+	if err := tx.Select(&facilities, selectFacilitySnapshotQuery, markerID); err != nil {
+		return nil, fmt.Errorf("fetching facility snapshot: %w", err)
+	}
+
+	access := model.AccessSnapshot(row.MarkerAccess)
+	snapshot := &model.MarkerRevision{
+		MarkerID:    markerID,
+		Latitude:    row.Latitude,
+		Longitude:   row.Longitude,
+		Description: row.Description,
+		Address:     row.Address,
+		Facilities:  facilities,
+		Access:      &access,
+		ActorUserID: row.UserID,
+	}
+	if row.CreatedAt.Valid {
+		snapshot.CreatedAt = row.CreatedAt.Time
+	}
+	return snapshot, nil
+}
+
+func (s *MarkerRevisionService) latestRevisionTx(tx *sqlx.Tx, markerID int) (*model.MarkerRevision, error) {
+	var revision model.MarkerRevision
+	if err := tx.Get(&revision, selectLatestRevisionQuery, markerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("fetching latest revision: %w", err)
+	}
+	return &revision, nil
+}
+
+func (s *MarkerRevisionService) insertRevisionTx(tx *sqlx.Tx, r *model.MarkerRevision) error {
+	// The baseline keeps the marker's creation time, everything else is stamped now
+	createdAt := sql.NullTime{}
+	if r.Source == RevisionSourceCreate && !r.CreatedAt.IsZero() {
+		createdAt = sql.NullTime{Time: r.CreatedAt, Valid: true}
+	}
+
+	res, err := tx.Exec(insertRevisionQuery,
+		r.MarkerID, r.ActorUserID, r.ReportID, r.Source,
+		r.Latitude, r.Longitude, r.Description, r.Address, r.Facilities, r.Access, createdAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	r.RevisionID = int(id)
+	return nil
+}
+
+func sameSnapshot(a, b *model.MarkerRevision) bool {
+	return len(diffSnapshots(a, b)) == 0
+}
+
+// diffSnapshots lists the tracked fields that differ between two snapshots.
+func diffSnapshots(from, to *model.MarkerRevision) []dto.MarkerFieldChange {
+	changes := make([]dto.MarkerFieldChange, 0, 4)
+
+	// ~1cm, ST_X/ST_Y round-trips are not bit exact
+	const coordEpsilon = 1e-7
+	if math.Abs(from.Latitude-to.Latitude) > coordEpsilon || math.Abs(from.Longitude-to.Longitude) > coordEpsilon {
+		changes = append(changes, dto.MarkerFieldChange{
+			Field: "location",
+			From:  map[string]float64{"latitude": from.Latitude, "longitude": from.Longitude},
+			To:    map[string]float64{"latitude": to.Latitude, "longitude": to.Longitude},
+		})
+	}
+
+	if from.Description != to.Description {
+		changes = append(changes, dto.MarkerFieldChange{Field: "description", From: from.Description, To: to.Description})
+	}
+
+	fromAddr, toAddr := derefString(from.Address), derefString(to.Address)
+	if fromAddr != toAddr {
+		changes = append(changes, dto.MarkerFieldChange{Field: "address", From: fromAddr, To: toAddr})
+	}
+
+	if !slices.Equal(sortedFacilities(from.Facilities), sortedFacilities(to.Facilities)) {
+		changes = append(changes, dto.MarkerFieldChange{Field: "facilities", From: from.Facilities, To: to.Facilities})
+	}
+
+	// No access data compares like access nobody has filled in
+	fromAccess, toAccess := from.Access, to.Access
+	if fromAccess == nil {
+		fromAccess = &model.AccessSnapshot{}
+	}
+	if toAccess == nil {
+		toAccess = &model.AccessSnapshot{}
+	}
+	if !reflect.DeepEqual(fromAccess, toAccess) {
+		changes = append(changes, dto.MarkerFieldChange{Field: "access", From: from.Access, To: to.Access})
+	}
+
+	return changes
+}
+
+func sortedFacilities(f model.FacilitySnapshot) model.FacilitySnapshot {
+	sorted := slices.Clone(f)
+	slices.SortFunc(sorted, func(a, b model.FacilityCount) int { return a.FacilityID - b.FacilityID })
+	return sorted
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
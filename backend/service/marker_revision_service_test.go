@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Alfex4936/chulbong-kr/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func revisionTestSnapshot() *model.MarkerRevision {
+	address := "서울특별시 종로구 세종대로 172"
+	return &model.MarkerRevision{
+		Latitude:    37.5759,
+		Longitude:   126.9769,
+		Description: "철봉 2개",
+		Address:     &address,
+		Facilities:  model.FacilitySnapshot{{FacilityID: 1, Quantity: 2}, {FacilityID: 2, Quantity: 1}},
+	}
+}
+
+func changedFields(from, to *model.MarkerRevision) []string {
+	changes := diffSnapshots(from, to)
+	fields := make([]string, len(changes))
+	for i, c := range changes {
+		fields[i] = c.Field
+	}
+	return fields
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	indoor := true
+	otherAddress := "서울특별시 중구 세종대로 110"
+
+	tests := []struct {
+		name   string
+		change func(r *model.MarkerRevision)
+		fields []string
+	}{
+		{"unchanged", func(r *model.MarkerRevision) {}, []string{}},
+		{"coordinate round-trip noise", func(r *model.MarkerRevision) { r.Latitude += 1e-9 }, []string{}},
+		{"moved", func(r *model.MarkerRevision) { r.Longitude += 0.001 }, []string{"location"}},
+		{"description", func(r *model.MarkerRevision) { r.Description = "철봉 3개" }, []string{"description"}},
+		{"address", func(r *model.MarkerRevision) { r.Address = &otherAddress }, []string{"address"}},
+		{"address removed", func(r *model.MarkerRevision) { r.Address = nil }, []string{"address"}},
+		{"facility order does not matter", func(r *model.MarkerRevision) {
+			r.Facilities = model.FacilitySnapshot{{FacilityID: 2, Quantity: 1}, {FacilityID: 1, Quantity: 2}}
+		}, []string{}},
+		{"facility quantity", func(r *model.MarkerRevision) { r.Facilities[0].Quantity = 3 }, []string{"facilities"}},
+		{"access", func(r *model.MarkerRevision) { r.Access = &model.AccessSnapshot{Indoor: &indoor} }, []string{"access"}},
+		{"no access data is unknown access", func(r *model.MarkerRevision) { r.Access = &model.AccessSnapshot{} }, []string{}},
+		{"several fields in order", func(r *model.MarkerRevision) {
+			r.Latitude += 0.01
+			r.Description = ""
+			r.Facilities = nil
+		}, []string{"location", "description", "facilities"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to := revisionTestSnapshot(), revisionTestSnapshot()
+			tt.change(to)
+			assert.Equal(t, tt.fields, changedFields(from, to))
+		})
+	}
+}
+
+func TestDiffSnapshotsEmptyAddress(t *testing.T) {
+	empty := ""
+	from, to := revisionTestSnapshot(), revisionTestSnapshot()
+	from.Address, to.Address = nil, &empty
+	assert.Empty(t, diffSnapshots(from, to))
+}
+
+func TestDiffSnapshotsValues(t *testing.T) {
+	from, to := revisionTestSnapshot(), revisionTestSnapshot()
+	to.Description = "철봉 3개"
+
+	changes := diffSnapshots(from, to)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, "철봉 2개", changes[0].From)
+		assert.Equal(t, "철봉 3개", changes[0].To)
+	}
+}
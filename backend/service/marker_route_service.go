@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/util"
+)
+
+const (
+	DefaultRouteStops    = 5
+	MaxRouteStops        = 15
+	DefaultRouteDistance = 5000  // meters
+	MaxRouteDistance     = 50000 // meters, the same as the close markers search
+
+	// Markers fetched around the start per stop, so the rating constraint has some to choose from
+	routeCandidatesPerStop = 4
+)
+
+var ErrInvalidRoute = errors.New("invalid route")
+
+// PlanRoute picks the markers closest to the start that pass facilities, access and the rating constraint,
+// at most req.Stops of them, and orders them into a short route. Stops beyond req.Distance meters of walking are cut off.
+func (s *MarkerLocationService) PlanRoute(req *dto.MarkerRouteRequest, facilities util.FacilityFilter, access dto.MarkerAccessFilter) (*dto.MarkerRoute, error) {
+	if req.Stops == 0 {
+		req.Stops = DefaultRouteStops
+	}
+	if req.Distance == 0 {
+		req.Distance = DefaultRouteDistance
+	}
+	switch {
+	case req.Stops < 1 || req.Stops > MaxRouteStops:
+		return nil, fmt.Errorf("%w: stops must be between 1 and %d", ErrInvalidRoute, MaxRouteStops)
+	case req.Distance < 1 || req.Distance > MaxRouteDistance:
+		return nil, fmt.Errorf("%w: distance must be between 1 and %d meters", ErrInvalidRoute, MaxRouteDistance)
+	case req.MinRating < 0 || req.MinRating > MaxReviewScore:
+		return nil, fmt.Errorf("%w: minRating must be between 0 and %d", ErrInvalidRoute, MaxReviewScore)
+	}
+
+	route := &dto.MarkerRoute{
+		Stops:          make([]dto.RouteStop, 0, req.Stops),
+		StartLatitude:  req.Latitude,
+		StartLongitude: req.Longitude,
+	}
+
+	// A marker further away than the route may be long can never be reached
+	candidates, total, err := s.FindClosestNMarkersFiltered(req.Latitude, req.Longitude, req.Distance, req.Stops*routeCandidatesPerStop, 0, facilities, access)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return route, nil
+	}
+
+	picked := make([]dto.MarkerWithDistanceAndPhoto, 0, req.Stops)
+	if req.MinRating > 0 {
+		ratings, err := s.fetchMarkerRatings(candidates)
+		if err != nil {
+			return nil, err
+		}
+		for _, marker := range candidates {
+			if rating, ok := ratings[marker.MarkerID]; ok && rating >= req.MinRating {
+				marker.Rating = &rating
+				picked = append(picked, marker)
+			}
+		}
+	} else {
+		picked = append(picked, candidates...)
+	}
+	// Candidates came in by distance, the closest ones make the shortest route
+	if len(picked) > req.Stops {
+		picked = picked[:req.Stops]
+	}
+	if len(picked) == 0 {
+		return route, nil
+	}
+
+	points := make([]util.RoutePoint, len(picked))
+	for i, marker := range picked {
+		points[i] = util.RoutePoint{Latitude: marker.Latitude, Longitude: marker.Longitude}
+	}
+	order, legs := util.PlanRoute(util.RoutePoint{Latitude: req.Latitude, Longitude: req.Longitude}, points)
+
+	for i, idx := range order {
+		if route.TotalDistance+legs[i] > float64(req.Distance) {
+			break
+		}
+		route.TotalDistance += legs[i]
+
+		marker := picked[idx]
+		route.Stops = append(route.Stops, dto.RouteStop{
+			Thumbnail:     marker.Thumbnail,
+			Rating:        marker.Rating,
+			Latitude:      marker.Latitude,
+			Longitude:     marker.Longitude,
+			LegDistance:   legs[i],
+			RouteDistance: route.TotalDistance,
+			MarkerID:      marker.MarkerID,
+			Description:   marker.Description,
+			Address:       marker.Address,
+		})
+	}
+	return route, nil
+}
+
+// WriteRouteGPX writes route as a GPX route for GPS units and map apps.
+func (s *MarkerLocationService) WriteRouteGPX(w io.Writer, route *dto.MarkerRoute) error {
+	stops := make([]util.ExportedMarker, len(route.Stops))
+	for i, stop := range route.Stops {
+		stops[i] = util.ExportedMarker{
+			Latitude:    stop.Latitude,
+			Longitude:   stop.Longitude,
+			MarkerID:    stop.MarkerID,
+			Description: stop.Description,
+			Address:     stop.Address,
+		}
+	}
+	description := fmt.Sprintf("철봉 %d곳, 총 %.0fm", len(route.Stops), route.TotalDistance)
+	return util.WriteRouteGPX(w, "철봉 운동 루트", description, util.RoutePoint{Latitude: route.StartLatitude, Longitude: route.StartLongitude}, stops)
+}
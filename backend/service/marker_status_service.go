@@ -0,0 +1,150 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	MarkerStatusActive            = "ACTIVE"
+	MarkerStatusDamaged           = "DAMAGED"
+	MarkerStatusUnderConstruction = "UNDER_CONSTRUCTION"
+	MarkerStatusRemoved           = "REMOVED"
+	// Set by MarkerMergeService on the soft-deleted duplicate, reports cannot ask for it
+	MarkerStatusMerged = "MERGED"
+
+	// A non-active status is applied once its weighted evidence reaches this and beats the evidence that the bar is fine.
+	markerStatusThreshold = 2.0
+	// Evidence loses half of its weight every markerStatusHalfLife, older reports say less about the bar today.
+	markerStatusHalfLife = 60 * 24 * time.Hour
+	// Approved reports older than this are ignored.
+	markerStatusWindowDays = 365
+
+	// Report weights by reporter
+	anonymousReportWeight    = 0.5
+	memberReportWeight       = 1.0
+	ownerOrAdminReportWeight = 2.0
+
+	getMarkerStatusEvidenceQuery = `
+SELECT
+	COALESCE(r.ReportedStatus, CASE WHEN r.DoesExist THEN 'ACTIVE' ELSE 'REMOVED' END) AS ReportedStatus,
+	r.CreatedAt,
+	COALESCE(r.UserID, 0) > 0 AS HasUser,
+	COALESCE(r.UserID = m.UserID, FALSE) OR COALESCE(u.Role, '') = 'admin' AS Trusted
+FROM Reports r
+JOIN Markers m ON r.MarkerID = m.MarkerID
+LEFT JOIN Users u ON r.UserID = u.UserID
+WHERE r.MarkerID = ? AND r.Status = 'APPROVED' AND r.CreatedAt >= NOW() - INTERVAL ? DAY`
+
+	getMarkerStatusQuery    = "SELECT Status FROM Markers WHERE MarkerID = ?"
+	updateMarkerStatusQuery = "UPDATE Markers SET Status = ? WHERE MarkerID = ?"
+)
+
+var markerStatuses = []string{MarkerStatusActive, MarkerStatusDamaged, MarkerStatusUnderConstruction, MarkerStatusRemoved}
+
+// statusEvidence is one approved report as far as the marker status is concerned.
+type statusEvidence struct {
+	CreatedAt      time.Time `db:"CreatedAt"`
+	ReportedStatus string    `db:"ReportedStatus"`
+	HasUser        bool      `db:"HasUser"`
+	Trusted        bool      `db:"Trusted"`
+}
+
+type MarkerStatusService struct {
+	DB     *sqlx.DB
+	Logger *zap.Logger
+}
+
+func NewMarkerStatusService(db *sqlx.DB, logger *zap.Logger) *MarkerStatusService {
+	return &MarkerStatusService{
+		DB:     db,
+		Logger: logger,
+	}
+}
+
+// NormalizeMarkerStatus upper-cases s and reports whether it is a known marker status.
+func NormalizeMarkerStatus(s string) (string, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	for _, status := range markerStatuses {
+		if s == status {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// ReevaluateTx recomputes the status of a marker from its approved reports inside tx.
+// It returns the resulting status and whether it changed.
+func (s *MarkerStatusService) ReevaluateTx(tx *sqlx.Tx, markerID int) (string, bool, error) {
+	var current string
+	if err := tx.Get(&current, getMarkerStatusQuery, markerID); err != nil {
+		return "", false, fmt.Errorf("fetching marker status: %w", err)
+	}
+
+	var evidence []statusEvidence
+	if err := tx.Select(&evidence, getMarkerStatusEvidenceQuery, markerID, markerStatusWindowDays); err != nil {
+		return "", false, fmt.Errorf("fetching status evidence: %w", err)
+	}
+
+	next := decideMarkerStatus(evidence, time.Now())
+	if next == current {
+		return current, false, nil
+	}
+
+	if _, err := tx.Exec(updateMarkerStatusQuery, next, markerID); err != nil {
+		return "", false, fmt.Errorf("updating marker status: %w", err)
+	}
+	if err := recordMarkerChanges(tx, markerID); err != nil {
+		return "", false, err
+	}
+
+	s.Logger.Info("Marker status changed",
+		zap.Int("markerID", markerID),
+		zap.String("from", current),
+		zap.String("to", next))
+
+	return next, true, nil
+}
+
+// decideMarkerStatus weighs every piece of evidence by reporter and age and picks the resulting status.
+func decideMarkerStatus(evidence []statusEvidence, now time.Time) string {
+	weights := make(map[string]float64, len(markerStatuses))
+	for _, e := range evidence {
+		status, ok := NormalizeMarkerStatus(e.ReportedStatus)
+		if !ok {
+			continue
+		}
+
+		weight := anonymousReportWeight
+		switch {
+		case e.Trusted:
+			weight = ownerOrAdminReportWeight
+		case e.HasUser:
+			weight = memberReportWeight
+		}
+
+		age := now.Sub(e.CreatedAt)
+		if age > 0 {
+			weight *= math.Pow(0.5, float64(age)/float64(markerStatusHalfLife))
+		}
+
+		weights[status] += weight
+	}
+
+	best, bestWeight := MarkerStatusActive, 0.0
+	for _, status := range markerStatuses[1:] {
+		if weights[status] > bestWeight {
+			best, bestWeight = status, weights[status]
+		}
+	}
+
+	if bestWeight >= markerStatusThreshold && bestWeight > weights[MarkerStatusActive] {
+		return best
+	}
+	return MarkerStatusActive
+}
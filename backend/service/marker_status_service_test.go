@@ -0,0 +1,109 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecideMarkerStatus(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	member := func(status string, at time.Time) statusEvidence {
+		return statusEvidence{ReportedStatus: status, CreatedAt: at, HasUser: true}
+	}
+	anonymous := func(status string, at time.Time) statusEvidence {
+		return statusEvidence{ReportedStatus: status, CreatedAt: at}
+	}
+	trusted := func(status string, at time.Time) statusEvidence {
+		return statusEvidence{ReportedStatus: status, CreatedAt: at, HasUser: true, Trusted: true}
+	}
+
+	tests := []struct {
+		name     string
+		evidence []statusEvidence
+		want     string
+	}{
+		{"no reports", nil, MarkerStatusActive},
+		{"one member is below the threshold", []statusEvidence{
+			member(MarkerStatusRemoved, now),
+		}, MarkerStatusActive},
+		{"two members reach the threshold", []statusEvidence{
+			member(MarkerStatusRemoved, now),
+			member(MarkerStatusRemoved, now),
+		}, MarkerStatusRemoved},
+		{"owner or admin alone reaches it", []statusEvidence{
+			trusted(MarkerStatusUnderConstruction, now),
+		}, MarkerStatusUnderConstruction},
+		{"three anonymous reports do not", []statusEvidence{
+			anonymous(MarkerStatusDamaged, now),
+			anonymous(MarkerStatusDamaged, now),
+			anonymous(MarkerStatusDamaged, now),
+		}, MarkerStatusActive},
+		{"four anonymous reports do", []statusEvidence{
+			anonymous(MarkerStatusDamaged, now),
+			anonymous(MarkerStatusDamaged, now),
+			anonymous(MarkerStatusDamaged, now),
+			anonymous(MarkerStatusDamaged, now),
+		}, MarkerStatusDamaged},
+		{"a half-life halves the weight", []statusEvidence{
+			trusted(MarkerStatusRemoved, ago(markerStatusHalfLife)),
+		}, MarkerStatusActive},
+		{"decayed reports need company", []statusEvidence{
+			trusted(MarkerStatusRemoved, ago(markerStatusHalfLife)),
+			member(MarkerStatusRemoved, now),
+		}, MarkerStatusRemoved},
+		{"just under the threshold after decay", []statusEvidence{
+			member(MarkerStatusRemoved, now),
+			member(MarkerStatusRemoved, ago(time.Hour)),
+		}, MarkerStatusActive},
+		{"future timestamps are not boosted", []statusEvidence{
+			member(MarkerStatusRemoved, now.Add(time.Hour)),
+			member(MarkerStatusRemoved, now),
+		}, MarkerStatusRemoved},
+		{"a tie with active stays active", []statusEvidence{
+			member(MarkerStatusRemoved, now),
+			member(MarkerStatusRemoved, now),
+			member(MarkerStatusActive, now),
+			member(MarkerStatusActive, now),
+		}, MarkerStatusActive},
+		{"active evidence is outweighed", []statusEvidence{
+			trusted(MarkerStatusDamaged, now),
+			member(MarkerStatusDamaged, now),
+			member(MarkerStatusActive, now),
+			member(MarkerStatusActive, now),
+		}, MarkerStatusDamaged},
+		{"the heaviest non-active status wins", []statusEvidence{
+			member(MarkerStatusDamaged, now),
+			member(MarkerStatusDamaged, now),
+			trusted(MarkerStatusRemoved, now),
+			member(MarkerStatusRemoved, now),
+		}, MarkerStatusRemoved},
+		{"evidence for different statuses does not add up", []statusEvidence{
+			member(MarkerStatusDamaged, now),
+			member(MarkerStatusRemoved, now),
+		}, MarkerStatusActive},
+		{"lower case and unknown statuses", []statusEvidence{
+			member("removed", now),
+			member(" Removed ", now),
+			trusted("GONE", now),
+		}, MarkerStatusRemoved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, decideMarkerStatus(tt.evidence, now))
+		})
+	}
+}
+
+func TestNormalizeMarkerStatus(t *testing.T) {
+	status, ok := NormalizeMarkerStatus(" under_construction ")
+	assert.True(t, ok)
+	assert.Equal(t, MarkerStatusUnderConstruction, status)
+
+	_, ok = NormalizeMarkerStatus("BROKEN")
+	assert.False(t, ok)
+}
@@ -163,24 +163,30 @@ LIMIT ? OFFSET ?;
 )
 
 type StoryService struct {
-	DB        *sqlx.DB
-	S3Service *S3Service
-	Redis     *RedisService
-	Logger    *zap.Logger
+	DB             *sqlx.DB
+	S3Service      *S3Service
+	Redis          *RedisService
+	BadgeService   *BadgeService
+	VariantService *PhotoVariantService
+	Logger         *zap.Logger
 }
 
 func NewMarkerStoryService(
 	db *sqlx.DB,
 	s3 *S3Service,
 	redis *RedisService,
+	badge *BadgeService,
+	variant *PhotoVariantService,
 	logger *zap.Logger,
 
 ) *StoryService {
 	return &StoryService{
-		DB:        db,
-		Redis:     redis,
-		S3Service: s3,
-		Logger:    logger,
+		DB:             db,
+		Redis:          redis,
+		S3Service:      s3,
+		BadgeService:   badge,
+		VariantService: variant,
+		Logger:         logger,
 	}
 }
 
@@ -265,6 +271,9 @@ func (s *StoryService) AddStory(markerID int, userID int, caption string, photo
 	s.Redis.ResetAllCache(fmt.Sprintf("stories:%d:*", markerID))
 	s.Redis.ResetAllCache("stories:all:*")
 
+	s.BadgeService.Publish(EventStoryPosted, userID)
+	s.VariantService.Enqueue(photoURL)
+
 	return &dto.StoryResponse{
 		StoryID:   int(storyID),
 		MarkerID:  markerID,
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const getTileMarkersQuery = `
+SELECT m.MarkerID,
+	ST_X(m.Location) AS Latitude,
+	ST_Y(m.Location) AS Longitude,
+	m.Status,
+	EXISTS (SELECT 1 FROM Photos p WHERE p.MarkerID = m.MarkerID AND p.DeletedAt IS NULL) AS HasPhoto,
+	COALESCE((
+		SELECT GROUP_CONCAT(CONCAT(f.FacilityID, ':', f.Quantity) ORDER BY f.FacilityID SEPARATOR ';')
+		FROM MarkerFacilities f
+		WHERE f.MarkerID = m.MarkerID AND f.Quantity > 0 AND f.DeletedAt IS NULL
+	), '') AS Facilities
+FROM Markers m
+WHERE MBRContains(ST_GeomFromText(?, 4326), m.Location)
+	AND m.DeletedAt IS NULL
+ORDER BY m.MarkerID`
+
+type tileMarkerRow struct {
+	MarkerID   int     `db:"MarkerID"`
+	Latitude   float64 `db:"Latitude"`
+	Longitude  float64 `db:"Longitude"`
+	Status     string  `db:"Status"`
+	HasPhoto   bool    `db:"HasPhoto"`
+	Facilities string  `db:"Facilities"`
+}
+
+// MarkerTileService serves markers as Mapbox Vector Tiles.
+type MarkerTileService struct {
+	DB           *sqlx.DB
+	CacheService *MarkerCacheService
+	Logger       *zap.Logger
+}
+
+func NewMarkerTileService(db *sqlx.DB, cacheService *MarkerCacheService, logger *zap.Logger) *MarkerTileService {
+	return &MarkerTileService{
+		DB:           db,
+		CacheService: cacheService,
+		Logger:       logger,
+	}
+}
+
+// GetMarkerTile returns the encoded tile z/x/y from the cache or builds it from the DB.
+// An empty slice means there are no markers on the tile.
+func (s *MarkerTileService) GetMarkerTile(z, x, y int) ([]byte, error) {
+	if err := util.ValidateTile(z, x, y); err != nil {
+		return nil, err
+	}
+
+	cached, generation, err := s.CacheService.GetMarkerTile(z, x, y)
+	if err == nil {
+		return cached, nil
+	}
+
+	box := util.MarkerTileBounds(z, x, y)
+
+	// MBRContains keeps the lookup on the spatial index, see findMarkersInBBoxWhere
+	var rows []tileMarkerRow
+	if err := s.DB.Select(&rows, getTileMarkersQuery, formatBBoxPolygon(box)); err != nil {
+		return nil, fmt.Errorf("fetching tile markers: %w", err)
+	}
+
+	points := make([]util.TilePoint, 0, len(rows))
+	for _, row := range rows {
+		facilities, err := util.ParseFacilityQuantities(row.Facilities)
+		if err != nil {
+			s.Logger.Warn("Skipping unreadable facilities in tile", zap.Int("markerID", row.MarkerID), zap.Error(err))
+		}
+		points = append(points, util.TilePoint{
+			Facilities: facilities,
+			Latitude:   row.Latitude,
+			Longitude:  row.Longitude,
+			MarkerID:   row.MarkerID,
+			HasPhoto:   row.HasPhoto,
+			Status:     row.Status,
+		})
+	}
+
+	tile := util.EncodeMarkerTile(z, x, y, points)
+	if err := s.CacheService.SetMarkerTile(generation, z, x, y, tile); err != nil {
+		s.Logger.Warn("Failed to cache marker tile", zap.Int("z", z), zap.Int("x", x), zap.Int("y", y), zap.Error(err))
+	}
+
+	return tile, nil
+}
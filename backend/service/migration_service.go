@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/migrations"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	createSchemaMigrationsQuery = `
+CREATE TABLE IF NOT EXISTS SchemaMigrations (
+    Version   BIGINT       NOT NULL PRIMARY KEY,
+    Name      VARCHAR(255) NOT NULL,
+    AppliedAt TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4`
+
+	getAppliedMigrationsQuery  = "SELECT Version, Name, AppliedAt FROM SchemaMigrations ORDER BY Version"
+	insertSchemaMigrationQuery = "INSERT INTO SchemaMigrations (Version, Name) VALUES (?, ?)"
+	deleteSchemaMigrationQuery = "DELETE FROM SchemaMigrations WHERE Version = ?"
+
+	// MySQL named lock, so two instances booting at the same time don't migrate concurrently
+	acquireMigrationLockQuery = "SELECT GET_LOCK('k-pullup:schema-migrations', 30)"
+	releaseMigrationLockQuery = "SELECT RELEASE_LOCK('k-pullup:schema-migrations')"
+)
+
+// Migration is a single versioned schema change with its up and down scripts.
+type Migration struct {
+	Version int64
+	Name    string
+	UpSQL   string
+	DownSQL string
+}
+
+// MigrationStatus reports whether a migration has been applied to the connected database.
+type MigrationStatus struct {
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+}
+
+type appliedMigration struct {
+	AppliedAt time.Time `db:"AppliedAt"`
+	Version   int64     `db:"Version"`
+	Name      string    `db:"Name"`
+}
+
+type MigrationService struct {
+	DB     *sqlx.DB
+	Logger *zap.Logger
+	Source fs.FS
+}
+
+func NewMigrationService(db *sqlx.DB, logger *zap.Logger) *MigrationService {
+	return &MigrationService{
+		DB:     db,
+		Logger: logger,
+		Source: migrations.FS,
+	}
+}
+
+// RunStartupMigrations applies pending migrations while the fx graph is being built.
+// It is opt-in through DB_AUTO_MIGRATE=true and has to be invoked before anything that prepares statements.
+func RunStartupMigrations(migrationService *MigrationService, logger *zap.Logger) error {
+	if os.Getenv("DB_AUTO_MIGRATE") != "true" {
+		return nil
+	}
+
+	applied, err := migrationService.Up(context.Background())
+	if err != nil {
+		return fmt.Errorf("running startup migrations: %w", err)
+	}
+	logger.Info("Schema migrations applied", zap.Int("count", len(applied)))
+	return nil
+}
+
+// Load reads every migration from the source, sorted by version.
+func (s *MigrationService) Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(s.Source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("reading migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, direction, err := parseMigrationFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(s.Source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("reading migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration version %d is used by both %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.UpSQL = string(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// Up applies every pending migration in version order and returns the ones it applied.
+func (s *MigrationService) Up(ctx context.Context) ([]Migration, error) {
+	all, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, release, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	applied, err := s.appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range all {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		// MySQL commits DDL implicitly, so a failing script may be partially applied.
+		// Scripts use IF (NOT) EXISTS where possible to make a retry safe.
+		if err := execScript(ctx, conn, m.UpSQL); err != nil {
+			return done, fmt.Errorf("applying migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		if _, err := conn.ExecContext(ctx, insertSchemaMigrationQuery, m.Version, m.Name); err != nil {
+			return done, fmt.Errorf("recording migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		s.Logger.Info("Applied migration", zap.Int64("version", m.Version), zap.String("name", m.Name))
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Down rolls back the last `steps` applied migrations, newest first.
+func (s *MigrationService) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+
+	all, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, release, err := s.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	applied, err := s.appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(all) - 1; i >= 0 && len(done) < steps; i-- {
+		m := all[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.DownSQL == "" {
+			return done, fmt.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+
+		if err := execScript(ctx, conn, m.DownSQL); err != nil {
+			return done, fmt.Errorf("rolling back migration %d_%s: %w", m.Version, m.Name, err)
+		}
+		if _, err := conn.ExecContext(ctx, deleteSchemaMigrationQuery, m.Version); err != nil {
+			return done, fmt.Errorf("unrecording migration %d_%s: %w", m.Version, m.Name, err)
+		}
+
+		s.Logger.Info("Rolled back migration", zap.Int64("version", m.Version), zap.String("name", m.Name))
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Status lists every known migration and whether it has been applied.
+func (s *MigrationService) Status(ctx context.Context) ([]MigrationStatus, error) {
+	all, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.DB.ExecContext(ctx, createSchemaMigrationsQuery); err != nil {
+		return nil, fmt.Errorf("creating SchemaMigrations table: %w", err)
+	}
+
+	var rows []appliedMigration
+	if err := s.DB.SelectContext(ctx, &rows, getAppliedMigrationsQuery); err != nil {
+		return nil, fmt.Errorf("fetching applied migrations: %w", err)
+	}
+	applied := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+
+	statuses := make([]MigrationStatus, 0, len(all))
+	for _, m := range all {
+		status := MigrationStatus{Version: m.Version, Name: m.Name}
+		if appliedAt, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// lock pins a single connection and takes the migration lock on it.
+// GET_LOCK is connection scoped, so every statement of a run has to go through the returned conn.
+func (s *MigrationService) lock(ctx context.Context) (*sqlx.Conn, func(), error) {
+	conn, err := s.DB.Connx(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting connection: %w", err)
+	}
+
+	var acquired int
+	if err := conn.GetContext(ctx, &acquired, acquireMigrationLockQuery); err != nil || acquired != 1 {
+		conn.Close()
+		if err == nil {
+			err = fmt.Errorf("another instance is running migrations")
+		}
+		return nil, nil, fmt.Errorf("acquiring migration lock: %w", err)
+	}
+
+	release := func() {
+		conn.ExecContext(context.Background(), releaseMigrationLockQuery)
+		conn.Close()
+	}
+
+	if _, err := conn.ExecContext(ctx, createSchemaMigrationsQuery); err != nil {
+		release()
+		return nil, nil, fmt.Errorf("creating SchemaMigrations table: %w", err)
+	}
+
+	return conn, release, nil
+}
+
+func (s *MigrationService) appliedVersions(ctx context.Context, conn *sqlx.Conn) (map[int64]struct{}, error) {
+	var rows []appliedMigration
+	if err := conn.SelectContext(ctx, &rows, getAppliedMigrationsQuery); err != nil {
+		return nil, fmt.Errorf("fetching applied migrations: %w", err)
+	}
+
+	applied := make(map[int64]struct{}, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = struct{}{}
+	}
+	return applied, nil
+}
+
+// parseMigrationFilename splits "000001_initial_schema.up.sql" into (1, "initial_schema", "up").
+func parseMigrationFilename(filename string) (int64, string, string, error) {
+	base := strings.TrimSuffix(path.Base(filename), ".sql")
+
+	var direction string
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("migration %s must end with .up.sql or .down.sql", filename)
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	versionPart, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("migration %s must be named <version>_<name>", filename)
+	}
+
+	version, err := strconv.ParseUint(versionPart, 10, 63)
+	if err != nil {
+		return 0, "", "", fmt.Errorf("migration %s has an invalid version: %w", filename, err)
+	}
+	if version == 0 {
+		return 0, "", "", fmt.Errorf("migration %s has an invalid version: versions start at 1", filename)
+	}
+
+	return int64(version), name, direction, nil
+}
+
+// execScript runs a migration script one statement at a time, since the DSN does not enable multiStatements.
+func execScript(ctx context.Context, conn *sqlx.Conn, script string) error {
+	for _, stmt := range splitSQLStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%w\n%s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// splitSQLStatements splits a script on ';' and drops comments, "--", "#" and "/* */".
+// Semicolons inside quoted strings and identifiers do not end a statement.
+func splitSQLStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      byte // the open quote character, 0 outside quotes
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+
+		if quote != 0 {
+			current.WriteByte(c)
+			switch {
+			case c == '\\' && quote != '`' && i+1 < len(script):
+				i++
+				current.WriteByte(script[i])
+			case c == quote && i+1 < len(script) && script[i+1] == quote:
+				// A doubled quote is an escaped one
+				i++
+				current.WriteByte(script[i])
+			case c == quote:
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '#' || (c == '-' && strings.HasPrefix(script[i:], "--")):
+			// Line comment, the newline itself is kept
+			for i+1 < len(script) && script[i+1] != '\n' {
+				i++
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				i = len(script)
+			} else {
+				i += end + 3
+			}
+			current.WriteByte(' ')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return statements
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{"empty", "", nil},
+		{"only comments", "-- nothing to do\n# still nothing\n/* or here */\n", nil},
+		{
+			"one per line",
+			"CREATE TABLE A (ID INT);\nCREATE TABLE B (ID INT);\n",
+			[]string{"CREATE TABLE A (ID INT)", "CREATE TABLE B (ID INT)"},
+		},
+		{
+			"spanning lines",
+			"ALTER TABLE Markers\n    ADD COLUMN Status VARCHAR(16),\n    ADD INDEX idx_status (Status);",
+			[]string{"ALTER TABLE Markers\n    ADD COLUMN Status VARCHAR(16),\n    ADD INDEX idx_status (Status)"},
+		},
+		{"two on one line", "SELECT 1; SELECT 2;", []string{"SELECT 1", "SELECT 2"}},
+		{"last without semicolon", "SELECT 1;\nSELECT 2", []string{"SELECT 1", "SELECT 2"}},
+		{
+			"semicolon in single quotes",
+			"INSERT INTO Notices (Title) VALUES ('a;b');\nSELECT 1;",
+			[]string{"INSERT INTO Notices (Title) VALUES ('a;b')", "SELECT 1"},
+		},
+		{
+			"semicolon ending a quoted line",
+			"INSERT INTO Notices (Content) VALUES ('first;\nsecond');",
+			[]string{"INSERT INTO Notices (Content) VALUES ('first;\nsecond')"},
+		},
+		{
+			"escaped quotes",
+			`INSERT INTO T (A, B) VALUES ('it''s; fine', 'back\'s;lash');`,
+			[]string{`INSERT INTO T (A, B) VALUES ('it''s; fine', 'back\'s;lash')`},
+		},
+		{"double quotes and backticks", "SELECT \"x;y\", `a;b` FROM T;", []string{"SELECT \"x;y\", `a;b` FROM T"}},
+		{"comment markers in quotes", "SELECT '-- not a comment', '/* nor this */';", []string{"SELECT '-- not a comment', '/* nor this */'"}},
+		{
+			"trailing comments",
+			"CREATE TABLE A (ID INT); -- the first table\nCREATE TABLE B (ID INT); # the second; with a semicolon\n",
+			[]string{"CREATE TABLE A (ID INT)", "CREATE TABLE B (ID INT)"},
+		},
+		{
+			"comments inside a statement",
+			"CREATE TABLE A (\n    ID INT, -- key; not the end\n    Name /* unused; */ TEXT\n);",
+			[]string{"CREATE TABLE A (\n    ID INT, \n    Name   TEXT\n)"},
+		},
+		{"empty statements", ";;\n;SELECT 1;;", []string{"SELECT 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitSQLStatements(tt.script))
+		})
+	}
+}
+
+func TestParseMigrationFilename(t *testing.T) {
+	version, name, direction, err := parseMigrationFilename("migrations/000013_contribution_ledger.up.sql")
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), version)
+	assert.Equal(t, "contribution_ledger", name)
+	assert.Equal(t, "up", direction)
+
+	version, name, direction, err = parseMigrationFilename("000002_marker_revisions.down.sql")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+	assert.Equal(t, "marker_revisions", name)
+	assert.Equal(t, "down", direction)
+
+	bad := []string{
+		"000001_initial_schema.sql",          // no direction
+		"000001_initial_schema.sideways.sql", // unknown direction
+		"000001_initial_schema.up.txt",       // not SQL
+		"000001.up.sql",                      // no name
+		"000001_.up.sql",                     // empty name
+		"first_initial_schema.up.sql",        // no version
+		"0001a_initial_schema.up.sql",        // not a number
+		"-1_initial_schema.up.sql",           // negative
+		"+1_initial_schema.up.sql",           // signed
+		"000000_initial_schema.up.sql",       // versions start at 1
+		"99999999999999999999_x.up.sql",      // overflows int64
+	}
+	for _, filename := range bad {
+		_, _, _, err := parseMigrationFilename(filename)
+		assert.Error(t, err, filename)
+	}
+}
@@ -33,6 +33,14 @@ type (
 	NotificationRedis = notification.NotificationRedis
 )
 
+// NotificationTypeBadge tells a user they earned an achievement badge
+const NotificationTypeBadge = "Badge"
+
+// isPersonalNotification reports whether notifications of ntype go to one user instead of everyone.
+func isPersonalNotification(ntype string) bool {
+	return ntype == "Like" || ntype == "Comment" || ntype == NotificationTypeBadge
+}
+
 // PostNotification posts a new notification into the database
 func (s *NotificationService) PostNotification(userID, notificationType, title, message string, metadata json.RawMessage) error {
 	result, err := s.DB.Exec(
@@ -48,7 +56,7 @@ func (s *NotificationService) PostNotification(userID, notificationType, title,
 
 	var channelName string
 	// Determine the appropriate channel based on notification type
-	if notificationType == "Like" || notificationType == "Comment" {
+	if isPersonalNotification(notificationType) {
 		channelName = "notifications:user:" + userID
 	} else {
 		channelName = "notifications:broadcast"
@@ -99,7 +107,7 @@ func (s *NotificationService) GetNotifications(userID string) ([]NotificationRed
 		wg.Add(1)
 		go func(idx int, notif Notification) {
 			defer wg.Done()
-			if notif.NotificationType == "Like" || notif.NotificationType == "Comment" {
+			if isPersonalNotification(notif.NotificationType) {
 				if !notif.Viewed {
 					results[idx] = mapToNotificationRedis(notif)
 				}
@@ -133,7 +141,7 @@ func (s *NotificationService) GetNotifications(userID string) ([]NotificationRed
 
 // markNotificationAsViewed(notification, userID)
 func (s *NotificationService) MarkNotificationAsViewed(nid int64, ntype, userID string) {
-	if ntype == "Like" || ntype == "Comment" {
+	if isPersonalNotification(ntype) {
 		if err := s.MarkPersonalNotificationViewed(nid, userID); err != nil {
 			log.Printf("Error marking personal notification as viewed: %v", err)
 		}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/dto"
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+const (
+	// Report photos only need a look while the report waits for a decision
+	flaggedPhotosWhere = `
+FROM (
+	SELECT 'MARKER' AS Source, p.PhotoID, p.MarkerID, NULL AS ReportID, p.PhotoURL, p.ThumbnailURL,
+		p.TakenAt, p.ExifDistance, p.VerificationScore, p.VerificationFlags, p.UploadedAt
+	FROM Photos p
+	WHERE p.VerificationFlags IS NOT NULL AND p.DeletedAt IS NULL
+	UNION ALL
+	SELECT 'REPORT' AS Source, rp.PhotoID, r.MarkerID, rp.ReportID, rp.PhotoURL, rp.ThumbnailURL,
+		rp.TakenAt, rp.ExifDistance, rp.VerificationScore, rp.VerificationFlags, rp.UploadedAt
+	FROM ReportPhotos rp
+	JOIN Reports r ON rp.ReportID = r.ReportID
+	WHERE rp.VerificationFlags IS NOT NULL AND r.Status = 'PENDING'
+) flagged`
+	countFlaggedPhotosQuery  = "SELECT COUNT(*)" + flaggedPhotosWhere
+	selectFlaggedPhotosQuery = "SELECT *" + flaggedPhotosWhere + `
+ORDER BY UploadedAt DESC, PhotoID DESC
+LIMIT ? OFFSET ?`
+
+	dismissPhotoFlagsQuery       = "UPDATE Photos SET VerificationFlags = NULL WHERE PhotoID = ? AND VerificationFlags IS NOT NULL"
+	dismissReportPhotoFlagsQuery = "UPDATE ReportPhotos SET VerificationFlags = NULL WHERE PhotoID = ? AND VerificationFlags IS NOT NULL"
+)
+
+const (
+	// The hash bands narrow the candidates down on their indexes, see util.HashBandCandidates
+	findDuplicatePhotoQuery = `
+SELECT EXISTS (
+	SELECT 1 FROM Photos
+	WHERE (HashBand0 IN (?) OR HashBand1 IN (?) OR HashBand2 IN (?) OR HashBand3 IN (?))
+		AND DeletedAt IS NULL AND MarkerID <> ? AND BIT_COUNT(PerceptualHash ^ ?) <= ?
+)`
+	selectHashedPhotosQuery = `
+SELECT PhotoID, MarkerID, PhotoURL, ThumbnailURL, PerceptualHash, UploadedAt
+FROM Photos
+WHERE PerceptualHash IS NOT NULL AND DeletedAt IS NULL
+ORDER BY UploadedAt, PhotoID`
+	selectHashedPhotoURLsQuery = "SELECT PhotoURL FROM Photos WHERE PerceptualHash IS NOT NULL"
+	updatePhotoHashQuery       = "UPDATE Photos SET PerceptualHash = ? WHERE PhotoURL = ? AND PerceptualHash IS NULL"
+)
+
+// Duplicate groups are built from every hashed photo, a moderator sees them at most this late
+const duplicatePhotoGroupsTTL = 10 * time.Minute
+
+var ErrFlaggedPhotoNotFound = errors.New("flagged photo not found")
+
+// PhotoModerationService lists uploaded photos moderators should look at.
+type PhotoModerationService struct {
+	DB           *sqlx.DB
+	MarkerManage *MarkerManageService
+	S3Service    *S3Service
+	Logger       *zap.Logger
+
+	backfilling atomic.Bool
+
+	groupsMu       sync.Mutex
+	groups         []dto.DuplicatePhotoGroup
+	groupsLoadedAt time.Time
+}
+
+func NewPhotoModerationService(db *sqlx.DB, markerManage *MarkerManageService, s3 *S3Service, logger *zap.Logger) *PhotoModerationService {
+	return &PhotoModerationService{
+		DB:           db,
+		MarkerManage: markerManage,
+		S3Service:    s3,
+		Logger:       logger,
+	}
+}
+
+// GetFlaggedPhotos lists marker photos and photos of pending reports that were taken far from the marker,
+// long ago or look like a photo of another marker, newest upload first.
+func (s *PhotoModerationService) GetFlaggedPhotos(page, pageSize int) (*dto.FlaggedPhotoList, error) {
+	offset := (page - 1) * pageSize
+
+	var total int
+	if err := s.DB.Get(&total, countFlaggedPhotosQuery); err != nil {
+		return nil, fmt.Errorf("counting flagged photos: %w", err)
+	}
+
+	photos := make([]dto.FlaggedPhoto, 0, pageSize)
+	if err := s.DB.Select(&photos, selectFlaggedPhotosQuery, pageSize, offset); err != nil {
+		return nil, fmt.Errorf("fetching flagged photos: %w", err)
+	}
+
+	return &dto.FlaggedPhotoList{
+		Photos:      photos,
+		CurrentPage: page,
+		TotalPages:  int(math.Ceil(float64(total) / float64(pageSize))),
+		TotalPhotos: total,
+	}, nil
+}
+
+// DismissFlags clears the flags of a photo a moderator found to be fine, source is MARKER or REPORT.
+// The verification score stays as it was.
+func (s *PhotoModerationService) DismissFlags(source string, photoID int) error {
+	query := dismissPhotoFlagsQuery
+	if source == dto.PhotoSourceReport {
+		query = dismissReportPhotoFlagsQuery
+	}
+
+	res, err := s.DB.Exec(query, photoID)
+	if err != nil {
+		return fmt.Errorf("dismissing photo flags: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrFlaggedPhotoNotFound
+	}
+	return nil
+}
+
+// GetDuplicatePhotoGroups groups the marker photos that are near-duplicates of each other by their perceptual hash.
+// Photos uploaded before hashing was added only show up once StartHashBackfill has hashed them.
+// The groups are cached for duplicatePhotoGroupsTTL and rebuilt when a backfill finishes.
+func (s *PhotoModerationService) GetDuplicatePhotoGroups() ([]dto.DuplicatePhotoGroup, error) {
+	s.groupsMu.Lock()
+	defer s.groupsMu.Unlock()
+
+	if s.groups != nil && time.Since(s.groupsLoadedAt) < duplicatePhotoGroupsTTL {
+		return s.groups, nil
+	}
+
+	groups, err := s.buildDuplicatePhotoGroups()
+	if err != nil {
+		return nil, err
+	}
+	s.groups = groups
+	s.groupsLoadedAt = time.Now()
+	return groups, nil
+}
+
+// refreshDuplicatePhotoGroups rebuilds the cached groups, after hashes were added.
+func (s *PhotoModerationService) refreshDuplicatePhotoGroups() {
+	s.groupsMu.Lock()
+	defer s.groupsMu.Unlock()
+
+	groups, err := s.buildDuplicatePhotoGroups()
+	if err != nil {
+		s.Logger.Error("Failed to build duplicate photo groups", zap.Error(err))
+		s.groups = nil
+		return
+	}
+	s.groups = groups
+	s.groupsLoadedAt = time.Now()
+}
+
+func (s *PhotoModerationService) buildDuplicatePhotoGroups() ([]dto.DuplicatePhotoGroup, error) {
+	var photos []dto.DuplicatePhoto
+	if err := s.DB.Select(&photos, selectHashedPhotosQuery); err != nil {
+		return nil, fmt.Errorf("fetching photo hashes: %w", err)
+	}
+
+	hashes := make([]uint64, len(photos))
+	for i, photo := range photos {
+		hashes[i] = photo.PerceptualHash
+	}
+
+	indexGroups := util.GroupSimilarHashes(hashes, util.DuplicatePhotoDistance)
+	groups := make([]dto.DuplicatePhotoGroup, 0, len(indexGroups))
+	for _, indices := range indexGroups {
+		group := dto.DuplicatePhotoGroup{Photos: make([]dto.DuplicatePhoto, 0, len(indices))}
+		markers := make(map[int]struct{}, len(indices))
+		for _, i := range indices {
+			photo := photos[i]
+			photo.Distance = util.HammingDistance(photo.PerceptualHash, photos[indices[0]].PerceptualHash)
+			group.Photos = append(group.Photos, photo)
+			markers[photo.MarkerID] = struct{}{}
+		}
+		group.Markers = len(markers)
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// StartHashBackfill computes the perceptual hash of every marker photo that has none yet, in the background.
+// It returns false without doing anything when a backfill is already running.
+func (s *PhotoModerationService) StartHashBackfill() bool {
+	if !s.backfilling.CompareAndSwap(false, true) {
+		return false
+	}
+
+	go func() {
+		defer s.backfilling.Store(false)
+		s.backfillHashes()
+	}()
+	return true
+}
+
+func (s *PhotoModerationService) backfillHashes() {
+	urls, err := s.MarkerManage.FetchAllPhotoURLsFromDB()
+	if err != nil {
+		s.Logger.Error("Failed to fetch photo URLs for hash backfill", zap.Error(err))
+		return
+	}
+
+	var hashedURLs []string
+	if err := s.DB.Select(&hashedURLs, selectHashedPhotoURLsQuery); err != nil {
+		s.Logger.Error("Failed to fetch hashed photo URLs", zap.Error(err))
+		return
+	}
+	hashed := make(map[string]struct{}, len(hashedURLs))
+	for _, url := range hashedURLs {
+		hashed[url] = struct{}{}
+	}
+
+	var done, uniform, failed int
+	for _, url := range urls {
+		if _, ok := hashed[url]; ok {
+			continue
+		}
+
+		hash, ok, err := s.hashStoredPhoto(url)
+		if err != nil {
+			s.Logger.Warn("Failed to hash photo", zap.String("photoURL", url), zap.Error(err))
+			failed++
+			continue
+		}
+		if !ok {
+			uniform++ // left without a hash, like such uploads
+			continue
+		}
+		if _, err := s.DB.Exec(updatePhotoHashQuery, hash, url); err != nil {
+			s.Logger.Error("Failed to store photo hash", zap.String("photoURL", url), zap.Error(err))
+			failed++
+			continue
+		}
+		done++
+	}
+
+	s.Logger.Info("Photo hash backfill finished",
+		zap.Int("photos", len(urls)),
+		zap.Int("alreadyHashed", len(hashed)),
+		zap.Int("hashed", done),
+		zap.Int("uniform", uniform),
+		zap.Int("failed", failed),
+	)
+
+	if done > 0 {
+		s.refreshDuplicatePhotoGroups()
+	}
+}
+
+// hashStoredPhoto downloads and hashes a photo, ok is false for a near-uniform one, see util.DHash.
+func (s *PhotoModerationService) hashStoredPhoto(url string) (hash uint64, ok bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	data, err := s.S3Service.DownloadFromS3(ctx, url)
+	if err != nil {
+		return 0, false, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, false, fmt.Errorf("decoding image: %w", err)
+	}
+	hash, ok = util.DHash(img)
+	return hash, ok, nil
+}
+
+// isDuplicateUpload tells whether an uploaded photo looks like a photo of another marker. Such photos are only
+// flagged for a moderator, so a failed lookup is logged and lets the photo through unflagged.
+func isDuplicateUpload(q sqlx.Queryer, logger *zap.Logger, markerID int, uploaded *UploadedFile) bool {
+	if uploaded.PerceptualHash == nil {
+		return false
+	}
+
+	hash := *uploaded.PerceptualHash
+	bands := util.HashBandCandidates(hash, util.DuplicatePhotoDistance)
+	query, args, err := sqlx.In(findDuplicatePhotoQuery, bands[0], bands[1], bands[2], bands[3], markerID, hash, util.DuplicatePhotoDistance)
+	if err != nil {
+		logger.Error("Failed to build duplicate photo query", zap.Int("markerID", markerID), zap.Error(err))
+		return false
+	}
+
+	var duplicate bool
+	if err := sqlx.Get(q, &duplicate, query, args...); err != nil {
+		logger.Error("Failed to look for duplicate photos", zap.Int("markerID", markerID), zap.Error(err))
+		return false
+	}
+	return duplicate
+}
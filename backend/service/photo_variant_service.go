@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Alfex4936/chulbong-kr/util"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// Photos resized at once, resizing is expensive on this server
+	photoVariantWorkers = 2
+	// Photos waiting for variants, more are left without them and served by resizing on the fly
+	photoVariantQueueSize = 256
+	photoVariantTimeout   = time.Minute
+
+	updatePhotoVariantsQuery       = "UPDATE Photos SET VariantWidths = ? WHERE PhotoURL = ?"
+	updateReportPhotoVariantsQuery = "UPDATE ReportPhotos SET VariantWidths = ? WHERE PhotoURL = ?"
+)
+
+// PhotoVariantService makes the resized copies of uploaded photos in the background, so uploads only wait
+// for the original. VariantWidths of a photo is filled in once its variants are stored, until then clients
+// get the original.
+type PhotoVariantService struct {
+	DB        *sqlx.DB
+	S3Service *S3Service
+	Logger    *zap.Logger
+
+	queue chan string
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func NewPhotoVariantService(db *sqlx.DB, s3 *S3Service, logger *zap.Logger) *PhotoVariantService {
+	return &PhotoVariantService{
+		DB:        db,
+		S3Service: s3,
+		Logger:    logger,
+		queue:     make(chan string, photoVariantQueueSize),
+		stop:      make(chan struct{}),
+	}
+}
+
+func RegisterPhotoVariantLifecycle(lifecycle fx.Lifecycle, service *PhotoVariantService) {
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			for range photoVariantWorkers {
+				service.wg.Add(1)
+				go service.run()
+			}
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			close(service.stop)
+			service.wg.Wait()
+			return nil
+		},
+	})
+}
+
+// Enqueue schedules variants for photos UploadFile stored, call it once the rows naming them are committed.
+func (s *PhotoVariantService) Enqueue(photoURLs ...string) {
+	for _, url := range photoURLs {
+		select {
+		case s.queue <- url:
+		default:
+			s.Logger.Warn("Photo variant queue is full, skipping", zap.String("photoURL", url))
+		}
+	}
+}
+
+func (s *PhotoVariantService) run() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case url := <-s.queue:
+			s.makeVariants(url)
+		}
+	}
+}
+
+func (s *PhotoVariantService) makeVariants(photoURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), photoVariantTimeout)
+	defer cancel()
+
+	widths, err := s.S3Service.UploadPhotoVariants(ctx, photoURL)
+	if err != nil {
+		s.Logger.Error("Failed to make photo variants", zap.String("photoURL", photoURL), zap.Error(err))
+		return
+	}
+	if len(widths) == 0 {
+		return
+	}
+
+	// A report photo is copied to Photos when the report is approved, whichever happens first
+	formatted := util.FormatVariantWidths(widths)
+	for _, query := range []string{updatePhotoVariantsQuery, updateReportPhotoVariantsQuery} {
+		if _, err := s.DB.Exec(query, formatted, photoURL); err != nil {
+			s.Logger.Error("Failed to store photo variant widths", zap.String("photoURL", photoURL), zap.Error(err))
+		}
+	}
+}
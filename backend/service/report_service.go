@@ -21,7 +21,7 @@ const (
 	getAllReportsQuery = `
 SELECT r.ReportID, r.MarkerID, r.UserID, ST_X(r.Location) AS Latitude, ST_Y(r.Location) AS Longitude,
 ST_X(r.NewLocation) AS NewLatitude, ST_Y(r.NewLocation) AS NewLongitude,
-r.Description, r.CreatedAt, r.Status, r.DoesExist, COALESCE(p.PhotoURL, '')
+r.Description, r.CreatedAt, r.Status, r.DoesExist, COALESCE(r.ReportedStatus, ''), COALESCE(p.PhotoURL, '')
 FROM Reports r
 LEFT JOIN ReportPhotos p ON r.ReportID = p.ReportID
 ORDER BY r.CreatedAt DESC`
@@ -29,15 +29,21 @@ ORDER BY r.CreatedAt DESC`
 	getAllReportsByQuery = `
 SELECT r.ReportID, r.MarkerID, r.UserID, ST_X(r.Location) AS Latitude, ST_Y(r.Location) AS Longitude,
 ST_X(r.NewLocation) AS NewLatitude, ST_Y(r.NewLocation) AS NewLongitude,
-r.Description, r.CreatedAt, r.Status, m.Address, COALESCE(p.PhotoURL, '') AS PhotoURL
+r.Description, r.CreatedAt, r.Status, COALESCE(r.ReportedStatus, '') AS ReportedStatus, m.Address, COALESCE(p.PhotoURL, '') AS PhotoURL
 FROM Reports r
 LEFT JOIN ReportPhotos p ON r.ReportID = p.ReportID
 LEFT JOIN Markers m ON r.MarkerID = m.MarkerID
 WHERE r.MarkerID = ?
 ORDER BY r.CreatedAt DESC`
 
-	insertReportQuery      = "INSERT INTO Reports (MarkerID, UserID, Location, NewLocation, Description, DoesExist) VALUES (?, ?, ST_PointFromText(?, 4326), ST_PointFromText(?, 4326), ?, ?)"
-	insertReportPhotoQuery = "INSERT INTO ReportPhotos (ReportID, PhotoURL) VALUES (?, ?)"
+	insertReportQuery = `
+INSERT INTO Reports (MarkerID, UserID, Location, NewLocation, Description, DoesExist, ReportedStatus,
+	ReportedAccessType, ReportedOpeningHours, ReportedLit, ReportedIndoor)
+VALUES (?, ?, ST_PointFromText(?, 4326), ST_PointFromText(?, 4326), ?, ?, NULLIF(?, ''), ?, ?, ?, ?)`
+	insertReportPhotoQuery = `
+INSERT INTO ReportPhotos (ReportID, PhotoURL, ThumbnailURL, Blurhash, PerceptualHash, QualityScore, Sharpness, Brightness,
+	TakenAt, ExifDistance, VerificationScore, VerificationFlags)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))`
 
 	// Use a derived table to avoid Error 1093
 	// SQL query tries to update a table (Reports) and simultaneously select from the same table within a subquery.
@@ -102,12 +108,16 @@ UPDATE Markers
 JOIN Reports ON Markers.MarkerID = Reports.MarkerID
 SET Markers.Location = COALESCE(Reports.NewLocation, Markers.Location),
 	Markers.Description = CASE WHEN Reports.Description != '' THEN COALESCE(Reports.Description, Markers.Description) ELSE Markers.Description END,
+	Markers.AccessType = COALESCE(Reports.ReportedAccessType, Markers.AccessType),
+	Markers.OpeningHours = COALESCE(Reports.ReportedOpeningHours, Markers.OpeningHours),
+	Markers.Lit = COALESCE(Reports.ReportedLit, Markers.Lit),
+	Markers.Indoor = COALESCE(Reports.ReportedIndoor, Markers.Indoor),
 	Markers.UpdatedAt = CURRENT_TIMESTAMP
 WHERE Reports.ReportID = ? AND Reports.Status = 'APPROVED'	`
 
 	updateReportPhotoQuery = `
-INSERT INTO Photos (MarkerID, PhotoURL, ThumbnailURL, Blurhash, UploadedAt)
-SELECT r.MarkerID, rp.PhotoURL, rp.ThumbnailURL, rp.Blurhash, rp.UploadedAt
+INSERT INTO Photos (MarkerID, PhotoURL, ThumbnailURL, VariantWidths, Blurhash, PerceptualHash, QualityScore, Sharpness, Brightness, UploadedAt)
+SELECT r.MarkerID, rp.PhotoURL, rp.ThumbnailURL, rp.VariantWidths, rp.Blurhash, rp.PerceptualHash, rp.QualityScore, rp.Sharpness, rp.Brightness, rp.UploadedAt
 FROM ReportPhotos rp
 JOIN Reports r ON rp.ReportID = r.ReportID
 WHERE r.ReportID = ? AND r.Status = 'APPROVED'
@@ -141,7 +151,7 @@ SELECT EXISTS(
 	getPendingReportsQuery = `
 SELECT r.ReportID, r.MarkerID, r.UserID, ST_X(r.Location) AS Latitude, ST_Y(r.Location) AS Longitude,
 ST_X(r.NewLocation) AS NewLatitude, ST_Y(r.NewLocation) AS NewLongitude,
-r.Description, r.CreatedAt, r.Status, r.DoesExist, COALESCE(p.PhotoURL, '')
+r.Description, r.CreatedAt, r.Status, r.DoesExist, COALESCE(r.ReportedStatus, ''), COALESCE(p.PhotoURL, '')
 FROM Reports r
 LEFT JOIN ReportPhotos p ON r.ReportID = p.ReportID
 WHERE r.Status = 'PENDING'
@@ -156,13 +166,24 @@ type ReportService struct {
 	LocationService *MarkerLocationService
 	CacheService    *MarkerCacheService
 	RedisService    *RedisService
+	RevisionService *MarkerRevisionService
+	StatusService   *MarkerStatusService
+	BadgeService    *BadgeService
+	VariantService  *PhotoVariantService
 	Logger          *zap.Logger
+
+	ContributionService *ContributionService
 }
 
 func NewReportService(db *sqlx.DB, s3Service *S3Service,
 	location *MarkerLocationService,
 	cache *MarkerCacheService,
 	redis *RedisService,
+	revision *MarkerRevisionService,
+	status *MarkerStatusService,
+	badge *BadgeService,
+	variant *PhotoVariantService,
+	contribution *ContributionService,
 	logger *zap.Logger) *ReportService {
 	return &ReportService{
 		DB:              db,
@@ -170,7 +191,13 @@ func NewReportService(db *sqlx.DB, s3Service *S3Service,
 		LocationService: location,
 		CacheService:    cache,
 		RedisService:    redis,
+		RevisionService: revision,
+		StatusService:   status,
+		BadgeService:    badge,
+		VariantService:  variant,
 		Logger:          logger,
+
+		ContributionService: contribution,
 	}
 }
 
@@ -189,7 +216,7 @@ func (s *ReportService) GetAllReports() ([]dto.MarkerReportResponse, error) {
 			url string
 		)
 		if err := rows.Scan(&r.ReportID, &r.MarkerID, &r.UserID, &r.Latitude, &r.Longitude,
-			&r.NewLatitude, &r.NewLongitude, &r.Description, &r.CreatedAt, &r.Status, &r.DoesExist, &url); err != nil {
+			&r.NewLatitude, &r.NewLongitude, &r.Description, &r.CreatedAt, &r.Status, &r.DoesExist, &r.ReportedStatus, &url); err != nil {
 			return nil, err
 		}
 		// Check if the URL is not empty before appending
@@ -232,7 +259,7 @@ func (s *ReportService) GetAllReportsBy(markerID int) ([]dto.MarkerReportRespons
 			url string
 		)
 		if err := rows.Scan(&r.ReportID, &r.MarkerID, &r.UserID, &r.Latitude, &r.Longitude,
-			&r.NewLatitude, &r.NewLongitude, &r.Description, &r.CreatedAt, &r.Status, &r.Address, &url); err != nil {
+			&r.NewLatitude, &r.NewLongitude, &r.Description, &r.CreatedAt, &r.Status, &r.ReportedStatus, &r.Address, &url); err != nil {
 			return nil, err
 		}
 		// Check if the URL is not empty before appending
@@ -262,36 +289,45 @@ func (s *ReportService) GetAllReportsBy(markerID int) ([]dto.MarkerReportRespons
 }
 
 // CreateReport handles the logic for creating a report and uploading photos related to that report.
-func (s *ReportService) CreateReport(report *dto.MarkerReportRequest, form *multipart.Form) error {
+// It returns the quality warnings of the photos, a photo that fails the quality checks fails the report.
+func (s *ReportService) CreateReport(report *dto.MarkerReportRequest, form *multipart.Form) ([]dto.PhotoWarning, error) {
 	// Process file uploads from the multipart form
 	files := form.File["photos"]
 	if len(files) == 0 {
-		return ErrNoPhotos
+		return nil, ErrNoPhotos
 	}
 
 	// Begin a transaction for database operations
 	tx, err := s.DB.Beginx()
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrBeginTransaction, err)
+		return nil, fmt.Errorf("%w: %v", ErrBeginTransaction, err)
 	}
 	defer tx.Rollback()
 
 	// Insert the main report record
 	point := formatPoint(report.Latitude, report.Longitude)
 	newPoint := formatPoint(report.NewLatitude, report.NewLongitude)
-	res, err := tx.Exec(insertReportQuery, report.MarkerID, report.UserID, point, newPoint, report.Description, report.DoesExist)
+	access := report.ReportedAccess
+	res, err := tx.Exec(insertReportQuery, report.MarkerID, report.UserID, point, newPoint, report.Description, report.DoesExist, report.ReportedStatus,
+		access.AccessType, access.OpeningHours, access.Lit, access.Indoor)
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrInsertReport, err)
+		return nil, fmt.Errorf("%w: %v", ErrInsertReport, err)
 	}
 
 	// Get the last inserted ID for the report
 	reportID, err := res.LastInsertId()
 	if err != nil {
-		return fmt.Errorf("%w: %v", ErrLastInsertID, err)
+		return nil, fmt.Errorf("%w: %v", ErrLastInsertID, err)
 	}
 
 	folder := fmt.Sprintf("reports/%d", reportID)
 
+	// Photos should show where the reporter says the marker is
+	claimedLat, claimedLong := report.Latitude, report.Longitude
+	if report.NewLatitude != 0 && report.NewLongitude != 0 {
+		claimedLat, claimedLong = report.NewLatitude, report.NewLongitude
+	}
+
 	// Create a cancellable context for the worker tasks.
 	taskCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -299,6 +335,20 @@ func (s *ReportService) CreateReport(report *dto.MarkerReportRequest, form *mult
 	// Channel to collect errors
 	errorChan := make(chan error, 1)
 
+	// Quality warnings of the photos that were accepted
+	var warningsMu sync.Mutex
+	var photoWarnings []dto.PhotoWarning
+
+	// Photos already in S3 are deleted again when the report is not created
+	var uploadedMu sync.Mutex
+	var uploadedURLs []string
+	committed := false
+	defer func() {
+		if !committed {
+			s.S3Service.DeleteUploadedFiles(uploadedURLs)
+		}
+	}()
+
 	var wg sync.WaitGroup
 	wg.Add(len(files))
 
@@ -310,7 +360,7 @@ func (s *ReportService) CreateReport(report *dto.MarkerReportRequest, form *mult
 			defer wg.Done()
 
 			// Upload the file to S3 with thumbnail
-			fileURL, thumbnailURL, err := s.S3Service.UploadFileToS3WithContext(taskCtx, folder, fileHeader, true)
+			uploaded, err := s.S3Service.UploadFile(taskCtx, folder, fileHeader, true)
 			if err != nil {
 				select {
 				case errorChan <- fmt.Errorf("S3 upload failed: %w", err):
@@ -318,6 +368,9 @@ func (s *ReportService) CreateReport(report *dto.MarkerReportRequest, form *mult
 				}
 				return
 			}
+			uploadedMu.Lock()
+			uploadedURLs = append(uploadedURLs, uploaded.URL)
+			uploadedMu.Unlock()
 
 			// Generate blurhash
 			file, _ := fileHeader.Open()
@@ -336,15 +389,30 @@ func (s *ReportService) CreateReport(report *dto.MarkerReportRequest, form *mult
 			}
 			blurhashString := util.EncodeBlurHashImage(img, 6, 5)
 
-			// Insert photo with thumbnail and blurhash
-			if _, err := tx.Exec("INSERT INTO ReportPhotos (ReportID, PhotoURL, ThumbnailURL, Blurhash) VALUES (?, ?, ?, ?)",
-				reportID, fileURL, thumbnailURL, blurhashString); err != nil {
+			// The uploaded copy has no EXIF any more, the original still tells where it was taken
+			verification := util.VerifyPhotoLocation(util.ReadPhotoExif(bytes.NewReader(rawBytes)), claimedLat, claimedLong, time.Now())
+			if isDuplicateUpload(s.DB, s.Logger, report.MarkerID, uploaded) {
+				verification.Flags = append(verification.Flags, util.PhotoFlagDuplicate)
+			}
+
+			// Insert photo with thumbnail, blurhash, hash, quality and verification
+			qualityScore, sharpness, brightness := uploaded.qualityColumns()
+			if _, err := tx.Exec(insertReportPhotoQuery, reportID, uploaded.URL, uploaded.ThumbnailURL,
+				blurhashString, uploaded.PerceptualHash,
+				qualityScore, sharpness, brightness,
+				verification.TakenAt, verification.Distance, verification.Score, verification.FlagList()); err != nil {
 				select {
 				case errorChan <- err:
 				default:
 				}
 				return
 			}
+
+			if warnings := uploaded.Warnings(fileHeader.Filename); len(warnings) > 0 {
+				warningsMu.Lock()
+				photoWarnings = append(photoWarnings, warnings...)
+				warningsMu.Unlock()
+			}
 		}()
 	}
 
@@ -354,15 +422,17 @@ func (s *ReportService) CreateReport(report *dto.MarkerReportRequest, form *mult
 
 	// Check for errors
 	if err, ok := <-errorChan; ok {
-		return fmt.Errorf("encountered an error during file upload or DB operation: %v", err)
+		return nil, fmt.Errorf("encountered an error during file upload or DB operation: %w", err)
 	}
 
 	// Commit the transaction after all operations succeed
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("%w: %v", ErrCommitTransaction, err)
+		return nil, fmt.Errorf("%w: %v", ErrCommitTransaction, err)
 	}
+	committed = true
+	s.VariantService.Enqueue(uploadedURLs...)
 
-	return nil
+	return photoWarnings, nil
 }
 
 func (s *ReportService) ApproveReport(reportID, userID int) error {
@@ -370,12 +440,7 @@ func (s *ReportService) ApproveReport(reportID, userID int) error {
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-		}
-	}()
+	defer tx.Rollback()
 
 	// Approve the report
 	res, err := tx.Exec(approveReportQuery, reportID, userID, reportID, userID)
@@ -388,7 +453,7 @@ func (s *ReportService) ApproveReport(reportID, userID int) error {
 	}
 
 	// Update the marker with report details
-	if err := s.UpdateMarkerWithReportDetailsTx(tx, reportID); err != nil {
+	if err := s.UpdateMarkerWithReportDetailsTx(tx, reportID, userID); err != nil {
 		return err
 	}
 
@@ -402,6 +467,12 @@ func (s *ReportService) ApproveReport(reportID, userID int) error {
 		return fmt.Errorf("failed to fetch report details: %w", err)
 	}
 
+	// Approved existence evidence may change whether the bar is still there
+	_, statusChanged, err := s.StatusService.ReevaluateTx(tx, report.MarkerID)
+	if err != nil {
+		return fmt.Errorf("failed to reevaluate marker status: %w", err)
+	}
+
 	// Determine comment text
 	var commentText string
 	if report.UserID == 0 {
@@ -417,26 +488,47 @@ func (s *ReportService) ApproveReport(reportID, userID int) error {
 	}
 
 	// Add comment as admin
-	commentService := NewMarkerCommentService(s.DB, s.RedisService)
+	commentService := NewMarkerCommentService(s.DB, s.RedisService, s.BadgeService)
 	_, err = commentService.CreateCommentTx(tx, report.MarkerID, 1, "k-pullup", commentText)
 	if err != nil {
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
 
+	contributors, err := s.ContributionService.SyncTx(tx, EventReportApproved, reportID, "")
+	if err != nil {
+		return err
+	}
+
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("error committing transaction: %w", err)
 	}
+	s.ContributionService.InvalidateProfiles(contributors)
 
 	// Update location and invalidate cache
 	s.UpdateDbLocation(reportID)
 	s.CacheService.InvalidateFullMarkersCache()
+	// Report photos and the status show on the tiles
+	s.CacheService.InvalidateMarkerTiles()
+	if statusChanged {
+		s.CacheService.RemoveMarkerCache(report.MarkerID)
+	}
+
+	// Anonymous reports have no one to award
+	s.BadgeService.Publish(EventReportApproved, report.UserID)
 
 	return nil
 }
 
+// DenyReport denies a report. Denying one that was approved before reverses it and takes back the reporter's points.
 func (s *ReportService) DenyReport(reportID, userID int) error {
-	res, err := s.DB.Exec(denyReportQuery, reportID, userID, reportID, userID)
+	tx, err := s.DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(denyReportQuery, reportID, userID, reportID, userID)
 	if err != nil {
 		return fmt.Errorf("error denying report: %w", err)
 	}
@@ -445,12 +537,32 @@ func (s *ReportService) DenyReport(reportID, userID int) error {
 		return fmt.Errorf("no report updated, either report does not exist or user is not the owner")
 	}
 
+	contributors, err := s.ContributionService.SyncTx(tx, EventReportApproved, reportID, ContributionReasonReportReversed)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	s.ContributionService.InvalidateProfiles(contributors)
+
 	return nil
 }
 
-func (s *ReportService) UpdateMarkerWithReportDetailsTx(tx *sqlx.Tx, reportID int) error {
+// UpdateMarkerWithReportDetailsTx applies an approved report to its marker, recording a revision by approverID.
+func (s *ReportService) UpdateMarkerWithReportDetailsTx(tx *sqlx.Tx, reportID, approverID int) error {
+	var markerID int
+	if err := tx.Get(&markerID, getReportByIdQuery, reportID); err != nil {
+		return fmt.Errorf("error fetching report marker: %w", err)
+	}
 
-	if _, err := tx.Exec(updateMarkeryReportQuery, reportID); err != nil {
+	actor := RevisionActor{UserID: &approverID, ReportID: &reportID, Source: RevisionSourceReport}
+	err := s.RevisionService.TrackTx(tx, markerID, actor, func() error {
+		_, err := tx.Exec(updateMarkeryReportQuery, reportID)
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("error updating marker with report details: %w", err)
 	}
 
@@ -509,10 +621,17 @@ func (s *ReportService) DeleteReport(reportID, userID, markerID int) error {
 		return fmt.Errorf("error deleting report: %w", err)
 	}
 
+	// An approved report takes its points with it
+	contributors, err := s.ContributionService.SyncTx(tx, EventReportApproved, reportID, ContributionReasonReportDeleted)
+	if err != nil {
+		return err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("could not commit transaction: %w", err)
 	}
+	s.ContributionService.InvalidateProfiles(contributors)
 
 	// Delete photos from S3 in a goroutine
 	go func(photoURLs []string) {
@@ -547,6 +666,9 @@ func (s *ReportService) UpdateDbLocation(reportID int) {
 			return
 		}
 
+		// The approved report may have moved the marker
+		s.CacheService.UpdateMarker(int(markerID), dto.MarkerSimple{Latitude: location.Latitude, Longitude: location.Longitude, MarkerID: int(markerID)})
+
 		var address string
 		var err error
 
@@ -600,7 +722,10 @@ func (s *ReportService) UpdateDbLocation(reportID int) {
 		standardizedAddress := standardizeAddress(address)
 
 		// Update the marker's address in the database after successful fetch
-		_, err = s.LocationService.DB.Exec(updateMarkerAddressByIdQuery, standardizedAddress, markerID)
+		err = s.RevisionService.Track(int(markerID), RevisionBySystem(), func(tx *sqlx.Tx) error {
+			_, err := tx.Exec(updateMarkerAddressByIdQuery, standardizedAddress, markerID)
+			return err
+		})
 		if err != nil {
 			s.Logger.Error("Failed to update address for marker", zap.Int64("markerID", markerID), zap.Error(err))
 		}
@@ -622,7 +747,7 @@ func (s *ReportService) GetPendingReports() ([]dto.MarkerReportResponse, error)
 			url string
 		)
 		if err := rows.Scan(&r.ReportID, &r.MarkerID, &r.UserID, &r.Latitude, &r.Longitude,
-			&r.NewLatitude, &r.NewLongitude, &r.Description, &r.CreatedAt, &r.Status, &r.DoesExist, &url); err != nil {
+			&r.NewLatitude, &r.NewLongitude, &r.Description, &r.CreatedAt, &r.Status, &r.DoesExist, &r.ReportedStatus, &url); err != nil {
 			return nil, err
 		}
 		// Check if the URL is not empty before appending
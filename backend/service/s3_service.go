@@ -17,6 +17,7 @@ import (
 	"time"
 
 	myconfig "github.com/Alfex4936/chulbong-kr/config"
+	"github.com/Alfex4936/chulbong-kr/dto"
 	"github.com/Alfex4936/chulbong-kr/util"
 	"github.com/chai2010/webp"
 	"github.com/disintegration/imaging"
@@ -29,6 +30,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// Quality of the WebP and JPEG photo variants
+const photoVariantQuality = 80
+
 type S3Service struct {
 	Config   *myconfig.S3Config
 	Redis    *RedisService
@@ -55,92 +59,70 @@ func NewS3Service(c *myconfig.S3Config, redis *RedisService, logger *zap.Logger)
 	}
 }
 
-func (s *S3Service) UploadFileToS3(folder string, file *multipart.FileHeader, thumbnail bool) (string, string, error) {
-	// Open the uploaded file
-	fileData, err := file.Open()
-	if err != nil {
-		return "", "", err
-	}
-	defer fileData.Close()
+// UploadedFile is a file put in S3 by UploadFile.
+type UploadedFile struct {
+	PerceptualHash *uint64            // dHash of the image, nil for other files, near-uniform images or images that could not be decoded
+	Quality        *util.PhotoQuality // nil for other files or images that could not be decoded, never has a Rejection
+	URL            string
+	ThumbnailURL   string // empty unless a thumbnail was asked for and could be made
+}
 
-	// Generate a UUID for a unique filename
-	uuid, err := uuid.NewRandom()
-	if err != nil {
-		return "", "", fmt.Errorf("failed to generate UUID: %w", err)
+// Warnings lists the quality warnings of the photo for its uploader.
+func (u *UploadedFile) Warnings(filename string) []dto.PhotoWarning {
+	if u.Quality == nil {
+		return nil
 	}
 
-	// Extract and lowercase the file extension
-	ext := filepathExtLower(file.Filename)
-
-	// Use the original file's extension but with a new UUID as the filename
-	// Estimate the key length: folder + '/' + UUID + ext
-	keyLen := len(folder) + 1 + 36 + len(ext)
-	keyBytes := make([]byte, 0, keyLen)
-	keyBytes = append(keyBytes, folder...)
-	keyBytes = append(keyBytes, '/')
-	keyBytes = appendUUID(keyBytes, uuid)
-	keyBytes = append(keyBytes, ext...)
+	warnings := make([]dto.PhotoWarning, 0, len(u.Quality.Warnings))
+	for _, w := range u.Quality.Warnings {
+		warnings = append(warnings, dto.PhotoWarning{Filename: filename, Check: w.Check, Message: w.Message})
+	}
+	return warnings
+}
 
-	// Convert keyBytes to string without allocation
-	key := util.BytesToString(keyBytes)
+// qualityColumns are the QualityScore, Sharpness and Brightness of the photo, all nil without a quality.
+func (u *UploadedFile) qualityColumns() (*int, *float64, *float64) {
+	if u.Quality == nil {
+		return nil, nil, nil
+	}
+	return &u.Quality.Score, &u.Quality.Sharpness, &u.Quality.Brightness
+}
 
+func (s *S3Service) UploadFileToS3(folder string, file *multipart.FileHeader, thumbnail bool) (string, string, error) {
 	// Create a context with a timeout if necessary
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var thumbnailURL string
-
-	// If thumbnail is requested and file is an image, generate the thumbnail
-	if thumbnail && isImage(ext) {
-		thumbnailURL, err = s.GenerateThumbnail(ctx, fileData, folder, uuid.String(), ext)
-		if err != nil {
-			s.logger.Error("failed to generate or upload thumbnail", zap.Error(err))
-		}
-
-		// Reset fileData to the beginning for uploading the original file
-		_, err = fileData.Seek(0, io.SeekStart)
-		if err != nil {
-			return "", "", fmt.Errorf("failed to seek fileData: %w", err)
-		}
+	uploaded, err := s.UploadFile(ctx, folder, file, thumbnail)
+	if err != nil {
+		return "", "", err
 	}
+	return uploaded.URL, uploaded.ThumbnailURL, nil
+}
 
-	// Upload the file to S3
-	_, err = s.s3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: &s.Config.S3BucketName,
-		Key:    &key,
-		Body:   fileData,
-	})
+func (s *S3Service) UploadFileToS3WithContext(ctx context.Context, folder string, file *multipart.FileHeader, thumbnail bool) (string, string, error) {
+	uploaded, err := s.UploadFile(ctx, folder, file, thumbnail)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to upload file to S3: %w", err)
+		return "", "", err
 	}
-
-	// Construct the file URL
-	// Estimate the URL length: "https://" + bucket + ".s3.amazonaws.com/" + key
-	urlLen := 8 + len(s.Config.S3BucketName) + 17 + len(key)
-	urlBytes := make([]byte, 0, urlLen)
-	urlBytes = append(urlBytes, "https://"...)
-	urlBytes = append(urlBytes, s.Config.S3BucketName...)
-	urlBytes = append(urlBytes, ".s3.amazonaws.com/"...)
-	urlBytes = append(urlBytes, keyBytes...)
-
-	// Convert urlBytes to string without allocation
-	fileURL := util.BytesToString(urlBytes)
-
-	return fileURL, thumbnailURL, nil
+	return uploaded.URL, uploaded.ThumbnailURL, nil
 }
 
-func (s *S3Service) UploadFileToS3WithContext(ctx context.Context, folder string, file *multipart.FileHeader, thumbnail bool) (string, string, error) {
+// UploadFile uploads a file under folder with a new UUID as its name. Images are stripped of their metadata first,
+// decoded once for their quality checks, perceptual hash and, if asked for, a thumbnail.
+// An image that fails the quality checks is not uploaded, the error is a util.PhotoQualityIssue.
+func (s *S3Service) UploadFile(ctx context.Context, folder string, file *multipart.FileHeader, thumbnail bool) (*UploadedFile, error) {
 	// Open the uploaded file
 	fileData, err := file.Open()
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	defer fileData.Close()
 
 	// Generate a UUID for a unique filename
 	uuid, err := uuid.NewRandom()
 	if err != nil {
-		return "", "", fmt.Errorf("failed to generate UUID: %w", err)
+		return nil, fmt.Errorf("failed to generate UUID: %w", err)
 	}
 
 	// Extract and lowercase the file extension
@@ -158,19 +140,42 @@ func (s *S3Service) UploadFileToS3WithContext(ctx context.Context, folder string
 	// Convert keyBytes to string without allocation
 	key := util.BytesToString(keyBytes)
 
-	var thumbnailURL string
+	uploaded := &UploadedFile{}
 
-	// If thumbnail is requested and file is an image, generate the thumbnail
-	if thumbnail && isImage(ext) {
-		thumbnailURL, err = s.GenerateThumbnail(ctx, fileData, folder, uuid.String(), ext)
+	body, err := stripUploadMetadata(fileData, ext)
+	if err != nil {
+		return nil, err
+	}
+
+	if isImage(ext) {
+		img, _, err := image.Decode(body)
 		if err != nil {
-			s.logger.Error("failed to generate or upload thumbnail", zap.Error(err))
+			s.logger.Error("failed to decode image", zap.String("key", key), zap.Error(err))
+		} else {
+			quality := util.AssessPhotoQuality(img)
+			if err := quality.Err(); err != nil {
+				return nil, err
+			}
+			uploaded.Quality = &quality
+
+			// Near-uniform images get no hash, they would look like duplicates of each other
+			if hash, ok := util.DHash(img); ok {
+				uploaded.PerceptualHash = &hash
+			}
+
+			// If thumbnail is requested, generate it from the decoded image
+			if thumbnail {
+				uploaded.ThumbnailURL, err = s.GenerateThumbnail(ctx, img, folder, uuid.String(), ext)
+				if err != nil {
+					s.logger.Error("failed to generate or upload thumbnail", zap.Error(err))
+				}
+			}
 		}
 
-		// Reset fileData to the beginning for uploading the original file
-		_, err = fileData.Seek(0, io.SeekStart)
+		// Reset body to the beginning for uploading the original file
+		_, err = body.Seek(0, io.SeekStart)
 		if err != nil {
-			return "", "", fmt.Errorf("failed to seek fileData: %w", err)
+			return nil, fmt.Errorf("failed to seek fileData: %w", err)
 		}
 	}
 
@@ -178,10 +183,10 @@ func (s *S3Service) UploadFileToS3WithContext(ctx context.Context, folder string
 	_, err = s.s3Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: &s.Config.S3BucketName,
 		Key:    &key,
-		Body:   fileData,
+		Body:   body,
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("failed to upload file to S3: %w", err)
+		return nil, fmt.Errorf("failed to upload file to S3: %w", err)
 	}
 
 	// Construct the file URL
@@ -194,33 +199,16 @@ func (s *S3Service) UploadFileToS3WithContext(ctx context.Context, folder string
 	urlBytes = append(urlBytes, keyBytes...)
 
 	// Convert urlBytes to string without allocation
-	fileURL := util.BytesToString(urlBytes)
+	uploaded.URL = util.BytesToString(urlBytes)
 
-	return fileURL, thumbnailURL, nil
+	return uploaded, nil
 }
 
 // DeleteDataFromS3 deletes a photo and its thumbnail from S3 given its URL.
 func (s *S3Service) DeleteDataFromS3(dataURL string) error {
-	var bucketName, key string
-
-	// Attempt to parse the input as a URL
-	parsedURL, err := url.Parse(dataURL)
-	if err == nil && parsedURL.Scheme != "" && parsedURL.Host != "" {
-		// It's a valid URL
-		parts := strings.SplitN(parsedURL.Host, ".", 2)
-		if len(parts) < 2 {
-			return errors.New("invalid S3 URL format")
-		}
-		bucketName = parts[0]
-		key = strings.TrimPrefix(parsedURL.Path, "/")
-	} else {
-		// It's not a valid URL, treat it as a key
-		bucketName = s.Config.S3BucketName
-		key = dataURL
-	}
-
-	if key == "" {
-		return errors.New("invalid key")
+	bucketName, key, err := s.parseDataURL(dataURL)
+	if err != nil {
+		return err
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -235,6 +223,13 @@ func (s *S3Service) DeleteDataFromS3(dataURL string) error {
 		// Generate the thumbnail key
 		thumbKey := generateThumbnailKey(key)
 		keysToDelete = append(keysToDelete, thumbKey)
+
+		// Photos uploaded before variants, or too small for some of them, simply do not have these
+		for _, width := range util.PhotoVariantWidths {
+			keysToDelete = append(keysToDelete,
+				util.PhotoVariantKey(key, width, util.PhotoVariantWebP),
+				util.PhotoVariantKey(key, width, util.PhotoVariantJPEG))
+		}
 	}
 
 	// Delete the objects
@@ -258,6 +253,65 @@ func (s *S3Service) DeleteDataFromS3(dataURL string) error {
 	return nil
 }
 
+// DeleteUploadedFiles deletes the files UploadFile put in S3 for a request that failed afterwards, with their
+// thumbnails and variants. A failure is only logged, the file is then left unreferenced.
+func (s *S3Service) DeleteUploadedFiles(urls []string) {
+	for _, url := range urls {
+		if err := s.DeleteDataFromS3(url); err != nil {
+			s.logger.Error("failed to delete uploaded file", zap.String("url", url), zap.Error(err))
+		}
+	}
+}
+
+// DownloadFromS3 reads the object at dataURL, a URL returned by UploadFile or a key in the bucket.
+func (s *S3Service) DownloadFromS3(ctx context.Context, dataURL string) ([]byte, error) {
+	bucketName, key, err := s.parseDataURL(dataURL)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &bucketName,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object from S3: %w", err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object from S3: %w", err)
+	}
+	return data, nil
+}
+
+// parseDataURL splits an S3 object URL into its bucket and key, anything else is taken to be a key in our bucket.
+func (s *S3Service) parseDataURL(dataURL string) (string, string, error) {
+	var bucketName, key string
+
+	// Attempt to parse the input as a URL
+	parsedURL, err := url.Parse(dataURL)
+	if err == nil && parsedURL.Scheme != "" && parsedURL.Host != "" {
+		// It's a valid URL
+		parts := strings.SplitN(parsedURL.Host, ".", 2)
+		if len(parts) < 2 {
+			return "", "", errors.New("invalid S3 URL format")
+		}
+		bucketName = parts[0]
+		key = strings.TrimPrefix(parsedURL.Path, "/")
+	} else {
+		// It's not a valid URL, treat it as a key
+		bucketName = s.Config.S3BucketName
+		key = dataURL
+	}
+
+	if key == "" {
+		return "", "", errors.New("invalid key")
+	}
+	return bucketName, key, nil
+}
+
 func (s *S3Service) ListAllObjectsInS3() ([]map[string]interface{}, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -367,23 +421,12 @@ func (s *S3Service) MoveFileInS3(sourceKey string, destinationKey string) error
 }
 
 // GenerateThumbnail generates a thumbnail for an image and uploads it to S3
-func (s *S3Service) GenerateThumbnail(ctx context.Context, fileData multipart.File, folder, uuidStr, ext string) (string, error) {
-	// Reset the fileData to the beginning
-	_, err := fileData.Seek(0, io.SeekStart)
-	if err != nil {
-		return "", fmt.Errorf("failed to seek fileData: %w", err)
-	}
-
-	// Decode the image
-	img, _, err := image.Decode(fileData)
-	if err != nil {
-		return "", fmt.Errorf("failed to decode image: %w", err)
-	}
-
+func (s *S3Service) GenerateThumbnail(ctx context.Context, img image.Image, folder, uuidStr, ext string) (string, error) {
 	// Generate thumbnail
 	thumbImg := imaging.Thumbnail(img, 300, 300, imaging.Lanczos)
 
 	// Encode thumbnail to buffer
+	var err error
 	var buf bytes.Buffer
 	switch ext {
 	case ".jpg", ".jpeg":
@@ -419,6 +462,77 @@ func (s *S3Service) GenerateThumbnail(ctx context.Context, fileData multipart.Fi
 	return thumbURL, nil
 }
 
+// UploadPhotoVariants puts resized copies of the photo at photoURL, a URL returned by UploadFile, next to it
+// and returns their widths. PhotoVariantService calls it once the original is stored.
+func (s *S3Service) UploadPhotoVariants(ctx context.Context, photoURL string) ([]int, error) {
+	_, key, err := s.parseDataURL(photoURL)
+	if err != nil {
+		return nil, err
+	}
+	if !isImage(strings.ToLower(filepath.Ext(key))) {
+		return nil, nil
+	}
+
+	data, err := s.DownloadFromS3(ctx, photoURL)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	return s.uploadVariants(ctx, img, key), nil
+}
+
+// uploadVariants puts resized copies of img, the image at key, next to it in WebP and JPEG and returns their widths.
+// A width that fails is logged and left out, clients fall back to the original.
+func (s *S3Service) uploadVariants(ctx context.Context, img image.Image, key string) []int {
+	widths := make([]int, 0, len(util.PhotoVariantWidths))
+	var buf bytes.Buffer
+
+	for _, width := range util.PhotoVariantWidthsFor(img.Bounds().Dx()) {
+		resized := imaging.Resize(img, width, 0, imaging.Lanczos)
+
+		uploaded := true
+		for _, ext := range []string{util.PhotoVariantWebP, util.PhotoVariantJPEG} {
+			buf.Reset()
+			var err error
+			if ext == util.PhotoVariantWebP {
+				err = webp.Encode(&buf, resized, &webp.Options{Quality: photoVariantQuality})
+			} else {
+				err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: photoVariantQuality})
+			}
+			if err == nil {
+				variantKey := util.PhotoVariantKey(key, width, ext)
+				_, err = s.s3Client.PutObject(ctx, &s3.PutObjectInput{
+					Bucket:      &s.Config.S3BucketName,
+					Key:         &variantKey,
+					Body:        bytes.NewReader(buf.Bytes()),
+					ContentType: aws.String(getContentType(ext)),
+				})
+			}
+			if err != nil {
+				s.logger.Error("failed to upload photo variant", zap.String("key", key), zap.Int("width", width), zap.Error(err))
+				uploaded = false
+				break
+			}
+		}
+		if uploaded {
+			widths = append(widths, width)
+		}
+	}
+	return widths
+}
+
+// IsBucketURL tells whether dataURL is the URL of an object in our bucket.
+func (s *S3Service) IsBucketURL(dataURL string) bool {
+	if !strings.HasPrefix(dataURL, "https://") {
+		return false
+	}
+	bucketName, _, err := s.parseDataURL(dataURL)
+	return err == nil && bucketName == s.Config.S3BucketName
+}
+
 // ObjectExists checks if an object exists in S3
 func (s *S3Service) ObjectExists(ctx context.Context, key string) (bool, error) {
 	_, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
@@ -434,6 +548,24 @@ func (s *S3Service) ObjectExists(ctx context.Context, key string) (bool, error)
 	return true, nil
 }
 
+// stripUploadMetadata returns the image in fileData without its EXIF and other metadata, photos often carry the
+// GPS position of whoever took them and none of it should go public. Other files are returned as they are.
+func stripUploadMetadata(fileData multipart.File, ext string) (io.ReadSeeker, error) {
+	if !isImage(ext) {
+		return fileData, nil
+	}
+
+	data, err := io.ReadAll(fileData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fileData: %w", err)
+	}
+	data, err = util.StripImageMetadata(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to strip image metadata: %w", err)
+	}
+	return bytes.NewReader(data), nil
+}
+
 // Helper function to determine if a file extension corresponds to an image
 func isImage(ext string) bool {
 	// Normalize the extension to lower case
@@ -30,6 +30,9 @@ LEFT JOIN Reports ON ReportPhotos.ReportID = Reports.ReportID
 WHERE Reports.ReportID IS NULL`
 
 	deleteViewedNotificationsQuery = "DELETE FROM Notifications WHERE Viewed = TRUE AND UpdatedAt < NOW() - INTERVAL ? DAY"
+
+	// how long a soft-deleted marker can still be restored
+	deletedMarkerRetentionDays = 30
 )
 
 type SchedulerService struct {
@@ -38,6 +41,7 @@ type SchedulerService struct {
 	S3Service           *S3Service
 	MarkerRankService   *MarkerRankService
 	MarkerManageService *MarkerManageService
+	MarkerChangeService *MarkerChangeService
 	RedisService        *RedisService
 	SmtpService         *SmtpService
 	ReportService       *ReportService
@@ -54,7 +58,7 @@ type SchedulerService struct {
 func NewSchedulerService(
 	db *sqlx.DB, tokenService *TokenService,
 	s3Service *S3Service, rankService *MarkerRankService, chatService *ChatService,
-	markerService *MarkerManageService, redisService *RedisService,
+	markerService *MarkerManageService, changeService *MarkerChangeService, redisService *RedisService,
 	smtpService *SmtpService, reportService *ReportService,
 	bleveService *BleveSearchService,
 
@@ -70,6 +74,7 @@ func NewSchedulerService(
 		S3Service:           s3Service,
 		MarkerRankService:   rankService,
 		MarkerManageService: markerService,
+		MarkerChangeService: changeService,
 		RedisService:        redisService,
 		SmtpService:         smtpService,
 		ReportService:       reportService,
@@ -115,6 +120,8 @@ func (s *SchedulerService) RunAllCrons(logger *zap.Logger) {
 	s.CronDeleteExpiredStories(logger)
 	s.CronDeleteExpiredMessages(logger)
 	s.CronBleveIndexBatch(logger)
+	s.CronPurgeDeletedMarkers(logger)
+	s.CronPruneMarkerChanges(logger)
 
 	// reports, err := s.ReportService.GetPendingReports()
 	// if err != nil {
@@ -318,6 +325,38 @@ func (s *SchedulerService) CronCleanUpOldDirs(logger *zap.Logger) {
 	}
 }
 
+// CronPurgeDeletedMarkers permanently removes markers soft-deleted more than deletedMarkerRetentionDays ago.
+func (s *SchedulerService) CronPurgeDeletedMarkers(logger *zap.Logger) {
+	_, err := s.Schedule("30 18 * * *", func() { // UTC 18:30, 3:30 AM KST
+		purged, err := s.MarkerManageService.PurgeDeletedMarkers(deletedMarkerRetentionDays)
+		if err != nil {
+			logger.Error("Error purging deleted markers", zap.Int("purged", purged), zap.Error(err))
+			return
+		}
+		logger.Info("Deleted markers purge executed successfully", zap.Int("purged", purged))
+	})
+	if err != nil {
+		logger.Error("Error scheduling the deleted markers purge job", zap.Error(err))
+		return
+	}
+}
+
+// CronPruneMarkerChanges drops marker change log rows older than markerChangeRetentionDays.
+func (s *SchedulerService) CronPruneMarkerChanges(logger *zap.Logger) {
+	_, err := s.Schedule("45 18 * * *", func() { // UTC 18:45, 3:45 AM KST
+		pruned, err := s.MarkerChangeService.PruneChanges(markerChangeRetentionDays)
+		if err != nil {
+			logger.Error("Error pruning marker changes", zap.Error(err))
+			return
+		}
+		logger.Info("Marker changes pruned successfully", zap.Int64("pruned", pruned))
+	})
+	if err != nil {
+		logger.Error("Error scheduling the marker changes prune job", zap.Error(err))
+		return
+	}
+}
+
 // CronCheckMarkerIndex periodically checks and removes indexes of bleve.
 func (s *SchedulerService) CronCheckMarkerIndex(logger *zap.Logger) {
 	_, err := s.Schedule("0 17 * * *", func() { // UTC 17pm
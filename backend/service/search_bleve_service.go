@@ -32,6 +32,18 @@ import (
 const (
 	Analyzer     = "koCJKEdgeNgram"
 	nearDistance = "2km"
+
+	getMarkerIndexQuery           = "SELECT MarkerID, Address FROM Markers WHERE MarkerID = ? AND DeletedAt IS NULL"
+	getMarkerIndexFacilitiesQuery = "SELECT FacilityID, Quantity FROM MarkerFacilities WHERE MarkerID = ? AND Quantity > 0 AND DeletedAt IS NULL"
+
+	// Documents without facilities need no backfill, they already match no facility filter
+	getFacilityMarkersIndexQuery = `
+SELECT m.MarkerID, m.Address FROM Markers m
+WHERE m.DeletedAt IS NULL
+	AND EXISTS (SELECT 1 FROM MarkerFacilities f WHERE f.MarkerID = m.MarkerID AND f.Quantity > 0 AND f.DeletedAt IS NULL)`
+
+	// Internal key on the first shard, set once documents carry facilities
+	facilitiesBackfilledKey = "facilities_backfilled"
 )
 
 var (
@@ -136,7 +148,7 @@ func NewBleveSearchService(
 	db *sqlx.DB, stationMap map[string]dto.KoreaStation) *BleveSearchService {
 	searchCache := gocache.New[dto.MarkerSearchResponse](localCacheStorage)
 
-	getMarkerStmt, _ := db.Preparex("SELECT MarkerID, Address FROM Markers")
+	getMarkerStmt, _ := db.Preparex("SELECT MarkerID, Address FROM Markers WHERE DeletedAt IS NULL")
 	levenshtein.CaseSensitive = false
 	levenshtein.InsertCost = 1
 	levenshtein.ReplaceCost = 2
@@ -152,6 +164,11 @@ func NewBleveSearchService(
 func RegisteBleveLifecycle(lifecycle fx.Lifecycle, service *BleveSearchService) {
 	lifecycle.Append(fx.Hook{
 		OnStart: func(context.Context) error {
+			go func() {
+				if err := service.BackfillFacilities(); err != nil {
+					service.Logger.Error("Failed to backfill facilities into the search index", zap.Error(err))
+				}
+			}()
 			return nil
 		},
 		OnStop: func(context.Context) error {
@@ -161,10 +178,13 @@ func RegisteBleveLifecycle(lifecycle fx.Lifecycle, service *BleveSearchService)
 	})
 }
 
-// SearchMarkerAddress calls bleve (Lucene-like) search
-func (s *BleveSearchService) SearchMarkerAddress(t string) (dto.MarkerSearchResponse, error) {
+// SearchMarkerAddress calls bleve (Lucene-like) search, only markers that pass facilities are returned
+func (s *BleveSearchService) SearchMarkerAddress(t string, facilities util.FacilityFilter) (dto.MarkerSearchResponse, error) {
 	// t is already trimmed
 	cacheKey := fmt.Sprintf("search:%s", t)
+	if !facilities.IsEmpty() {
+		cacheKey += ":" + facilities.CacheKey()
+	}
 	cachedResponse, err := s.searchCache.Get(context.Background(), cacheKey)
 	if err == nil {
 		return cachedResponse, nil
@@ -192,13 +212,15 @@ func (s *BleveSearchService) SearchMarkerAddress(t string) (dto.MarkerSearchResp
 
 	terms[0] = standardizeInitials(terms[0])
 
+	facilityQuery := facilityFilterQuery(facilities)
+
 	// Channels to receive search results and the time taken
 	resultsChan := make(chan *bleve_search.DocumentMatch, 100)
 	tookTimesChan := make(chan time.Duration, 1)
 
 	// Launch a single goroutine to perform the search
 	go func() {
-		performWholeQuerySearch(s.Index, t, terms, resultsChan, tookTimesChan, s.stationMap)
+		performWholeQuerySearch(s.Index, t, terms, facilityQuery, resultsChan, tookTimesChan, s.stationMap)
 		close(resultsChan)
 		close(tookTimesChan)
 	}()
@@ -237,7 +259,7 @@ func (s *BleveSearchService) SearchMarkerAddress(t string) (dto.MarkerSearchResp
 
 	if len(allResults) == 0 { // or if len <= 3?
 		// If no results, try fuzzy search with controlled fuzziness
-		fuzzyResults, fuzzyTook := performFuzzySearch(s.Index, terms)
+		fuzzyResults, fuzzyTook := performFuzzySearch(s.Index, terms, facilityQuery)
 		allResults = fuzzyResults
 		totalTook += fuzzyTook
 	}
@@ -287,6 +309,15 @@ func (s *BleveSearchService) AutoComplete(term string) ([]string, error) {
 }
 
 func (s *BleveSearchService) InsertMarkerIndex(indexBody MarkerIndexData) error {
+	// Callers only know the address, facilities are looked up here so every document carries them
+	if indexBody.Facilities == nil {
+		facilities, err := s.getMarkerIndexFacilities(indexBody.MarkerID)
+		if err != nil {
+			return err
+		}
+		indexBody.Facilities = facilities
+	}
+
 	// Compute which shard to use based on the marker ID (or any other key)
 	shardIndex := indexBody.MarkerID % len(s.Shards)
 	selectedShard := s.Shards[shardIndex]
@@ -336,6 +367,62 @@ func (s *BleveSearchService) InsertMarkerIndex(indexBody MarkerIndexData) error
 	return nil
 }
 
+// ReindexMarker indexes the marker again with its current address and facilities.
+func (s *BleveSearchService) ReindexMarker(markerID int) error {
+	var marker dto.MarkerOnlyWithAddr
+	if err := s.DB.Get(&marker, getMarkerIndexQuery, markerID); err != nil {
+		return fmt.Errorf("fetching marker %d to index: %w", markerID, err)
+	}
+	return s.InsertMarkerIndex(dto.MarkerIndexData{MarkerID: marker.MarkerID, Address: marker.Address})
+}
+
+// BackfillFacilities reindexes every marker with facilities once. Documents indexed before
+// facilities were part of them would otherwise never match a facility filter.
+func (s *BleveSearchService) BackfillFacilities() error {
+	done, err := s.Shards[0].GetInternal([]byte(facilitiesBackfilledKey))
+	if err != nil {
+		return fmt.Errorf("reading backfill state: %w", err)
+	}
+	if done != nil {
+		return nil
+	}
+
+	var markers []dto.MarkerOnlyWithAddr
+	if err := s.DB.Select(&markers, getFacilityMarkersIndexQuery); err != nil {
+		return fmt.Errorf("fetching markers with facilities: %w", err)
+	}
+	for _, marker := range markers {
+		if err := s.InsertMarkerIndex(dto.MarkerIndexData{MarkerID: marker.MarkerID, Address: marker.Address}); err != nil {
+			return fmt.Errorf("reindexing marker %d: %w", marker.MarkerID, err)
+		}
+	}
+	if err := s.FlushAllBatches(); err != nil {
+		return fmt.Errorf("flushing backfilled documents: %w", err)
+	}
+
+	if err := s.Shards[0].SetInternal([]byte(facilitiesBackfilledKey), []byte("1")); err != nil {
+		return fmt.Errorf("saving backfill state: %w", err)
+	}
+	s.Logger.Info("Facilities backfilled into the search index", zap.Int("markers", len(markers)))
+	return nil
+}
+
+func (s *BleveSearchService) getMarkerIndexFacilities(markerID int) (map[string]int, error) {
+	var rows []struct {
+		FacilityID int `db:"FacilityID"`
+		Quantity   int `db:"Quantity"`
+	}
+	if err := s.DB.Select(&rows, getMarkerIndexFacilitiesQuery, markerID); err != nil {
+		return nil, fmt.Errorf("fetching facilities of marker %d to index: %w", markerID, err)
+	}
+
+	facilities := make(map[string]int, len(rows))
+	for _, row := range rows {
+		facilities[strconv.Itoa(row.FacilityID)] = row.Quantity
+	}
+	return facilities, nil
+}
+
 func (s *BleveSearchService) DeleteMarkerIndex(markerId int) error {
 	// Compute which shard to use based on the marker ID (same as in InsertMarkerIndex)
 	shardIndex := markerId % len(s.Shards)
@@ -681,7 +768,7 @@ func (s *BleveSearchService) IndexAndRefresh(id int, data MarkerIndexData) error
 // 	}
 // }
 
-func performWholeQuerySearch(index bleve.Index, t string, terms []string, results chan<- *bleve_search.DocumentMatch, tookTimes chan<- time.Duration, stationMap map[string]dto.KoreaStation) {
+func performWholeQuerySearch(index bleve.Index, t string, terms []string, facilityQuery query.Query, results chan<- *bleve_search.DocumentMatch, tookTimes chan<- time.Duration, stationMap map[string]dto.KoreaStation) {
 	// Pre-process terms to assign them to fields
 	termAssignments := assignTermsToFields(terms)
 
@@ -807,6 +894,9 @@ func performWholeQuerySearch(index bleve.Index, t string, terms []string, result
 	// Combine all per-term disjunctions into a ConjunctionQuery (logical AND)
 	overallConjunction := bleve.NewConjunctionQuery(perTermDisjunctions...)
 	boolQuery.AddMust(overallConjunction)
+	if facilityQuery != nil {
+		boolQuery.AddMust(facilityQuery)
+	}
 
 	// If both province and city are present, boost documents where both terms match
 	if hasProvince && hasCity {
@@ -995,14 +1085,18 @@ func performSearchFacet(index bleve.Index, term string, results chan<- *bleve_se
 	}
 }
 
-func performFuzzySearch(index bleve.Index, terms []string) ([]*bleve_search.DocumentMatch, time.Duration) {
+func performFuzzySearch(index bleve.Index, terms []string, facilityQuery query.Query) ([]*bleve_search.DocumentMatch, time.Duration) {
 	var allResults []*bleve_search.DocumentMatch
 	var totalTook time.Duration
 
 	for _, term := range terms {
 		fuzzyQuery := bleve.NewFuzzyQuery(term)
 		fuzzyQuery.Fuzziness = 1
-		searchRequest := bleve.NewSearchRequest(fuzzyQuery)
+		var termQuery query.Query = fuzzyQuery
+		if facilityQuery != nil {
+			termQuery = bleve.NewConjunctionQuery(fuzzyQuery, facilityQuery)
+		}
+		searchRequest := bleve.NewSearchRequest(termQuery)
 		searchRequest.Fields = []string{"fullAddress", "address", "province", "city", "initialConsonants"}
 		searchRequest.Size = 10
 		searchRequest.Highlight = bleve.NewHighlightWithStyle("html")
@@ -1019,6 +1113,27 @@ func performFuzzySearch(index bleve.Index, terms []string) ([]*bleve_search.Docu
 	return allResults, totalTook
 }
 
+// facilityFilterQuery matches documents whose indexed facilities pass filter, nil for an empty filter.
+func facilityFilterQuery(filter util.FacilityFilter) query.Query {
+	if filter.IsEmpty() {
+		return nil
+	}
+
+	inclusive := true
+	requirements := make([]query.Query, len(filter.Requirements))
+	for i, r := range filter.Requirements {
+		minQuantity := float64(r.MinQuantity)
+		q := bleve.NewNumericRangeInclusiveQuery(&minQuantity, nil, &inclusive, nil)
+		q.SetField("facilities." + strconv.Itoa(r.FacilityID))
+		requirements[i] = q
+	}
+
+	if filter.MatchAny {
+		return bleve.NewDisjunctionQuery(requirements...)
+	}
+	return bleve.NewConjunctionQuery(requirements...)
+}
+
 func extractMarkers(allResults []*bleve_search.DocumentMatch) []dto.ZincMarker {
 	markers := make([]dto.ZincMarker, 0, len(allResults))
 	for _, hit := range allResults {
@@ -66,7 +66,7 @@ ORDER BY
 SELECT Markers.MarkerID, ST_X(Markers.Location) AS Latitude, ST_Y(Markers.Location) AS Longitude, Markers.Description, Markers.Address
 FROM Favorites
 JOIN Markers ON Favorites.MarkerID = Markers.MarkerID
-WHERE Favorites.UserID = ?
+WHERE Favorites.UserID = ? AND Favorites.DeletedAt IS NULL
 ORDER BY Markers.CreatedAt DESC` // Order by CreatedAt in descending order
 
 	getPhotoByUserIdQuery = "SELECT PhotoURL FROM Photos WHERE MarkerID IN (SELECT MarkerID FROM Markers WHERE UserID = ?)"
@@ -82,9 +82,7 @@ ORDER BY Markers.CreatedAt DESC` // Order by CreatedAt in descending order
 	// Count query to get how many reports a user makes for any marker by UserID
 	countQueryHowManyReportsAUserMakesQuery = "SELECT COUNT(*) As ReportCount FROM Reports WHERE UserID = ?"
 	// Count query to get how many markers a user makes by UserID
-	countQueryHowManyMarkersAUserMakesQuery = "SELECT COUNT(*) AS MarkerCount FROM Markers WHERE UserID = ?"
-
-	sumContributionScoresForAUserQuery = "SELECT SUM(Points) AS TotalPoints FROM UserContributions WHERE UserID = ?"
+	countQueryHowManyMarkersAUserMakesQuery = "SELECT COUNT(*) AS MarkerCount FROM Markers WHERE UserID = ? AND DeletedAt IS NULL"
 
 	getUsernameByIdQuery = "SELECT Username FROM Users WHERE UserID = ?"
 )
@@ -495,8 +493,8 @@ func (s *UserService) GetUserStatistics(userID int) (int, int, error) {
 func (s *UserService) GetUserContributionScores(userID int) (int, string, error) {
 	var contributions int
 
-	// Execute the query for total contribution scores
-	err := s.DB.Get(&contributions, sumContributionScoresForAUserQuery, userID)
+	// The total is kept next to the points ledger, see ContributionService
+	err := s.DB.Get(&contributions, getContributionTotalQuery, userID)
 	if err != nil {
 		return 0, "", fmt.Errorf("error fetching contribution scores for userID %d: %w", userID, err)
 	}
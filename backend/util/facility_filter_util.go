@@ -0,0 +1,98 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FacilityMatchAll = "all"
+	FacilityMatchAny = "any"
+)
+
+var ErrInvalidFacilityMatch = errors.New("facilityMatch must be any or all")
+
+// FacilityRequirement asks for at least MinQuantity of one facility.
+type FacilityRequirement struct {
+	FacilityID  int
+	MinQuantity int
+}
+
+// FacilityFilter narrows marker listings down by their facilities, the zero value matches every marker.
+type FacilityFilter struct {
+	Requirements []FacilityRequirement // sorted by FacilityID
+	MatchAny     bool                  // one met requirement is enough instead of all of them
+}
+
+// ParseFacilityRequirements reads facility IDs or names in names with an optional minimum quantity, "1,pyeong:2" or "rings:3".
+// match is "all" (the default) or "any".
+func ParseFacilityRequirements(s, match string, names FacilityNames) (FacilityFilter, error) {
+	var filter FacilityFilter
+
+	switch strings.ToLower(strings.TrimSpace(match)) {
+	case "", FacilityMatchAll:
+	case FacilityMatchAny:
+		filter.MatchAny = true
+	default:
+		return FacilityFilter{}, ErrInvalidFacilityMatch
+	}
+
+	minQuantities := make(map[int]int)
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		key, value, hasQuantity := strings.Cut(part, ":")
+		facilityID, err := names.Lookup(key)
+		if err != nil {
+			return FacilityFilter{}, err
+		}
+		minQuantity := 1
+		if hasQuantity {
+			minQuantity, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || minQuantity < 1 {
+				return FacilityFilter{}, fmt.Errorf("invalid minimum quantity in %q", strings.TrimSpace(part))
+			}
+		}
+
+		// Asking for the same facility twice means the stricter of the two
+		minQuantities[facilityID] = max(minQuantities[facilityID], minQuantity)
+	}
+
+	for facilityID, minQuantity := range minQuantities {
+		filter.Requirements = append(filter.Requirements, FacilityRequirement{FacilityID: facilityID, MinQuantity: minQuantity})
+	}
+	sort.Slice(filter.Requirements, func(i, j int) bool {
+		return filter.Requirements[i].FacilityID < filter.Requirements[j].FacilityID
+	})
+	return filter, nil
+}
+
+func (f FacilityFilter) IsEmpty() bool {
+	return len(f.Requirements) == 0
+}
+
+// CacheKey identifies the filter in cache keys, it is empty for the zero value so unfiltered keys stay as they were.
+func (f FacilityFilter) CacheKey() string {
+	if f.IsEmpty() {
+		return ""
+	}
+
+	var sb strings.Builder
+	for i, r := range f.Requirements {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.Itoa(r.FacilityID))
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(r.MinQuantity))
+	}
+	if f.MatchAny && len(f.Requirements) > 1 {
+		sb.WriteString(";" + FacilityMatchAny)
+	}
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFacilityRequirements(t *testing.T) {
+	filter, err := ParseFacilityRequirements("pyeong:2, chulbong,1:3,", "", BuiltinFacilityNames)
+	require.NoError(t, err)
+	assert.False(t, filter.MatchAny)
+	assert.Equal(t, []FacilityRequirement{{FacilityID: 1, MinQuantity: 3}, {FacilityID: 2, MinQuantity: 2}}, filter.Requirements)
+	assert.Equal(t, "1:3,2:2", filter.CacheKey())
+
+	filter, err = ParseFacilityRequirements("2,1", "ANY", BuiltinFacilityNames)
+	require.NoError(t, err)
+	assert.True(t, filter.MatchAny)
+	assert.Equal(t, "1:1,2:1;any", filter.CacheKey())
+
+	// any and all are the same thing for a single facility
+	filter, err = ParseFacilityRequirements("1", "any", BuiltinFacilityNames)
+	require.NoError(t, err)
+	assert.Equal(t, "1:1", filter.CacheKey())
+
+	filter, err = ParseFacilityRequirements("", "", BuiltinFacilityNames)
+	require.NoError(t, err)
+	assert.True(t, filter.IsEmpty())
+	assert.Empty(t, filter.CacheKey())
+
+	for _, s := range []string{"trampoline", "1:0", "1:x", "0"} {
+		_, err = ParseFacilityRequirements(s, "", BuiltinFacilityNames)
+		assert.Error(t, err, s)
+	}
+	_, err = ParseFacilityRequirements("1", "some", BuiltinFacilityNames)
+	assert.ErrorIs(t, err, ErrInvalidFacilityMatch)
+}
+
+func TestParseFacilityRequirementsCatalogNames(t *testing.T) {
+	names := FacilityNames{"chulbong": 1, "rings": 3, "monkey-bars": 4, "구름사다리": 4}
+
+	filter, err := ParseFacilityRequirements("Rings:2,monkey-bars", "", names)
+	require.NoError(t, err)
+	assert.Equal(t, []FacilityRequirement{{FacilityID: 3, MinQuantity: 2}, {FacilityID: 4, MinQuantity: 1}}, filter.Requirements)
+
+	filter, err = ParseFacilityRequirements("구름사다리:2", "", names)
+	require.NoError(t, err)
+	assert.Equal(t, "4:2", filter.CacheKey())
+
+	_, err = ParseFacilityRequirements("rings", "", BuiltinFacilityNames)
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Windows a leaderboard is kept for
+const (
+	LeaderboardWeekly  = "weekly"
+	LeaderboardMonthly = "monthly"
+	LeaderboardAllTime = "all"
+)
+
+var (
+	LeaderboardPeriods = []string{LeaderboardWeekly, LeaderboardMonthly, LeaderboardAllTime}
+
+	ErrInvalidLeaderboardPeriod = errors.New("period must be weekly, monthly or all")
+)
+
+// ParseLeaderboardPeriod reads the period query parameter, weekly when it is empty.
+func ParseLeaderboardPeriod(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return LeaderboardWeekly, nil
+	}
+	for _, period := range LeaderboardPeriods {
+		if s == period {
+			return s, nil
+		}
+	}
+	return "", ErrInvalidLeaderboardPeriod
+}
+
+// LeaderboardPeriodKey names the window of period that t falls in, by the calendar in loc:
+// the ISO week "2024-W07", the month "2024-02", or "all".
+func LeaderboardPeriodKey(period string, t time.Time, loc *time.Location) string {
+	t = t.In(loc)
+	switch period {
+	case LeaderboardWeekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case LeaderboardMonthly:
+		return t.Format("2006-01")
+	default:
+		return LeaderboardAllTime
+	}
+}
+
+// StartOfDay returns midnight of the calendar day t falls on in loc.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// IsPlausibleTravel reports whether distanceMeters can be covered in elapsed without going faster than maxKmh.
+func IsPlausibleTravel(distanceMeters float64, elapsed time.Duration, maxKmh float64) bool {
+	if elapsed <= 0 {
+		return distanceMeters == 0
+	}
+	return distanceMeters/elapsed.Hours()/1000 <= maxKmh
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLeaderboardPeriod(t *testing.T) {
+	period, err := ParseLeaderboardPeriod("")
+	assert.NoError(t, err)
+	assert.Equal(t, LeaderboardWeekly, period)
+
+	period, err = ParseLeaderboardPeriod(" Monthly ")
+	assert.NoError(t, err)
+	assert.Equal(t, LeaderboardMonthly, period)
+
+	_, err = ParseLeaderboardPeriod("daily")
+	assert.ErrorIs(t, err, ErrInvalidLeaderboardPeriod)
+}
+
+func TestLeaderboardPeriodKey(t *testing.T) {
+	// Sunday 2024-03-31 20:00 UTC is already Monday 2024-04-01 in KST
+	at := time.Date(2024, time.March, 31, 20, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "2024-W14", LeaderboardPeriodKey(LeaderboardWeekly, at, KST))
+	assert.Equal(t, "2024-W13", LeaderboardPeriodKey(LeaderboardWeekly, at, time.UTC))
+	assert.Equal(t, "2024-04", LeaderboardPeriodKey(LeaderboardMonthly, at, KST))
+	assert.Equal(t, "2024-03", LeaderboardPeriodKey(LeaderboardMonthly, at, time.UTC))
+	assert.Equal(t, LeaderboardAllTime, LeaderboardPeriodKey(LeaderboardAllTime, at, KST))
+
+	// The ISO year of the first days of January can be the year before
+	assert.Equal(t, "2020-W53", LeaderboardPeriodKey(LeaderboardWeekly, time.Date(2021, time.January, 2, 12, 0, 0, 0, KST), KST))
+}
+
+func TestStartOfDay(t *testing.T) {
+	at := time.Date(2024, time.March, 31, 20, 0, 0, 0, time.UTC)
+	assert.True(t, time.Date(2024, time.April, 1, 0, 0, 0, 0, KST).Equal(StartOfDay(at, KST)))
+}
+
+func TestIsPlausibleTravel(t *testing.T) {
+	assert.True(t, IsPlausibleTravel(50_000, time.Hour, 150))
+	assert.False(t, IsPlausibleTravel(50_000, 10*time.Minute, 150)) // 300 km/h
+	assert.True(t, IsPlausibleTravel(0, 0, 150))
+	assert.False(t, IsPlausibleTravel(10, 0, 150))
+}
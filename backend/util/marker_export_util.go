@@ -0,0 +1,432 @@
+package util
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	ExportFormatGeoJSON = "geojson"
+	ExportFormatKML     = "kml"
+	ExportFormatGPX     = "gpx"
+)
+
+const (
+	gpxExportHeader = xml.Header + `<gpx version="1.1" creator="k-pullup" xmlns="http://www.topografix.com/GPX/1/1">`
+	gpxExportFooter = "</gpx>\n"
+)
+
+var (
+	ErrUnknownExportFormat = errors.New("unknown export format, use geojson, kml or gpx")
+	ErrInvalidBBox         = errors.New("bbox must be minLng,minLat,maxLng,maxLat")
+)
+
+// facilityExportNames are the property names exported facility counts get, they import back through BuiltinFacilityNames.
+var facilityExportNames = map[int]string{
+	1: "chulbong",
+	2: "pyeong",
+}
+
+// facilityLabels are shown to people reading the exported file in a map app.
+var facilityLabels = map[int]string{
+	1: "철봉",
+	2: "평행봉",
+}
+
+// ExportedMarker is one marker written to an export file.
+type ExportedMarker struct {
+	Facilities    map[int]int // FacilityID -> Quantity
+	Latitude      float64
+	Longitude     float64
+	MarkerID      int
+	Description   string
+	Address       string
+	Status        string
+	CoverPhotoURL string
+}
+
+// BBox is a bounding box in WGS84 degrees.
+type BBox struct {
+	MinLat, MinLng float64
+	MaxLat, MaxLng float64
+}
+
+// ParseBBox reads "minLng,minLat,maxLng,maxLat", the order GeoJSON and most GIS tools use.
+func ParseBBox(s string) (BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BBox{}, ErrInvalidBBox
+	}
+
+	var values [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BBox{}, ErrInvalidBBox
+		}
+		values[i] = v
+	}
+
+	box := BBox{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+	if box.MinLat > box.MaxLat || box.MinLng > box.MaxLng ||
+		box.MinLat < -90 || box.MaxLat > 90 || box.MinLng < -180 || box.MaxLng > 180 {
+		return BBox{}, ErrInvalidBBox
+	}
+	return box, nil
+}
+
+// ParseFacilityFilter reads a comma separated list of facility IDs or names in names ("1,2" or "chulbong,평행봉").
+func ParseFacilityFilter(s string, names FacilityNames) ([]int, error) {
+	seen := make(map[int]struct{})
+	var ids []int
+	for _, key := range strings.Split(s, ",") {
+		if strings.TrimSpace(key) == "" {
+			continue
+		}
+		facilityID, err := names.Lookup(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[facilityID]; ok {
+			continue
+		}
+		seen[facilityID] = struct{}{}
+		ids = append(ids, facilityID)
+	}
+	return ids, nil
+}
+
+// ParseFacilityQuantities reads the "1:2;2:1" form the export query aggregates facilities into.
+func ParseFacilityQuantities(s string) (map[int]int, error) {
+	facilities := make(map[int]int)
+	if err := parseFacilityList(facilities, s, nil); err != nil {
+		return nil, err
+	}
+	return facilities, nil
+}
+
+// ExportContentType returns the Content-Type of an export format.
+func ExportContentType(format string) string {
+	switch format {
+	case ExportFormatGeoJSON:
+		return "application/geo+json"
+	case ExportFormatKML:
+		return "application/vnd.google-earth.kml+xml"
+	case ExportFormatGPX:
+		return "application/gpx+xml"
+	}
+	return "application/octet-stream"
+}
+
+// NormalizeExportFormat lower-cases format and reports whether it can be exported.
+func NormalizeExportFormat(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", ExportFormatGeoJSON, "json":
+		return ExportFormatGeoJSON, nil
+	case ExportFormatKML:
+		return ExportFormatKML, nil
+	case ExportFormatGPX:
+		return ExportFormatGPX, nil
+	}
+	return "", ErrUnknownExportFormat
+}
+
+// MarkerExportEncoder writes markers one by one so large exports never sit in memory.
+// Close must be called to finish the document.
+type MarkerExportEncoder interface {
+	Encode(m ExportedMarker) error
+	Close() error
+}
+
+// NewMarkerExportEncoder writes the document header for format to w and returns an encoder for the markers.
+func NewMarkerExportEncoder(format string, w io.Writer) (MarkerExportEncoder, error) {
+	var enc MarkerExportEncoder
+	var header string
+	switch format {
+	case ExportFormatGeoJSON:
+		enc, header = &geoJSONExportEncoder{w: w}, `{"type":"FeatureCollection","features":[`
+	case ExportFormatKML:
+		enc, header = &xmlExportEncoder{w: w, enc: xml.NewEncoder(w), footer: "</Document></kml>\n", kml: true},
+			xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>k-pullup</name>`
+	case ExportFormatGPX:
+		enc, header = &xmlExportEncoder{w: w, enc: xml.NewEncoder(w), footer: gpxExportFooter}, gpxExportHeader
+	default:
+		return nil, ErrUnknownExportFormat
+	}
+
+	if _, err := io.WriteString(w, header); err != nil {
+		return nil, err
+	}
+	return enc, nil
+}
+
+// GeoJSON
+
+type geoJSONExportFeature struct {
+	Type     string `json:"type"`
+	Geometry struct {
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type geoJSONExportEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *geoJSONExportEncoder) Encode(m ExportedMarker) error {
+	feature := geoJSONExportFeature{Type: "Feature"}
+	feature.Geometry.Type = "Point"
+	// GeoJSON is [longitude, latitude]
+	feature.Geometry.Coordinates = [2]float64{m.Longitude, m.Latitude}
+
+	facilities := sortedFacilities(m.Facilities)
+	list := make([]map[string]int, 0, len(facilities))
+	feature.Properties = map[string]any{
+		"markerId":    m.MarkerID,
+		"description": m.Description,
+		"address":     m.Address,
+	}
+	for _, facilityID := range facilities {
+		list = append(list, map[string]int{"facilityId": facilityID, "quantity": m.Facilities[facilityID]})
+		if name, ok := facilityExportNames[facilityID]; ok {
+			feature.Properties[name] = m.Facilities[facilityID]
+		}
+	}
+	feature.Properties["facilities"] = list
+	if m.Status != "" {
+		feature.Properties["status"] = m.Status
+	}
+	if m.CoverPhotoURL != "" {
+		feature.Properties["photoUrl"] = m.CoverPhotoURL
+	}
+
+	data, err := json.Marshal(feature)
+	if err != nil {
+		return fmt.Errorf("encoding marker %d: %w", m.MarkerID, err)
+	}
+	if e.started {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.started = true
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *geoJSONExportEncoder) Close() error {
+	_, err := io.WriteString(e.w, "]}\n")
+	return err
+}
+
+// KML and GPX
+
+type kmlExportPlacemark struct {
+	XMLName     xml.Name        `xml:"Placemark"`
+	ID          string          `xml:"id,attr"`
+	Name        string          `xml:"name"`
+	Description string          `xml:"description,omitempty"`
+	Coordinates string          `xml:"Point>coordinates"`
+	Data        []kmlExportData `xml:"ExtendedData>Data"`
+}
+
+type kmlExportData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type gpxExportWaypoint struct {
+	XMLName     xml.Name       `xml:"wpt"`
+	Latitude    float64        `xml:"lat,attr"`
+	Longitude   float64        `xml:"lon,attr"`
+	Name        string         `xml:"name"`
+	Comment     string         `xml:"cmt,omitempty"`
+	Description string         `xml:"desc,omitempty"`
+	Link        *gpxExportLink `xml:"link,omitempty"`
+	Type        string         `xml:"type"`
+}
+
+// gpxExportRoutePoint is a gpxExportWaypoint on a route.
+type gpxExportRoutePoint struct {
+	XMLName     xml.Name       `xml:"rtept"`
+	Latitude    float64        `xml:"lat,attr"`
+	Longitude   float64        `xml:"lon,attr"`
+	Name        string         `xml:"name"`
+	Comment     string         `xml:"cmt,omitempty"`
+	Description string         `xml:"desc,omitempty"`
+	Link        *gpxExportLink `xml:"link,omitempty"`
+	Type        string         `xml:"type"`
+}
+
+type gpxExportRoute struct {
+	XMLName     xml.Name              `xml:"rte"`
+	Name        string                `xml:"name"`
+	Description string                `xml:"desc,omitempty"`
+	Points      []gpxExportRoutePoint `xml:"rtept"`
+}
+
+type gpxExportLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:"text"`
+}
+
+type xmlExportEncoder struct {
+	w      io.Writer
+	enc    *xml.Encoder
+	footer string
+	kml    bool
+}
+
+func (e *xmlExportEncoder) Encode(m ExportedMarker) error {
+	var v any
+	if e.kml {
+		v = kmlPlacemarkFor(m)
+	} else {
+		v = gpxWaypointFor(m)
+	}
+	if err := e.enc.Encode(v); err != nil {
+		return fmt.Errorf("encoding marker %d: %w", m.MarkerID, err)
+	}
+	return nil
+}
+
+func (e *xmlExportEncoder) Close() error {
+	_, err := io.WriteString(e.w, e.footer)
+	return err
+}
+
+func kmlPlacemarkFor(m ExportedMarker) kmlExportPlacemark {
+	pm := kmlExportPlacemark{
+		ID:          "marker-" + strconv.Itoa(m.MarkerID),
+		Name:        exportName(m),
+		Description: m.Description,
+		// KML is "longitude,latitude"
+		Coordinates: strconv.FormatFloat(m.Longitude, 'f', -1, 64) + "," + strconv.FormatFloat(m.Latitude, 'f', -1, 64),
+		Data: []kmlExportData{
+			{Name: "markerId", Value: strconv.Itoa(m.MarkerID)},
+			{Name: "address", Value: m.Address},
+			{Name: "facilities", Value: facilityList(m.Facilities)},
+		},
+	}
+	for _, facilityID := range sortedFacilities(m.Facilities) {
+		if name, ok := facilityExportNames[facilityID]; ok {
+			pm.Data = append(pm.Data, kmlExportData{Name: name, Value: strconv.Itoa(m.Facilities[facilityID])})
+		}
+	}
+	if m.Status != "" {
+		pm.Data = append(pm.Data, kmlExportData{Name: "status", Value: m.Status})
+	}
+	if m.CoverPhotoURL != "" {
+		pm.Data = append(pm.Data, kmlExportData{Name: "photoUrl", Value: m.CoverPhotoURL})
+	}
+	return pm
+}
+
+func gpxWaypointFor(m ExportedMarker) gpxExportWaypoint {
+	wpt := gpxExportWaypoint{
+		Latitude:  m.Latitude,
+		Longitude: m.Longitude,
+		Name:      exportName(m),
+		Comment:   m.Address,
+		Type:      "철봉",
+	}
+
+	// GPS units only show desc, so the facility counts go in there too
+	lines := make([]string, 0, 2)
+	if m.Description != "" {
+		lines = append(lines, m.Description)
+	}
+	if summary := facilitySummary(m.Facilities); summary != "" {
+		lines = append(lines, summary)
+	}
+	wpt.Description = strings.Join(lines, "\n")
+
+	if m.CoverPhotoURL != "" {
+		wpt.Link = &gpxExportLink{Href: m.CoverPhotoURL, Text: "사진"}
+	}
+	return wpt
+}
+
+// WriteRouteGPX writes a planned route as a GPX document with a single route GPS units can navigate:
+// the start followed by the markers in visiting order.
+func WriteRouteGPX(w io.Writer, name, description string, start RoutePoint, stops []ExportedMarker) error {
+	rte := gpxExportRoute{
+		Name:        name,
+		Description: description,
+		Points:      make([]gpxExportRoutePoint, 0, len(stops)+1),
+	}
+	rte.Points = append(rte.Points, gpxExportRoutePoint{
+		Latitude:  start.Latitude,
+		Longitude: start.Longitude,
+		Name:      "출발",
+		Type:      "출발",
+	})
+	for _, m := range stops {
+		rte.Points = append(rte.Points, gpxExportRoutePoint(gpxWaypointFor(m)))
+	}
+
+	if _, err := io.WriteString(w, gpxExportHeader); err != nil {
+		return err
+	}
+	if err := xml.NewEncoder(w).Encode(rte); err != nil {
+		return fmt.Errorf("encoding route: %w", err)
+	}
+	_, err := io.WriteString(w, gpxExportFooter)
+	return err
+}
+
+// exportName is the label map apps show next to the pin.
+func exportName(m ExportedMarker) string {
+	if m.Description != "" {
+		return m.Description
+	}
+	if m.Address != "" {
+		return m.Address
+	}
+	return "철봉 #" + strconv.Itoa(m.MarkerID)
+}
+
+func sortedFacilities(facilities map[int]int) []int {
+	ids := make([]int, 0, len(facilities))
+	for facilityID, quantity := range facilities {
+		if quantity > 0 {
+			ids = append(ids, facilityID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// facilityList writes facilities in the "1:2;2:1" form parseFacilityList reads.
+func facilityList(facilities map[int]int) string {
+	ids := sortedFacilities(facilities)
+	pairs := make([]string, 0, len(ids))
+	for _, facilityID := range ids {
+		pairs = append(pairs, strconv.Itoa(facilityID)+":"+strconv.Itoa(facilities[facilityID]))
+	}
+	return strings.Join(pairs, ";")
+}
+
+// facilitySummary reads like "철봉 2, 평행봉 1".
+func facilitySummary(facilities map[int]int) string {
+	ids := sortedFacilities(facilities)
+	parts := make([]string, 0, len(ids))
+	for _, facilityID := range ids {
+		label, ok := facilityLabels[facilityID]
+		if !ok {
+			label = "시설 " + strconv.Itoa(facilityID)
+		}
+		parts = append(parts, label+" "+strconv.Itoa(facilities[facilityID]))
+	}
+	return strings.Join(parts, ", ")
+}
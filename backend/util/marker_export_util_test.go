@@ -0,0 +1,151 @@
+package util
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportTestMarkers = []ExportedMarker{
+	{
+		MarkerID:      1,
+		Latitude:      37.5665,
+		Longitude:     126.978,
+		Description:   "시청 앞 <공원> & 철봉",
+		Address:       "서울특별시 중구 세종대로 110",
+		Status:        "ACTIVE",
+		CoverPhotoURL: "https://cdn.example.com/1.jpg",
+		Facilities:    map[int]int{1: 2, 2: 1},
+	},
+	{
+		MarkerID:  2,
+		Latitude:  35.1796,
+		Longitude: 129.0756,
+		Address:   "부산광역시 연제구",
+	},
+}
+
+func exportMarkers(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	enc, err := NewMarkerExportEncoder(format, &buf)
+	require.NoError(t, err)
+	for _, m := range exportTestMarkers {
+		require.NoError(t, enc.Encode(m))
+	}
+	require.NoError(t, enc.Close())
+	return buf.Bytes()
+}
+
+func TestMarkerExportRoundTrip(t *testing.T) {
+	for _, format := range []string{ExportFormatGeoJSON, ExportFormatKML} {
+		data := exportMarkers(t, format)
+
+		markers, err := ParseMarkerImport(format, bytes.NewReader(data), BuiltinFacilityNames)
+		require.NoError(t, err, format)
+		require.Len(t, markers, 2, format)
+
+		assert.NoError(t, markers[0].Err, format)
+		assert.InDelta(t, 37.5665, markers[0].Latitude, 1e-9, format)
+		assert.InDelta(t, 126.978, markers[0].Longitude, 1e-9, format)
+		assert.Equal(t, "시청 앞 <공원> & 철봉", markers[0].Description, format)
+		assert.Equal(t, map[int]int{1: 2, 2: 1}, markers[0].Facilities, format)
+
+		assert.NoError(t, markers[1].Err, format)
+		assert.InDelta(t, 35.1796, markers[1].Latitude, 1e-9, format)
+		assert.Empty(t, markers[1].Facilities, format)
+	}
+}
+
+func TestMarkerExportGPX(t *testing.T) {
+	data := exportMarkers(t, ExportFormatGPX)
+
+	var doc struct {
+		Waypoints []struct {
+			Lat  float64 `xml:"lat,attr"`
+			Lon  float64 `xml:"lon,attr"`
+			Name string  `xml:"name"`
+			Cmt  string  `xml:"cmt"`
+			Desc string  `xml:"desc"`
+			Link struct {
+				Href string `xml:"href,attr"`
+			} `xml:"link"`
+		} `xml:"wpt"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	require.Len(t, doc.Waypoints, 2)
+
+	wpt := doc.Waypoints[0]
+	assert.Equal(t, 37.5665, wpt.Lat)
+	assert.Equal(t, 126.978, wpt.Lon)
+	assert.Equal(t, "서울특별시 중구 세종대로 110", wpt.Cmt)
+	assert.Equal(t, "시청 앞 <공원> & 철봉\n철봉 2, 평행봉 1", wpt.Desc)
+	assert.Equal(t, "https://cdn.example.com/1.jpg", wpt.Link.Href)
+
+	// Without a description the address names the waypoint
+	assert.Equal(t, "부산광역시 연제구", doc.Waypoints[1].Name)
+}
+
+func TestWriteRouteGPX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteRouteGPX(&buf, "운동 루트", "2곳, 총 1200m", RoutePoint{Latitude: 37.56, Longitude: 126.97}, exportTestMarkers))
+
+	var doc struct {
+		Routes []struct {
+			Name   string `xml:"name"`
+			Desc   string `xml:"desc"`
+			Points []struct {
+				Lat  float64 `xml:"lat,attr"`
+				Lon  float64 `xml:"lon,attr"`
+				Name string  `xml:"name"`
+			} `xml:"rtept"`
+		} `xml:"rte"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Len(t, doc.Routes, 1)
+
+	rte := doc.Routes[0]
+	assert.Equal(t, "운동 루트", rte.Name)
+	assert.Equal(t, "2곳, 총 1200m", rte.Desc)
+	require.Len(t, rte.Points, 3)
+	assert.Equal(t, "출발", rte.Points[0].Name)
+	assert.Equal(t, 37.56, rte.Points[0].Lat)
+	assert.Equal(t, 126.978, rte.Points[1].Lon)
+	assert.Equal(t, "부산광역시 연제구", rte.Points[2].Name)
+}
+
+func TestMarkerExportEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	enc, err := NewMarkerExportEncoder(ExportFormatGeoJSON, &buf)
+	require.NoError(t, err)
+	require.NoError(t, enc.Close())
+	assert.JSONEq(t, `{"type":"FeatureCollection","features":[]}`, buf.String())
+
+	_, err = NewMarkerExportEncoder("shp", &buf)
+	assert.ErrorIs(t, err, ErrUnknownExportFormat)
+}
+
+func TestParseBBox(t *testing.T) {
+	box, err := ParseBBox("126.9, 37.4,127.1,37.7")
+	require.NoError(t, err)
+	assert.Equal(t, BBox{MinLng: 126.9, MinLat: 37.4, MaxLng: 127.1, MaxLat: 37.7}, box)
+
+	for _, s := range []string{"", "126.9,37.4,127.1", "a,b,c,d", "127.1,37.4,126.9,37.7", "126.9,-91,127.1,37.7"} {
+		_, err := ParseBBox(s)
+		assert.ErrorIs(t, err, ErrInvalidBBox, s)
+	}
+}
+
+func TestParseFacilityFilter(t *testing.T) {
+	ids, err := ParseFacilityFilter("chulbong, 2,평행봉,", BuiltinFacilityNames)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids)
+
+	_, err = ParseFacilityFilter("trampoline", BuiltinFacilityNames)
+	assert.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "trampoline"))
+}
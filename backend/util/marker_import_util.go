@@ -0,0 +1,418 @@
+package util
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+const (
+	ImportFormatGeoJSON = "geojson"
+	ImportFormatCSV     = "csv"
+	ImportFormatKML     = "kml"
+)
+
+var ErrUnknownImportFormat = errors.New("unknown import format, use geojson, csv or kml")
+
+// FacilityNames resolves the facility names used in requests and import files to FacilityID, keyed by lower case name.
+// FacilityCatalogService builds it from the catalog slugs and names on top of BuiltinFacilityNames.
+type FacilityNames map[string]int
+
+// BuiltinFacilityNames are the column/property names datasets used for facility counts before the catalog existed.
+var BuiltinFacilityNames = FacilityNames{
+	"chulbong":   1,
+	"철봉":         1,
+	"pyeong":     2,
+	"pyeongbong": 2,
+	"평행봉":        2,
+}
+
+// ImportedMarker is one marker read from an import file.
+// Err is set when the row itself could not be read, the rest of the file is still usable.
+type ImportedMarker struct {
+	Facilities  map[int]int // FacilityID -> Quantity
+	Err         error
+	Latitude    float64
+	Longitude   float64
+	Row         int // 1-based feature/placemark index, or line number for CSV
+	Description string
+}
+
+// DetectImportFormat guesses the import format from a file name.
+func DetectImportFormat(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".geojson", ".json":
+		return ImportFormatGeoJSON, nil
+	case ".csv":
+		return ImportFormatCSV, nil
+	case ".kml":
+		return ImportFormatKML, nil
+	}
+	return "", ErrUnknownImportFormat
+}
+
+// ParseMarkerImport reads markers from a GeoJSON FeatureCollection, a CSV file with a header row or a KML document.
+// Facility columns and properties are recognized by the names in names.
+func ParseMarkerImport(format string, r io.Reader, names FacilityNames) ([]ImportedMarker, error) {
+	switch strings.ToLower(format) {
+	case ImportFormatGeoJSON, "json":
+		return parseGeoJSONImport(r, names)
+	case ImportFormatCSV:
+		return parseCSVImport(r, names)
+	case ImportFormatKML:
+		return parseKMLImport(r, names)
+	}
+	return nil, ErrUnknownImportFormat
+}
+
+// GeoJSON
+
+type geoJSONImport struct {
+	Type     string `json:"type"`
+	Features []struct {
+		Geometry *struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties map[string]any `json:"properties"`
+	} `json:"features"`
+}
+
+func parseGeoJSONImport(r io.Reader, names FacilityNames) ([]ImportedMarker, error) {
+	var doc geoJSONImport
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding GeoJSON: %w", err)
+	}
+	if doc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("expected a GeoJSON FeatureCollection, got %q", doc.Type)
+	}
+
+	markers := make([]ImportedMarker, 0, len(doc.Features))
+	for i, feature := range doc.Features {
+		m := ImportedMarker{Row: i + 1, Facilities: map[int]int{}}
+
+		switch {
+		case feature.Geometry == nil:
+			m.Err = errors.New("feature has no geometry")
+		case feature.Geometry.Type != "Point":
+			m.Err = fmt.Errorf("unsupported geometry %q, only Point is imported", feature.Geometry.Type)
+		default:
+			var coords []float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &coords); err != nil || len(coords) < 2 {
+				m.Err = errors.New("invalid Point coordinates")
+			} else {
+				// GeoJSON is [longitude, latitude]
+				m.Longitude, m.Latitude = coords[0], coords[1]
+			}
+		}
+
+		for key, value := range feature.Properties {
+			key = strings.ToLower(key)
+			switch key {
+			case "description", "desc":
+				if s, ok := value.(string); ok {
+					m.Description = strings.TrimSpace(s)
+				}
+			case "name":
+				if s, ok := value.(string); ok && m.Description == "" {
+					m.Description = strings.TrimSpace(s)
+				}
+			case "facilities":
+				if err := addGeoJSONFacilities(m.Facilities, value, names); err != nil && m.Err == nil {
+					m.Err = err
+				}
+			default:
+				if facilityID, ok := names[key]; ok {
+					if quantity, ok := toQuantity(value); ok {
+						m.Facilities[facilityID] = quantity
+					}
+				}
+			}
+		}
+
+		markers = append(markers, m)
+	}
+
+	return markers, nil
+}
+
+// addGeoJSONFacilities accepts [{"facilityId": 1, "quantity": 2}], {"1": 2} or the "1:2;2:1" string form.
+func addGeoJSONFacilities(facilities map[int]int, value any, names FacilityNames) error {
+	switch v := value.(type) {
+	case string:
+		return parseFacilityList(facilities, v, names)
+	case []any:
+		for _, item := range v {
+			obj, ok := item.(map[string]any)
+			if !ok {
+				return errors.New("invalid facilities entry")
+			}
+			facilityID, okID := toQuantity(obj["facilityId"])
+			quantity, okQty := toQuantity(obj["quantity"])
+			if !okID || !okQty {
+				return errors.New("facilities entries need facilityId and quantity")
+			}
+			facilities[facilityID] = quantity
+		}
+	case map[string]any:
+		for key, raw := range v {
+			facilityID, err := names.Lookup(key)
+			if err != nil {
+				return err
+			}
+			quantity, ok := toQuantity(raw)
+			if !ok {
+				return fmt.Errorf("invalid quantity for facility %q", key)
+			}
+			facilities[facilityID] = quantity
+		}
+	case nil:
+	default:
+		return errors.New("invalid facilities property")
+	}
+	return nil
+}
+
+// CSV
+
+func parseCSVImport(r io.Reader, names FacilityNames) ([]ImportedMarker, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+
+	latCol, okLat := firstColumn(columns, "lat", "latitude", "위도")
+	lngCol, okLng := firstColumn(columns, "lng", "lon", "long", "longitude", "경도")
+	if !okLat || !okLng {
+		return nil, errors.New("CSV header needs lat and lng columns")
+	}
+	descCol, hasDesc := firstColumn(columns, "description", "desc", "name", "설명")
+	facilitiesCol, hasFacilities := firstColumn(columns, "facilities")
+
+	var markers []ImportedMarker
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("reading CSV: %w", err)
+			}
+			markers = append(markers, ImportedMarker{Row: parseErr.StartLine, Err: err})
+			continue
+		}
+
+		// Line numbers rather than record numbers, so rejected rows are easy to find in the file
+		line, _ := reader.FieldPos(0)
+		m := ImportedMarker{Row: line, Facilities: map[int]int{}}
+		field := func(i int) string {
+			if i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		if m.Latitude, err = strconv.ParseFloat(field(latCol), 64); err != nil {
+			m.Err = fmt.Errorf("invalid latitude %q", field(latCol))
+		} else if m.Longitude, err = strconv.ParseFloat(field(lngCol), 64); err != nil {
+			m.Err = fmt.Errorf("invalid longitude %q", field(lngCol))
+		}
+
+		if hasDesc {
+			m.Description = field(descCol)
+		}
+		if hasFacilities {
+			if err := parseFacilityList(m.Facilities, field(facilitiesCol), names); err != nil && m.Err == nil {
+				m.Err = err
+			}
+		}
+		for alias, facilityID := range names {
+			if i, ok := columns[alias]; ok && field(i) != "" {
+				quantity, err := strconv.Atoi(field(i))
+				if err != nil {
+					if m.Err == nil {
+						m.Err = fmt.Errorf("invalid %s count %q", alias, field(i))
+					}
+					continue
+				}
+				m.Facilities[facilityID] = quantity
+			}
+		}
+
+		markers = append(markers, m)
+	}
+
+	return markers, nil
+}
+
+func firstColumn(columns map[string]int, names ...string) (int, bool) {
+	for _, name := range names {
+		if i, ok := columns[name]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// KML
+
+type kmlImport struct {
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+	Folders    []kmlFolder    `xml:"Document>Folder"`
+	Root       []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlFolder struct {
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+	Folders    []kmlFolder    `xml:"Folder"`
+}
+
+type kmlPlacemark struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Point       *struct {
+		Coordinates string `xml:"coordinates"`
+	} `xml:"Point"`
+	Data []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"ExtendedData>Data"`
+}
+
+func (f kmlFolder) collect(dst []kmlPlacemark) []kmlPlacemark {
+	dst = append(dst, f.Placemarks...)
+	for _, sub := range f.Folders {
+		dst = sub.collect(dst)
+	}
+	return dst
+}
+
+func parseKMLImport(r io.Reader, names FacilityNames) ([]ImportedMarker, error) {
+	var doc kmlImport
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding KML: %w", err)
+	}
+
+	placemarks := append(doc.Root, doc.Placemarks...)
+	for _, folder := range doc.Folders {
+		placemarks = folder.collect(placemarks)
+	}
+
+	markers := make([]ImportedMarker, 0, len(placemarks))
+	for i, pm := range placemarks {
+		m := ImportedMarker{Row: i + 1, Facilities: map[int]int{}}
+
+		m.Description = strings.TrimSpace(pm.Description)
+		if m.Description == "" {
+			m.Description = strings.TrimSpace(pm.Name)
+		}
+
+		if pm.Point == nil {
+			m.Err = errors.New("placemark has no Point")
+		} else {
+			// KML is "longitude,latitude[,altitude]"
+			parts := strings.Split(strings.TrimSpace(pm.Point.Coordinates), ",")
+			var errLng, errLat error
+			if len(parts) >= 2 {
+				m.Longitude, errLng = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+				m.Latitude, errLat = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+			}
+			if len(parts) < 2 || errLng != nil || errLat != nil {
+				m.Err = fmt.Errorf("invalid coordinates %q", pm.Point.Coordinates)
+			}
+		}
+
+		for _, data := range pm.Data {
+			name := strings.ToLower(strings.TrimSpace(data.Name))
+			value := strings.TrimSpace(data.Value)
+			if name == "facilities" {
+				if err := parseFacilityList(m.Facilities, value, names); err != nil && m.Err == nil {
+					m.Err = err
+				}
+				continue
+			}
+			if facilityID, ok := names[name]; ok {
+				quantity, err := strconv.Atoi(value)
+				if err != nil {
+					if m.Err == nil {
+						m.Err = fmt.Errorf("invalid %s count %q", name, value)
+					}
+					continue
+				}
+				m.Facilities[facilityID] = quantity
+			}
+		}
+
+		markers = append(markers, m)
+	}
+
+	return markers, nil
+}
+
+// parseFacilityList reads "1:2;2:1" (FacilityID:Quantity pairs, ";" or "|" separated).
+// Facility names in names such as "chulbong:2" are accepted as well.
+func parseFacilityList(facilities map[int]int, s string, names FacilityNames) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	for _, pair := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '|' }) {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return fmt.Errorf("invalid facility %q, expected id:quantity", pair)
+		}
+		facilityID, err := names.Lookup(key)
+		if err != nil {
+			return err
+		}
+		quantity, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid quantity in %q", pair)
+		}
+		facilities[facilityID] = quantity
+	}
+	return nil
+}
+
+// Lookup returns the FacilityID for a facility name or a numeric ID. Whether the ID is in the catalog is checked elsewhere.
+func (n FacilityNames) Lookup(key string) (int, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if facilityID, ok := n[key]; ok {
+		return facilityID, nil
+	}
+	facilityID, err := strconv.Atoi(key)
+	if err != nil || facilityID <= 0 {
+		return 0, fmt.Errorf("unknown facility %q", key)
+	}
+	return facilityID, nil
+}
+
+func toQuantity(v any) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), n == float64(int(n))
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		return i, err == nil
+	}
+	return 0, false
+}
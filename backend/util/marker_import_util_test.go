@@ -0,0 +1,142 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectImportFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"parks.geojson": ImportFormatGeoJSON,
+		"parks.JSON":    ImportFormatGeoJSON,
+		"parks.csv":     ImportFormatCSV,
+		"parks.kml":     ImportFormatKML,
+	} {
+		got, err := DetectImportFormat(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, want, got, name)
+	}
+
+	_, err := DetectImportFormat("parks.xlsx")
+	assert.ErrorIs(t, err, ErrUnknownImportFormat)
+}
+
+func TestParseMarkerImportGeoJSON(t *testing.T) {
+	const doc = `{
+	"type": "FeatureCollection",
+	"features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [126.9780, 37.5665]},
+		 "properties": {"name": "시청 앞 공원", "chulbong": 2, "facilities": [{"facilityId": 2, "quantity": 1}]}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[126.9, 37.5], [127.0, 37.6]]},
+		 "properties": {"description": "산책로"}},
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [129.0756, 35.1796]},
+		 "properties": {"description": "부산 운동장", "name": "ignored", "facilities": {"1": 3}}}
+	]
+}`
+
+	markers, err := ParseMarkerImport(ImportFormatGeoJSON, strings.NewReader(doc), BuiltinFacilityNames)
+	require.NoError(t, err)
+	require.Len(t, markers, 3)
+
+	assert.NoError(t, markers[0].Err)
+	assert.Equal(t, 1, markers[0].Row)
+	assert.InDelta(t, 37.5665, markers[0].Latitude, 1e-9)
+	assert.InDelta(t, 126.9780, markers[0].Longitude, 1e-9)
+	assert.Equal(t, "시청 앞 공원", markers[0].Description)
+	assert.Equal(t, map[int]int{1: 2, 2: 1}, markers[0].Facilities)
+
+	assert.Error(t, markers[1].Err)
+
+	assert.NoError(t, markers[2].Err)
+	assert.Equal(t, "부산 운동장", markers[2].Description)
+	assert.Equal(t, map[int]int{1: 3}, markers[2].Facilities)
+
+	_, err = ParseMarkerImport(ImportFormatGeoJSON, strings.NewReader(`{"type": "Feature"}`), BuiltinFacilityNames)
+	assert.Error(t, err)
+}
+
+func TestParseMarkerImportCSV(t *testing.T) {
+	const doc = "\ufefflat,lng,description,facilities,pyeong\n" +
+		"37.5665,126.9780,시청 앞 공원,1:2,1\n" +
+		"not-a-number,126.9780,bad row,,\n" +
+		"35.1796,129.0756,\"부산, 운동장\",chulbong:3;2:2,\n"
+
+	markers, err := ParseMarkerImport(ImportFormatCSV, strings.NewReader(doc), BuiltinFacilityNames)
+	require.NoError(t, err)
+	require.Len(t, markers, 3)
+
+	assert.NoError(t, markers[0].Err)
+	assert.Equal(t, 2, markers[0].Row)
+	assert.Equal(t, "시청 앞 공원", markers[0].Description)
+	assert.Equal(t, map[int]int{1: 2, 2: 1}, markers[0].Facilities)
+
+	assert.Error(t, markers[1].Err)
+	assert.Equal(t, 3, markers[1].Row)
+
+	assert.NoError(t, markers[2].Err)
+	assert.Equal(t, "부산, 운동장", markers[2].Description)
+	assert.Equal(t, map[int]int{1: 3, 2: 2}, markers[2].Facilities)
+
+	_, err = ParseMarkerImport(ImportFormatCSV, strings.NewReader("x,y\n1,2\n"), BuiltinFacilityNames)
+	assert.Error(t, err)
+}
+
+func TestParseMarkerImportKML(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Placemark>
+      <name>시청 앞 공원</name>
+      <Point><coordinates>126.9780,37.5665,0</coordinates></Point>
+      <ExtendedData>
+        <Data name="chulbong"><value>2</value></Data>
+      </ExtendedData>
+    </Placemark>
+    <Folder>
+      <Placemark>
+        <name>부산 운동장</name>
+        <description>체력단련장</description>
+        <Point><coordinates> 129.0756, 35.1796 </coordinates></Point>
+        <ExtendedData>
+          <Data name="facilities"><value>1:1;2:1</value></Data>
+        </ExtendedData>
+      </Placemark>
+      <Placemark>
+        <name>no point</name>
+      </Placemark>
+    </Folder>
+  </Document>
+</kml>`
+
+	markers, err := ParseMarkerImport(ImportFormatKML, strings.NewReader(doc), BuiltinFacilityNames)
+	require.NoError(t, err)
+	require.Len(t, markers, 3)
+
+	assert.NoError(t, markers[0].Err)
+	assert.Equal(t, "시청 앞 공원", markers[0].Description)
+	assert.InDelta(t, 37.5665, markers[0].Latitude, 1e-9)
+	assert.InDelta(t, 126.9780, markers[0].Longitude, 1e-9)
+	assert.Equal(t, map[int]int{1: 2}, markers[0].Facilities)
+
+	assert.NoError(t, markers[1].Err)
+	assert.Equal(t, "체력단련장", markers[1].Description)
+	assert.InDelta(t, 35.1796, markers[1].Latitude, 1e-9)
+	assert.Equal(t, map[int]int{1: 1, 2: 1}, markers[1].Facilities)
+
+	assert.Error(t, markers[2].Err)
+}
+
+func TestParseMarkerImportCatalogNames(t *testing.T) {
+	names := FacilityNames{"chulbong": 1, "rings": 3, "monkey-bars": 4}
+	const doc = "lat,lng,rings,facilities\n" +
+		"37.5665,126.9780,2,monkey-bars:1\n"
+
+	markers, err := ParseMarkerImport(ImportFormatCSV, strings.NewReader(doc), names)
+	require.NoError(t, err)
+	require.Len(t, markers, 1)
+	assert.NoError(t, markers[0].Err)
+	assert.Equal(t, map[int]int{3: 2, 4: 1}, markers[0].Facilities)
+}
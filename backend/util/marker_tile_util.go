@@ -0,0 +1,268 @@
+package util
+
+import (
+	"errors"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// MarkerTileLayer is the layer name clients style the markers with
+	MarkerTileLayer = "markers"
+	// MarkerTileMaxZoom is the deepest zoom tiles are served for, the client overzooms past it
+	MarkerTileMaxZoom = 20
+
+	tileExtent = 4096
+	// Points this close outside the tile are kept so icons on the edge are not cut in half
+	tileBuffer = 64
+	// From this zoom on every marker is drawn
+	tileFullDetailZoom = 15
+	// Largest thinning cell, 8x8 cells per tile at the lowest zooms
+	tileMaxCell = 512
+
+	maxMercatorLat = 85.05112878
+)
+
+var ErrInvalidTile = errors.New("invalid tile coordinates")
+
+// TilePoint is one marker as drawn on a vector tile.
+type TilePoint struct {
+	Facilities map[int]int // FacilityID -> Quantity
+	Latitude   float64
+	Longitude  float64
+	MarkerID   int
+	HasPhoto   bool
+	Status     string
+}
+
+// ValidateTile reports whether z/x/y names a tile that exists.
+func ValidateTile(z, x, y int) error {
+	if z < 0 || z > MarkerTileMaxZoom {
+		return ErrInvalidTile
+	}
+	n := 1 << z
+	if x < 0 || x >= n || y < 0 || y >= n {
+		return ErrInvalidTile
+	}
+	return nil
+}
+
+// MarkerTileBounds returns the area to query for a tile, including the edge buffer.
+func MarkerTileBounds(z, x, y int) BBox {
+	n := float64(int(1) << z)
+	buffer := float64(tileBuffer) / tileExtent
+
+	box := BBox{
+		MinLng: (float64(x)-buffer)/n*360 - 180,
+		MaxLng: (float64(x)+1+buffer)/n*360 - 180,
+		// Tile y grows southwards
+		MaxLat: tileYToLat(float64(y)-buffer, n),
+		MinLat: tileYToLat(float64(y)+1+buffer, n),
+	}
+	box.MinLng = math.Max(box.MinLng, -180)
+	box.MaxLng = math.Min(box.MaxLng, 180)
+	return box
+}
+
+func tileYToLat(y, n float64) float64 {
+	lat := math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	return math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat))
+}
+
+// projectToTile converts WGS84 to integer coordinates inside tile z/x/y (0..tileExtent).
+func projectToTile(lat, lng float64, z, x, y int) (int, int) {
+	n := float64(int(1) << z)
+	lat = math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat))
+	latRad := lat * math.Pi / 180
+
+	worldX := (lng + 180) / 360 * n
+	worldY := (1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * n
+
+	return int(math.Floor((worldX - float64(x)) * tileExtent)), int(math.Floor((worldY - float64(y)) * tileExtent))
+}
+
+// markerTileCell is the thinning grid size in tile units for zoom z, 0 disables thinning.
+func markerTileCell(z int) int {
+	if z >= tileFullDetailZoom {
+		return 0
+	}
+	cell := 16 << (tileFullDetailZoom - z)
+	if cell > tileMaxCell {
+		cell = tileMaxCell
+	}
+	return cell
+}
+
+type tileFeature struct {
+	point  TilePoint
+	px, py int
+	count  int
+}
+
+// EncodeMarkerTile encodes points as a Mapbox Vector Tile with a single "markers" layer.
+// Below tileFullDetailZoom points sharing a grid cell are thinned to one, preferring markers with a photo,
+// and the survivor's count property says how many markers it stands for.
+// An empty tile encodes to an empty slice.
+func EncodeMarkerTile(z, x, y int, points []TilePoint) []byte {
+	cell := markerTileCell(z)
+
+	features := make([]*tileFeature, 0, len(points))
+	cells := make(map[[2]int]*tileFeature)
+	for _, p := range points {
+		px, py := projectToTile(p.Latitude, p.Longitude, z, x, y)
+		if px < -tileBuffer || px > tileExtent+tileBuffer || py < -tileBuffer || py > tileExtent+tileBuffer {
+			continue
+		}
+
+		if cell == 0 {
+			features = append(features, &tileFeature{point: p, px: px, py: py, count: 1})
+			continue
+		}
+
+		key := [2]int{floorDiv(px, cell), floorDiv(py, cell)}
+		if kept, ok := cells[key]; ok {
+			kept.count++
+			if p.HasPhoto && !kept.point.HasPhoto {
+				kept.point, kept.px, kept.py = p, px, py
+			}
+			continue
+		}
+		f := &tileFeature{point: p, px: px, py: py, count: 1}
+		cells[key] = f
+		features = append(features, f)
+	}
+
+	if len(features) == 0 {
+		return []byte{}
+	}
+
+	layer := newTileLayer()
+	for _, f := range features {
+		layer.addFeature(f)
+	}
+
+	return protowire.AppendBytes(protowire.AppendTag(nil, 3, protowire.BytesType), layer.encode())
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && a < 0 {
+		q--
+	}
+	return q
+}
+
+// tileLayer builds the layer message, keys and values are shared tables the feature tags index into.
+type tileLayer struct {
+	features [][]byte
+	keys     []string
+	keyIndex map[string]uint64
+	values   [][]byte
+	valIndex map[any]uint64
+}
+
+func newTileLayer() *tileLayer {
+	return &tileLayer{
+		keyIndex: make(map[string]uint64),
+		valIndex: make(map[any]uint64),
+	}
+}
+
+func (l *tileLayer) key(k string) uint64 {
+	if i, ok := l.keyIndex[k]; ok {
+		return i
+	}
+	i := uint64(len(l.keys))
+	l.keys = append(l.keys, k)
+	l.keyIndex[k] = i
+	return i
+}
+
+// value adds a string, bool or uint64 to the value table.
+func (l *tileLayer) value(v any) uint64 {
+	if i, ok := l.valIndex[v]; ok {
+		return i
+	}
+
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		b = protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), v)
+	case uint64:
+		b = protowire.AppendVarint(protowire.AppendTag(nil, 5, protowire.VarintType), v)
+	case bool:
+		b = protowire.AppendVarint(protowire.AppendTag(nil, 7, protowire.VarintType), protowire.EncodeBool(v))
+	}
+
+	i := uint64(len(l.values))
+	l.values = append(l.values, b)
+	l.valIndex[v] = i
+	return i
+}
+
+type tileProperty struct {
+	key   string
+	value any
+}
+
+func (l *tileLayer) addFeature(f *tileFeature) {
+	props := []tileProperty{
+		{"markerId", uint64(f.point.MarkerID)},
+		{"hasPhoto", f.point.HasPhoto},
+		{"count", uint64(f.count)},
+	}
+	if summary := facilitySummary(f.point.Facilities); summary != "" {
+		props = append(props, tileProperty{"facilities", summary})
+	}
+	if f.point.Status != "" {
+		props = append(props, tileProperty{"status", f.point.Status})
+	}
+
+	var tags []byte
+	for _, prop := range props {
+		tags = protowire.AppendVarint(tags, l.key(prop.key))
+		tags = protowire.AppendVarint(tags, l.value(prop.value))
+	}
+
+	// A single MoveTo(1) command followed by the zigzag encoded point
+	var geometry []byte
+	geometry = protowire.AppendVarint(geometry, 1|1<<3)
+	geometry = protowire.AppendVarint(geometry, protowire.EncodeZigZag(int64(f.px)))
+	geometry = protowire.AppendVarint(geometry, protowire.EncodeZigZag(int64(f.py)))
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType) // id
+	b = protowire.AppendVarint(b, uint64(f.point.MarkerID))
+	b = protowire.AppendTag(b, 2, protowire.BytesType) // tags
+	b = protowire.AppendBytes(b, tags)
+	b = protowire.AppendTag(b, 3, protowire.VarintType) // type POINT
+	b = protowire.AppendVarint(b, 1)
+	b = protowire.AppendTag(b, 4, protowire.BytesType) // geometry
+	b = protowire.AppendBytes(b, geometry)
+
+	l.features = append(l.features, b)
+}
+
+func (l *tileLayer) encode() []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 15, protowire.VarintType) // version
+	b = protowire.AppendVarint(b, 2)
+	b = protowire.AppendTag(b, 1, protowire.BytesType) // name
+	b = protowire.AppendString(b, MarkerTileLayer)
+	for _, f := range l.features {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, f)
+	}
+	for _, k := range l.keys {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendString(b, k)
+	}
+	for _, v := range l.values {
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, v)
+	}
+	b = protowire.AppendTag(b, 5, protowire.VarintType) // extent
+	b = protowire.AppendVarint(b, tileExtent)
+	return b
+}
@@ -0,0 +1,162 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type decodedTileFeature struct {
+	id    uint64
+	props map[string]any
+	x, y  int64
+}
+
+// decodeMarkerTile reads back the single layer EncodeMarkerTile writes.
+func decodeMarkerTile(t *testing.T, data []byte) (string, uint64, []decodedTileFeature) {
+	t.Helper()
+
+	num, typ, n := protowire.ConsumeTag(data)
+	require.Equal(t, protowire.Number(3), num)
+	require.Equal(t, protowire.BytesType, typ)
+	layer, m := protowire.ConsumeBytes(data[n:])
+	require.Equal(t, len(data), n+m)
+
+	var name string
+	var extent uint64
+	var keys []string
+	var values []any
+	var rawFeatures [][]byte
+	for len(layer) > 0 {
+		num, typ, n := protowire.ConsumeTag(layer)
+		layer = layer[n:]
+		switch {
+		case typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(layer)
+			layer = layer[n:]
+			if num == 5 {
+				extent = v
+			}
+		case num == 1:
+			v, n := protowire.ConsumeString(layer)
+			layer, name = layer[n:], v
+		case num == 2:
+			v, n := protowire.ConsumeBytes(layer)
+			layer, rawFeatures = layer[n:], append(rawFeatures, v)
+		case num == 3:
+			v, n := protowire.ConsumeString(layer)
+			layer, keys = layer[n:], append(keys, v)
+		case num == 4:
+			v, n := protowire.ConsumeBytes(layer)
+			layer = layer[n:]
+			vnum, _, vn := protowire.ConsumeTag(v)
+			switch vnum {
+			case 1:
+				s, _ := protowire.ConsumeString(v[vn:])
+				values = append(values, s)
+			case 5:
+				u, _ := protowire.ConsumeVarint(v[vn:])
+				values = append(values, u)
+			case 7:
+				b, _ := protowire.ConsumeVarint(v[vn:])
+				values = append(values, protowire.DecodeBool(b))
+			}
+		}
+	}
+
+	var features []decodedTileFeature
+	for _, raw := range rawFeatures {
+		f := decodedTileFeature{props: map[string]any{}}
+		for len(raw) > 0 {
+			num, _, n := protowire.ConsumeTag(raw)
+			raw = raw[n:]
+			switch num {
+			case 1, 3:
+				v, n := protowire.ConsumeVarint(raw)
+				raw = raw[n:]
+				if num == 1 {
+					f.id = v
+				}
+			case 2, 4:
+				packed, n := protowire.ConsumeBytes(raw)
+				raw = raw[n:]
+				var ints []uint64
+				for len(packed) > 0 {
+					v, n := protowire.ConsumeVarint(packed)
+					packed, ints = packed[n:], append(ints, v)
+				}
+				if num == 2 {
+					for i := 0; i+1 < len(ints); i += 2 {
+						f.props[keys[ints[i]]] = values[ints[i+1]]
+					}
+				} else {
+					require.Len(t, ints, 3)
+					require.Equal(t, uint64(9), ints[0]) // MoveTo, count 1
+					f.x, f.y = protowire.DecodeZigZag(ints[1]), protowire.DecodeZigZag(ints[2])
+				}
+			}
+		}
+		features = append(features, f)
+	}
+
+	return name, extent, features
+}
+
+func TestEncodeMarkerTile(t *testing.T) {
+	// Seoul city hall at zoom 15
+	const z, x, y = 15, 27941, 12689
+	points := []TilePoint{
+		{MarkerID: 7, Latitude: 37.5665, Longitude: 126.978, HasPhoto: true, Status: "ACTIVE", Facilities: map[int]int{1: 2, 2: 1}},
+		{MarkerID: 8, Latitude: 37.5666, Longitude: 126.9781},
+		{MarkerID: 9, Latitude: 35.1796, Longitude: 129.0756}, // Busan, not on this tile
+	}
+
+	box := MarkerTileBounds(z, x, y)
+	assert.True(t, box.MinLat < 37.5665 && 37.5665 < box.MaxLat)
+	assert.True(t, box.MinLng < 126.978 && 126.978 < box.MaxLng)
+
+	name, extent, features := decodeMarkerTile(t, EncodeMarkerTile(z, x, y, points))
+	assert.Equal(t, MarkerTileLayer, name)
+	assert.Equal(t, uint64(4096), extent)
+	require.Len(t, features, 2)
+
+	assert.Equal(t, uint64(7), features[0].id)
+	assert.Equal(t, uint64(7), features[0].props["markerId"])
+	assert.Equal(t, true, features[0].props["hasPhoto"])
+	assert.Equal(t, uint64(1), features[0].props["count"])
+	assert.Equal(t, "철봉 2, 평행봉 1", features[0].props["facilities"])
+	assert.Equal(t, "ACTIVE", features[0].props["status"])
+	assert.True(t, features[0].x >= 0 && features[0].x < 4096)
+	assert.True(t, features[0].y >= 0 && features[0].y < 4096)
+
+	assert.Equal(t, false, features[1].props["hasPhoto"])
+	assert.NotContains(t, features[1].props, "facilities")
+}
+
+func TestEncodeMarkerTileThinning(t *testing.T) {
+	// Three markers a few meters apart collapse into one at zoom 10, the one with a photo is kept
+	const z, x, y = 10, 873, 396
+	points := []TilePoint{
+		{MarkerID: 1, Latitude: 37.5665, Longitude: 126.978},
+		{MarkerID: 2, Latitude: 37.5666, Longitude: 126.9781, HasPhoto: true},
+		{MarkerID: 3, Latitude: 37.5667, Longitude: 126.9782},
+	}
+
+	_, _, features := decodeMarkerTile(t, EncodeMarkerTile(z, x, y, points))
+	require.Len(t, features, 1)
+	assert.Equal(t, uint64(2), features[0].id)
+	assert.Equal(t, uint64(3), features[0].props["count"])
+
+	// Nothing nearby
+	assert.Empty(t, EncodeMarkerTile(z, 0, 0, points))
+}
+
+func TestValidateTile(t *testing.T) {
+	assert.NoError(t, ValidateTile(0, 0, 0))
+	assert.NoError(t, ValidateTile(15, 27941, 12689))
+	assert.ErrorIs(t, ValidateTile(1, 2, 0), ErrInvalidTile)
+	assert.ErrorIs(t, ValidateTile(-1, 0, 0), ErrInvalidTile)
+	assert.ErrorIs(t, ValidateTile(MarkerTileMaxZoom+1, 0, 0), ErrInvalidTile)
+}
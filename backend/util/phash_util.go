@@ -0,0 +1,157 @@
+package util
+
+import (
+	"image"
+	"math/bits"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// Photos whose hashes differ in at most this many of the 64 bits are taken to be the same picture
+	DuplicatePhotoDistance = 5
+
+	// HashBands is how many 16-bit bands a hash is split into to look up near-duplicates, band 0 holds the top bits.
+	// Two hashes within d bits have a band within d/HashBands bits of each other.
+	HashBands = 4
+
+	// A thumbnail whose luma deviates less than this (of 255) is near-uniform, its hash bits are only noise
+	minHashDeviation = 4
+)
+
+// DHash is the difference hash of img: shrunk to 9x8 gray pixels, each bit tells whether a pixel is brighter
+// than its right neighbour. Resized, recompressed or lightly edited copies of a picture get hashes a few bits apart.
+// ok is false for near-uniform images, like a blank wall or a dark frame, whose hashes would match unrelated pictures.
+func DHash(img image.Image) (hash uint64, ok bool) {
+	small := imaging.Resize(img, 9, 8, imaging.Box)
+
+	var gray [8][9]int
+	var sum int64
+	for y := range 8 {
+		for x := range 9 {
+			i := small.PixOffset(x, y)
+			r, g, b := int(small.Pix[i]), int(small.Pix[i+1]), int(small.Pix[i+2])
+			gray[y][x] = 299*r + 587*g + 114*b // ITU-R 601 luma, scaled by 1000
+			sum += int64(gray[y][x])
+		}
+	}
+
+	mean := sum / (8 * 9)
+	var variance int64
+	for y := range 8 {
+		for x := range 9 {
+			d := int64(gray[y][x]) - mean
+			variance += d * d
+		}
+	}
+	variance /= 8 * 9
+	if variance < minHashDeviation*minHashDeviation*1000*1000 {
+		return 0, false
+	}
+
+	for y := range 8 {
+		for x := range 8 {
+			hash <<= 1
+			if gray[y][x] > gray[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, true
+}
+
+// HammingDistance counts the bits two hashes differ in.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// HashBand returns band i of hash, see HashBands.
+func HashBand(hash uint64, i int) uint16 {
+	return uint16(hash >> (16 * (HashBands - 1 - i)))
+}
+
+// HashBandCandidates returns, for every band of hash, the band values a hash within maxDistance bits of it
+// has in at least one band. Looking up these values finds every near-duplicate without comparing all pairs.
+func HashBandCandidates(hash uint64, maxDistance int) [HashBands][]uint16 {
+	radius := maxDistance / HashBands
+
+	var candidates [HashBands][]uint16
+	for i := range HashBands {
+		band := HashBand(hash, i)
+		values := []uint16{band}
+		var flip func(v uint16, from, left int)
+		flip = func(v uint16, from, left int) {
+			for bit := from; bit < 16; bit++ {
+				w := v ^ 1<<bit
+				values = append(values, w)
+				if left > 1 {
+					flip(w, bit+1, left-1)
+				}
+			}
+		}
+		if radius > 0 {
+			flip(band, 0, radius)
+		}
+		candidates[i] = values
+	}
+	return candidates
+}
+
+// GroupSimilarHashes groups the indices of hashes that are within maxDistance of each other, directly or through
+// other hashes in the group. Only groups of two or more are returned, each in index order, ordered by their first index.
+// Hashes are bucketed by band, so only the hashes sharing a candidate band value are compared.
+func GroupSimilarHashes(hashes []uint64, maxDistance int) [][]int {
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	var buckets [HashBands]map[uint16][]int
+	for b := range buckets {
+		buckets[b] = make(map[uint16][]int)
+	}
+	for i, hash := range hashes {
+		for b := range HashBands {
+			band := HashBand(hash, b)
+			buckets[b][band] = append(buckets[b][band], i)
+		}
+	}
+
+	for i, hash := range hashes {
+		for b, values := range HashBandCandidates(hash, maxDistance) {
+			for _, v := range values {
+				for _, j := range buckets[b][v] {
+					if j <= i || HammingDistance(hash, hashes[j]) > maxDistance {
+						continue
+					}
+					if ri, rj := find(i), find(j); ri != rj {
+						parent[max(ri, rj)] = min(ri, rj)
+					}
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range hashes {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+
+	groups := make([][]int, 0)
+	for _, group := range members {
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0] < groups[j][0] })
+	return groups
+}
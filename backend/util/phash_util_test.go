@@ -0,0 +1,167 @@
+package util
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// phashTestImage is a bar-like picture: a dark horizontal bar and two posts on a bright gradient.
+func phashTestImage(width, height int, mirror bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			v := uint8(120 + 100*x/width)
+			if mirror {
+				v = uint8(220 - 100*x/width)
+			}
+			if (y > height/5 && y < height/4) || (x > width/6 && x < width/5) || (x > 4*width/5 && x < 5*width/6) {
+				v = 30
+			}
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v + 20, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDHashNearDuplicates(t *testing.T) {
+	original := phashTestImage(640, 480, false)
+	hash, ok := DHash(original)
+	require.True(t, ok)
+
+	// A smaller, recompressed copy is the same picture
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, imaging.Resize(original, 320, 240, imaging.Lanczos), &jpeg.Options{Quality: 40}))
+	copied, err := jpeg.Decode(&buf)
+	require.NoError(t, err)
+	copiedHash, ok := DHash(copied)
+	require.True(t, ok)
+	assert.LessOrEqual(t, HammingDistance(hash, copiedHash), DuplicatePhotoDistance)
+
+	// A mirrored one is not
+	mirroredHash, ok := DHash(phashTestImage(640, 480, true))
+	require.True(t, ok)
+	assert.Greater(t, HammingDistance(hash, mirroredHash), DuplicatePhotoDistance)
+}
+
+func TestDHashNearUniform(t *testing.T) {
+	// A blank wall with a little sensor noise
+	img := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	for y := range 240 {
+		for x := range 320 {
+			v := uint8(200 + (x*7+y*13)%3)
+			img.Set(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	_, ok := DHash(img)
+	assert.False(t, ok)
+
+	_, ok = DHash(image.NewRGBA(image.Rect(0, 0, 64, 64)))
+	assert.False(t, ok)
+}
+
+func TestHashBandCandidates(t *testing.T) {
+	hash := uint64(0x1234_5678_9ABC_DEF0)
+	assert.Equal(t, uint16(0x1234), HashBand(hash, 0))
+	assert.Equal(t, uint16(0xDEF0), HashBand(hash, 3))
+
+	// Up to 3 bits apart, some band matches exactly
+	candidates := HashBandCandidates(hash, 3)
+	for i := range HashBands {
+		assert.Equal(t, []uint16{HashBand(hash, i)}, candidates[i])
+	}
+
+	// 5 bits apart, some band is at most one bit off
+	candidates = HashBandCandidates(hash, DuplicatePhotoDistance)
+	for i := range HashBands {
+		assert.Len(t, candidates[i], 17)
+		assert.Contains(t, candidates[i], HashBand(hash, i)^0x8000)
+	}
+	near := hash ^ 1<<63 ^ 1<<47 ^ 1<<31 ^ 1<<15 ^ 1<<14
+	found := false
+	for i := range HashBands {
+		found = found || slices.Contains(candidates[i], HashBand(near, i))
+	}
+	assert.True(t, found)
+}
+
+func TestHammingDistance(t *testing.T) {
+	assert.Equal(t, 0, HammingDistance(0xF0F0, 0xF0F0))
+	assert.Equal(t, 4, HammingDistance(0xF0F0, 0xF0FF))
+	assert.Equal(t, 64, HammingDistance(0, ^uint64(0)))
+}
+
+func TestGroupSimilarHashes(t *testing.T) {
+	hashes := []uint64{
+		0b0000,           // 0
+		0xFFFF_0000_0000, // 1
+		0b0011,           // 2: 2 bits from 0
+		0xFFFF_0000_0001, // 3: 1 bit from 1
+		0b1111,           // 4: 2 bits from 2, 4 from 0, grouped through 2
+		0xF0F0_F0F0_F0F0, // 5: alone
+	}
+
+	assert.Equal(t, [][]int{{0, 2, 4}, {1, 3}}, GroupSimilarHashes(hashes, 2))
+	assert.Empty(t, GroupSimilarHashes(hashes, 0))
+	assert.Empty(t, GroupSimilarHashes(nil, 5))
+}
+
+func TestGroupSimilarHashesMatchesAllPairs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	// Clumps of hashes a few random bits off a base, plus unrelated ones
+	var hashes []uint64
+	for range 40 {
+		base := rng.Uint64()
+		for range 1 + rng.Intn(4) {
+			hash := base
+			for range rng.Intn(5) {
+				hash ^= 1 << rng.Intn(64)
+			}
+			hashes = append(hashes, hash)
+		}
+	}
+
+	// What comparing every pair finds
+	parent := make([]int, len(hashes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range hashes {
+		for j := i + 1; j < len(hashes); j++ {
+			if HammingDistance(hashes[i], hashes[j]) <= DuplicatePhotoDistance {
+				if ri, rj := find(i), find(j); ri != rj {
+					parent[max(ri, rj)] = min(ri, rj)
+				}
+			}
+		}
+	}
+	members := make(map[int][]int)
+	for i := range hashes {
+		members[find(i)] = append(members[find(i)], i)
+	}
+	var want [][]int
+	for i := range hashes {
+		if group := members[i]; len(group) > 1 {
+			want = append(want, group)
+		}
+	}
+
+	require.NotEmpty(t, want)
+	assert.Equal(t, want, GroupSimilarHashes(hashes, DuplicatePhotoDistance))
+}
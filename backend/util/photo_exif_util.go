@@ -0,0 +1,273 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image/jpeg"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+const (
+	// Full verification score within PhotoNearDistance of the marker, none from PhotoFarDistance on
+	PhotoNearDistance = 100.0 // meters
+	PhotoFarDistance  = 500.0 // meters
+
+	// A photo older than this may show a bar that is not there any more
+	PhotoMaxAge = 2 * 365 * 24 * time.Hour
+
+	// Score of a photo whose EXIF has no position to check
+	photoUnverifiedScore = 50
+)
+
+// Why a photo needs a look from a moderator
+const (
+	PhotoFlagFar       = "FAR"       // taken PhotoFarDistance or more from the marker
+	PhotoFlagOld       = "OLD"       // taken more than PhotoMaxAge ago
+	PhotoFlagDuplicate = "DUPLICATE" // near-duplicate of a photo of another marker, see DHash
+)
+
+var ErrInvalidImage = errors.New("invalid or truncated image")
+
+// PhotoExif is where and when a photo was taken according to its EXIF, nil for what it does not say.
+type PhotoExif struct {
+	TakenAt   *time.Time
+	Latitude  *float64
+	Longitude *float64
+}
+
+// PhotoVerification is how well a photo backs up the place it was uploaded for.
+type PhotoVerification struct {
+	TakenAt  *time.Time
+	Distance *float64 // meters from the place, nil when the photo has no GPS position
+	Flags    []string
+	Score    int // 0-100
+}
+
+// ReadPhotoExif reads the GPS position and capture time from the EXIF of a JPEG (or TIFF based) image.
+// Images without EXIF give an empty PhotoExif.
+func ReadPhotoExif(r io.Reader) PhotoExif {
+	var result PhotoExif
+
+	x, err := exif.Decode(r)
+	if err != nil {
+		return result
+	}
+
+	// Some cameras write 0,0 when they have no fix
+	if lat, long, err := x.LatLong(); err == nil && (lat != 0 || long != 0) &&
+		!math.IsNaN(lat) && !math.IsNaN(long) && math.Abs(lat) <= 90 && math.Abs(long) <= 180 {
+		result.Latitude, result.Longitude = &lat, &long
+	}
+	if takenAt, err := x.DateTime(); err == nil && !takenAt.IsZero() {
+		result.TakenAt = &takenAt
+	}
+	return result
+}
+
+// VerifyPhotoLocation scores a photo uploaded for the place at lat, long by how close to it and how recently it was taken.
+// Photos without a GPS position score in the middle, they are common since many apps drop it.
+func VerifyPhotoLocation(x PhotoExif, lat, long float64, now time.Time) PhotoVerification {
+	v := PhotoVerification{
+		TakenAt: x.TakenAt,
+		Score:   photoUnverifiedScore,
+	}
+
+	if x.Latitude != nil && x.Longitude != nil {
+		d := distance(*x.Latitude, *x.Longitude, lat, long)
+		v.Distance = &d
+		switch {
+		case d <= PhotoNearDistance:
+			v.Score = 100
+		case d >= PhotoFarDistance:
+			v.Score = 0
+			v.Flags = append(v.Flags, PhotoFlagFar)
+		default:
+			v.Score = int(math.Round(100 * (PhotoFarDistance - d) / (PhotoFarDistance - PhotoNearDistance)))
+		}
+	}
+
+	if x.TakenAt != nil && now.Sub(*x.TakenAt) > PhotoMaxAge {
+		v.Score /= 2
+		v.Flags = append(v.Flags, PhotoFlagOld)
+	}
+	return v
+}
+
+// FlagList joins the flags for the VerificationFlags column, empty when there are none.
+func (v PhotoVerification) FlagList() string {
+	return strings.Join(v.Flags, ",")
+}
+
+// StripImageMetadata removes EXIF, XMP, IPTC and text metadata, including any GPS position, from a JPEG, PNG or WebP
+// image without re-encoding it. Color profiles are kept. A JPEG that relies on its EXIF orientation is re-encoded
+// upright instead, it would show sideways without it. Other formats are returned as they are.
+func StripImageMetadata(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		if orientation := GetOrientationByReader(bytes.NewReader(data)); orientation != 1 {
+			return encodeUprightJPEG(data, orientation)
+		}
+		return stripJPEGMetadata(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNGMetadata(data)
+	case len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return stripWebPMetadata(data)
+	}
+	return data, nil
+}
+
+func encodeUprightJPEG(data []byte, orientation int) ([]byte, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidImage, err)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(data))
+	if err := jpeg.Encode(&buf, FixOrientation(img, orientation), &jpeg.Options{Quality: 92}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jpegMetadataSegment tells whether a JPEG segment holds metadata rather than something needed to decode or color the image.
+// APP0 (JFIF), APP2 (ICC profile) and APP14 (Adobe color transform) are kept.
+func jpegMetadataSegment(marker byte) bool {
+	return marker == 0xE1 || // EXIF, XMP
+		(marker >= 0xE3 && marker <= 0xED) || // APP13 is Photoshop IPTC
+		marker == 0xEF ||
+		marker == 0xFE // comment
+}
+
+func stripJPEGMetadata(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...) // SOI
+
+	for i := 2; i < len(data); {
+		if data[i] != 0xFF {
+			return nil, ErrInvalidImage
+		}
+		// Markers may be preceded by any number of fill bytes
+		for i < len(data) && data[i] == 0xFF {
+			i++
+		}
+		if i == len(data) {
+			return nil, ErrInvalidImage
+		}
+		marker := data[i]
+		start := i - 1
+		i++
+
+		// Standalone markers have no length
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, 0xFF, marker)
+			continue
+		}
+		if marker == 0xD9 { // EOI
+			out = append(out, 0xFF, marker)
+			break
+		}
+
+		if i+2 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		end := i + int(binary.BigEndian.Uint16(data[i:]))
+		if end > len(data) || end < i+2 {
+			return nil, ErrInvalidImage
+		}
+
+		if marker == 0xDA { // SOS, the entropy coded data follows and runs to the end of the image
+			out = append(out, data[start:]...)
+			break
+		}
+		if !jpegMetadataSegment(marker) {
+			out = append(out, data[start:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// pngMetadataChunks are dropped, everything else is copied as is
+var pngMetadataChunks = map[string]struct{}{
+	"eXIf": {},
+	"tEXt": {},
+	"zTXt": {},
+	"iTXt": {},
+	"tIME": {},
+}
+
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	for i := len(pngSignature); i < len(data); {
+		// length, type, data, CRC
+		if i+8 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end > len(data) || end < i+12 {
+			return nil, ErrInvalidImage
+		}
+
+		chunkType := string(data[i+4 : i+8])
+		if _, drop := pngMetadataChunks[chunkType]; !drop {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return out, nil
+}
+
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+
+	for i := 12; i < len(data); {
+		// FourCC, little endian size, data padded to an even length
+		if i+8 > len(data) {
+			return nil, ErrInvalidImage
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size&1
+		if end == len(data)+1 {
+			end-- // some encoders leave out the padding of the last chunk
+		}
+		if end > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ": // dropped
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if len(chunk) > 8 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,194 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exifTestImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x * 40), G: uint8(y * 40), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// exifTestSegment builds an APP1 segment with an orientation, a capture time and a GPS position in the northern
+// and eastern hemisphere, laid out as IFD0 | DateTime | GPS IFD | latitude | longitude.
+func exifTestSegment(orientation uint16, lat, long float64, takenAt string) []byte {
+	var tiff bytes.Buffer
+	be := binary.BigEndian
+	entry := func(tag, typ uint16, count, value uint32) {
+		binary.Write(&tiff, be, tag)
+		binary.Write(&tiff, be, typ)
+		binary.Write(&tiff, be, count)
+		binary.Write(&tiff, be, value)
+	}
+	rationals := func(deg float64) {
+		d := math.Floor(deg)
+		m := math.Floor((deg - d) * 60)
+		s := ((deg-d)*60 - m) * 60
+		for _, v := range [][2]uint32{{uint32(d), 1}, {uint32(m), 1}, {uint32(math.Round(s * 1000)), 1000}} {
+			binary.Write(&tiff, be, v)
+		}
+	}
+
+	tiff.WriteString("MM\x00\x2a")
+	binary.Write(&tiff, be, uint32(8))
+
+	// IFD0 at 8, 3 entries: ends at 50
+	binary.Write(&tiff, be, uint16(3))
+	entry(0x0112, 3, 1, uint32(orientation)<<16) // Orientation, SHORT left justified
+	entry(0x0132, 2, 20, 50)                     // DateTime
+	entry(0x8825, 4, 1, 70)                      // GPS IFD
+	binary.Write(&tiff, be, uint32(0))
+	tiff.WriteString(takenAt + "\x00") // 50-70
+
+	// GPS IFD at 70, 4 entries: ends at 124
+	binary.Write(&tiff, be, uint16(4))
+	entry(0x0001, 2, 2, uint32('N')<<24)
+	entry(0x0002, 5, 3, 124)
+	entry(0x0003, 2, 2, uint32('E')<<24)
+	entry(0x0004, 5, 3, 148)
+	binary.Write(&tiff, be, uint32(0))
+	rationals(lat)
+	rationals(long)
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	be.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// exifTestJPEG encodes img and puts segments right after SOI.
+func exifTestJPEG(t *testing.T, img image.Image, segments ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	data := buf.Bytes()
+
+	out := append([]byte(nil), data[:2]...)
+	for _, segment := range segments {
+		out = append(out, segment...)
+	}
+	return append(out, data[2:]...)
+}
+
+func TestReadPhotoExif(t *testing.T) {
+	data := exifTestJPEG(t, exifTestImage(4, 2), exifTestSegment(1, 37.5665, 126.978, "2023:05:01 07:30:00"))
+
+	x := ReadPhotoExif(bytes.NewReader(data))
+	require.NotNil(t, x.Latitude)
+	require.NotNil(t, x.Longitude)
+	assert.InDelta(t, 37.5665, *x.Latitude, 1e-5)
+	assert.InDelta(t, 126.978, *x.Longitude, 1e-5)
+	require.NotNil(t, x.TakenAt)
+	assert.Equal(t, 2023, x.TakenAt.Year())
+
+	assert.Equal(t, PhotoExif{}, ReadPhotoExif(bytes.NewReader(exifTestJPEG(t, exifTestImage(4, 2)))))
+}
+
+func TestVerifyPhotoLocation(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, -1, 0)
+	old := now.AddDate(-3, 0, 0)
+	at := func(lat, long float64, takenAt *time.Time) PhotoExif {
+		return PhotoExif{Latitude: &lat, Longitude: &long, TakenAt: takenAt}
+	}
+
+	v := VerifyPhotoLocation(at(37.5665, 126.978, &recent), 37.5665, 126.9785, now)
+	assert.Equal(t, 100, v.Score)
+	assert.Empty(t, v.Flags)
+	require.NotNil(t, v.Distance)
+	assert.InDelta(t, 44, *v.Distance, 1)
+
+	// About 300 meters away, halfway between near and far
+	v = VerifyPhotoLocation(at(37.5665, 126.978, nil), 37.5692, 126.978, now)
+	assert.InDelta(t, 50, v.Score, 2)
+	assert.Empty(t, v.Flags)
+
+	v = VerifyPhotoLocation(at(35.1796, 129.0756, &old), 37.5665, 126.978, now)
+	assert.Equal(t, 0, v.Score)
+	assert.Equal(t, []string{PhotoFlagFar, PhotoFlagOld}, v.Flags)
+	assert.Equal(t, "FAR,OLD", v.FlagList())
+
+	// Nothing to check
+	v = VerifyPhotoLocation(PhotoExif{}, 37.5665, 126.978, now)
+	assert.Equal(t, 50, v.Score)
+	assert.Nil(t, v.Distance)
+	assert.Empty(t, v.FlagList())
+
+	v = VerifyPhotoLocation(PhotoExif{TakenAt: &old}, 37.5665, 126.978, now)
+	assert.Equal(t, 25, v.Score)
+	assert.Equal(t, []string{PhotoFlagOld}, v.Flags)
+}
+
+func TestStripImageMetadataJPEG(t *testing.T) {
+	comment := []byte{0xFF, 0xFE, 0x00, 0x07, 'h', 'e', 'l', 'l', 'o'}
+	data := exifTestJPEG(t, exifTestImage(4, 2), exifTestSegment(1, 37.5665, 126.978, "2023:05:01 07:30:00"), comment)
+
+	stripped, err := StripImageMetadata(data)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stripped), "Exif")
+	assert.NotContains(t, string(stripped), "hello")
+	assert.Equal(t, PhotoExif{}, ReadPhotoExif(bytes.NewReader(stripped)))
+
+	// The image data itself is untouched
+	assert.Equal(t, exifTestJPEG(t, exifTestImage(4, 2)), stripped)
+
+	_, err = StripImageMetadata(data[:30])
+	assert.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func TestStripImageMetadataRotatedJPEG(t *testing.T) {
+	data := exifTestJPEG(t, exifTestImage(4, 2), exifTestSegment(6, 37.5665, 126.978, "2023:05:01 07:30:00"))
+
+	stripped, err := StripImageMetadata(data)
+	require.NoError(t, err)
+	assert.NotContains(t, string(stripped), "Exif")
+
+	img, err := jpeg.Decode(bytes.NewReader(stripped))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 2, 4), img.Bounds(), "turned upright")
+}
+
+func TestStripImageMetadataPNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, exifTestImage(4, 2)))
+	clean := buf.Bytes()
+
+	// A tEXt chunk right after IHDR
+	text := []byte("Comment\x00taken at home")
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)))
+	chunk = append(append(chunk, "tEXt"...), text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	ihdrEnd := 8 + 12 + 13
+	data := append(append(append([]byte(nil), clean[:ihdrEnd]...), chunk...), clean[ihdrEnd:]...)
+
+	stripped, err := StripImageMetadata(data)
+	require.NoError(t, err)
+	assert.Equal(t, clean, stripped)
+
+	_, err = png.Decode(bytes.NewReader(stripped))
+	assert.NoError(t, err)
+}
+
+func TestStripImageMetadataOther(t *testing.T) {
+	gif := []byte("GIF89a...")
+	stripped, err := StripImageMetadata(gif)
+	require.NoError(t, err)
+	assert.Equal(t, gif, stripped)
+}
@@ -0,0 +1,177 @@
+package util
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// Smallest short side, in pixels, and the longest a photo may be relative to its short side
+	MinPhotoSide        = 320
+	MaxPhotoAspectRatio = 4.0
+
+	// Photos are measured at this size so the blur thresholds do not depend on the camera resolution
+	photoQualitySize = 512
+
+	// Variance of the Laplacian: below the first a photo is turned down, below the second the uploader is warned,
+	// from the last one on a photo counts as fully sharp
+	photoBlurRejectVariance = 10.0
+	photoBlurWarnVariance   = 50.0
+	photoSharpVariance      = 300.0
+
+	// Luma at or below photoDarkLuma is black, at or above photoBrightLuma white.
+	// A photo that is almost all black or white is turned down, a dark or bright one gets a warning.
+	photoDarkLuma       = 20
+	photoBrightLuma     = 235
+	photoClippedReject  = 0.97
+	photoDarkWarnMean   = 60.0
+	photoBrightWarnMean = 200.0
+)
+
+// What a photo quality issue is about
+const (
+	PhotoCheckResolution  = "RESOLUTION"
+	PhotoCheckAspectRatio = "ASPECT_RATIO"
+	PhotoCheckExposure    = "EXPOSURE"
+	PhotoCheckBlur        = "BLUR"
+)
+
+// PhotoQualityIssue is a quality problem of a photo with a message the uploader can act on.
+// As an error it is why a photo was turned down.
+type PhotoQualityIssue struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func (i PhotoQualityIssue) Error() string {
+	return i.Message
+}
+
+// PhotoQuality is how good a photo looks, measured without any external service.
+type PhotoQuality struct {
+	Rejection  *PhotoQualityIssue // nil when the photo may be uploaded
+	Warnings   []PhotoQualityIssue
+	Sharpness  float64 // variance of the Laplacian, higher is sharper
+	Brightness float64 // mean luma, 0-255
+	Width      int
+	Height     int
+	Score      int // 0-100, 60 for sharpness and 40 for exposure
+}
+
+// Err is the rejection of the photo as an error, nil when it passed.
+func (q PhotoQuality) Err() error {
+	if q.Rejection == nil {
+		return nil
+	}
+	return *q.Rejection
+}
+
+// AssessPhotoQuality checks the resolution, aspect ratio, exposure and sharpness of a photo.
+// A photo that is too small, too narrow, almost black or white, or far out of focus is rejected,
+// the first failed check being the reason. Dark, overexposed or somewhat blurry photos only get warnings.
+func AssessPhotoQuality(img image.Image) PhotoQuality {
+	bounds := img.Bounds()
+	q := PhotoQuality{Width: bounds.Dx(), Height: bounds.Dy()}
+
+	short, long := min(q.Width, q.Height), max(q.Width, q.Height)
+	if short < MinPhotoSide {
+		q.Rejection = &PhotoQualityIssue{PhotoCheckResolution,
+			fmt.Sprintf("사진 해상도가 너무 낮습니다 (%dx%d). 짧은 변이 %dpx 이상인 사진을 올려주세요.", q.Width, q.Height, MinPhotoSide)}
+		return q
+	}
+	if float64(long)/float64(short) > MaxPhotoAspectRatio {
+		q.Rejection = &PhotoQualityIssue{PhotoCheckAspectRatio,
+			fmt.Sprintf("사진이 너무 길쭉합니다 (%dx%d). 가로세로 비율이 %g:1 이하인 사진을 올려주세요.", q.Width, q.Height, MaxPhotoAspectRatio)}
+		return q
+	}
+
+	luma, w, h := photoLuma(img)
+
+	var histogram [256]int
+	var sum float64
+	for _, l := range luma {
+		histogram[int(l)]++
+		sum += l
+	}
+	n := float64(len(luma))
+	q.Brightness = sum / n
+
+	var dark, bright int
+	for l := range 256 {
+		if l <= photoDarkLuma {
+			dark += histogram[l]
+		} else if l >= photoBrightLuma {
+			bright += histogram[l]
+		}
+	}
+	darkShare, brightShare := float64(dark)/n, float64(bright)/n
+
+	q.Sharpness = laplacianVariance(luma, w, h)
+
+	// Exposure is best for a mid-gray mean with nothing clipped to black or white
+	sharpness := math.Min(1, q.Sharpness/photoSharpVariance)
+	exposure := (1 - math.Abs(q.Brightness-128)/128) * (1 - darkShare - brightShare)
+	q.Score = int(math.Round(60*sharpness + 40*math.Max(0, exposure)))
+
+	switch {
+	case darkShare >= photoClippedReject:
+		q.Rejection = &PhotoQualityIssue{PhotoCheckExposure, "사진이 거의 검은색입니다. 밝은 곳에서 다시 찍어주세요."}
+	case brightShare >= photoClippedReject:
+		q.Rejection = &PhotoQualityIssue{PhotoCheckExposure, "사진이 거의 흰색입니다. 역광을 피해 다시 찍어주세요."}
+	case q.Sharpness < photoBlurRejectVariance:
+		q.Rejection = &PhotoQualityIssue{PhotoCheckBlur, "사진이 너무 흐립니다. 초점을 맞춰 다시 찍어주세요."}
+	}
+	if q.Rejection != nil {
+		return q
+	}
+
+	if q.Brightness < photoDarkWarnMean {
+		q.Warnings = append(q.Warnings, PhotoQualityIssue{PhotoCheckExposure, "사진이 어둡습니다. 더 밝은 사진이 있다면 그 사진이 더 잘 보입니다."})
+	} else if q.Brightness > photoBrightWarnMean {
+		q.Warnings = append(q.Warnings, PhotoQualityIssue{PhotoCheckExposure, "사진이 너무 밝습니다. 더 잘 나온 사진이 있다면 그 사진이 더 잘 보입니다."})
+	}
+	if q.Sharpness < photoBlurWarnVariance {
+		q.Warnings = append(q.Warnings, PhotoQualityIssue{PhotoCheckBlur, "사진이 조금 흐립니다. 더 선명한 사진이 있다면 그 사진이 더 잘 보입니다."})
+	}
+	return q
+}
+
+// photoLuma shrinks img to fit photoQualitySize and returns its ITU-R 601 luma, row by row, with its size.
+func photoLuma(img image.Image) ([]float64, int, int) {
+	small := imaging.Fit(img, photoQualitySize, photoQualitySize, imaging.Box)
+	w, h := small.Rect.Dx(), small.Rect.Dy()
+
+	luma := make([]float64, 0, w*h)
+	for y := range h {
+		for x := range w {
+			i := small.PixOffset(x, y)
+			r, g, b := float64(small.Pix[i]), float64(small.Pix[i+1]), float64(small.Pix[i+2])
+			luma = append(luma, math.Min(255, 0.299*r+0.587*g+0.114*b))
+		}
+	}
+	return luma, w, h
+}
+
+// laplacianVariance is the variance of the 4-neighbour Laplacian over the inner pixels. Sharp edges give large
+// responses, so the lower it is the blurrier the photo.
+func laplacianVariance(luma []float64, w, h int) float64 {
+	if w < 3 || h < 3 {
+		return 0
+	}
+
+	var sum, sumSquares float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			l := luma[i-w] + luma[i+w] + luma[i-1] + luma[i+1] - 4*luma[i]
+			sum += l
+			sumSquares += l * l
+		}
+	}
+	n := float64((w - 2) * (h - 2))
+	mean := sum / n
+	return sumSquares/n - mean*mean
+}
@@ -0,0 +1,78 @@
+package util
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// qualityTestImage is a mid-gray scene with posts and bars, base sets its overall brightness.
+func qualityTestImage(width, height int, base uint8) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			v := base + uint8(x*40/width)
+			if (x/24)%3 == 0 || (y/40)%4 == 0 {
+				v /= 3
+			}
+			img.Set(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestAssessPhotoQualityGood(t *testing.T) {
+	q := AssessPhotoQuality(qualityTestImage(1024, 768, 120))
+
+	assert.NoError(t, q.Err())
+	assert.Empty(t, q.Warnings)
+	assert.Equal(t, 1024, q.Width)
+	assert.Greater(t, q.Sharpness, photoSharpVariance)
+	assert.Greater(t, q.Score, 70)
+}
+
+func TestAssessPhotoQualityRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		img   image.Image
+		check string
+	}{
+		{"too small", qualityTestImage(300, 200, 120), PhotoCheckResolution},
+		{"too narrow", qualityTestImage(2000, 400, 120), PhotoCheckAspectRatio},
+		{"black", imaging.New(800, 600, color.NRGBA{R: 5, G: 5, B: 5, A: 255}), PhotoCheckExposure},
+		{"white", imaging.New(800, 600, color.NRGBA{R: 250, G: 250, B: 250, A: 255}), PhotoCheckExposure},
+		{"out of focus", imaging.Blur(qualityTestImage(800, 600, 120), 12), PhotoCheckBlur},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := AssessPhotoQuality(tt.img)
+			require.NotNil(t, q.Rejection)
+			assert.Equal(t, tt.check, q.Rejection.Check)
+			assert.NotEmpty(t, q.Rejection.Message)
+
+			var issue PhotoQualityIssue
+			assert.True(t, errors.As(q.Err(), &issue))
+		})
+	}
+}
+
+func TestAssessPhotoQualityWarnings(t *testing.T) {
+	q := AssessPhotoQuality(qualityTestImage(800, 600, 40))
+	require.NoError(t, q.Err())
+	require.Len(t, q.Warnings, 1)
+	assert.Equal(t, PhotoCheckExposure, q.Warnings[0].Check)
+
+	q = AssessPhotoQuality(imaging.Blur(qualityTestImage(800, 600, 120), 2))
+	require.NoError(t, q.Err())
+	require.Len(t, q.Warnings, 1)
+	assert.Equal(t, PhotoCheckBlur, q.Warnings[0].Check)
+
+	good := AssessPhotoQuality(qualityTestImage(800, 600, 120))
+	assert.Greater(t, good.Score, q.Score, "sharper photos score higher")
+}
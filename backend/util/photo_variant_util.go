@@ -0,0 +1,100 @@
+package util
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Widths photos are resized to after upload, each stored as WebP and as JPEG for clients without WebP
+var PhotoVariantWidths = []int{320, 640, 1280}
+
+// Widths the image endpoint resizes to, requested widths are rounded up to one of them so a few cached copies
+// serve every client. It starts with PhotoVariantWidths so those can be served as they are.
+var optimizedImageWidths = []int{320, 640, 1280, 1920, 3840}
+
+// Formats of the photo variants
+const (
+	PhotoVariantWebP = ".webp"
+	PhotoVariantJPEG = ".jpg"
+)
+
+// PhotoVariantWidthsFor is the variant widths worth making for a photo this wide, the ones smaller than the photo itself.
+func PhotoVariantWidthsFor(width int) []int {
+	widths := make([]int, 0, len(PhotoVariantWidths))
+	for _, w := range PhotoVariantWidths {
+		if w < width {
+			widths = append(widths, w)
+		}
+	}
+	return widths
+}
+
+// PhotoVariantKey is the key, or URL, of the variant of the photo at key with the given width and format,
+// e.g. markers/1/<uuid>.png becomes markers/1/<uuid>_w640.webp.
+func PhotoVariantKey(key string, width int, ext string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_w" + strconv.Itoa(width) + ext
+}
+
+// FormatVariantWidths joins variant widths for the VariantWidths column, empty when there are none.
+func FormatVariantWidths(widths []int) string {
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		parts[i] = strconv.Itoa(w)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseVariantWidths reads the VariantWidths column, skipping anything that is not a width.
+func ParseVariantWidths(s string) []int {
+	var widths []int
+	for _, part := range strings.Split(s, ",") {
+		if w, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && w > 0 {
+			widths = append(widths, w)
+		}
+	}
+	return widths
+}
+
+// SnapImageWidth rounds a requested width up to the next width the image endpoint resizes to, 0 keeps the original width.
+func SnapImageWidth(width int) int {
+	if width <= 0 {
+		return 0
+	}
+	for _, w := range optimizedImageWidths {
+		if width <= w {
+			return w
+		}
+	}
+	return optimizedImageWidths[len(optimizedImageWidths)-1]
+}
+
+// NegotiateImageFormat picks the extension to serve an image with extension srcExt in, given the Accept header of the
+// request. WebP is served to clients that list it with a non-zero quality, browsers without WebP only send image/*.
+// Others get the original format if it is JPEG, PNG or GIF, and JPEG otherwise.
+func NegotiateImageFormat(accept, srcExt string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(mediaType), "image/webp") {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if name, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(name) == "q" {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			return ".webp"
+		}
+	}
+
+	switch ext := strings.ToLower(srcExt); ext {
+	case ".jpg", ".jpeg", ".png", ".gif":
+		return ext
+	}
+	return ".jpeg"
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPhotoVariantWidthsFor(t *testing.T) {
+	assert.Equal(t, []int{320, 640, 1280}, PhotoVariantWidthsFor(4032))
+	assert.Equal(t, []int{320, 640}, PhotoVariantWidthsFor(1280))
+	assert.Empty(t, PhotoVariantWidthsFor(320))
+}
+
+func TestPhotoVariantKey(t *testing.T) {
+	assert.Equal(t, "markers/1/abc_w640.webp", PhotoVariantKey("markers/1/abc.png", 640, PhotoVariantWebP))
+	assert.Equal(t, "https://bucket.s3.amazonaws.com/markers/1/abc_w320.jpg",
+		PhotoVariantKey("https://bucket.s3.amazonaws.com/markers/1/abc.jpeg", 320, PhotoVariantJPEG))
+}
+
+func TestVariantWidthsColumn(t *testing.T) {
+	assert.Equal(t, "320,640", FormatVariantWidths([]int{320, 640}))
+	assert.Empty(t, FormatVariantWidths(nil))
+
+	assert.Equal(t, []int{320, 640}, ParseVariantWidths("320,640"))
+	assert.Equal(t, []int{1280}, ParseVariantWidths(" 1280 ,x,"))
+	assert.Empty(t, ParseVariantWidths(""))
+}
+
+func TestSnapImageWidth(t *testing.T) {
+	assert.Equal(t, 0, SnapImageWidth(0))
+	assert.Equal(t, 320, SnapImageWidth(100))
+	assert.Equal(t, 640, SnapImageWidth(640))
+	assert.Equal(t, 1280, SnapImageWidth(828))
+	assert.Equal(t, 3840, SnapImageWidth(10000))
+}
+
+func TestNegotiateImageFormat(t *testing.T) {
+	chrome := "image/avif,image/webp,image/apng,image/svg+xml,image/*,*/*;q=0.8"
+	oldSafari := "image/png,image/svg+xml,image/*;q=0.8,video/*;q=0.8,*/*;q=0.5"
+
+	assert.Equal(t, ".webp", NegotiateImageFormat(chrome, ".png"))
+	assert.Equal(t, ".webp", NegotiateImageFormat("image/webp;q=0.5", ".jpg"))
+	assert.Equal(t, ".png", NegotiateImageFormat(oldSafari, ".PNG"))
+	assert.Equal(t, ".jpg", NegotiateImageFormat("image/webp;q=0, image/*", ".jpg"))
+	assert.Equal(t, ".jpeg", NegotiateImageFormat(oldSafari, ".webp"))
+	assert.Equal(t, ".jpeg", NegotiateImageFormat("", ""))
+}
@@ -0,0 +1,86 @@
+package util
+
+// RoutePoint is a place on a planned route in WGS84 degrees.
+type RoutePoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// PlanRoute orders stops into a short walk from start that visits each of them once, without coming back.
+// It builds a nearest neighbor tour and improves it with 2-opt until no reversal shortens it, which is close
+// enough to optimal for the handful of stops a workout has.
+// It returns the indices of stops in visiting order and the length of each leg in meters, legs[i] ends at stops[order[i]].
+func PlanRoute(start RoutePoint, stops []RoutePoint) (order []int, legs []float64) {
+	if len(stops) == 0 {
+		return nil, nil
+	}
+
+	// Node 0 is the start, node i+1 is stops[i]
+	nodes := make([]RoutePoint, 0, len(stops)+1)
+	nodes = append(append(nodes, start), stops...)
+	dist := make([][]float64, len(nodes))
+	for i := range nodes {
+		dist[i] = make([]float64, len(nodes))
+		for j := range i {
+			dist[i][j] = distance(nodes[i].Latitude, nodes[i].Longitude, nodes[j].Latitude, nodes[j].Longitude)
+			dist[j][i] = dist[i][j]
+		}
+	}
+
+	tour := nearestNeighborTour(dist)
+	twoOpt(tour, dist)
+
+	order = make([]int, len(stops))
+	legs = make([]float64, len(stops))
+	for i := 1; i < len(tour); i++ {
+		order[i-1] = tour[i] - 1
+		legs[i-1] = dist[tour[i-1]][tour[i]]
+	}
+	return order, legs
+}
+
+// nearestNeighborTour walks from node 0 to the closest node not visited yet until all are.
+func nearestNeighborTour(dist [][]float64) []int {
+	visited := make([]bool, len(dist))
+	visited[0] = true
+	tour := make([]int, 1, len(dist))
+
+	for current := 0; len(tour) < len(dist); {
+		next := -1
+		for j := range dist {
+			if !visited[j] && (next < 0 || dist[current][j] < dist[current][next]) {
+				next = j
+			}
+		}
+		visited[next] = true
+		tour = append(tour, next)
+		current = next
+	}
+	return tour
+}
+
+// twoOpt reverses sections of the open tour in place while that makes it shorter, node 0 stays first.
+func twoOpt(tour []int, dist [][]float64) {
+	const epsilon = 1e-9 // meters, keeps rounding noise from swapping back and forth
+
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < len(tour)-1; i++ {
+			for j := i + 1; j < len(tour); j++ {
+				// Reversing tour[i..j] replaces the edges a-b and c-d with a-c and b-d, the last stop has no d
+				a, b, c := tour[i-1], tour[i], tour[j]
+				delta := dist[a][c] - dist[a][b]
+				if j+1 < len(tour) {
+					d := tour[j+1]
+					delta += dist[b][d] - dist[c][d]
+				}
+				if delta < -epsilon {
+					for l, r := i, j; l < r; l, r = l+1, r-1 {
+						tour[l], tour[r] = tour[r], tour[l]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// routeTestPoint is lng degrees east of the start, along the same parallel.
+func routeTestPoint(lng float64) RoutePoint {
+	return RoutePoint{Latitude: 37.5, Longitude: 127 + lng}
+}
+
+func TestPlanRouteNearestNeighbor(t *testing.T) {
+	start := routeTestPoint(0)
+	stops := []RoutePoint{routeTestPoint(0.03), routeTestPoint(0.01), routeTestPoint(0.02)}
+
+	order, legs := PlanRoute(start, stops)
+	assert.Equal(t, []int{1, 2, 0}, order)
+	assert.Len(t, legs, 3)
+
+	step := distance(37.5, 127, 37.5, 127.01)
+	for _, leg := range legs {
+		assert.InDelta(t, step, leg, 1)
+	}
+}
+
+func TestPlanRouteTwoOpt(t *testing.T) {
+	// Nearest neighbor goes east first, then doubles back west and east again: 1 + 2.5 + 5.5 steps.
+	// Heading west first is 1.5 + 2.5 + 3.
+	start := routeTestPoint(0)
+	stops := []RoutePoint{routeTestPoint(0.01), routeTestPoint(-0.015), routeTestPoint(0.04)}
+
+	order, legs := PlanRoute(start, stops)
+	assert.Equal(t, []int{1, 0, 2}, order)
+
+	var total float64
+	for _, leg := range legs {
+		total += leg
+	}
+	assert.InDelta(t, 7*distance(37.5, 127, 37.5, 127.01), total, 5)
+}
+
+func TestPlanRouteEmpty(t *testing.T) {
+	order, legs := PlanRoute(routeTestPoint(0), nil)
+	assert.Empty(t, order)
+	assert.Empty(t, legs)
+
+	order, legs = PlanRoute(routeTestPoint(0), []RoutePoint{routeTestPoint(0.01)})
+	assert.Equal(t, []int{0}, order)
+	assert.Len(t, legs, 1)
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"sort"
+	"time"
+)
+
+// KST is the day boundary for workout streaks, a late night session still counts for the day it started on.
+var KST = time.FixedZone("KST", 9*60*60)
+
+// Streak is how many days in a row someone was active.
+type Streak struct {
+	Current int // ending today, or yesterday since today is not over yet
+	Longest int
+	Days    int // distinct active days
+}
+
+// CalculateStreak counts consecutive calendar days in loc that have at least one of times, in any order.
+func CalculateStreak(times []time.Time, now time.Time, loc *time.Location) Streak {
+	if len(times) == 0 {
+		return Streak{}
+	}
+
+	// Days since the epoch in loc, so consecutive calendar days differ by exactly one
+	dayNumber := func(t time.Time) int64 {
+		y, m, d := t.In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+	}
+
+	seen := make(map[int64]struct{}, len(times))
+	days := make([]int64, 0, len(times))
+	for _, t := range times {
+		day := dayNumber(t)
+		if _, ok := seen[day]; !ok {
+			seen[day] = struct{}{}
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+
+	streak := Streak{Days: len(days)}
+	run := 0
+	for i, day := range days {
+		if i > 0 && day == days[i-1]+1 {
+			run++
+		} else {
+			run = 1
+		}
+		streak.Longest = max(streak.Longest, run)
+	}
+
+	// run ends on the latest active day, it only counts if that day is today or yesterday
+	if today := dayNumber(now); days[len(days)-1] >= today-1 {
+		streak.Current = run
+	}
+	return streak
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateStreak(t *testing.T) {
+	kst := func(day, hour int) time.Time {
+		return time.Date(2024, time.March, day, hour, 0, 0, 0, KST)
+	}
+	now := kst(10, 12)
+
+	assert.Equal(t, Streak{}, CalculateStreak(nil, now, KST))
+
+	// Two sessions on the 9th count once, the 10th is still going
+	streak := CalculateStreak([]time.Time{kst(10, 7), kst(8, 20), kst(9, 6), kst(9, 21)}, now, KST)
+	assert.Equal(t, Streak{Current: 3, Longest: 3, Days: 3}, streak)
+
+	// Nothing yet today, yesterday keeps the streak alive
+	streak = CalculateStreak([]time.Time{kst(8, 20), kst(9, 6)}, now, KST)
+	assert.Equal(t, Streak{Current: 2, Longest: 2, Days: 2}, streak)
+
+	// A missed day breaks it, the longest run is kept
+	streak = CalculateStreak([]time.Time{kst(1, 9), kst(2, 9), kst(3, 9), kst(7, 9)}, now, KST)
+	assert.Equal(t, Streak{Current: 0, Longest: 3, Days: 4}, streak)
+
+	// 23:30 UTC on the 8th is already the 9th in KST
+	utc := time.Date(2024, time.March, 8, 23, 30, 0, 0, time.UTC)
+	streak = CalculateStreak([]time.Time{utc, kst(10, 8)}, now, KST)
+	assert.Equal(t, Streak{Current: 2, Longest: 2, Days: 2}, streak)
+
+	// Across a month boundary
+	streak = CalculateStreak([]time.Time{kst(0, 10), kst(1, 10)}, kst(1, 23), KST)
+	assert.Equal(t, Streak{Current: 2, Longest: 2, Days: 2}, streak)
+}